@@ -3,6 +3,8 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -31,6 +33,8 @@ import (
 //+kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=endpoints/status,verbs=get
 
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
 // DataplaneReconciler reconciles the dataplane pods.
 type DataplaneReconciler struct {
 	client.Client
@@ -39,6 +43,24 @@ type DataplaneReconciler struct {
 	backendsClientManager *dataplane.BackendsClientManager
 
 	updates chan event.GenericEvent
+	// gatewayUpdates notifies the Gateway controller when no dataplane Pod is
+	// ready anymore, or when some are ready again.
+	gatewayUpdates chan event.GenericEvent
+
+	readyMu    sync.Mutex
+	readyPods  int
+	readyKnown bool
+
+	// RateLimiter configures the rate limiter of the workqueue of the
+	// controller, the controller-runtime default is used if it's zero.
+	RateLimiter RateLimiterConfig
+}
+
+// DataPlaneReadiness reports the number of ready dataplane instances.
+type DataPlaneReadiness interface {
+	// ReadyDataPlanes returns the number of ready dataplane Pods, and false if
+	// it's not known yet.
+	ReadyDataPlanes() (int, bool)
 }
 
 func NewDataplaneReconciler(client client.Client, schema *runtime.Scheme, manager *dataplane.BackendsClientManager) *DataplaneReconciler {
@@ -47,9 +69,15 @@ func NewDataplaneReconciler(client client.Client, schema *runtime.Scheme, manage
 		scheme:                schema,
 		backendsClientManager: manager,
 		updates:               make(chan event.GenericEvent, 1),
+		gatewayUpdates:        make(chan event.GenericEvent, 1),
 	}
 }
 
+// dataplanePodIPRequeueInterval is how long to wait before setting the
+// dataplane clients list again when ready Pods have no IP yet, or aren't
+// serving yet.
+const dataplanePodIPRequeueInterval = time.Second
+
 var (
 	podOwnerKey = ".metadata.controller"
 	apiGVStr    = appsv1.SchemeGroupVersion.String()
@@ -82,6 +110,7 @@ func (r *DataplaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controllerOptions(r.RateLimiter)).
 		For(&appsv1.DaemonSet{},
 			builder.WithPredicates(predicate.NewPredicateFuncs(r.daemonsetHasMatchingAnnotations)),
 		).
@@ -139,6 +168,11 @@ func (r *DataplaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	readyPodByNN := make(map[types.NamespacedName]corev1.Pod)
 	for _, pod := range childPods.Items {
+		// terminating Pods are draining their dataplane, so they aren't
+		// programmed with new VIPs even while they're still ready.
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
 		for _, container := range pod.Status.ContainerStatuses {
 			if container.Name == vars.DefaultDataPlaneComponentLabel && container.Ready {
 				key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
@@ -147,6 +181,15 @@ func (r *DataplaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	if r.setReadyDataPlanes(len(readyPodByNN)) {
+		logger.Info("DataplaneReconciler", "reconcile status", "ready dataplane pods changed from or to none, sending gateways event")
+		select {
+		case r.gatewayUpdates <- event.GenericEvent{Object: ds}:
+		default:
+			logger.Info("DataplaneReconciler", "reconcile status", "gateways event skipped - channel is full")
+		}
+	}
+
 	logger.Info("DataplaneReconciler", "reconcile status", "setting updated backends client list", "num ready pods", len(readyPodByNN))
 	updated, err := r.backendsClientManager.SetClientsList(readyPodByNN)
 	if updated {
@@ -158,6 +201,18 @@ func (r *DataplaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			logger.Info("DataplaneReconciler", "reconcile status", "generic event skipped - channel is full")
 		}
 	}
+	if isDataPlanePodIPNotAssigned(err) {
+		// updates to the status of the Pods don't trigger a reconcile, so the
+		// Pods which are ready but have no IP yet are retried shortly.
+		logger.Info("DataplaneReconciler", "reconcile status", "waiting for dataplane pods to be assigned an IP", "error", err.Error())
+		return ctrl.Result{RequeueAfter: dataplanePodIPRequeueInterval}, nil
+	}
+	if isDataPlanePodNotServing(err) {
+		// the health of the Pods isn't watched either, so the Pods which are
+		// ready but not serving yet are retried shortly too.
+		logger.Info("DataplaneReconciler", "reconcile status", "waiting for dataplane pods to be serving", "error", err.Error())
+		return ctrl.Result{RequeueAfter: dataplanePodIPRequeueInterval}, nil
+	}
 	if err != nil {
 		logger.Error(err, "DataplaneReconciler", "reconcile status", "partial failure for backends client list update")
 		return ctrl.Result{Requeue: true}, err
@@ -170,3 +225,27 @@ func (r *DataplaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 func (r *DataplaneReconciler) GetUpdates() <-chan event.GenericEvent {
 	return r.updates
 }
+
+// GetGatewayUpdates returns the events sent when no dataplane Pod is ready
+// anymore, or when some are ready again, which affects all the Gateways.
+func (r *DataplaneReconciler) GetGatewayUpdates() <-chan event.GenericEvent {
+	return r.gatewayUpdates
+}
+
+// ReadyDataPlanes returns the number of ready dataplane Pods as of the last
+// reconcile, and false if there was none yet.
+func (r *DataplaneReconciler) ReadyDataPlanes() (int, bool) {
+	r.readyMu.Lock()
+	defer r.readyMu.Unlock()
+	return r.readyPods, r.readyKnown
+}
+
+// setReadyDataPlanes records the number of ready dataplane Pods, and returns
+// true if it dropped to zero or rose from zero.
+func (r *DataplaneReconciler) setReadyDataPlanes(ready int) bool {
+	r.readyMu.Lock()
+	defer r.readyMu.Unlock()
+	changed := !r.readyKnown || (r.readyPods == 0) != (ready == 0)
+	r.readyPods, r.readyKnown = ready, true
+	return changed
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+)
+
+// DefaultPartialUpdateRequeueInterval is the default period after which a
+// route whose Targets failed to be pushed to some of the dataplane instances
+// is reconciled again, to retry the push on those instances only.
+const DefaultPartialUpdateRequeueInterval = 5 * time.Second
+
+// partialUpdateRequeueInterval returns the provided interval, or
+// DefaultPartialUpdateRequeueInterval if it's zero.
+func partialUpdateRequeueInterval(interval time.Duration) time.Duration {
+	if interval == 0 {
+		return DefaultPartialUpdateRequeueInterval
+	}
+	return interval
+}
+
+// pushedTargets tracks the Targets last pushed to the dataplane for each VIP,
+// so that changes to the backends of a route can be pushed incrementally
+// instead of replacing the whole backend set, which resets the load
+// balancing of the VIP.
+type pushedTargets struct {
+	mu      sync.Mutex
+	targets map[string]routeTargets
+}
+
+// routeTargets are the Targets pushed for a VIP, along with the route they
+// were compiled from.
+type routeTargets struct {
+	route   types.NamespacedName
+	targets *dataplane.Targets
+	// pendingPods are the dataplane Pods which failed to apply the Targets,
+	// while the others applied them.
+	pendingPods []string
+}
+
+func newPushedTargets() *pushedTargets {
+	return &pushedTargets{targets: map[string]routeTargets{}}
+}
+
+// push programs the provided Targets of the provided route into the
+// dataplane. If Targets were already pushed for the VIP by the same route,
+// only the backends which were added and removed since are pushed with
+// AddBackend and RemoveBackend. If no Targets were pushed for the VIP yet,
+// they're pushed with Sync, which only pushes the difference with the
+// backends already programmed into the dataplane, e.g. before the control
+// plane restarted. Otherwise, or if the settings of a backend changed, or if
+// either backend set repeats a backend, as weighted backends do, or if the
+// tracker is nil, the whole backend set is pushed with Update. The VIP is
+// forgotten if the push fails, so that the next push replaces the backend
+// set. If the push only failed on
+// some of the dataplane Pods, the next push of the same Targets is only sent
+// to those Pods. The result of the last call to the dataplane is returned, it's
+// nil if nothing needed to be pushed.
+func (p *pushedTargets) push(ctx context.Context, updater dataplane.BackendsUpdater, route types.NamespacedName, targets *dataplane.Targets) (*dataplane.UpdateResult, error) {
+	if p == nil {
+		return updater.Update(ctx, targets)
+	}
+
+	key := targets.GetVip().Key()
+	p.mu.Lock()
+	previous, ok := p.targets[key]
+	p.mu.Unlock()
+
+	var (
+		result *dataplane.UpdateResult
+		err    error
+	)
+	// retryPods indicates whether the Pods which applied the push are up to
+	// date if it only failed on some of the Pods.
+	retryPods := true
+	switch {
+	case ok && previous.route == route && len(previous.pendingPods) > 0 && proto.Equal(previous.targets, targets):
+		result, err = updater.UpdatePods(ctx, targets, previous.pendingPods)
+	case !ok:
+		result, err = updater.Sync(ctx, targets)
+	case previous.route != route || len(previous.pendingPods) > 0 || targetSettingsChanged(previous.targets, targets):
+		result, err = updater.Update(ctx, targets)
+	case dataplane.HasDuplicateTargets(previous.targets.GetTargets()) || dataplane.HasDuplicateTargets(targets.GetTargets()):
+		// the delta of weighted backends misses the weight changes.
+		if !sameTargets(previous.targets.GetTargets(), targets.GetTargets()) {
+			result, err = updater.Update(ctx, targets)
+		}
+	default:
+		added, removed := dataplane.TargetsDelta(previous.targets.GetTargets(), targets.GetTargets())
+		if len(added) > 0 {
+			result, err = updater.AddBackend(ctx, &dataplane.Targets{Vip: targets.GetVip(), Targets: added})
+		}
+		if err == nil && len(removed) > 0 {
+			result, err = updater.RemoveBackend(ctx, &dataplane.Targets{Vip: targets.GetVip(), Targets: removed})
+		} else if err != nil && len(removed) > 0 {
+			// the removed backends weren't pushed to any Pod.
+			retryPods = false
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var partialErr *dataplane.PartialUpdateError
+	switch {
+	case retryPods && errors.As(err, &partialErr):
+		p.targets[key] = routeTargets{route: route, targets: targets, pendingPods: partialErr.Pods}
+	case err != nil:
+		delete(p.targets, key)
+	default:
+		p.targets[key] = routeTargets{route: route, targets: targets}
+	}
+	return result, err
+}
+
+// targetSettingsChanged indicates whether the settings of the VIP, or of a
+// backend which is in both sets of Targets, changed, as AddBackend and
+// RemoveBackend only push the backends which were added or removed.
+func targetSettingsChanged(previous, current *dataplane.Targets) bool {
+	if previous.GetAction() != current.GetAction() || previous.GetTcpIdleTimeoutSeconds() != current.GetTcpIdleTimeoutSeconds() {
+		return true
+	}
+	maxConnections := make(map[string]uint32, len(previous.GetTargets()))
+	for _, target := range previous.GetTargets() {
+		maxConnections[target.Addr()] = target.GetMaxConnections()
+	}
+	for _, target := range current.GetTargets() {
+		if previous, ok := maxConnections[target.Addr()]; ok && previous != target.GetMaxConnections() {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTargets indicates whether the provided sorted Targets have the same
+// backends, in the same number.
+func sameTargets(previous, current []*dataplane.Target) bool {
+	if len(previous) != len(current) {
+		return false
+	}
+	for i := range previous {
+		if previous[i].Addr() != current[i].Addr() {
+			return false
+		}
+	}
+	return true
+}
+
+// forget stops tracking the Targets pushed for the provided VIP, for
+// instance because it was deleted from the dataplane.
+func (p *pushedTargets) forget(vip *dataplane.Vip) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.targets, vip.Key())
+}
+
+// forgetRoute stops tracking the Targets pushed by the provided route, for
+// instance because it isn't programmed by this controller anymore.
+func (p *pushedTargets) forgetRoute(route types.NamespacedName) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, pushed := range p.targets {
+		if pushed.route == route {
+			delete(p.targets, key)
+		}
+	}
+}
+
+// reset stops tracking the Targets pushed for all VIPs, so that they're all
+// replaced on their next push. This is used when dataplane instances are
+// added, as they need the whole backend sets.
+func (p *pushedTargets) reset() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.targets = map[string]routeTargets{}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+)
+
+func TestPushedTargets_weightedTargets(t *testing.T) {
+	ctx := context.Background()
+	pushed := newPushedTargets()
+	backends := &fakeBackendsUpdater{}
+	route := types.NamespacedName{Namespace: "test-namespace", Name: "test-tcproute"}
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}
+	a := &dataplane.Target{Daddr: 0x0af4000a, Dport: 80}
+	b := &dataplane.Target{Daddr: 0x0af4000b, Dport: 80}
+
+	t.Log("backends weighted 75/25 are pushed with the whole backend set")
+	weighted := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{a, a, a, b}}
+	_, err := pushed.push(ctx, backends, route, weighted)
+	require.NoError(t, err)
+	require.Equal(t, []*dataplane.Targets{weighted}, backends.updates)
+
+	t.Log("unchanged weighted backends aren't pushed again")
+	_, err = pushed.push(ctx, backends, route, weighted)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+
+	t.Log("a change of the weights only replaces the whole backend set")
+	even := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{a, b}}
+	_, err = pushed.push(ctx, backends, route, even)
+	require.NoError(t, err)
+	require.Equal(t, []*dataplane.Targets{weighted, even}, backends.updates)
+	require.Empty(t, backends.adds)
+	require.Empty(t, backends.removes)
+
+	t.Log("the weights are pushed again when they change back")
+	_, err = pushed.push(ctx, backends, route, weighted)
+	require.NoError(t, err)
+	require.Equal(t, []*dataplane.Targets{weighted, even, weighted}, backends.updates)
+	require.Empty(t, backends.adds)
+	require.Empty(t, backends.removes)
+}
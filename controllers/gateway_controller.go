@@ -21,21 +21,27 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/kubernetes-sigs/blixt/pkg/vars"
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -43,6 +49,7 @@ import (
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways/finalizers,verbs=update
 
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services/status,verbs=get
 
@@ -53,18 +60,262 @@ import (
 
 const gatewayServiceLabel = "blixt.gateway.networking.k8s.io/owned-by-gateway"
 
+const (
+	// DefaultServiceReadyRequeueInterval is the default initial period after
+	// which a Gateway whose Service is not ready yet is reconciled again.
+	DefaultServiceReadyRequeueInterval = time.Second
+
+	// DefaultServiceReadyMaxRequeueInterval is the default maximum period after
+	// which a Gateway whose Service is not ready yet is reconciled again.
+	DefaultServiceReadyMaxRequeueInterval = time.Minute
+)
+
+// noDataPlaneRequeueInterval is the period after which a Gateway is reconciled
+// again while the dataplane DaemonSet is missing or has no ready Pods.
+const noDataPlaneRequeueInterval = 30 * time.Second
+
+// ServiceManagementAnnotation can be set on a Gateway to select whether its
+// LoadBalancer Service is created by Blixt (ServiceManagementManaged, the
+// default) or provided by the user (ServiceManagementExternal).
+const ServiceManagementAnnotation = "blixt/service-management"
+
+// LoadBalancerClassAnnotation can be set on a Gateway to select the
+// loadBalancerClass of its LoadBalancer Service, on clusters running multiple
+// load balancer implementations. Without the annotation, or if it's empty, the
+// class of the Service is left to the cluster, which might set a default one,
+// and it isn't changed once the Service exists.
+const LoadBalancerClassAnnotation = "blixt/load-balancer-class"
+
+// ExternalTrafficPolicyAnnotation can be set on a Gateway to select the
+// externalTrafficPolicy of its LoadBalancer Service, e.g. Local to preserve the
+// source IPs of the clients. The Kubernetes default is kept when the
+// annotation is missing.
+const ExternalTrafficPolicyAnnotation = "blixt/external-traffic-policy"
+
+// ServiceManagement identifies who manages the LoadBalancer Service of a
+// Gateway.
+type ServiceManagement string
+
+const (
+	// ServiceManagementManaged has the Gateway reconciler create the Gateway
+	// Service and keep its ports in sync with the Gateway listeners.
+	ServiceManagementManaged ServiceManagement = "Managed"
+
+	// ServiceManagementExternal has the Gateway reconciler use a Service
+	// provided by the user, labeled with the name of the Gateway, which is
+	// never created or modified by Blixt: only its allocated address is read.
+	ServiceManagementExternal ServiceManagement = "External"
+)
+
+// serviceManagementForGateway returns the ServiceManagement mode selected by
+// the ServiceManagementAnnotation of the provided Gateway.
+func serviceManagementForGateway(gw *gatewayv1beta1.Gateway) (ServiceManagement, error) {
+	value, ok := gw.Annotations[ServiceManagementAnnotation]
+	if !ok {
+		return ServiceManagementManaged, nil
+	}
+	switch mode := ServiceManagement(value); mode {
+	case ServiceManagementManaged, ServiceManagementExternal:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported %s annotation value %q (supported: %s, %s)", ServiceManagementAnnotation, value,
+			ServiceManagementManaged, ServiceManagementExternal)
+	}
+}
+
+// loadBalancerClassForGateway returns the loadBalancerClass selected by the
+// LoadBalancerClassAnnotation of the provided Gateway, if any.
+func loadBalancerClassForGateway(gw *gatewayv1beta1.Gateway) *string {
+	if class := gw.Annotations[LoadBalancerClassAnnotation]; class != "" {
+		return &class
+	}
+	return nil
+}
+
+// externalTrafficPolicyForGateway returns the externalTrafficPolicy selected
+// by the ExternalTrafficPolicyAnnotation of the provided Gateway, or an empty
+// policy if the annotation is missing.
+func externalTrafficPolicyForGateway(gw *gatewayv1beta1.Gateway) (corev1.ServiceExternalTrafficPolicy, error) {
+	value, ok := gw.Annotations[ExternalTrafficPolicyAnnotation]
+	if !ok {
+		return "", nil
+	}
+	switch policy := corev1.ServiceExternalTrafficPolicy(value); policy {
+	case corev1.ServiceExternalTrafficPolicyCluster, corev1.ServiceExternalTrafficPolicyLocal:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported %s annotation value %q (supported: %s, %s)", ExternalTrafficPolicyAnnotation, value,
+			corev1.ServiceExternalTrafficPolicyCluster, corev1.ServiceExternalTrafficPolicyLocal)
+	}
+}
+
+// validateGatewayAnnotations returns an error if any of the annotations
+// configuring the Service of the provided Gateway has an unsupported value.
+func validateGatewayAnnotations(gw *gatewayv1beta1.Gateway) error {
+	if _, err := serviceManagementForGateway(gw); err != nil {
+		return err
+	}
+	_, err := externalTrafficPolicyForGateway(gw)
+	return err
+}
+
+// LoadBalancerProvider identifies what provisions the LoadBalancer Services of
+// Gateways, which determines how the health of those Services is detected.
+type LoadBalancerProvider string
+
+const (
+	// LoadBalancerProviderMetalLB relies on MetalLB events to detect address
+	// allocation failures, and manufactures Endpoints for the Gateway Service
+	// to work around MetalLB L2 mode issues.
+	LoadBalancerProviderMetalLB LoadBalancerProvider = "metallb"
+
+	// LoadBalancerProviderCloud trusts the status of the Gateway Service, as
+	// populated by the cloud provider, without any MetalLB specific handling.
+	LoadBalancerProviderCloud LoadBalancerProvider = "cloud"
+
+	// LoadBalancerProviderNone disables any provider specific handling, the
+	// Gateway Service status is trusted as for LoadBalancerProviderCloud.
+	LoadBalancerProviderNone LoadBalancerProvider = "none"
+)
+
+// ParseLoadBalancerProvider returns the LoadBalancerProvider of the provided
+// name, or an error if it's not a supported provider.
+func ParseLoadBalancerProvider(name string) (LoadBalancerProvider, error) {
+	switch provider := LoadBalancerProvider(name); provider {
+	case LoadBalancerProviderMetalLB, LoadBalancerProviderCloud, LoadBalancerProviderNone:
+		return provider, nil
+	default:
+		return "", fmt.Errorf("unsupported load balancer provider %q (supported: %s, %s, %s)", name,
+			LoadBalancerProviderMetalLB, LoadBalancerProviderCloud, LoadBalancerProviderNone)
+	}
+}
+
 // GatewayReconciler reconciles a Gateway object
 type GatewayReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Log    logr.Logger
+
+	// NamedAddressAnnotation is the Service annotation which the name of a
+	// Gateway address of type NamedAddress is mapped to, so that the
+	// LoadBalancer provider can allocate an IP from the named pool. If empty,
+	// NamedAddress addresses are not supported.
+	NamedAddressAnnotation string
+
+	// DisableMetalLBEndpointsHack disables the creation of a manufactured
+	// Endpoints object for the Gateway's Service, which is only needed to work
+	// around https://github.com/metallb/metallb/issues/1640 on clusters that
+	// use MetalLB in L2 mode.
+	DisableMetalLBEndpointsHack bool
+
+	// LBProvider selects how the health of Gateway Services is detected and
+	// whether the MetalLB workarounds apply. Defaults to MetalLB if empty.
+	LBProvider LoadBalancerProvider
+
+	// BackendsClientManager is used to remove the dataplane configuration of
+	// listeners whose protocol changed. If nil, no cleanup is performed.
+	BackendsClientManager dataplane.BackendsUpdater
+
+	// DataPlaneDaemonSet is the dataplane DaemonSet, the Gateways aren't
+	// Programmed while it doesn't exist, isn't labeled as the dataplane or
+	// has no ready Pods. If nil, it's not checked.
+	DataPlaneDaemonSet *types.NamespacedName
+
+	// DataPlaneReadiness reports the number of ready dataplane instances, the
+	// Gateways aren't Programmed while there are none. If nil, it's not
+	// checked.
+	DataPlaneReadiness DataPlaneReadiness
+
+	// DataPlaneUpdates receives events when the number of ready dataplane
+	// instances drops to zero or rises from zero, to reconcile all Gateways.
+	DataPlaneUpdates <-chan event.GenericEvent
+
+	// WatchNamespaces restricts the controller to objects in the provided
+	// namespaces. All namespaces are watched if empty.
+	WatchNamespaces []string
+
+	// ControllerName is the GatewayClass controller name identifying the
+	// resources managed by this controller. Defaults to
+	// vars.GatewayClassControllerName if empty.
+	ControllerName gatewayv1beta1.GatewayController
+
+	// ServiceReadyRequeueInterval is the initial period after which a Gateway
+	// whose Service is not ready yet is reconciled again, doubled on each
+	// reconcile up to ServiceReadyMaxRequeueInterval while the Service stays
+	// not ready. Defaults to DefaultServiceReadyRequeueInterval and
+	// DefaultServiceReadyMaxRequeueInterval if zero.
+	ServiceReadyRequeueInterval    time.Duration
+	ServiceReadyMaxRequeueInterval time.Duration
+
+	// RateLimiter configures the rate limiter of the workqueue of the
+	// controller, the controller-runtime default is used if it's zero.
+	RateLimiter RateLimiterConfig
+
+	// EnableHTTPRoute advertises HTTPRoute as a supported route kind of the
+	// HTTP and HTTPS listeners. HTTPRoutes aren't implemented, these listeners
+	// are programmed as TCP listeners: it's only meant for the Gateway API
+	// conformance tests, whose default Gateways have HTTP listeners. If it's
+	// false, the HTTP and HTTPS listeners report InvalidRouteKinds unless
+	// they only allow TCPRoutes.
+	EnableHTTPRoute bool
+
+	serviceReadyBackoffMu sync.Mutex
+	serviceReadyBackoff   map[types.NamespacedName]time.Duration
+}
+
+// nextServiceReadyRequeue returns the period after which the provided Gateway,
+// whose Service is not ready, must be reconciled again, and doubles it for
+// the next reconcile.
+func (r *GatewayReconciler) nextServiceReadyRequeue(key types.NamespacedName) time.Duration {
+	initial, max := r.ServiceReadyRequeueInterval, r.ServiceReadyMaxRequeueInterval
+	if initial == 0 {
+		initial = DefaultServiceReadyRequeueInterval
+	}
+	if max == 0 {
+		max = DefaultServiceReadyMaxRequeueInterval
+	}
+
+	r.serviceReadyBackoffMu.Lock()
+	defer r.serviceReadyBackoffMu.Unlock()
+	if r.serviceReadyBackoff == nil {
+		r.serviceReadyBackoff = map[types.NamespacedName]time.Duration{}
+	}
+	requeue, ok := r.serviceReadyBackoff[key]
+	if !ok {
+		requeue = initial
+	}
+	if requeue > max {
+		requeue = max
+	}
+	r.serviceReadyBackoff[key] = requeue * 2
+	return requeue
+}
+
+// resetServiceReadyRequeue resets the backoff of the provided Gateway once its
+// Service is ready.
+func (r *GatewayReconciler) resetServiceReadyRequeue(key types.NamespacedName) {
+	r.serviceReadyBackoffMu.Lock()
+	defer r.serviceReadyBackoffMu.Unlock()
+	delete(r.serviceReadyBackoff, key)
+}
+
+// usesMetalLB indicates whether the MetalLB specific workarounds apply.
+func (r *GatewayReconciler) usesMetalLB() bool {
+	return r.LBProvider == "" || r.LBProvider == LoadBalancerProviderMetalLB
 }
 
 // SetupWithManager loads the controller into the provided controller manager.
 func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Log = log.FromContext(context.Background())
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).WithOptions(controllerOptions(r.RateLimiter))
+	if r.DataPlaneUpdates != nil {
+		b = b.WatchesRawSource(
+			&source.Channel{Source: r.DataPlaneUpdates},
+			handler.EnqueueRequestsFromMapFunc(r.mapDataPlaneToGateways),
+		)
+	}
+	return b.
 		For(&gatewayv1beta1.Gateway{},
 			builder.WithPredicates(predicate.NewPredicateFuncs(r.gatewayHasMatchingGatewayClass)),
 		).
@@ -76,6 +327,22 @@ func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&gatewayv1beta1.GatewayClass{},
 			handler.EnqueueRequestsFromMapFunc(r.mapGatewayClassToGateway),
 		).
+		Watches(
+			&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.mapBackendToGateways),
+		).
+		Watches(
+			&corev1.Endpoints{},
+			handler.EnqueueRequestsFromMapFunc(r.mapBackendToGateways),
+		).
+		Watches(
+			&gatewayv1alpha2.TCPRoute{},
+			handler.EnqueueRequestsFromMapFunc(mapRouteToGateways),
+		).
+		Watches(
+			&gatewayv1alpha2.UDPRoute{},
+			handler.EnqueueRequestsFromMapFunc(mapRouteToGateways),
+		).
 		Complete(r)
 }
 
@@ -86,71 +353,134 @@ func (r *GatewayReconciler) gatewayHasMatchingGatewayClass(obj client.Object) bo
 		return false
 	}
 
-	gatewayClass := &gatewayv1beta1.GatewayClass{}
-	if err := r.Client.Get(context.Background(), client.ObjectKey{Name: string(gateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
-		if errors.IsNotFound(err) {
-			return false
-		}
+	managed, err := isGatewayClassManaged(context.Background(), r.Client, gateway.Spec.GatewayClassName, r.ControllerName)
+	if err != nil {
 		r.Log.Error(err, "couldn't retrieve gatewayclass for unknown reason, enqueing gateway anyway to avoid miss", "gatewayclass", gateway.Spec.GatewayClassName)
 		return true
 	}
-
-	return gatewayClass.Spec.ControllerName == vars.GatewayClassControllerName
+	return managed
 }
 
 // Reconcile provisions (and de-provisions) resources relevant to this controller.
 // TODO: this whole thing needs a rewrite
-func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	defer func() {
+		if err == nil {
+			metrics.LastSuccessfulReconcile.WithLabelValues("Gateway").SetToCurrentTime()
+		}
+	}()
+
 	log := log.FromContext(ctx)
 
+	// the cache only holds the watched namespaces, this also ignores the
+	// Gateways of other namespaces which would be enqueued by a mapping.
+	if !isNamespaceWatched(r.WatchNamespaces, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
 	gateway := new(gatewayv1beta1.Gateway)
 	if err := r.Client.Get(ctx, req.NamespacedName, gateway); err != nil {
 		if errors.IsNotFound(err) {
 			log.Info("object enqueued no longer exists, skipping")
+			r.resetServiceReadyRequeue(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
-	gatewayClass := new(gatewayv1beta1.GatewayClass)
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: string(gateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
-		if errors.IsNotFound(err) {
-			return ctrl.Result{}, nil
-		}
+	managed, err := isGatewayClassManaged(ctx, r.Client, gateway.Spec.GatewayClassName, r.ControllerName)
+	if err != nil || !managed {
 		return ctrl.Result{}, err
 	}
 
-	if gatewayClass.Spec.ControllerName != vars.GatewayClassControllerName {
-		return ctrl.Result{}, nil
-	}
-
 	log.Info("found a supported Gateway, determining whether the gateway has been accepted")
 	oldGateway := gateway.DeepCopy()
+	classAccepted, err := isGatewayClassAccepted(ctx, r.Client, gateway.Spec.GatewayClassName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !classAccepted {
+		// the Gateway is enqueued again by the GatewayClass watch once the
+		// GatewayClass is accepted.
+		log.Info("waiting for the gatewayclass to be accepted", "gatewayclass", gateway.Spec.GatewayClassName)
+		message := fmt.Sprintf("waiting for GatewayClass %s to be accepted", gateway.Spec.GatewayClassName)
+		setCond(gateway, metav1.Condition{
+			Type:               string(gatewayv1beta1.GatewayConditionAccepted),
+			ObservedGeneration: gateway.Generation,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             string(gatewayv1beta1.GatewayReasonPending),
+			Message:            message,
+		})
+		setCond(gateway, metav1.Condition{
+			Type:               string(gatewayv1beta1.GatewayConditionProgrammed),
+			ObservedGeneration: gateway.Generation,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             string(gatewayv1beta1.GatewayReasonPending),
+			Message:            message,
+		})
+		updateConditionGeneration(gateway)
+		return r.patchGatewayStatus(ctx, gateway, oldGateway, ctrl.Result{})
+	}
 	if !isGatewayAccepted(gateway) {
 		log.Info("gateway not yet accepted")
-		setGatewayListenerStatus(gateway)
-		setGatewayStatus(gateway)
+		setGatewayListenerStatus(gateway, r.EnableHTTPRoute)
+		r.setGatewayStatus(gateway)
 		updateConditionGeneration(gateway)
-		return ctrl.Result{}, r.Status().Patch(ctx, gateway, client.MergeFrom(oldGateway))
+		return r.patchGatewayStatus(ctx, gateway, oldGateway, ctrl.Result{})
 	}
 
+	if err := validateGatewayAnnotations(gateway); err != nil {
+		log.Info("gateway has an invalid annotation", "error", err.Error())
+		r.setGatewayStatus(gateway)
+		updateConditionGeneration(gateway)
+		return r.patchGatewayStatus(ctx, gateway, oldGateway, ctrl.Result{})
+	}
+	serviceManagement, _ := serviceManagementForGateway(gateway)
+
 	log.Info("checking for Service for Gateway")
 	svc, err := r.getServiceForGateway(ctx, gateway)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 	if svc == nil {
+		if serviceManagement == ServiceManagementExternal {
+			log.Info("waiting for the user provided Service for Gateway")
+			setCond(gateway, metav1.Condition{
+				Type:               string(gatewayv1beta1.GatewayConditionProgrammed),
+				ObservedGeneration: gateway.Generation,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: metav1.Now(),
+				Reason:             string(gatewayv1beta1.GatewayReasonAddressNotAssigned),
+				Message:            fmt.Sprintf("no Service labeled %s=%s found for the Gateway", gatewayServiceLabel, gateway.Name),
+			})
+			updateConditionGeneration(gateway)
+			return r.patchGatewayStatus(ctx, gateway, oldGateway, ctrl.Result{}) // service creation will requeue gateway
+		}
 		log.Info("creating Service for Gateway")
 		return ctrl.Result{}, r.createServiceForGateway(ctx, gateway) // service creation will requeue gateway
 	}
 
-	log.Info("checking Service configuration")
-	needsUpdate, err := r.ensureServiceConfiguration(ctx, svc, gateway)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-	if needsUpdate {
-		return ctrl.Result{}, r.Client.Update(ctx, svc)
+	if serviceManagement == ServiceManagementManaged {
+		log.Info("checking Service configuration")
+		if class := loadBalancerClassForGateway(gateway); class != nil && !ptr.Equal(svc.Spec.LoadBalancerClass, class) {
+			// the loadBalancerClass of a Service is immutable, the Service is
+			// recreated with the new class once deleted.
+			log.Info("recreating Service for Gateway with a new load balancer class", "namespace", svc.Namespace, "name", svc.Name)
+			return ctrl.Result{}, client.IgnoreNotFound(r.Client.Delete(ctx, svc))
+		}
+		oldPorts := append([]corev1.ServicePort(nil), svc.Spec.Ports...)
+		needsUpdate, err := r.ensureServiceConfiguration(ctx, svc, gateway)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if needsUpdate {
+			if err := r.deleteProtocolChangedVIPs(ctx, gateway, oldPorts, svc.Spec.Ports); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, r.Client.Update(ctx, svc)
+		}
 	}
 
 	log.Info("checking Service status", "namespace", svc.Namespace, "name", svc.Name)
@@ -169,15 +499,20 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 					Message:            err.Error(),
 				})
 				updateConditionGeneration(gateway)
-				return ctrl.Result{Requeue: true}, r.Status().Patch(ctx, gateway, client.MergeFrom(oldGateway))
+				return r.patchGatewayStatus(ctx, gateway, oldGateway, ctrl.Result{Requeue: true})
 			}
 			return ctrl.Result{}, err
 		}
 
-		if svc.Spec.ClusterIP == "" || len(svc.Status.LoadBalancer.Ingress) < 1 {
-			log.Info("waiting for Service to be ready")
-			return ctrl.Result{RequeueAfter: time.Second}, nil
+		// the readiness of the Service is based on its load balancer ingress
+		// only: its ClusterIP may legitimately be "None" or empty, for
+		// instance if it's headless, and never be assigned.
+		if len(svc.Status.LoadBalancer.Ingress) < 1 {
+			requeueAfter := r.nextServiceReadyRequeue(req.NamespacedName)
+			log.Info("waiting for Service to be ready", "clusterIP", svc.Spec.ClusterIP, "requeueAfter", requeueAfter)
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
 		}
+		r.resetServiceReadyRequeue(req.NamespacedName)
 	default:
 		return ctrl.Result{}, fmt.Errorf("found unsupported Service type: %s (only LoadBalancer type is currently supported)", t)
 	}
@@ -185,17 +520,45 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	// hack for metallb - https://github.com/metallb/metallb/issues/1640
 	// no need to enforce the gateway status here, as this endpoint is not reconciled by the controller
 	// and no reconciliation loop is triggered upon its change or deletion.
-	created, err := r.hackEnsureEndpoints(ctx, svc)
+	// user provided Services are left for the user to work around it.
+	if r.usesMetalLB() && !r.DisableMetalLBEndpointsHack && serviceManagement == ServiceManagementManaged {
+		created, err := r.hackEnsureEndpoints(ctx, svc)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if created {
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
+
+	missingBackends, unhealthyBackends, err := r.getListenersBackendIssues(ctx, gateway)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	if created {
-		return ctrl.Result{Requeue: true}, nil
-	}
+	result := ctrl.Result{}
 
 	log.Info("Service is ready, setting Gateway as programmed")
 	setGatewayStatusAddresses(gateway, svc)
-	setGatewayListenerConditionsAndProgrammed(gateway)
+	setGatewayListenerConditionsAndProgrammed(gateway, missingBackends, unhealthyBackends, r.EnableHTTPRoute)
+	reason, message, err := r.checkDataPlane(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if reason != "" {
+		log.Info("the dataplane is not ready, setting Gateway as not programmed", "reason", reason)
+		setCond(gateway, metav1.Condition{
+			Type:               string(gatewayv1beta1.GatewayConditionProgrammed),
+			ObservedGeneration: gateway.Generation,
+			Status:             metav1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             string(reason),
+			Message:            message,
+		})
+		// the creation of the DaemonSet is only noticed once it has ready Pods.
+		if reason == GatewayReasonNoDataplane {
+			result.RequeueAfter = noDataPlaneRequeueInterval
+		}
+	}
 	updateConditionGeneration(gateway)
-	return ctrl.Result{}, r.Status().Patch(ctx, gateway, client.MergeFrom(oldGateway))
+	return r.patchGatewayStatus(ctx, gateway, oldGateway, result)
 }
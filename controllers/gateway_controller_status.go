@@ -17,31 +17,77 @@ limitations under the License.
 package controllers
 
 import (
+	"fmt"
+	"net"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
+// ListenerReasonUnsupportedValue is used with the Accepted condition when a
+// listener has a value which this implementation doesn't support, such as a
+// port outside of the valid range.
+const ListenerReasonUnsupportedValue gatewayv1beta1.ListenerConditionReason = "UnsupportedValue"
+
+// ListenerReasonBackendNotFound is used with the ResolvedRefs condition when a
+// route attached to the listener refers to a backend Service which doesn't
+// exist. Unlike invalid route kinds, this doesn't prevent the listener from
+// being programmed, as the other routes attached to it are still served.
+const ListenerReasonBackendNotFound gatewayv1beta1.ListenerConditionReason = "BackendNotFound"
+
+// ListenerConditionBackendsHealthy indicates whether the routes attached to a
+// listener have ready backend endpoints. It's only set, to False, on the
+// listeners with routes which have none: such listeners are still programmed,
+// as the other routes attached to them are still served.
+const ListenerConditionBackendsHealthy gatewayv1beta1.ListenerConditionType = "BackendsHealthy"
+
+// ListenerReasonNoReadyEndpoints is used with the BackendsHealthy condition
+// when a route attached to the listener has no ready backend endpoints.
+const ListenerReasonNoReadyEndpoints gatewayv1beta1.ListenerConditionReason = "NoReadyEndpoints"
+
+// GatewayReasonNoReadyDataplanes is used with the Programmed condition when
+// the Service of the Gateway is ready, but no dataplane instance is ready to
+// route its traffic, for instance while the nodes are drained.
+const GatewayReasonNoReadyDataplanes gatewayv1beta1.GatewayConditionReason = "NoReadyDataplanes"
+
+// GatewayReasonNoDataplane is used with the Programmed condition when the
+// dataplane DaemonSet doesn't exist, isn't labeled as the dataplane or has no
+// ready Pods, so that no traffic can be routed.
+const GatewayReasonNoDataplane gatewayv1beta1.GatewayConditionReason = "NoDataplane"
+
+// setGatewayStatusAddresses sets the addresses of the Gateway from the load
+// balancer ingress points of its Service. The IP of an ingress point is
+// preferred over its hostname, as both refer to the same load balancer, and
+// the invalid or duplicate addresses are skipped.
 func setGatewayStatusAddresses(gateway *gatewayv1beta1.Gateway, svc *corev1.Service) {
 	gwaddrs := []gatewayv1beta1.GatewayStatusAddress{}
+	seen := map[string]bool{}
+	add := func(addrType *gatewayv1beta1.AddressType, value string) {
+		if !seen[value] {
+			seen[value] = true
+			gwaddrs = append(gwaddrs, gatewayv1beta1.GatewayStatusAddress{Type: addrType, Value: value})
+		}
+	}
 	for _, addr := range svc.Status.LoadBalancer.Ingress {
-		if addr.IP != "" {
-			gwaddrs = append(gwaddrs, gatewayv1beta1.GatewayStatusAddress{
-				Type:  &ipAddrType,
-				Value: addr.IP,
-			})
+		if ip := net.ParseIP(addr.IP); ip != nil {
+			add(&ipAddrType, ip.String())
+			continue
 		}
-		if addr.Hostname != "" {
-			gwaddrs = append(gwaddrs, gatewayv1beta1.GatewayStatusAddress{
-				Type:  &hostAddrType,
-				Value: addr.Hostname,
-			})
+		if addr.Hostname != "" && len(validation.IsDNS1123Subdomain(addr.Hostname)) == 0 {
+			add(&hostAddrType, addr.Hostname)
 		}
 	}
 	gateway.Status.Addresses = gwaddrs
 }
 
-func setGatewayListenerConditionsAndProgrammed(gateway *gatewayv1beta1.Gateway) {
+// setGatewayListenerConditionsAndProgrammed sets the listener conditions and
+// the Programmed condition of a Gateway whose Service is ready. The provided
+// maps hold, by listener name, why some backends of the routes attached to
+// the listener couldn't be resolved, and which attached routes have no ready
+// backend endpoints. HTTPRoute is only a supported kind if httpRouteEnabled.
+func setGatewayListenerConditionsAndProgrammed(gateway *gatewayv1beta1.Gateway, missingBackends, unhealthyBackends map[gatewayv1beta1.SectionName]string, httpRouteEnabled bool) {
 	programmed := metav1.Condition{
 		Type:               string(gatewayv1beta1.GatewayConditionProgrammed),
 		Status:             metav1.ConditionTrue,
@@ -53,35 +99,52 @@ func setGatewayListenerConditionsAndProgrammed(gateway *gatewayv1beta1.Gateway)
 
 	listenersStatus := make([]gatewayv1beta1.ListenerStatus, 0, len(gateway.Spec.Listeners))
 	for _, l := range gateway.Spec.Listeners {
-		supportedKinds, resolvedRefsCondition := getSupportedKinds(gateway.Generation, l)
+		supportedKinds, resolvedRefsCondition := getSupportedKinds(gateway.Generation, l, httpRouteEnabled)
+		acceptedCondition := getListenerAcceptedCondition(gateway.Generation, l)
 		listenerProgrammedStatus := corev1.ConditionTrue
 		listenerProgrammedReason := gatewayv1beta1.ListenerReasonProgrammed
 		if resolvedRefsCondition.Status == metav1.ConditionFalse {
 			listenerProgrammedStatus = corev1.ConditionStatus(metav1.ConditionFalse)
 			listenerProgrammedReason = gatewayv1beta1.ListenerReasonResolvedRefs
 		}
+		if acceptedCondition.Status == metav1.ConditionFalse {
+			listenerProgrammedStatus = corev1.ConditionStatus(metav1.ConditionFalse)
+			listenerProgrammedReason = gatewayv1beta1.ListenerReasonInvalid
+		}
+		kindsResolved := resolvedRefsCondition.Status == metav1.ConditionTrue
+		if message, ok := missingBackends[l.Name]; ok && kindsResolved {
+			resolvedRefsCondition.Status = metav1.ConditionFalse
+			resolvedRefsCondition.Reason = string(ListenerReasonBackendNotFound)
+			resolvedRefsCondition.Message = message
+		}
+		conditions := []metav1.Condition{
+			acceptedCondition,
+			{
+				Type:               string(gatewayv1beta1.ListenerConditionProgrammed),
+				Status:             metav1.ConditionStatus(listenerProgrammedStatus),
+				Reason:             string(listenerProgrammedReason),
+				ObservedGeneration: gateway.Generation,
+				LastTransitionTime: metav1.Now(),
+				Message:            getListenerProgrammedMessage(l),
+			},
+			resolvedRefsCondition,
+		}
+		if message, ok := unhealthyBackends[l.Name]; ok && kindsResolved {
+			conditions = append(conditions, metav1.Condition{
+				Type:               string(ListenerConditionBackendsHealthy),
+				Status:             metav1.ConditionFalse,
+				Reason:             string(ListenerReasonNoReadyEndpoints),
+				ObservedGeneration: gateway.Generation,
+				LastTransitionTime: metav1.Now(),
+				Message:            message,
+			})
+		}
 		listenersStatus = append(listenersStatus, gatewayv1beta1.ListenerStatus{
 			Name:           l.Name,
 			SupportedKinds: supportedKinds,
-			Conditions: []metav1.Condition{
-				{
-					Type:               string(gatewayv1beta1.ListenerConditionAccepted),
-					Status:             metav1.ConditionTrue,
-					Reason:             string(gatewayv1beta1.ListenerReasonAccepted),
-					ObservedGeneration: gateway.Generation,
-					LastTransitionTime: metav1.Now(),
-				},
-				{
-					Type:               string(gatewayv1beta1.ListenerConditionProgrammed),
-					Status:             metav1.ConditionStatus(listenerProgrammedStatus),
-					Reason:             string(listenerProgrammedReason),
-					ObservedGeneration: gateway.Generation,
-					LastTransitionTime: metav1.Now(),
-				},
-				resolvedRefsCondition,
-			},
+			Conditions:     conditions,
 		})
-		if resolvedRefsCondition.Status == metav1.ConditionFalse {
+		if !kindsResolved {
 			programmed.Status = metav1.ConditionFalse
 			programmed.Reason = string(gatewayv1beta1.GatewayReasonAddressNotAssigned)
 			programmed.Message = "the gateway is not ready to route traffic"
@@ -91,28 +154,101 @@ func setGatewayListenerConditionsAndProgrammed(gateway *gatewayv1beta1.Gateway)
 	setCond(gateway, programmed)
 }
 
-func setGatewayListenerStatus(gateway *gatewayv1beta1.Gateway) {
+func setGatewayListenerStatus(gateway *gatewayv1beta1.Gateway, httpRouteEnabled bool) {
 	gateway.Status.Listeners = make([]gatewayv1beta1.ListenerStatus, 0, len(gateway.Spec.Listeners))
 	for _, l := range gateway.Spec.Listeners {
-		supportedKinds, resolvedRefsCondition := getSupportedKinds(gateway.Generation, l)
+		supportedKinds, resolvedRefsCondition := getSupportedKinds(gateway.Generation, l, httpRouteEnabled)
+		conditions := []metav1.Condition{
+			{
+				Type:               string(gatewayv1beta1.ListenerConditionProgrammed),
+				Status:             metav1.ConditionFalse,
+				Reason:             string(gatewayv1beta1.ListenerReasonPending),
+				ObservedGeneration: gateway.Generation,
+				LastTransitionTime: metav1.Now(),
+				Message:            getListenerProgrammedMessage(l),
+			},
+			resolvedRefsCondition,
+		}
+		if acceptedCondition := getListenerAcceptedCondition(gateway.Generation, l); acceptedCondition.Status == metav1.ConditionFalse {
+			conditions = append([]metav1.Condition{acceptedCondition}, conditions...)
+		}
 		gateway.Status.Listeners = append(gateway.Status.Listeners, gatewayv1beta1.ListenerStatus{
 			Name:           l.Name,
 			SupportedKinds: supportedKinds,
-			Conditions: []metav1.Condition{
-				{
-					Type:               string(gatewayv1beta1.ListenerConditionProgrammed),
-					Status:             metav1.ConditionFalse,
-					Reason:             string(gatewayv1beta1.ListenerReasonPending),
-					ObservedGeneration: gateway.Generation,
-					LastTransitionTime: metav1.Now(),
-				},
-				resolvedRefsCondition,
-			},
+			Conditions:     conditions,
 		})
 	}
 }
 
-func getSupportedKinds(generation int64, listener gatewayv1beta1.Listener) (supportedKinds []gatewayv1beta1.RouteGroupKind, resolvedRefsCondition metav1.Condition) {
+// getListenerAcceptedCondition returns the Accepted condition for the provided
+// listener, which is only accepted if its port is in the valid range.
+func getListenerAcceptedCondition(generation int64, listener gatewayv1beta1.Listener) metav1.Condition {
+	if !isListenerPortValid(listener.Port) {
+		return metav1.Condition{
+			Type:               string(gatewayv1beta1.ListenerConditionAccepted),
+			Status:             metav1.ConditionFalse,
+			Reason:             string(ListenerReasonUnsupportedValue),
+			ObservedGeneration: generation,
+			LastTransitionTime: metav1.Now(),
+			Message:            fmt.Sprintf("port %d is not in the valid range 1-65535", listener.Port),
+		}
+	}
+	return metav1.Condition{
+		Type:               string(gatewayv1beta1.ListenerConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1beta1.ListenerReasonAccepted),
+		ObservedGeneration: generation,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+// getListenerProgrammedMessage returns the message for the Programmed condition
+// of the provided listener. HTTP and HTTPS listeners are only programmed as
+// plain TCP listeners (see getSupportedKinds), which is called out so that
+// users don't expect L7 routing or TLS termination.
+func getListenerProgrammedMessage(listener gatewayv1beta1.Listener) string {
+	switch {
+	case listener.Protocol == gatewayv1beta1.HTTPProtocolType, listener.Protocol == gatewayv1beta1.HTTPSProtocolType:
+		return fmt.Sprintf("%s listeners have limited support: traffic is forwarded as plain TCP, without HTTP routing or TLS termination", listener.Protocol)
+	case isTLSPassthroughListener(listener):
+		return "TLS passthrough listeners have limited support: traffic is forwarded as plain TCP, without SNI routing"
+	default:
+		return ""
+	}
+}
+
+// listenerTLSMode returns the TLS mode of the provided listener, which defaults
+// to Terminate when the listener has a TLS configuration, or an empty mode if
+// it has none.
+func listenerTLSMode(listener gatewayv1beta1.Listener) gatewayv1beta1.TLSModeType {
+	if listener.TLS == nil {
+		return ""
+	}
+	if listener.TLS.Mode == nil {
+		return gatewayv1beta1.TLSModeTerminate
+	}
+	return *listener.TLS.Mode
+}
+
+// isTLSPassthroughListener indicates whether the provided listener is a TLS or
+// TCP listener in Passthrough mode, which is programmed as plain TCP
+// forwarding: the TLS connections are forwarded to the backends as-is.
+func isTLSPassthroughListener(listener gatewayv1beta1.Listener) bool {
+	return (listener.Protocol == gatewayv1beta1.TLSProtocolType || listener.Protocol == gatewayv1beta1.TCPProtocolType) &&
+		listenerTLSMode(listener) == gatewayv1beta1.TLSModePassthrough
+}
+
+// isListenerPortValid indicates whether the provided listener port can be
+// exposed by the Gateway's Service.
+func isListenerPortValid(port gatewayv1beta1.PortNumber) bool {
+	return port >= 1 && port <= 65535
+}
+
+// getSupportedKinds returns the route kinds supported by the provided listener
+// and its ResolvedRefs condition, which is False if it allows unsupported
+// kinds. HTTPRoute is only supported by HTTP and HTTPS listeners if
+// httpRouteEnabled.
+func getSupportedKinds(generation int64, listener gatewayv1beta1.Listener, httpRouteEnabled bool) (supportedKinds []gatewayv1beta1.RouteGroupKind, resolvedRefsCondition metav1.Condition) {
 	supportedKinds = make([]gatewayv1beta1.RouteGroupKind, 0)
 	resolvedRefsCondition = metav1.Condition{
 		Type:               string(gatewayv1beta1.ListenerConditionResolvedRefs),
@@ -127,27 +263,35 @@ func getSupportedKinds(generation int64, listener gatewayv1beta1.Listener) (supp
 		case gatewayv1beta1.TCPProtocolType:
 			supportedKinds = append(supportedKinds, gatewayv1beta1.RouteGroupKind{
 				Group: (*gatewayv1beta1.Group)(&gatewayv1beta1.GroupVersion.Group),
-				Kind:  "TCPRoute",
+				Kind:  TCPRouteKind,
 			})
 		case gatewayv1beta1.UDPProtocolType:
 			supportedKinds = append(supportedKinds, gatewayv1beta1.RouteGroupKind{
 				Group: (*gatewayv1beta1.Group)(&gatewayv1beta1.GroupVersion.Group),
-				Kind:  "UDPRoute",
+				Kind:  UDPRouteKind,
+			})
+		// TLSRoute is not supported: TLS listeners are only programmed in
+		// Passthrough mode, as plain TCP listeners.
+		case gatewayv1beta1.TLSProtocolType:
+			supportedKinds = append(supportedKinds, gatewayv1beta1.RouteGroupKind{
+				Group: (*gatewayv1beta1.Group)(&gatewayv1beta1.GroupVersion.Group),
+				Kind:  TCPRouteKind,
 			})
 		// TODO: this is a hack to workaround defaults listener configurations
 		// that were present in the Gateway API conformance tests, so that we
 		// can still pass the tests. For now, we just treat an HTTP/S listener
 		// as a TCP listener to workaround this (but we don't actually support
-		// HTTPRoute).
-		case gatewayv1beta1.HTTPProtocolType:
-			supportedKinds = append(supportedKinds, gatewayv1beta1.RouteGroupKind{
-				Group: (*gatewayv1beta1.Group)(&gatewayv1beta1.GroupVersion.Group),
-				Kind:  "HTTPRoute",
-			})
-		case gatewayv1beta1.HTTPSProtocolType:
+		// HTTPRoute), which is why it's gated by httpRouteEnabled.
+		case gatewayv1beta1.HTTPProtocolType, gatewayv1beta1.HTTPSProtocolType:
+			if !httpRouteEnabled {
+				resolvedRefsCondition.Status = metav1.ConditionFalse
+				resolvedRefsCondition.Reason = string(gatewayv1beta1.ListenerReasonInvalidRouteKinds)
+				resolvedRefsCondition.Message = fmt.Sprintf("%s is not supported, only %s is on %s listeners", HTTPRouteKind, TCPRouteKind, listener.Protocol)
+				break
+			}
 			supportedKinds = append(supportedKinds, gatewayv1beta1.RouteGroupKind{
 				Group: (*gatewayv1beta1.Group)(&gatewayv1beta1.GroupVersion.Group),
-				Kind:  "HTTPRoute",
+				Kind:  HTTPRouteKind,
 			})
 		default:
 			resolvedRefsCondition.Status = metav1.ConditionFalse
@@ -157,33 +301,65 @@ func getSupportedKinds(generation int64, listener gatewayv1beta1.Listener) (supp
 
 	for _, k := range listener.AllowedRoutes.Kinds {
 		if (k.Group != nil && *k.Group != "" && *k.Group != gatewayv1beta1.Group(gatewayv1beta1.GroupVersion.Group)) ||
-			(k.Kind != "UDPRoute" && k.Kind != "TCPRoute") {
+			!(isSupportedRouteKind(string(k.Kind)) || (httpRouteEnabled && k.Kind == HTTPRouteKind)) {
 			resolvedRefsCondition.Status = metav1.ConditionFalse
 			resolvedRefsCondition.Reason = string(gatewayv1beta1.ListenerReasonInvalidRouteKinds)
 			continue
 		}
+		if !isRouteKindCompatible(string(k.Kind), listener.Protocol) {
+			resolvedRefsCondition.Status = metav1.ConditionFalse
+			resolvedRefsCondition.Reason = string(gatewayv1beta1.ListenerReasonInvalidRouteKinds)
+			resolvedRefsCondition.Message = fmt.Sprintf("%s is not supported on %s listeners", k.Kind, listener.Protocol)
+			continue
+		}
 		supportedKinds = append(supportedKinds, gatewayv1beta1.RouteGroupKind{
 			Group: k.Group,
 			Kind:  k.Kind,
 		})
 	}
+
+	// TLS termination is not supported yet, so the certificates can't be
+	// resolved.
+	if mode := listenerTLSMode(listener); mode != "" && mode != gatewayv1beta1.TLSModePassthrough {
+		resolvedRefsCondition.Status = metav1.ConditionFalse
+		resolvedRefsCondition.Reason = string(gatewayv1beta1.ListenerReasonInvalidCertificateRef)
+		resolvedRefsCondition.Message = fmt.Sprintf("TLS mode %s is not supported, only Passthrough is", mode)
+	}
 	return supportedKinds, resolvedRefsCondition
 }
 
-// updateConditionGeneration takes the old gateway conditions not transitioned and copies them
-// into the new gateway status, so that only the transitioning conditions gets actually patched.
+// isRouteKindCompatible indicates whether routes of the provided kind can be
+// attached to a listener with the provided protocol: TCPRoutes to the TCP
+// listeners, including the TLS and HTTP/S listeners which are programmed as
+// TCP listeners, UDPRoutes to the UDP listeners, and HTTPRoutes to the HTTP/S
+// listeners.
+func isRouteKindCompatible(kind string, protocol gatewayv1beta1.ProtocolType) bool {
+	switch kind {
+	case HTTPRouteKind:
+		return protocol == gatewayv1beta1.HTTPProtocolType || protocol == gatewayv1beta1.HTTPSProtocolType
+	case TCPRouteKind:
+		switch protocol {
+		case gatewayv1beta1.TCPProtocolType, gatewayv1beta1.TLSProtocolType,
+			gatewayv1beta1.HTTPProtocolType, gatewayv1beta1.HTTPSProtocolType:
+			return true
+		}
+	case UDPRouteKind:
+		return protocol == gatewayv1beta1.UDPProtocolType
+	}
+	return false
+}
+
+// updateConditionGeneration sets the ObservedGeneration of all the Gateway
+// and listener conditions to the current generation of the Gateway.
 func updateConditionGeneration(gateway *gatewayv1beta1.Gateway) {
-	for i := 0; i < len(gateway.Status.Conditions); i++ {
-		gateway.Status.Conditions[0].ObservedGeneration = gateway.Generation
+	for i := range gateway.Status.Conditions {
+		gateway.Status.Conditions[i].ObservedGeneration = gateway.Generation
 	}
 
-	for i := 0; i < len(gateway.Status.Listeners); i++ {
-		updatedListenerConditions := []metav1.Condition{}
-		for _, cond := range gateway.Status.Listeners[0].Conditions {
-			cond.ObservedGeneration = gateway.Generation
-			updatedListenerConditions = append(updatedListenerConditions, cond)
+	for i := range gateway.Status.Listeners {
+		for j := range gateway.Status.Listeners[i].Conditions {
+			gateway.Status.Listeners[i].Conditions[j].ObservedGeneration = gateway.Generation
 		}
-		gateway.Status.Listeners[0].Conditions = updatedListenerConditions
 	}
 }
 
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func TestUpdateConditionGeneration(t *testing.T) {
+	conditions := func() []metav1.Condition {
+		return []metav1.Condition{
+			{Type: string(gatewayv1beta1.GatewayConditionAccepted), ObservedGeneration: 1},
+			{Type: string(gatewayv1beta1.GatewayConditionProgrammed), ObservedGeneration: 2},
+		}
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Status: gatewayv1beta1.GatewayStatus{
+			Conditions: conditions(),
+			Listeners: []gatewayv1beta1.ListenerStatus{
+				{Name: "tcp", Conditions: conditions()},
+				{Name: "udp", Conditions: conditions()},
+			},
+		},
+	}
+
+	updateConditionGeneration(gateway)
+
+	for _, cond := range gateway.Status.Conditions {
+		assert.Equal(t, int64(3), cond.ObservedGeneration, "gateway condition %s", cond.Type)
+	}
+	for _, listener := range gateway.Status.Listeners {
+		for _, cond := range listener.Conditions {
+			assert.Equal(t, int64(3), cond.ObservedGeneration, "listener %s condition %s", listener.Name, cond.Type)
+		}
+	}
+}
+
+func TestSetGatewayStatusAddresses(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		ingress  []corev1.LoadBalancerIngress
+		expected []gatewayv1beta1.GatewayStatusAddress
+	}{
+		{
+			name:     "no ingress",
+			expected: []gatewayv1beta1.GatewayStatusAddress{},
+		},
+		{
+			name: "the IP is preferred over the hostname of the same ingress",
+			ingress: []corev1.LoadBalancerIngress{
+				{IP: "1.2.3.4", Hostname: "lb.example.com"},
+			},
+			expected: []gatewayv1beta1.GatewayStatusAddress{
+				{Type: &ipAddrType, Value: "1.2.3.4"},
+			},
+		},
+		{
+			name: "duplicate ingress are deduplicated",
+			ingress: []corev1.LoadBalancerIngress{
+				{IP: "1.2.3.4"},
+				{Hostname: "lb.example.com"},
+				{IP: "1.2.3.4"},
+				{Hostname: "lb.example.com"},
+				{IP: "::ffff:1.2.3.4"},
+			},
+			expected: []gatewayv1beta1.GatewayStatusAddress{
+				{Type: &ipAddrType, Value: "1.2.3.4"},
+				{Type: &hostAddrType, Value: "lb.example.com"},
+			},
+		},
+		{
+			name: "invalid addresses are skipped",
+			ingress: []corev1.LoadBalancerIngress{
+				{IP: "1.2.3"},
+				{Hostname: "not a hostname"},
+				{IP: "not-an-ip", Hostname: "lb.example.com"},
+				{IP: "5.6.7.8"},
+			},
+			expected: []gatewayv1beta1.GatewayStatusAddress{
+				{Type: &hostAddrType, Value: "lb.example.com"},
+				{Type: &ipAddrType, Value: "5.6.7.8"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &gatewayv1beta1.Gateway{}
+			svc := &corev1.Service{
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{Ingress: tt.ingress},
+				},
+			}
+
+			setGatewayStatusAddresses(gateway, svc)
+
+			assert.Equal(t, tt.expected, gateway.Status.Addresses)
+		})
+	}
+}
@@ -18,25 +18,57 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	controllerruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
 	"github.com/kubernetes-sigs/blixt/internal/test/utils"
 	"github.com/kubernetes-sigs/blixt/pkg/vars"
 )
 
 func init() {
 	_ = gatewayv1beta1.AddToScheme(scheme.Scheme)
+	_ = gatewayv1alpha2.AddToScheme(scheme.Scheme)
+}
+
+// acceptedGatewayClassStatus is the status of a GatewayClass accepted by the
+// GatewayClass reconciler, which Gateways wait for before being programmed.
+var acceptedGatewayClassStatus = gatewayv1beta1.GatewayClassStatus{
+	Conditions: []metav1.Condition{{
+		Type:   string(gatewayv1beta1.GatewayClassConditionStatusAccepted),
+		Status: metav1.ConditionTrue,
+		Reason: string(gatewayv1beta1.GatewayClassReasonAccepted),
+	}},
+}
+
+// newGatewayTestClientBuilder returns a fake client builder with the route
+// indexes the GatewayReconciler lists the routes with.
+func newGatewayTestClientBuilder() *fakectrlruntimeclient.ClientBuilder {
+	return fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithIndex(&gatewayv1alpha2.TCPRoute{}, routeParentGatewayKey, tcpRouteParentGateways).
+		WithIndex(&gatewayv1alpha2.UDPRoute{}, routeParentGatewayKey, udpRouteParentGateways).
+		WithIndex(&gatewayv1alpha2.TCPRoute{}, routeBackendServiceKey, tcpRouteBackendServices).
+		WithIndex(&gatewayv1alpha2.UDPRoute{}, routeBackendServiceKey, udpRouteBackendServices)
 }
 
 func TestGatewayReconciler_gatewayHasMatchingGatewayClass(t *testing.T) {
@@ -117,6 +149,67 @@ func TestGatewayReconciler_gatewayHasMatchingGatewayClass(t *testing.T) {
 	}
 }
 
+func TestGatewayReconciler_gatewayClassLookups(t *testing.T) {
+	ctx := context.Background()
+	logger, output := utils.NewBytesBufferLogger()
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: corev1.NamespaceDefault},
+	}
+	managedGWC, _, fakeClient := utils.NewFakeClientWithGatewayClasses(gateway)
+	gateway.Spec.GatewayClassName = managedGWC
+	require.NoError(t, fakeClient.Update(ctx, gateway))
+
+	var gets int
+	var getErr error
+	cachedClient := interceptor.NewClient(fakeClient.(controllerruntimeclient.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c controllerruntimeclient.WithWatch, key controllerruntimeclient.ObjectKey, obj controllerruntimeclient.Object, opts ...controllerruntimeclient.GetOption) error {
+			if _, ok := obj.(*gatewayv1beta1.GatewayClass); ok {
+				gets++
+				if getErr != nil {
+					return getErr
+				}
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	r := GatewayReconciler{Client: cachedClient, Scheme: fakeClient.Scheme(), Log: logger}
+
+	t.Log("the predicate looks up the GatewayClass through the reconciler's client")
+	assert.True(t, r.gatewayHasMatchingGatewayClass(gateway))
+	assert.Equal(t, 1, gets)
+
+	t.Log("a missing GatewayClass doesn't match and isn't reported as an error")
+	missing := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing-gateway", Namespace: corev1.NamespaceDefault},
+		Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: "non-existent-gateway-class"},
+	}
+	assert.False(t, r.gatewayHasMatchingGatewayClass(missing))
+	assert.Empty(t, output.String())
+
+	t.Log("a transient error enqueues the gateway anyway")
+	getErr = apierrors.NewServiceUnavailable("cache not synced")
+	assert.True(t, r.gatewayHasMatchingGatewayClass(gateway))
+	assert.Contains(t, output.String(), "couldn't retrieve gatewayclass")
+
+	t.Log("the reconcilers return transient errors to be retried")
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)})
+	assert.ErrorIs(t, err, getErr)
+	tcpReconciler := TCPRouteReconciler{Client: cachedClient, log: logger}
+	_, _, err = tcpReconciler.isTCPRouteManaged(ctx, gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: corev1.NamespaceDefault},
+		Spec: gatewayv1alpha2.TCPRouteSpec{CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+			ParentRefs: []gatewayv1alpha2.ParentReference{{Name: "test-gateway"}},
+		}},
+	})
+	assert.ErrorIs(t, err, getErr)
+
+	t.Log("the reconcilers ignore gateways with a missing GatewayClass")
+	getErr = nil
+	require.NoError(t, fakeClient.Create(ctx, missing))
+	_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(missing)})
+	require.NoError(t, err)
+}
+
 func TestGatewayReconciler_reconcile(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -125,7 +218,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 		gateway      *gatewayv1beta1.Gateway
 		objectsToAdd []controllerruntimeclient.Object
 
-		run func(t *testing.T, reconciler GatewayReconciler, gatewayReq reconcile.Request, gatewayClass *gatewayv1beta1.Gateway)
+		run func(t *testing.T, reconciler *GatewayReconciler, gatewayReq reconcile.Request, gatewayClass *gatewayv1beta1.Gateway)
 	}{
 		{
 			name: "gatewayclass not accepted",
@@ -159,7 +252,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 					},
 				},
 			},
-			run: func(t *testing.T, reconciler GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
+			run: func(t *testing.T, reconciler *GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
 				ctx := context.Background()
 				_, err := reconciler.Reconcile(ctx, gatewayReq)
 				require.NoError(t, err)
@@ -186,6 +279,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 				Spec: gatewayv1beta1.GatewayClassSpec{
 					ControllerName: vars.GatewayClassControllerName,
 				},
+				Status: acceptedGatewayClassStatus,
 			},
 			gateway: &gatewayv1beta1.Gateway{
 				ObjectMeta: metav1.ObjectMeta{
@@ -241,7 +335,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 					},
 				},
 			},
-			run: func(t *testing.T, reconciler GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
+			run: func(t *testing.T, reconciler *GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
 				ctx := context.Background()
 				// first reconcile to initialize the Gateway status
 				_, err := reconciler.Reconcile(ctx, gatewayReq)
@@ -283,6 +377,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 				Spec: gatewayv1beta1.GatewayClassSpec{
 					ControllerName: vars.GatewayClassControllerName,
 				},
+				Status: acceptedGatewayClassStatus,
 			},
 			gateway: &gatewayv1beta1.Gateway{
 				ObjectMeta: metav1.ObjectMeta{
@@ -301,7 +396,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 					},
 				},
 			},
-			run: func(t *testing.T, reconciler GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
+			run: func(t *testing.T, reconciler *GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
 				ctx := context.Background()
 				_, err := reconciler.Reconcile(ctx, gatewayReq)
 				require.NoError(t, err)
@@ -343,6 +438,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 				Spec: gatewayv1beta1.GatewayClassSpec{
 					ControllerName: vars.GatewayClassControllerName,
 				},
+				Status: acceptedGatewayClassStatus,
 			},
 			gateway: &gatewayv1beta1.Gateway{
 				ObjectMeta: metav1.ObjectMeta{
@@ -404,7 +500,7 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 					},
 				},
 			},
-			run: func(t *testing.T, reconciler GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
+			run: func(t *testing.T, reconciler *GatewayReconciler, gatewayReq reconcile.Request, gateway *gatewayv1beta1.Gateway) {
 				ctx := context.Background()
 				// first reconcile to initialize the Gateway status
 				_, err := reconciler.Reconcile(ctx, gatewayReq)
@@ -455,18 +551,1842 @@ func TestGatewayReconciler_reconcile(t *testing.T) {
 			}
 			objectsToAdd = append(objectsToAdd, tc.objectsToAdd...)
 
-			fakeClient := fakectrlruntimeclient.
-				NewClientBuilder().
-				WithScheme(scheme.Scheme).
+			fakeClient := newGatewayTestClientBuilder().
 				WithObjects(objectsToAdd...).
 				WithStatusSubresource(objectsToAdd...).
 				Build()
 
+			reconciler := &GatewayReconciler{
+				Client:          fakeClient,
+				EnableHTTPRoute: true,
+			}
+
+			tc.run(t, reconciler, tc.gatewayReq, tc.gateway)
+		})
+	}
+}
+
+func TestGatewayReconciler_namedAddress(t *testing.T) {
+	namedAddrType := gatewayv1beta1.NamedAddressType
+
+	for _, tt := range []struct {
+		name                   string
+		namedAddressAnnotation string
+		addressType            *gatewayv1beta1.AddressType
+		expectedAccepted       metav1.ConditionStatus
+		expectedAnnotations    map[string]string
+	}{
+		{
+			name:                   "a NamedAddress is mapped to the configured Service annotation",
+			namedAddressAnnotation: vars.DefaultNamedAddressAnnotation,
+			addressType:            &namedAddrType,
+			expectedAccepted:       metav1.ConditionTrue,
+			expectedAnnotations:    map[string]string{vars.DefaultNamedAddressAnnotation: "test-pool"},
+		},
+		{
+			name:             "a NamedAddress is not supported when no annotation is configured",
+			addressType:      &namedAddrType,
+			expectedAccepted: metav1.ConditionFalse,
+		},
+		{
+			name:                   "a Hostname address is not supported",
+			namedAddressAnnotation: vars.DefaultNamedAddressAnnotation,
+			addressType:            &hostAddrType,
+			expectedAccepted:       metav1.ConditionFalse,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:          "tcp",
+						Protocol:      gatewayv1beta1.TCPProtocolType,
+						Port:          8080,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					}},
+					Addresses: []gatewayv1beta1.GatewayAddress{{
+						Type:  tt.addressType,
+						Value: "test-pool",
+					}},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway).
+				WithStatusSubresource(gatewayClass, gateway).
+				Build()
+			reconciler := GatewayReconciler{
+				Client:                 fakeClient,
+				NamedAddressAnnotation: tt.namedAddressAnnotation,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			// first reconcile to initialize the Gateway status
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			accepted := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionAccepted))
+			require.NotNil(t, accepted)
+			require.Equal(t, tt.expectedAccepted, accepted.Status)
+			if tt.expectedAccepted == metav1.ConditionFalse {
+				require.Equal(t, string(gatewayv1beta1.GatewayReasonUnsupportedAddress), accepted.Reason)
+				return
+			}
+
+			// second reconcile to create the Service for the Gateway
+			_, err = reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+
+			svcs := &corev1.ServiceList{}
+			require.NoError(t, reconciler.Client.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+			require.Len(t, svcs.Items, 1)
+			require.Equal(t, tt.expectedAnnotations, svcs.Items[0].Annotations)
+			require.Empty(t, svcs.Items[0].Spec.LoadBalancerIP)
+		})
+	}
+}
+
+func TestGatewayReconciler_gatewayClassNotAccepted(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway).
+		Build()
+	reconciler := GatewayReconciler{Client: fakeClient}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+	requireAccepted := func(status metav1.ConditionStatus, reason gatewayv1beta1.GatewayConditionReason) {
+		t.Helper()
+		newGateway := &gatewayv1beta1.Gateway{}
+		require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+		accepted := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionAccepted))
+		require.NotNil(t, accepted)
+		require.Equal(t, status, accepted.Status)
+		require.Equal(t, string(reason), accepted.Reason)
+	}
+	svcs := &corev1.ServiceList{}
+
+	t.Log("the Gateway waits for its GatewayClass to be accepted")
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	requireAccepted(metav1.ConditionFalse, gatewayv1beta1.GatewayReasonPending)
+	require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+	require.Empty(t, svcs.Items)
+
+	t.Log("accepting the GatewayClass enqueues the Gateway")
+	gatewayClassReconciler := GatewayClassReconciler{Client: fakeClient}
+	_, err := gatewayClassReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gatewayClass)})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(gatewayClass), gatewayClass))
+	require.Equal(t, []reconcile.Request{gatewayReq}, reconciler.mapGatewayClassToGateway(ctx, gatewayClass))
+
+	t.Log("the Gateway is accepted and programmed once its GatewayClass is accepted")
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	requireAccepted(metav1.ConditionTrue, gatewayv1beta1.GatewayReasonAccepted)
+	require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+	require.Len(t, svcs.Items, 1)
+}
+
+func TestGatewayReconciler_metallbEndpointsHack(t *testing.T) {
+	for _, tt := range []struct {
+		name              string
+		disableHack       bool
+		expectedEndpoints int
+	}{
+		{
+			name:              "the Endpoints for the Gateway Service are created by default",
+			expectedEndpoints: 1,
+		},
+		{
+			name:              "no Endpoints are created when the hack is disabled",
+			disableHack:       true,
+			expectedEndpoints: 0,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:          "udp",
+						Protocol:      gatewayv1beta1.UDPProtocolType,
+						Port:          9875,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					}},
+				},
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-namespace",
+					Name:      "service-for-gateway-test-gateway",
+					Labels: map[string]string{
+						gatewayServiceLabel: "test-gateway",
+					},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeLoadBalancer,
+					ClusterIP: "1.1.1.1",
+					Ports: []corev1.ServicePort{{
+						Name:     "udp",
+						Protocol: corev1.ProtocolUDP,
+						Port:     9875,
+					}},
+				},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{
+						Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+					},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway, svc).
+				WithStatusSubresource(gatewayClass, gateway, svc).
+				Build()
+			reconciler := GatewayReconciler{
+				Client:                      fakeClient,
+				DisableMetalLBEndpointsHack: tt.disableHack,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			// reconcile until the Gateway is programmed
+			for i := 0; i < 3; i++ {
+				_, err := reconciler.Reconcile(ctx, gatewayReq)
+				require.NoError(t, err)
+			}
+
+			endpoints := &corev1.EndpointsList{}
+			require.NoError(t, reconciler.Client.List(ctx, endpoints, controllerruntimeclient.InNamespace(gateway.Namespace)))
+			require.Len(t, endpoints.Items, tt.expectedEndpoints)
+
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			require.Len(t, newGateway.Status.Addresses, 1)
+		})
+	}
+}
+
+func TestGatewayReconciler_invalidListenerPort(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		port gatewayv1beta1.PortNumber
+	}{
+		{
+			name: "port 0 is rejected",
+			port: 0,
+		},
+		{
+			name: "port 70000 is rejected",
+			port: 70000,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{
+						{
+							Name:          "valid",
+							Protocol:      gatewayv1beta1.TCPProtocolType,
+							Port:          8080,
+							AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+						},
+						{
+							Name:          "invalid",
+							Protocol:      gatewayv1beta1.TCPProtocolType,
+							Port:          tt.port,
+							AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+						},
+					},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway).
+				WithStatusSubresource(gatewayClass, gateway).
+				Build()
 			reconciler := GatewayReconciler{
 				Client: fakeClient,
 			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
 
-			tc.run(t, reconciler, tc.gatewayReq, tc.gateway)
+			// first reconcile to initialize the Gateway status
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+			// second reconcile to create the Service for the Gateway
+			_, err = reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			require.Len(t, newGateway.Status.Listeners, 2)
+			for _, l := range newGateway.Status.Listeners {
+				accepted := meta.FindStatusCondition(l.Conditions, string(gatewayv1beta1.ListenerConditionAccepted))
+				if l.Name == "invalid" {
+					require.NotNil(t, accepted)
+					require.Equal(t, metav1.ConditionFalse, accepted.Status)
+					require.Equal(t, string(ListenerReasonUnsupportedValue), accepted.Reason)
+				} else {
+					require.Nil(t, accepted)
+				}
+			}
+
+			svcs := &corev1.ServiceList{}
+			require.NoError(t, reconciler.Client.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+			require.Len(t, svcs.Items, 1)
+			require.Len(t, svcs.Items[0].Spec.Ports, 1)
+			require.Equal(t, "valid", svcs.Items[0].Spec.Ports[0].Name)
+		})
+	}
+}
+
+func TestGatewayReconciler_incompatibleRouteKinds(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		protocol       gatewayv1beta1.ProtocolType
+		kind           gatewayv1beta1.Kind
+		expectedStatus metav1.ConditionStatus
+	}{
+		{
+			name:           "a UDP listener allowing TCPRoutes has invalid route kinds",
+			protocol:       gatewayv1beta1.UDPProtocolType,
+			kind:           TCPRouteKind,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "a TCP listener allowing UDPRoutes has invalid route kinds",
+			protocol:       gatewayv1beta1.TCPProtocolType,
+			kind:           UDPRouteKind,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "a UDP listener allowing UDPRoutes resolves its route kinds",
+			protocol:       gatewayv1beta1.UDPProtocolType,
+			kind:           UDPRouteKind,
+			expectedStatus: metav1.ConditionTrue,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:     "test-listener",
+						Protocol: tt.protocol,
+						Port:     8080,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{
+							Kinds: []gatewayv1beta1.RouteGroupKind{{Kind: tt.kind}},
+						},
+					}},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway).
+				WithStatusSubresource(gatewayClass, gateway).
+				Build()
+			reconciler := GatewayReconciler{
+				Client: fakeClient,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			// first reconcile to initialize the Gateway status
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+			// second reconcile to set the listener status
+			_, err = reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			require.Len(t, newGateway.Status.Listeners, 1)
+			resolvedRefs := meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(gatewayv1beta1.ListenerConditionResolvedRefs))
+			require.NotNil(t, resolvedRefs)
+			require.Equal(t, tt.expectedStatus, resolvedRefs.Status)
+			if tt.expectedStatus == metav1.ConditionFalse {
+				require.Equal(t, string(gatewayv1beta1.ListenerReasonInvalidRouteKinds), resolvedRefs.Reason)
+				require.Empty(t, newGateway.Status.Listeners[0].SupportedKinds)
+			} else {
+				require.Len(t, newGateway.Status.Listeners[0].SupportedKinds, 1)
+			}
+		})
+	}
+}
+
+func TestGatewayReconciler_httpRouteKind(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		enabled        bool
+		protocol       gatewayv1beta1.ProtocolType
+		kinds          []gatewayv1beta1.RouteGroupKind
+		expectedStatus metav1.ConditionStatus
+		expectedKinds  []gatewayv1beta1.Kind
+	}{
+		{
+			name:           "an HTTP listener supports HTTPRoutes by default when enabled",
+			enabled:        true,
+			protocol:       gatewayv1beta1.HTTPProtocolType,
+			expectedStatus: metav1.ConditionTrue,
+			expectedKinds:  []gatewayv1beta1.Kind{HTTPRouteKind},
+		},
+		{
+			name:           "an HTTPS listener allowing HTTPRoutes resolves its route kinds when enabled",
+			enabled:        true,
+			protocol:       gatewayv1beta1.HTTPSProtocolType,
+			kinds:          []gatewayv1beta1.RouteGroupKind{{Kind: HTTPRouteKind}},
+			expectedStatus: metav1.ConditionTrue,
+			expectedKinds:  []gatewayv1beta1.Kind{HTTPRouteKind},
+		},
+		{
+			name:           "a TCP listener allowing HTTPRoutes has invalid route kinds when enabled",
+			enabled:        true,
+			protocol:       gatewayv1beta1.TCPProtocolType,
+			kinds:          []gatewayv1beta1.RouteGroupKind{{Kind: HTTPRouteKind}},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "an HTTP listener has invalid route kinds by default when disabled",
+			protocol:       gatewayv1beta1.HTTPProtocolType,
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "an HTTPS listener allowing HTTPRoutes has invalid route kinds when disabled",
+			protocol:       gatewayv1beta1.HTTPSProtocolType,
+			kinds:          []gatewayv1beta1.RouteGroupKind{{Kind: HTTPRouteKind}},
+			expectedStatus: metav1.ConditionFalse,
+		},
+		{
+			name:           "an HTTP listener allowing TCPRoutes resolves its route kinds when disabled",
+			protocol:       gatewayv1beta1.HTTPProtocolType,
+			kinds:          []gatewayv1beta1.RouteGroupKind{{Kind: TCPRouteKind}},
+			expectedStatus: metav1.ConditionTrue,
+			expectedKinds:  []gatewayv1beta1.Kind{TCPRouteKind},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:          "test-listener",
+						Protocol:      tt.protocol,
+						Port:          8080,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{Kinds: tt.kinds},
+					}},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway).
+				WithStatusSubresource(gatewayClass, gateway).
+				Build()
+			reconciler := GatewayReconciler{
+				Client:          fakeClient,
+				EnableHTTPRoute: tt.enabled,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			// first reconcile to initialize the Gateway status
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+			// second reconcile to set the listener status
+			_, err = reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			require.Len(t, newGateway.Status.Listeners, 1)
+			resolvedRefs := meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(gatewayv1beta1.ListenerConditionResolvedRefs))
+			require.NotNil(t, resolvedRefs)
+			require.Equal(t, tt.expectedStatus, resolvedRefs.Status)
+			if tt.expectedStatus == metav1.ConditionFalse {
+				require.Equal(t, string(gatewayv1beta1.ListenerReasonInvalidRouteKinds), resolvedRefs.Reason)
+			}
+			var kinds []gatewayv1beta1.Kind
+			for _, supported := range newGateway.Status.Listeners[0].SupportedKinds {
+				kinds = append(kinds, supported.Kind)
+			}
+			require.Equal(t, tt.expectedKinds, kinds)
+		})
+	}
+}
+
+func TestGatewayReconciler_httpsListenerWarning(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{
+				{
+					Name:          "https",
+					Protocol:      gatewayv1beta1.HTTPSProtocolType,
+					Port:          443,
+					AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+				},
+				{
+					Name:          "tcp",
+					Protocol:      gatewayv1beta1.TCPProtocolType,
+					Port:          8080,
+					AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+				},
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "service-for-gateway-test-gateway",
+			Labels: map[string]string{
+				gatewayServiceLabel: "test-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "1.1.1.1",
+			Ports: []corev1.ServicePort{
+				{Name: "https", Protocol: corev1.ProtocolTCP, Port: 443},
+				{Name: "tcp", Protocol: corev1.ProtocolTCP, Port: 8080},
+			},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway, svc).
+		WithStatusSubresource(gatewayClass, gateway, svc).
+		Build()
+	reconciler := GatewayReconciler{
+		Client:                      fakeClient,
+		DisableMetalLBEndpointsHack: true,
+		EnableHTTPRoute:             true,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	// first reconcile to initialize the Gateway status
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	// second reconcile to have a complete status
+	_, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+
+	newGateway := &gatewayv1beta1.Gateway{}
+	require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	require.Len(t, newGateway.Status.Listeners, 2)
+	for _, l := range newGateway.Status.Listeners {
+		programmed := meta.FindStatusCondition(l.Conditions, string(gatewayv1beta1.ListenerConditionProgrammed))
+		require.NotNil(t, programmed)
+		require.Equal(t, metav1.ConditionTrue, programmed.Status)
+		if l.Name == "https" {
+			require.Contains(t, programmed.Message, "without HTTP routing or TLS termination")
+		} else {
+			require.Empty(t, programmed.Message)
+		}
+	}
+}
+
+func TestGatewayReconciler_lbProvider(t *testing.T) {
+	for _, tt := range []struct {
+		name                    string
+		provider                LoadBalancerProvider
+		expectAllocationFailure bool
+		expectedEndpoints       int
+	}{
+		{
+			name:                    "metallb events and endpoints hack are used with the metallb provider",
+			provider:                LoadBalancerProviderMetalLB,
+			expectAllocationFailure: true,
+			expectedEndpoints:       1,
+		},
+		{
+			name:              "the cloud provider skips both metallb hacks",
+			provider:          LoadBalancerProviderCloud,
+			expectedEndpoints: 0,
+		},
+		{
+			name:              "the none provider skips both metallb hacks",
+			provider:          LoadBalancerProviderNone,
+			expectedEndpoints: 0,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:          "udp",
+						Protocol:      gatewayv1beta1.UDPProtocolType,
+						Port:          9875,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					}},
+				},
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-namespace",
+					Name:      "service-for-gateway-test-gateway",
+					Labels: map[string]string{
+						gatewayServiceLabel: "test-gateway",
+					},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeLoadBalancer,
+					ClusterIP: "1.1.1.1",
+					Ports: []corev1.ServicePort{{
+						Name:     "udp",
+						Protocol: corev1.ProtocolUDP,
+						Port:     9875,
+					}},
+				},
+			}
+			// an event as emitted by MetalLB when it can't allocate an IP
+			event := &corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Namespace: "test-namespace", Name: "allocation-failed"},
+				InvolvedObject: corev1.ObjectReference{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name},
+				Reason:         "AllocationFailed",
+				Message:        "Failed to allocate IP for \"test-namespace/service-for-gateway-test-gateway\": no available IPs",
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway, svc, event).
+				WithStatusSubresource(gatewayClass, gateway, svc).
+				Build()
+			reconciler := GatewayReconciler{
+				Client:     fakeClient,
+				LBProvider: tt.provider,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			t.Log("reconciling while the Gateway Service has no address")
+			for i := 0; i < 2; i++ {
+				_, err := reconciler.Reconcile(ctx, gatewayReq)
+				require.NoError(t, err)
+			}
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			programmed := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+			require.NotNil(t, programmed)
+			require.Equal(t, metav1.ConditionFalse, programmed.Status)
+			if tt.expectAllocationFailure {
+				require.Equal(t, string(gatewayv1beta1.GatewayReasonAddressNotUsable), programmed.Reason)
+			} else {
+				require.NotEqual(t, string(gatewayv1beta1.GatewayReasonAddressNotUsable), programmed.Reason)
+			}
+
+			t.Log("reconciling once the Gateway Service has an address")
+			require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(svc), svc))
+			svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+			require.NoError(t, reconciler.Client.Status().Update(ctx, svc))
+			for i := 0; i < 3; i++ {
+				_, err := reconciler.Reconcile(ctx, gatewayReq)
+				require.NoError(t, err)
+			}
+
+			endpoints := &corev1.EndpointsList{}
+			require.NoError(t, reconciler.Client.List(ctx, endpoints, controllerruntimeclient.InNamespace(gateway.Namespace)))
+			require.Len(t, endpoints.Items, tt.expectedEndpoints)
+
+			require.NoError(t, reconciler.Client.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			require.Len(t, newGateway.Status.Addresses, 1)
+		})
+	}
+}
+
+func TestGatewayReconciler_listenerProtocolChange(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		oldProtocol     corev1.Protocol
+		address         string
+		expectedDeletes []*dataplane.Vip
+	}{
+		{
+			name:            "the VIP is removed from the dataplane when the listener switches from UDP to TCP",
+			oldProtocol:     corev1.ProtocolUDP,
+			address:         "172.18.0.240",
+			expectedDeletes: []*dataplane.Vip{{Ip: 0xac1200f0, Port: 9875, Protocol: dataplane.Vip_UDP}},
+		},
+		{
+			name:        "the VIP is left alone when the protocol is unchanged",
+			oldProtocol: corev1.ProtocolTCP,
+			address:     "172.18.0.240",
+		},
+		{
+			name:        "no VIP was programmed for an IPv6 Gateway address",
+			oldProtocol: corev1.ProtocolUDP,
+			address:     "fd00::f0",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:          "listener",
+						Protocol:      gatewayv1beta1.TCPProtocolType,
+						Port:          9875,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					}},
+				},
+				Status: gatewayv1beta1.GatewayStatus{
+					Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+						Type:  &ipAddrType,
+						Value: tt.address,
+					}},
+				},
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-namespace",
+					Name:      "service-for-gateway-test-gateway",
+					Labels: map[string]string{
+						gatewayServiceLabel: "test-gateway",
+					},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeLoadBalancer,
+					ClusterIP: "1.1.1.1",
+					Ports: []corev1.ServicePort{{
+						Name:     "listener",
+						Protocol: tt.oldProtocol,
+						Port:     9875,
+					}},
+				},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{
+						Ingress: []corev1.LoadBalancerIngress{{IP: tt.address}},
+					},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway, svc).
+				WithStatusSubresource(gatewayClass, gateway, svc).
+				Build()
+			backends := &fakeBackendsUpdater{}
+			reconciler := GatewayReconciler{
+				Client:                      fakeClient,
+				DisableMetalLBEndpointsHack: true,
+				BackendsClientManager:       backends,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			for i := 0; i < 3; i++ {
+				_, err := reconciler.Reconcile(ctx, gatewayReq)
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.expectedDeletes, backends.deletes)
+			newSvc := &corev1.Service{}
+			require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(svc), newSvc))
+			require.Equal(t, corev1.ProtocolTCP, newSvc.Spec.Ports[0].Protocol)
 		})
 	}
 }
+
+func TestGatewayReconciler_concurrentServiceCreation(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-gateway",
+			Namespace: "test-namespace",
+			UID:       "d6c6c9a0-43c2-4f5e-9a5e-0d2c1b3a4f5e",
+		},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway).
+		Build()
+	// simulate a stale cache which doesn't contain the Services created by
+	// previous reconciles yet.
+	staleClient := interceptor.NewClient(fakeClient, interceptor.Funcs{
+		List: func(ctx context.Context, c controllerruntimeclient.WithWatch, list controllerruntimeclient.ObjectList, opts ...controllerruntimeclient.ListOption) error {
+			if _, ok := list.(*corev1.ServiceList); ok {
+				return nil
+			}
+			return c.List(ctx, list, opts...)
+		},
+	})
+	reconciler := GatewayReconciler{Client: staleClient}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	t.Log("accepting the Gateway")
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+
+	t.Log("reconciling twice before the created Service is in the cache")
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+
+	svcs := &corev1.ServiceList{}
+	require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+	require.Len(t, svcs.Items, 1)
+	require.Equal(t, "service-for-gateway-d6c6c9a0-43c2-4f5e-9a5e-0d2c1b3a4f5e", svcs.Items[0].Name)
+}
+
+func TestGatewayReconciler_serviceLookup(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-gateway",
+			Namespace: "test-namespace",
+			UID:       "d6c6c9a0-43c2-4f5e-9a5e-0d2c1b3a4f5e",
+		},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	legacySvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "legacy-service-for-test-gateway",
+			Labels:    map[string]string{gatewayServiceLabel: "test-gateway"},
+		},
+	}
+	newReconciler := func(objs ...controllerruntimeclient.Object) (*GatewayReconciler, controllerruntimeclient.Client, *int) {
+		fakeClient := newGatewayTestClientBuilder().
+			WithObjects(append([]controllerruntimeclient.Object{gatewayClass, gateway}, objs...)...).
+			WithStatusSubresource(gatewayClass, gateway).
+			Build()
+		serviceLists := 0
+		countingClient := interceptor.NewClient(fakeClient, interceptor.Funcs{
+			List: func(ctx context.Context, c controllerruntimeclient.WithWatch, list controllerruntimeclient.ObjectList, opts ...controllerruntimeclient.ListOption) error {
+				if _, ok := list.(*corev1.ServiceList); ok {
+					serviceLists++
+				}
+				return c.List(ctx, list, opts...)
+			},
+		})
+		return &GatewayReconciler{Client: countingClient, LBProvider: LoadBalancerProviderCloud}, fakeClient, &serviceLists
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	t.Run("the created Service has a deterministic name and is fetched by name", func(t *testing.T) {
+		reconciler, fakeClient, serviceLists := newReconciler()
+		for i := 0; i < 2; i++ {
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+		}
+		svcs := &corev1.ServiceList{}
+		require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+		require.Len(t, svcs.Items, 1)
+		require.Equal(t, serviceNameForGateway(gateway), svcs.Items[0].Name)
+
+		*serviceLists = 0
+		svc, err := reconciler.getServiceForGateway(ctx, gateway)
+		require.NoError(t, err)
+		require.Equal(t, serviceNameForGateway(gateway), svc.Name)
+		require.Zero(t, *serviceLists, "the Service should be fetched without listing Services")
+	})
+
+	t.Run("a labeled Service with another name is still found", func(t *testing.T) {
+		reconciler, fakeClient, _ := newReconciler(legacySvc)
+		for i := 0; i < 2; i++ {
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+		}
+		svcs := &corev1.ServiceList{}
+		require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+		require.Len(t, svcs.Items, 1, "no Service should be created next to the labeled one")
+		require.Equal(t, legacySvc.Name, svcs.Items[0].Name)
+		require.Equal(t, []corev1.ServicePort{{Name: "tcp", Protocol: corev1.ProtocolTCP, Port: 9875}}, svcs.Items[0].Spec.Ports,
+			"the labeled Service should be configured")
+	})
+
+	t.Run("the deterministically named Service takes precedence over a labeled one", func(t *testing.T) {
+		named := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test-namespace",
+				Name:      serviceNameForGateway(gateway),
+				Labels:    map[string]string{gatewayServiceLabel: "test-gateway"},
+			},
+		}
+		reconciler, _, _ := newReconciler(legacySvc, named)
+		svc, err := reconciler.getServiceForGateway(ctx, gateway)
+		require.NoError(t, err)
+		require.Equal(t, named.Name, svc.Name)
+	})
+}
+
+func TestGatewayReconciler_loadBalancerClass(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-gateway",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{LoadBalancerClassAnnotation: "example.com/lb-a"},
+		},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway).
+		Build()
+	reconciler := GatewayReconciler{Client: fakeClient}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+	reconcileTwice := func() {
+		for i := 0; i < 2; i++ {
+			_, err := reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+		}
+	}
+	getService := func() *corev1.Service {
+		svcs := &corev1.ServiceList{}
+		require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+		require.Len(t, svcs.Items, 1)
+		return &svcs.Items[0]
+	}
+
+	t.Log("the Service is created with the load balancer class of the Gateway")
+	reconcileTwice()
+	require.Equal(t, ptr.To("example.com/lb-a"), getService().Spec.LoadBalancerClass)
+
+	t.Log("a Service whose load balancer class drifted is recreated with the class of the Gateway")
+	svc := getService()
+	svc.Spec.LoadBalancerClass = ptr.To("example.com/lb-b")
+	require.NoError(t, fakeClient.Update(ctx, svc))
+	reconcileTwice()
+	require.Equal(t, ptr.To("example.com/lb-a"), getService().Spec.LoadBalancerClass)
+
+	t.Log("the Service is recreated when the load balancer class of the Gateway changes")
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, gateway))
+	gateway.Annotations[LoadBalancerClassAnnotation] = "example.com/lb-b"
+	require.NoError(t, fakeClient.Update(ctx, gateway))
+	reconcileTwice()
+	require.Equal(t, ptr.To("example.com/lb-b"), getService().Spec.LoadBalancerClass)
+
+	t.Log("the Service and its load balancer class are left alone once the annotation is removed")
+	// the marker is lost if the Service is recreated.
+	svc = getService()
+	svc.Labels["test-marker"] = "true"
+	require.NoError(t, fakeClient.Update(ctx, svc))
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, gateway))
+	delete(gateway.Annotations, LoadBalancerClassAnnotation)
+	require.NoError(t, fakeClient.Update(ctx, gateway))
+	reconcileTwice()
+	require.Equal(t, "true", getService().Labels["test-marker"])
+	require.Equal(t, ptr.To("example.com/lb-b"), getService().Spec.LoadBalancerClass)
+
+	t.Log("a load balancer class defaulted by the cluster is left alone without the annotation")
+	svc = getService()
+	svc.Spec.LoadBalancerClass = ptr.To("example.com/default")
+	require.NoError(t, fakeClient.Update(ctx, svc))
+	reconcileTwice()
+	require.Equal(t, "true", getService().Labels["test-marker"])
+	require.Equal(t, ptr.To("example.com/default"), getService().Spec.LoadBalancerClass)
+}
+
+func TestGatewayReconciler_externalTrafficPolicy(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-gateway",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{ExternalTrafficPolicyAnnotation: "Local"},
+		},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway).
+		Build()
+	reconciler := GatewayReconciler{Client: fakeClient}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+	getService := func() *corev1.Service {
+		svcs := &corev1.ServiceList{}
+		require.NoError(t, fakeClient.List(ctx, svcs, controllerruntimeclient.InNamespace(gateway.Namespace)))
+		require.Len(t, svcs.Items, 1)
+		return &svcs.Items[0]
+	}
+
+	t.Log("the Service is created with the external traffic policy of the Gateway")
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	require.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, getService().Spec.ExternalTrafficPolicy)
+
+	t.Log("an external edit of the policy is corrected")
+	svc := getService()
+	svc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyCluster
+	require.NoError(t, fakeClient.Update(ctx, svc))
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, getService().Spec.ExternalTrafficPolicy)
+
+	t.Log("an unsupported policy rejects the Gateway")
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, gateway))
+	gateway.Annotations[ExternalTrafficPolicyAnnotation] = "Nearest"
+	require.NoError(t, fakeClient.Update(ctx, gateway))
+	_, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, gateway))
+	accepted := meta.FindStatusCondition(gateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionAccepted))
+	require.NotNil(t, accepted)
+	require.Equal(t, metav1.ConditionFalse, accepted.Status)
+	require.Equal(t, string(gatewayv1beta1.GatewayReasonInvalid), accepted.Reason)
+	require.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, getService().Spec.ExternalTrafficPolicy)
+}
+
+func TestGatewayReconciler_externalServiceManagement(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-gateway",
+			Namespace:   "test-namespace",
+			UID:         "test-uid",
+			Annotations: map[string]string{ServiceManagementAnnotation: string(ServiceManagementExternal)},
+		},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "udp",
+				Protocol:      gatewayv1beta1.UDPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway).
+		Build()
+	reconciler := GatewayReconciler{
+		Client:     fakeClient,
+		LBProvider: LoadBalancerProviderCloud,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	t.Log("reconciling before the user provided the Gateway Service")
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	svcs := &corev1.ServiceList{}
+	require.NoError(t, fakeClient.List(ctx, svcs))
+	require.Empty(t, svcs.Items, "no Service should be created in External mode")
+	newGateway := &gatewayv1beta1.Gateway{}
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	programmed := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionFalse, programmed.Status)
+	require.Equal(t, string(gatewayv1beta1.GatewayReasonAddressNotAssigned), programmed.Reason)
+
+	t.Log("reconciling once the user provided a Service with an allocated address")
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "user-provided-service",
+			Labels: map[string]string{
+				gatewayServiceLabel: "test-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "1.1.1.1",
+			Ports: []corev1.ServicePort{{
+				Name:     "custom",
+				Protocol: corev1.ProtocolUDP,
+				Port:     9875,
+			}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	require.NoError(t, fakeClient.Create(ctx, svc))
+	require.Equal(t, []reconcile.Request{gatewayReq}, mapServiceToGateway(ctx, svc))
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	require.Len(t, newGateway.Status.Addresses, 1)
+	require.Equal(t, "1.2.3.4", newGateway.Status.Addresses[0].Value)
+	programmed = meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionTrue, programmed.Status)
+
+	t.Log("the user provided Service is left unchanged")
+	newSvc := &corev1.Service{}
+	require.NoError(t, fakeClient.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(svc), newSvc))
+	require.Equal(t, svc.Spec.Ports, newSvc.Spec.Ports)
+	require.Empty(t, newSvc.OwnerReferences)
+
+	t.Log("an invalid service management mode rejects the Gateway")
+	newGateway.Annotations[ServiceManagementAnnotation] = "Unmanaged"
+	require.NoError(t, fakeClient.Update(ctx, newGateway))
+	_, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	accepted := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionAccepted))
+	require.NotNil(t, accepted)
+	require.Equal(t, metav1.ConditionFalse, accepted.Status)
+	require.Equal(t, string(gatewayv1beta1.GatewayReasonInvalid), accepted.Reason)
+}
+
+func TestGatewayReconciler_statusPatchConflict(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway).
+		Build()
+	// simulate a concurrent update of the Gateway on the first status patch.
+	conflicts := 1
+	conflictingClient := interceptor.NewClient(fakeClient, interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c controllerruntimeclient.Client, subResourceName string, obj controllerruntimeclient.Object, patch controllerruntimeclient.Patch, opts ...controllerruntimeclient.SubResourcePatchOption) error {
+			if conflicts > 0 {
+				conflicts--
+				return apierrors.NewConflict(gatewayv1beta1.Resource("gateways"), obj.GetName(), errors.New("the object has been modified"))
+			}
+			return c.Status().Patch(ctx, obj, patch, opts...)
+		},
+	})
+	reconciler := GatewayReconciler{Client: conflictingClient}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	t.Log("the conflict requeues the Gateway without an error")
+	result, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.True(t, result.Requeue)
+
+	t.Log("the retry patches the Gateway status")
+	_, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	newGateway := &gatewayv1beta1.Gateway{}
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	require.True(t, isGatewayAccepted(newGateway))
+}
+
+func TestGatewayReconciler_listenerMissingBackends(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{
+				{
+					Name:          "tcp",
+					Protocol:      gatewayv1beta1.TCPProtocolType,
+					Port:          8080,
+					AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+				},
+				{
+					Name:          "udp",
+					Protocol:      gatewayv1beta1.UDPProtocolType,
+					Port:          9875,
+					AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+				},
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "service-for-gateway-test-gateway",
+			Labels: map[string]string{
+				gatewayServiceLabel: "test-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "1.1.1.1",
+			Ports: []corev1.ServicePort{
+				{Name: "tcp", Protocol: corev1.ProtocolTCP, Port: 8080},
+				{Name: "udp", Protocol: corev1.ProtocolUDP, Port: 9875},
+			},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	// the TCPRoute attached to the tcp listener refers to a missing Service.
+	tcproute := newTestTCPRoute("tcproute-missing-backend", time.Now())
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway, svc, tcproute).
+		WithStatusSubresource(gatewayClass, gateway, svc).
+		Build()
+	reconciler := GatewayReconciler{
+		Client:     fakeClient,
+		LBProvider: LoadBalancerProviderCloud,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+	require.Equal(t, []reconcile.Request{gatewayReq}, mapRouteToGateways(ctx, tcproute))
+	backend := types.NamespacedName{Namespace: "test-namespace", Name: "test-backend"}
+	require.Equal(t, []reconcile.Request{gatewayReq}, reconciler.mapBackendToGateways(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: backend.Namespace, Name: backend.Name},
+	}))
+	require.Equal(t, []reconcile.Request{gatewayReq}, reconciler.mapBackendToGateways(ctx, &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: backend.Namespace, Name: backend.Name},
+	}))
+	require.Empty(t, reconciler.mapBackendToGateways(ctx, svc))
+
+	var result reconcile.Result
+	for i := 0; i < 2; i++ {
+		var err error
+		result, err = reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	require.Zero(t, result.RequeueAfter, "the backend Service is watched")
+
+	newGateway := &gatewayv1beta1.Gateway{}
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	require.Len(t, newGateway.Status.Listeners, 2)
+	tcpResolvedRefs := meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(gatewayv1beta1.ListenerConditionResolvedRefs))
+	require.NotNil(t, tcpResolvedRefs)
+	require.Equal(t, metav1.ConditionFalse, tcpResolvedRefs.Status)
+	require.Equal(t, string(ListenerReasonBackendNotFound), tcpResolvedRefs.Reason)
+	require.Contains(t, tcpResolvedRefs.Message, "test-namespace/test-backend")
+	require.Nil(t, meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(ListenerConditionBackendsHealthy)),
+		"the route whose only backend is missing isn't reported as unhealthy too")
+	udpResolvedRefs := meta.FindStatusCondition(newGateway.Status.Listeners[1].Conditions, string(gatewayv1beta1.ListenerConditionResolvedRefs))
+	require.NotNil(t, udpResolvedRefs)
+	require.Equal(t, metav1.ConditionTrue, udpResolvedRefs.Status)
+
+	t.Log("the missing backend doesn't prevent the Gateway from being programmed")
+	programmed := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionTrue, programmed.Status)
+
+	t.Log("the listener refs are resolved once the backend Service exists")
+	require.NoError(t, fakeClient.Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: backend.Name, Namespace: backend.Namespace},
+	}))
+	result, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	tcpResolvedRefs = meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(gatewayv1beta1.ListenerConditionResolvedRefs))
+	require.NotNil(t, tcpResolvedRefs)
+	require.Equal(t, metav1.ConditionTrue, tcpResolvedRefs.Status)
+
+	t.Log("the listener reports the attached route without ready endpoints, but stays programmed")
+	require.Zero(t, result.RequeueAfter, "the backend Endpoints are watched")
+	tcpBackendsHealthy := meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(ListenerConditionBackendsHealthy))
+	require.NotNil(t, tcpBackendsHealthy)
+	require.Equal(t, metav1.ConditionFalse, tcpBackendsHealthy.Status)
+	require.Equal(t, string(ListenerReasonNoReadyEndpoints), tcpBackendsHealthy.Reason)
+	require.Contains(t, tcpBackendsHealthy.Message, "TCPRoute test-namespace/tcproute-missing-backend")
+	tcpProgrammed := meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(gatewayv1beta1.ListenerConditionProgrammed))
+	require.NotNil(t, tcpProgrammed)
+	require.Equal(t, metav1.ConditionTrue, tcpProgrammed.Status)
+	require.Nil(t, meta.FindStatusCondition(newGateway.Status.Listeners[1].Conditions, string(ListenerConditionBackendsHealthy)))
+	programmed = meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionTrue, programmed.Status)
+
+	t.Log("the degraded backends are cleared once the backend has ready endpoints")
+	require.NoError(t, fakeClient.Create(ctx, &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"},
+		Subsets: []corev1.EndpointSubset{{
+			NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+		}},
+	}))
+	result, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	tcpBackendsHealthy = meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(ListenerConditionBackendsHealthy))
+	require.NotNil(t, tcpBackendsHealthy)
+	require.Equal(t, metav1.ConditionFalse, tcpBackendsHealthy.Status, "not ready addresses are not healthy backends")
+	endpoints := new(corev1.Endpoints)
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "test-backend", Namespace: "test-namespace"}, endpoints))
+	endpoints.Subsets[0].Addresses = []corev1.EndpointAddress{{IP: "10.0.0.1"}}
+	require.NoError(t, fakeClient.Update(ctx, endpoints))
+	result, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	require.Nil(t, meta.FindStatusCondition(newGateway.Status.Listeners[0].Conditions, string(ListenerConditionBackendsHealthy)))
+}
+
+func TestGatewayReconciler_serviceReadyBackoff(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "udp",
+				Protocol:      gatewayv1beta1.UDPProtocolType,
+				Port:          9875,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	// a Service which is never allocated an address.
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "service-for-gateway-test-gateway",
+			Labels: map[string]string{
+				gatewayServiceLabel: "test-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "1.1.1.1",
+			Ports: []corev1.ServicePort{{
+				Name:     "udp",
+				Protocol: corev1.ProtocolUDP,
+				Port:     9875,
+			}},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway, svc).
+		WithStatusSubresource(gatewayClass, gateway, svc).
+		Build()
+	reconciler := GatewayReconciler{
+		Client:                         fakeClient,
+		LBProvider:                     LoadBalancerProviderCloud,
+		ServiceReadyRequeueInterval:    time.Second,
+		ServiceReadyMaxRequeueInterval: 5 * time.Second,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	t.Log("accepting the Gateway")
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+
+	t.Log("the requeue interval grows up to the maximum while the Service isn't ready")
+	var intervals []time.Duration
+	for i := 0; i < 5; i++ {
+		result, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+		intervals = append(intervals, result.RequeueAfter)
+	}
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, intervals)
+
+	t.Log("the backoff is reset once the Service is ready")
+	require.NoError(t, fakeClient.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(svc), svc))
+	svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+	require.NoError(t, fakeClient.Status().Update(ctx, svc))
+	_, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	svc.Status.LoadBalancer.Ingress = nil
+	require.NoError(t, fakeClient.Status().Update(ctx, svc))
+	result, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, result.RequeueAfter)
+}
+
+func TestGatewayReconciler_serviceWithoutClusterIP(t *testing.T) {
+	for name, clusterIP := range map[string]string{
+		"headless Service": corev1.ClusterIPNone,
+		"empty ClusterIP":  "",
+	} {
+		clusterIP := clusterIP
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			gatewayClass := &gatewayv1beta1.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1beta1.GatewayClassSpec{
+					ControllerName: vars.GatewayClassControllerName,
+				},
+				Status: acceptedGatewayClassStatus,
+			}
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: "test-gatewayclass",
+					Listeners: []gatewayv1beta1.Listener{{
+						Name:          "udp",
+						Protocol:      gatewayv1beta1.UDPProtocolType,
+						Port:          9875,
+						AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					}},
+				},
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-namespace",
+					Name:      "service-for-gateway-test-gateway",
+					Labels: map[string]string{
+						gatewayServiceLabel: "test-gateway",
+					},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeLoadBalancer,
+					ClusterIP: clusterIP,
+					Ports: []corev1.ServicePort{{
+						Name:     "udp",
+						Protocol: corev1.ProtocolUDP,
+						Port:     9875,
+					}},
+				},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{
+						Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+					},
+				},
+			}
+			fakeClient := newGatewayTestClientBuilder().
+				WithObjects(gatewayClass, gateway, svc).
+				WithStatusSubresource(gatewayClass, gateway, svc).
+				Build()
+			reconciler := GatewayReconciler{
+				Client:     fakeClient,
+				LBProvider: LoadBalancerProviderCloud,
+			}
+			gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+			t.Log("the Gateway is programmed from the load balancer ingress, without requeuing")
+			var result reconcile.Result
+			for i := 0; i < 2; i++ {
+				var err error
+				result, err = reconciler.Reconcile(ctx, gatewayReq)
+				require.NoError(t, err)
+			}
+			require.Equal(t, reconcile.Result{}, result)
+
+			newGateway := &gatewayv1beta1.Gateway{}
+			require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+			require.Len(t, newGateway.Status.Addresses, 1)
+			require.Equal(t, "1.2.3.4", newGateway.Status.Addresses[0].Value)
+			programmed := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+			require.NotNil(t, programmed)
+			require.Equal(t, metav1.ConditionTrue, programmed.Status)
+		})
+	}
+}
+
+func TestGatewayReconciler_tlsListenerModes(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace", UID: "test-uid"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{
+				{
+					Name:          "passthrough",
+					Protocol:      gatewayv1beta1.TLSProtocolType,
+					Port:          443,
+					AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					TLS:           &gatewayv1beta1.GatewayTLSConfig{Mode: ptr.To(gatewayv1beta1.TLSModePassthrough)},
+				},
+				{
+					Name:          "terminate",
+					Protocol:      gatewayv1beta1.TLSProtocolType,
+					Port:          8443,
+					AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+					TLS: &gatewayv1beta1.GatewayTLSConfig{
+						Mode:            ptr.To(gatewayv1beta1.TLSModeTerminate),
+						CertificateRefs: []gatewayv1beta1.SecretObjectReference{{Name: "test-certificate"}},
+					},
+				},
+			},
+		},
+	}
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway).
+		WithStatusSubresource(gatewayClass, gateway, &corev1.Service{}).
+		Build()
+	reconciler := GatewayReconciler{
+		Client:     fakeClient,
+		LBProvider: LoadBalancerProviderCloud,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+
+	t.Log("accepting the Gateway and creating its Service")
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	svc := &corev1.Service{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-namespace", Name: serviceNameForGateway(gateway)}, svc))
+	require.Equal(t, []corev1.ServicePort{{Name: "passthrough", Protocol: corev1.ProtocolTCP, Port: 443}}, svc.Spec.Ports,
+		"only the passthrough listener should be exposed")
+
+	t.Log("programming the Gateway once its Service is ready")
+	svc.Spec.ClusterIP = "1.1.1.1"
+	require.NoError(t, fakeClient.Update(ctx, svc))
+	svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+	require.NoError(t, fakeClient.Status().Update(ctx, svc))
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+
+	newGateway := &gatewayv1beta1.Gateway{}
+	require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+	require.Len(t, newGateway.Status.Listeners, 2)
+
+	passthrough := newGateway.Status.Listeners[0]
+	require.Equal(t, gatewayv1beta1.SectionName("passthrough"), passthrough.Name)
+	require.Len(t, passthrough.SupportedKinds, 1)
+	require.Equal(t, gatewayv1beta1.Kind(TCPRouteKind), passthrough.SupportedKinds[0].Kind)
+	for _, condType := range []gatewayv1beta1.ListenerConditionType{
+		gatewayv1beta1.ListenerConditionAccepted,
+		gatewayv1beta1.ListenerConditionResolvedRefs,
+		gatewayv1beta1.ListenerConditionProgrammed,
+	} {
+		cond := meta.FindStatusCondition(passthrough.Conditions, string(condType))
+		require.NotNil(t, cond, condType)
+		require.Equal(t, metav1.ConditionTrue, cond.Status, condType)
+	}
+	programmed := meta.FindStatusCondition(passthrough.Conditions, string(gatewayv1beta1.ListenerConditionProgrammed))
+	require.Contains(t, programmed.Message, "without SNI routing")
+
+	terminate := newGateway.Status.Listeners[1]
+	require.Equal(t, gatewayv1beta1.SectionName("terminate"), terminate.Name)
+	resolvedRefs := meta.FindStatusCondition(terminate.Conditions, string(gatewayv1beta1.ListenerConditionResolvedRefs))
+	require.NotNil(t, resolvedRefs)
+	require.Equal(t, metav1.ConditionFalse, resolvedRefs.Status)
+	require.Equal(t, string(gatewayv1beta1.ListenerReasonInvalidCertificateRef), resolvedRefs.Reason)
+	programmed = meta.FindStatusCondition(terminate.Conditions, string(gatewayv1beta1.ListenerConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionFalse, programmed.Status)
+}
+
+// newReadyGatewayTestObjects returns a GatewayClass, a Gateway with a TCP
+// listener and its ready Service, so that the Gateway gets programmed.
+func newReadyGatewayTestObjects() (*gatewayv1beta1.GatewayClass, *gatewayv1beta1.Gateway, *corev1.Service) {
+	gatewayClass := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+		Status: acceptedGatewayClassStatus,
+	}
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			GatewayClassName: "test-gatewayclass",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:          "tcp",
+				Protocol:      gatewayv1beta1.TCPProtocolType,
+				Port:          8080,
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{},
+			}},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "service-for-gateway-test-gateway",
+			Labels: map[string]string{
+				gatewayServiceLabel: "test-gateway",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "1.1.1.1",
+			Ports:     []corev1.ServicePort{{Name: "tcp", Protocol: corev1.ProtocolTCP, Port: 8080}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			},
+		},
+	}
+	return gatewayClass, gateway, svc
+}
+
+func TestGatewayReconciler_noReadyDataplanes(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass, gateway, svc := newReadyGatewayTestObjects()
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway, svc).
+		WithStatusSubresource(gatewayClass, gateway, svc).
+		Build()
+	dataplaneReconciler := NewDataplaneReconciler(fakeClient, scheme.Scheme, nil)
+	reconciler := GatewayReconciler{
+		Client:             fakeClient,
+		LBProvider:         LoadBalancerProviderCloud,
+		DataPlaneReadiness: dataplaneReconciler,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+	requireProgrammed := func(status metav1.ConditionStatus, reason string) {
+		t.Helper()
+		newGateway := &gatewayv1beta1.Gateway{}
+		require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+		programmed := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+		require.NotNil(t, programmed)
+		require.Equal(t, status, programmed.Status)
+		require.Equal(t, reason, programmed.Reason)
+	}
+
+	t.Log("the gateway is programmed while dataplanes are ready")
+	require.True(t, dataplaneReconciler.setReadyDataPlanes(2))
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, gatewayReq)
+		require.NoError(t, err)
+	}
+	requireProgrammed(metav1.ConditionTrue, string(gatewayv1beta1.GatewayReasonProgrammed))
+
+	t.Log("the gateways are reconciled when the ready dataplanes drop to zero")
+	require.False(t, dataplaneReconciler.setReadyDataPlanes(1))
+	require.True(t, dataplaneReconciler.setReadyDataPlanes(0))
+	require.Equal(t, []reconcile.Request{gatewayReq}, reconciler.mapDataPlaneToGateways(ctx, nil))
+	_, err := reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	requireProgrammed(metav1.ConditionFalse, string(GatewayReasonNoReadyDataplanes))
+
+	t.Log("the gateway is programmed again once a dataplane is ready")
+	require.True(t, dataplaneReconciler.setReadyDataPlanes(1))
+	_, err = reconciler.Reconcile(ctx, gatewayReq)
+	require.NoError(t, err)
+	requireProgrammed(metav1.ConditionTrue, string(gatewayv1beta1.GatewayReasonProgrammed))
+}
+
+func TestGatewayReconciler_noDataplaneDaemonSet(t *testing.T) {
+	ctx := context.Background()
+	gatewayClass, gateway, svc := newReadyGatewayTestObjects()
+	fakeClient := newGatewayTestClientBuilder().
+		WithObjects(gatewayClass, gateway, svc).
+		WithStatusSubresource(gatewayClass, gateway, svc).
+		Build()
+	dsKey := types.NamespacedName{Namespace: vars.DefaultNamespace, Name: vars.DefaultDataPlaneDaemonSetName}
+	reconciler := GatewayReconciler{
+		Client:             fakeClient,
+		LBProvider:         LoadBalancerProviderCloud,
+		DataPlaneDaemonSet: &dsKey,
+	}
+	gatewayReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gateway)}
+	reconcileProgrammed := func() (reconcile.Result, *metav1.Condition) {
+		t.Helper()
+		var result reconcile.Result
+		for i := 0; i < 2; i++ {
+			var err error
+			result, err = reconciler.Reconcile(ctx, gatewayReq)
+			require.NoError(t, err)
+		}
+		newGateway := &gatewayv1beta1.Gateway{}
+		require.NoError(t, fakeClient.Get(ctx, gatewayReq.NamespacedName, newGateway))
+		programmed := meta.FindStatusCondition(newGateway.Status.Conditions, string(gatewayv1beta1.GatewayConditionProgrammed))
+		require.NotNil(t, programmed)
+		return result, programmed
+	}
+
+	t.Log("the gateway isn't programmed without a dataplane DaemonSet")
+	result, programmed := reconcileProgrammed()
+	require.Equal(t, metav1.ConditionFalse, programmed.Status)
+	require.Equal(t, string(GatewayReasonNoDataplane), programmed.Reason)
+	require.Contains(t, programmed.Message, "not found")
+	require.Equal(t, noDataPlaneRequeueInterval, result.RequeueAfter)
+
+	t.Log("a DaemonSet which doesn't select the dataplane pods isn't the dataplane")
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: dsKey.Namespace, Name: dsKey.Name},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}},
+		},
+		Status: appsv1.DaemonSetStatus{NumberReady: 1},
+	}
+	require.NoError(t, fakeClient.Create(ctx, ds))
+	_, programmed = reconcileProgrammed()
+	require.Equal(t, string(GatewayReasonNoDataplane), programmed.Reason)
+	require.Contains(t, programmed.Message, "doesn't select the dataplane Pods")
+
+	t.Log("the gateway is programmed once the dataplane DaemonSet has ready pods")
+	ds.Spec.Selector.MatchLabels = map[string]string{"app": vars.DefaultDataPlaneAppLabel, "component": vars.DefaultDataPlaneComponentLabel}
+	require.NoError(t, fakeClient.Update(ctx, ds))
+	result, programmed = reconcileProgrammed()
+	require.Equal(t, metav1.ConditionTrue, programmed.Status)
+	require.Zero(t, result.RequeueAfter)
+}
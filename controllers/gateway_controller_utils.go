@@ -20,18 +20,40 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
 )
 
+// getServiceForGateway returns the Service of the provided Gateway, or nil if
+// it has none. It's the Service named by serviceNameForGateway, which is
+// fetched directly. Otherwise it's the Service labeled with the Gateway's
+// name, as provided by users when the Service is externally managed, or as
+// created with another name by previous versions.
 func (r *GatewayReconciler) getServiceForGateway(ctx context.Context, gw *gatewayv1beta1.Gateway) (*corev1.Service, error) {
+	svc := new(corev1.Service)
+	err := r.Get(ctx, types.NamespacedName{Namespace: gw.Namespace, Name: serviceNameForGateway(gw)}, svc)
+	if err == nil {
+		return svc, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
 	svcs := new(corev1.ServiceList)
 	if err := r.List(ctx, svcs, client.InNamespace(gw.Namespace), client.MatchingLabels{gatewayServiceLabel: gw.Name}); err != nil {
 		return nil, err
@@ -48,11 +70,19 @@ func (r *GatewayReconciler) getServiceForGateway(ctx context.Context, gw *gatewa
 	return nil, nil
 }
 
+// serviceNameForGateway returns the name of the Service created for the
+// provided Gateway. The name is derived from the Gateway's UID so that it is
+// deterministic, which prevents duplicate Services from being created by
+// reconciles racing with the cache.
+func serviceNameForGateway(gw *gatewayv1beta1.Gateway) string {
+	return fmt.Sprintf("service-for-gateway-%s", gw.UID)
+}
+
 func (r *GatewayReconciler) createServiceForGateway(ctx context.Context, gw *gatewayv1beta1.Gateway) error {
 	svc := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    gw.Namespace,
-			GenerateName: fmt.Sprintf("service-for-gateway-%s-", gw.Name),
+			Namespace: gw.Namespace,
+			Name:      serviceNameForGateway(gw),
 			Labels: map[string]string{
 				gatewayServiceLabel: gw.Name,
 			},
@@ -62,19 +92,17 @@ func (r *GatewayReconciler) createServiceForGateway(ctx context.Context, gw *gat
 	if len(gw.Spec.Addresses) > 0 {
 		addr := gw.Spec.Addresses[0]
 
-		if *addr.Type != gatewayv1beta1.IPAddressType {
+		if !r.isAddressTypeSupported(addr.Type) {
 			// TODO: update status https://github.com/Kong/blixt/issues/96
-			return fmt.Errorf("status addresses of type %s are not supported, only IP addresses are supported", *addr.Type)
+			return fmt.Errorf("addresses of type %s are not supported", *addr.Type)
 		}
-
-		svc.Spec.LoadBalancerIP = addr.Value
 	}
 
 	if len(gw.Spec.Addresses) > 1 {
 		// TODO: update status https://github.com/Kong/blixt/issues/96
 		r.Log.Error(
-			fmt.Errorf("assigning multiple static IPs for a Gateway is not currently supported"),
-			fmt.Sprintf("%d addresses were requested, only %s will be allocated", len(gw.Spec.Addresses), svc.Spec.LoadBalancerIP),
+			fmt.Errorf("assigning multiple static addresses for a Gateway is not currently supported"),
+			fmt.Sprintf("%d addresses were requested, only %s will be allocated", len(gw.Spec.Addresses), gw.Spec.Addresses[0].Value),
 		)
 	}
 
@@ -85,7 +113,15 @@ func (r *GatewayReconciler) createServiceForGateway(ctx context.Context, gw *gat
 
 	setOwnerReference(&svc, gw)
 
-	return r.Client.Create(ctx, &svc)
+	if err := r.Client.Create(ctx, &svc); err != nil {
+		if errors.IsAlreadyExists(err) {
+			// a previous reconcile already created the Service, but it hasn't
+			// made it to the cache yet: its creation will requeue the Gateway.
+			return nil
+		}
+		return err
+	}
+	return nil
 }
 
 func setOwnerReference(svc *corev1.Service, gw client.Object) {
@@ -104,6 +140,11 @@ func (r *GatewayReconciler) svcIsHealthy(ctx context.Context, svc *corev1.Servic
 		return nil
 	}
 
+	// other providers are trusted to eventually populate the Service status.
+	if !r.usesMetalLB() {
+		return nil
+	}
+
 	// FIXME: the following is a hack to use metallb events to determine if the
 	// service is having trouble getting an IP allocated for it. This was created
 	// in a hurry and needs to be replaced with something robust.
@@ -155,18 +196,41 @@ func (r *GatewayReconciler) svcIsHealthy(ctx context.Context, svc *corev1.Servic
 func (r *GatewayReconciler) ensureServiceConfiguration(ctx context.Context, svc *corev1.Service, gw *gatewayv1beta1.Gateway) (bool, error) {
 	updated := false
 
-	if len(gw.Spec.Addresses) > 0 && svc.Spec.LoadBalancerIP != gw.Spec.Addresses[0].Value {
+	loadBalancerIP, addressName := "", ""
+	if len(gw.Spec.Addresses) > 0 {
+		addr := gw.Spec.Addresses[0]
+		switch {
+		case addr.Type == nil || *addr.Type == gatewayv1beta1.IPAddressType:
+			loadBalancerIP = addr.Value
+		case *addr.Type == gatewayv1beta1.NamedAddressType && r.NamedAddressAnnotation != "":
+			addressName = addr.Value
+		}
+	}
+
+	if svc.Spec.LoadBalancerIP != loadBalancerIP {
 		if len(gw.Spec.Addresses) > 1 {
 			r.Log.Info(fmt.Sprintf("found %d addresses on gateway, but currently we only support 1", len(gw.Spec.Addresses)), gw.Namespace, gw.Name)
 		}
-		r.Log.Info(fmt.Sprintf("using address %s for gateway", gw.Spec.Addresses[0].Value), gw.Namespace, gw.Name)
-		svc.Spec.LoadBalancerIP = gw.Spec.Addresses[0].Value
+		if loadBalancerIP != "" {
+			r.Log.Info(fmt.Sprintf("using address %s for gateway", loadBalancerIP), gw.Namespace, gw.Name)
+		} else {
+			r.Log.Info("service for gateway had a left over address that's no longer specified, removing", gw.Namespace, gw.Name)
+		}
+		svc.Spec.LoadBalancerIP = loadBalancerIP
 		updated = true
 	}
 
-	if svc.Spec.LoadBalancerIP != "" && len(gw.Spec.Addresses) == 0 {
-		r.Log.Info("service for gateway had a left over address that's no longer specified, removing", gw.Namespace, gw.Name)
-		svc.Spec.LoadBalancerIP = ""
+	if r.NamedAddressAnnotation != "" && svc.Annotations[r.NamedAddressAnnotation] != addressName {
+		if addressName != "" {
+			r.Log.Info(fmt.Sprintf("using named address %s for gateway", addressName), gw.Namespace, gw.Name)
+			if svc.Annotations == nil {
+				svc.Annotations = map[string]string{}
+			}
+			svc.Annotations[r.NamedAddressAnnotation] = addressName
+		} else {
+			r.Log.Info("service for gateway had a left over named address that's no longer specified, removing", gw.Namespace, gw.Name)
+			delete(svc.Annotations, r.NamedAddressAnnotation)
+		}
 		updated = true
 	}
 
@@ -175,8 +239,29 @@ func (r *GatewayReconciler) ensureServiceConfiguration(ctx context.Context, svc
 		updated = true
 	}
 
+	policy, err := externalTrafficPolicyForGateway(gw)
+	if err != nil {
+		return false, err
+	}
+	// without the annotation the policy defaulted by Kubernetes is kept.
+	if policy != "" && svc.Spec.ExternalTrafficPolicy != policy {
+		r.Log.Info(fmt.Sprintf("using external traffic policy %s for gateway", policy), gw.Namespace, gw.Name)
+		svc.Spec.ExternalTrafficPolicy = policy
+		updated = true
+	}
+
+	if class := loadBalancerClassForGateway(gw); class != nil && !ptr.Equal(svc.Spec.LoadBalancerClass, class) {
+		r.Log.Info(fmt.Sprintf("using load balancer class %q for gateway", ptr.Deref(class, "")), gw.Namespace, gw.Name)
+		svc.Spec.LoadBalancerClass = class
+		updated = true
+	}
+
 	ports := make([]corev1.ServicePort, 0, len(gw.Spec.Listeners))
 	for _, listener := range gw.Spec.Listeners {
+		if !isListenerPortValid(listener.Port) {
+			// the listener is not accepted, see getListenerAcceptedCondition.
+			continue
+		}
 		switch proto := listener.Protocol; proto {
 		case gatewayv1beta1.TCPProtocolType:
 			ports = append(ports, corev1.ServicePort{
@@ -207,6 +292,14 @@ func (r *GatewayReconciler) ensureServiceConfiguration(ctx context.Context, svc
 				Protocol: corev1.ProtocolTCP,
 				Port:     int32(listener.Port),
 			})
+		case gatewayv1beta1.TLSProtocolType:
+			if isTLSPassthroughListener(listener) {
+				ports = append(ports, corev1.ServicePort{
+					Name:     string(listener.Name),
+					Protocol: corev1.ProtocolTCP,
+					Port:     int32(listener.Port),
+				})
+			}
 		}
 	}
 
@@ -234,6 +327,64 @@ func (r *GatewayReconciler) ensureServiceConfiguration(ctx context.Context, svc
 	return updated, nil
 }
 
+// protocolChangedPorts returns the old Service ports whose port number is
+// exposed by both the old and new Service ports, but with a different
+// protocol.
+func protocolChangedPorts(oldPorts, newPorts []corev1.ServicePort) []corev1.ServicePort {
+	newProtocols := make(map[int32]corev1.Protocol, len(newPorts))
+	for _, port := range newPorts {
+		newProtocols[port.Port] = port.Protocol
+	}
+
+	var changed []corev1.ServicePort
+	for _, port := range oldPorts {
+		if protocol, ok := newProtocols[port.Port]; ok && protocol != port.Protocol {
+			changed = append(changed, port)
+		}
+	}
+	return changed
+}
+
+// deleteProtocolChangedVIPs removes the dataplane configuration of the
+// Gateway VIPs whose listener protocol changed: the routes of the old
+// protocol don't match the listener anymore, but the VIP of the old protocol
+// would otherwise keep forwarding its traffic until they're deleted.
+func (r *GatewayReconciler) deleteProtocolChangedVIPs(ctx context.Context, gw *gatewayv1beta1.Gateway, oldPorts, newPorts []corev1.ServicePort) error {
+	if r.BackendsClientManager == nil {
+		return nil
+	}
+
+	changed := protocolChangedPorts(oldPorts, newPorts)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	gwIP, err := dataplane.GetGatewayIP(gw)
+	if err != nil {
+		// without an address nothing was programmed for the Gateway yet.
+		return nil
+	}
+
+	for _, port := range changed {
+		protocol := dataplane.Vip_TCP
+		if port.Protocol == corev1.ProtocolUDP {
+			protocol = dataplane.Vip_UDP
+		}
+		vip, err := dataplane.GatewayVip(gw, gwIP, uint32(port.Port), protocol)
+		if err != nil {
+			// nothing is programmed for Gateway addresses the dataplane
+			// doesn't support.
+			r.Log.Info("Gateway has no IPv4 address, skipping data-plane DELETE", "namespace", gw.Namespace, "name", gw.Name, "reason", err.Error())
+			return nil
+		}
+		r.Log.Info("listener protocol changed, removing the Gateway VIP from the dataplane", "namespace", gw.Namespace, "name", gw.Name, "port", port.Port, "protocol", port.Protocol)
+		if _, err := r.BackendsClientManager.Delete(ctx, vip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var (
 	ipAddrType   = gatewayv1beta1.IPAddressType
 	hostAddrType = gatewayv1beta1.HostnameAddressType
@@ -300,6 +451,9 @@ func (r *GatewayReconciler) mapGatewayClassToGateway(_ context.Context, obj clie
 	}
 
 	for _, gateway := range gateways.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, gateway.Namespace) {
+			continue
+		}
 		if gateway.Spec.GatewayClassName == gatewayv1beta1.ObjectName(gatewayClass.Name) {
 			recs = append(recs, reconcile.Request{NamespacedName: types.NamespacedName{
 				Namespace: gateway.Namespace,
@@ -311,6 +465,70 @@ func (r *GatewayReconciler) mapGatewayClassToGateway(_ context.Context, obj clie
 	return
 }
 
+// checkDataPlane returns the reason and message of the Programmed condition
+// of the Gateways if the dataplane can't route their traffic, or an empty
+// reason if it can.
+func (r *GatewayReconciler) checkDataPlane(ctx context.Context) (gatewayv1beta1.GatewayConditionReason, string, error) {
+	if r.DataPlaneDaemonSet != nil {
+		ds := new(appsv1.DaemonSet)
+		if err := r.Client.Get(ctx, *r.DataPlaneDaemonSet, ds); err != nil {
+			if !errors.IsNotFound(err) {
+				return "", "", err
+			}
+			return GatewayReasonNoDataplane, fmt.Sprintf("the dataplane DaemonSet %s was not found", r.DataPlaneDaemonSet), nil
+		}
+		if !r.daemonsetIsDataPlane(ds) {
+			return GatewayReasonNoDataplane, fmt.Sprintf("the DaemonSet %s doesn't select the dataplane Pods (app=%s, component=%s)",
+				r.DataPlaneDaemonSet, vars.DefaultDataPlaneAppLabel, vars.DefaultDataPlaneComponentLabel), nil
+		}
+		if ds.Status.NumberReady == 0 {
+			return GatewayReasonNoDataplane, fmt.Sprintf("the dataplane DaemonSet %s has no ready Pods", r.DataPlaneDaemonSet), nil
+		}
+	}
+
+	if r.DataPlaneReadiness != nil {
+		if ready, known := r.DataPlaneReadiness.ReadyDataPlanes(); known && ready == 0 {
+			return GatewayReasonNoReadyDataplanes, "no dataplane instance is ready to route the traffic of the gateway", nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// daemonsetIsDataPlane indicates whether the provided DaemonSet selects the
+// dataplane Pods.
+func (r *GatewayReconciler) daemonsetIsDataPlane(ds *appsv1.DaemonSet) bool {
+	if ds.Spec.Selector == nil {
+		return false
+	}
+	matchLabels := ds.Spec.Selector.MatchLabels
+	return matchLabels["app"] == vars.DefaultDataPlaneAppLabel && matchLabels["component"] == vars.DefaultDataPlaneComponentLabel
+}
+
+// mapDataPlaneToGateways enqueues reconcilation for all the Gateways when the
+// number of ready dataplane instances drops to zero or rises from zero, as it
+// affects whether they're programmed.
+func (r *GatewayReconciler) mapDataPlaneToGateways(ctx context.Context, _ client.Object) (recs []reconcile.Request) {
+	gateways := &gatewayv1beta1.GatewayList{}
+	if err := r.Client.List(ctx, gateways); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.Log.Error(err, "could not map dataplane event to gateways")
+		return
+	}
+
+	for _, gateway := range gateways.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, gateway.Namespace) {
+			continue
+		}
+		recs = append(recs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: gateway.Namespace,
+			Name:      gateway.Name,
+		}})
+	}
+
+	return
+}
+
 func mapServiceToGateway(_ context.Context, obj client.Object) (reqs []reconcile.Request) {
 	svc, ok := obj.(*corev1.Service)
 	if !ok {
@@ -328,17 +546,259 @@ func mapServiceToGateway(_ context.Context, obj client.Object) (reqs []reconcile
 		}
 	}
 
+	// user provided Services of Gateways with the External service management
+	// mode have no owner reference, only the Gateway label.
+	if name, ok := svc.Labels[gatewayServiceLabel]; ok && len(reqs) == 0 {
+		reqs = append(reqs, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: svc.Namespace,
+				Name:      name,
+			},
+		})
+	}
+
+	return
+}
+
+// patchGatewayStatus patches the status of the provided Gateway and returns the
+// provided result. A conflict with a concurrent update of the Gateway is not
+// an error: the Gateway is requeued to compute its status again from its
+// latest version.
+func (r *GatewayReconciler) patchGatewayStatus(ctx context.Context, gateway, oldGateway *gatewayv1beta1.Gateway, result ctrl.Result) (ctrl.Result, error) {
+	if err := r.Status().Patch(ctx, gateway, client.MergeFrom(oldGateway)); err != nil {
+		if errors.IsConflict(err) {
+			log.FromContext(ctx).V(1).Info("conflict while patching the Gateway status, requeueing")
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// mapRouteToGateways enqueues the Gateways the provided TCPRoute or UDPRoute
+// is attached to, so that their listener status reflects the route's backends.
+func mapRouteToGateways(_ context.Context, obj client.Object) (reqs []reconcile.Request) {
+	var refs []gatewayv1alpha2.ParentReference
+	switch route := obj.(type) {
+	case *gatewayv1alpha2.TCPRoute:
+		refs = route.Spec.ParentRefs
+	case *gatewayv1alpha2.UDPRoute:
+		refs = route.Spec.ParentRefs
+	default:
+		return
+	}
+
+	for _, ref := range refs {
+		namespace := obj.GetNamespace()
+		if ref.Namespace != nil {
+			namespace = string(*ref.Namespace)
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: namespace,
+			Name:      string(ref.Name),
+		}})
+	}
 	return
 }
 
-func setGatewayStatus(gateway *gatewayv1beta1.Gateway) {
-	newAccepted := determineGatewayAcceptance(gateway)
+// mapBackendToGateways enqueues the Gateways of the TCPRoutes and UDPRoutes
+// referencing the provided Service, or the Service of the provided Endpoints,
+// as a backend whenever an event occurs on it, so that their listener status
+// reflects whether the backend exists and has ready endpoints. The routes are
+// listed with the routeBackendServiceKey index.
+func (r *GatewayReconciler) mapBackendToGateways(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	key := client.MatchingFields{routeBackendServiceKey: client.ObjectKeyFromObject(obj).String()}
+	var routes []client.Object
+
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := r.Client.List(ctx, tcproutes, key); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.Log.Error(err, "could not enqueue Gateways for backend update")
+		return
+	}
+	for i := range tcproutes.Items {
+		routes = append(routes, &tcproutes.Items[i])
+	}
+
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := r.Client.List(ctx, udproutes, key); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.Log.Error(err, "could not enqueue Gateways for backend update")
+		return
+	}
+	for i := range udproutes.Items {
+		routes = append(routes, &udproutes.Items[i])
+	}
+
+	seen := map[types.NamespacedName]struct{}{}
+	for _, route := range routes {
+		if !isNamespaceWatched(r.WatchNamespaces, route.GetNamespace()) {
+			continue
+		}
+		for _, req := range mapRouteToGateways(ctx, route) {
+			if _, ok := seen[req.NamespacedName]; ok {
+				continue
+			}
+			seen[req.NamespacedName] = struct{}{}
+			reqs = append(reqs, req)
+		}
+	}
+
+	return
+}
+
+// routeAttachesToListener indicates whether a route of the provided kind with
+// the provided ParentReference to the Gateway attaches to the listener.
+func routeAttachesToListener(kind string, ref gatewayv1alpha2.ParentReference, listener gatewayv1beta1.Listener) bool {
+	if ref.SectionName != nil && *ref.SectionName != listener.Name {
+		return false
+	}
+	if ref.Port != nil && *ref.Port != listener.Port {
+		return false
+	}
+	// only TCPRoutes and UDPRoutes are attached, whether HTTPRoutes are
+	// supported doesn't matter.
+	supportedKinds, _ := getSupportedKinds(0, listener, false)
+	for _, supported := range supportedKinds {
+		if string(supported.Kind) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// getListenersBackendIssues returns, by listener name, a message listing the
+// backend Services which don't exist for the routes attached to each listener
+// of the provided Gateway, and a message listing the attached routes which
+// have no ready backend endpoints.
+func (r *GatewayReconciler) getListenersBackendIssues(ctx context.Context, gw *gatewayv1beta1.Gateway) (missingBackends, unhealthyBackends map[gatewayv1beta1.SectionName]string, err error) {
+	missing := map[gatewayv1beta1.SectionName][]string{}
+	unhealthy := map[gatewayv1beta1.SectionName][]string{}
+	checkRoute := func(kind string, route client.Object, refs []gatewayv1alpha2.ParentReference, backendRefs []gatewayv1alpha2.BackendRef) error {
+		if !isNamespaceWatched(r.WatchNamespaces, route.GetNamespace()) {
+			return nil
+		}
+		ref, ok := parentRefForGateway(route.GetNamespace(), refs, gw)
+		if !ok {
+			return nil
+		}
+		var listeners []gatewayv1beta1.SectionName
+		for _, listener := range gw.Spec.Listeners {
+			if routeAttachesToListener(kind, ref, listener) {
+				listeners = append(listeners, listener.Name)
+			}
+		}
+
+		// the routes with backends whose endpoints aren't tracked, such as
+		// static or ExternalName backends, are assumed to be healthy.
+		// the routes whose backend Services are all missing are only
+		// reported as such.
+		healthy := len(backendRefs) == 0
+		found := false
+		for _, backendRef := range backendRefs {
+			if _, ok := dataplane.EndpointSourceFor(backendRef).(dataplane.ServiceEndpointSource); !ok {
+				healthy = true
+				continue
+			}
+			key := types.NamespacedName{Namespace: route.GetNamespace(), Name: string(backendRef.Name)}
+			if backendRef.Namespace != nil {
+				key.Namespace = string(*backendRef.Namespace)
+			}
+			svc := new(corev1.Service)
+			if err := r.Client.Get(ctx, key, svc); err != nil {
+				if !errors.IsNotFound(err) {
+					return err
+				}
+				for _, listener := range listeners {
+					missing[listener] = append(missing[listener],
+						fmt.Sprintf("Service %s referenced by %s %s/%s", key, kind, route.GetNamespace(), route.GetName()))
+				}
+				continue
+			}
+			found = true
+			if svc.Spec.Type == corev1.ServiceTypeExternalName {
+				healthy = true
+				continue
+			}
+			ready, err := r.hasReadyEndpoints(ctx, key)
+			if err != nil {
+				return err
+			}
+			healthy = healthy || ready
+		}
+		if !healthy && found {
+			for _, listener := range listeners {
+				unhealthy[listener] = append(unhealthy[listener], fmt.Sprintf("%s %s/%s", kind, route.GetNamespace(), route.GetName()))
+			}
+		}
+		return nil
+	}
+
+	byGateway := client.MatchingFields{routeParentGatewayKey: client.ObjectKeyFromObject(gw).String()}
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := r.Client.List(ctx, tcproutes, byGateway); err != nil {
+		return nil, nil, err
+	}
+	for i := range tcproutes.Items {
+		tcproute := &tcproutes.Items[i]
+		var backendRefs []gatewayv1alpha2.BackendRef
+		for _, rule := range tcproute.Spec.Rules {
+			backendRefs = append(backendRefs, rule.BackendRefs...)
+		}
+		if err := checkRoute(TCPRouteKind, tcproute, tcproute.Spec.ParentRefs, backendRefs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := r.Client.List(ctx, udproutes, byGateway); err != nil {
+		return nil, nil, err
+	}
+	for i := range udproutes.Items {
+		udproute := &udproutes.Items[i]
+		var backendRefs []gatewayv1alpha2.BackendRef
+		for _, rule := range udproute.Spec.Rules {
+			backendRefs = append(backendRefs, rule.BackendRefs...)
+		}
+		if err := checkRoute(UDPRouteKind, udproute, udproute.Spec.ParentRefs, backendRefs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	missingBackends = make(map[gatewayv1beta1.SectionName]string, len(missing))
+	for listener, backends := range missing {
+		missingBackends[listener] = fmt.Sprintf("backends not found: %s", strings.Join(backends, ", "))
+	}
+	unhealthyBackends = make(map[gatewayv1beta1.SectionName]string, len(unhealthy))
+	for listener, routes := range unhealthy {
+		unhealthyBackends[listener] = fmt.Sprintf("routes without ready backend endpoints: %s", strings.Join(routes, ", "))
+	}
+	return missingBackends, unhealthyBackends, nil
+}
+
+// hasReadyEndpoints indicates whether the Endpoints of the Service with the
+// provided key have at least one ready address.
+func (r *GatewayReconciler) hasReadyEndpoints(ctx context.Context, key types.NamespacedName) (bool, error) {
+	endpoints := new(corev1.Endpoints)
+	if err := r.Client.Get(ctx, key, endpoints); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *GatewayReconciler) setGatewayStatus(gateway *gatewayv1beta1.Gateway) {
+	newAccepted := r.determineGatewayAcceptance(gateway)
 	newProgrammed := determineGatewayProgrammed(gateway)
 	setCond(gateway, newAccepted)
 	setCond(gateway, newProgrammed)
 }
 
-func determineGatewayAcceptance(gateway *gatewayv1beta1.Gateway) metav1.Condition {
+func (r *GatewayReconciler) determineGatewayAcceptance(gateway *gatewayv1beta1.Gateway) metav1.Condition {
 	// this is the default accepted condition, it may get overidden if there are
 	// unsupported values in the specification.
 	accepted := metav1.Condition{
@@ -350,18 +810,41 @@ func determineGatewayAcceptance(gateway *gatewayv1beta1.Gateway) metav1.Conditio
 		Message:            "blixt controlplane accepts responsibility for the Gateway",
 	}
 
+	if err := validateGatewayAnnotations(gateway); err != nil {
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = string(gatewayv1beta1.GatewayReasonInvalid)
+		accepted.Message = err.Error()
+	}
+
 	// verify that all addresses are supported
 	for _, addr := range gateway.Spec.Addresses {
-		if addr.Type != nil && *addr.Type != gatewayv1beta1.IPAddressType {
+		if !r.isAddressTypeSupported(addr.Type) {
 			accepted.Status = metav1.ConditionFalse
 			accepted.Reason = string(gatewayv1beta1.GatewayReasonUnsupportedAddress)
-			accepted.Message = fmt.Sprintf("found an address of type %s, only IPAddress is supported", *addr.Type)
+			accepted.Message = fmt.Sprintf("found an address of type %s, which is not supported", *addr.Type)
 		}
 	}
 
 	return accepted
 }
 
+// isAddressTypeSupported indicates whether Gateway addresses of the provided
+// type can be provisioned. IPAddress (the default) is always supported, and
+// NamedAddress is supported when it can be mapped to a Service annotation.
+func (r *GatewayReconciler) isAddressTypeSupported(addrType *gatewayv1beta1.AddressType) bool {
+	if addrType == nil {
+		return true
+	}
+	switch *addrType {
+	case gatewayv1beta1.IPAddressType:
+		return true
+	case gatewayv1beta1.NamedAddressType:
+		return r.NamedAddressAnnotation != ""
+	default:
+		return false
+	}
+}
+
 func determineGatewayProgrammed(gateway *gatewayv1beta1.Gateway) metav1.Condition {
 	// TODO: give this client access and make it dynamic
 	return metav1.Condition{
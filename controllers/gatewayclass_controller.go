@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,8 +28,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
-
-	"github.com/kubernetes-sigs/blixt/pkg/vars"
 )
 
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses,verbs=get;list;watch;create;update;patch;delete
@@ -39,18 +38,32 @@ import (
 type GatewayClassReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// ResyncPeriod, if set, is the period after which managed GatewayClasses
+	// are reconciled again, so that one which lost its Accepted condition is
+	// re-accepted even if no event was received for it.
+	ResyncPeriod time.Duration
+
+	// ControllerName is the controller name of the GatewayClasses managed by
+	// this controller. Defaults to vars.GatewayClassControllerName if empty.
+	ControllerName gatewayv1beta1.GatewayController
+
+	// RateLimiter configures the rate limiter of the workqueue of the
+	// controller, the controller-runtime default is used if it's zero.
+	RateLimiter RateLimiterConfig
 }
 
 // SetupWithManager loads the controller into the provided controller manager.
 func (r *GatewayClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controllerOptions(r.RateLimiter)).
 		For(&gatewayv1beta1.GatewayClass{}).
 		WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
 			gwc, ok := obj.(*gatewayv1beta1.GatewayClass)
 			if !ok {
 				return false
 			}
-			return gwc.Spec.ControllerName == vars.GatewayClassControllerName // filter out unmanaged GWCs
+			return gwc.Spec.ControllerName == controllerNameOrDefault(r.ControllerName) // filter out unmanaged GWCs
 		})).
 		Complete(r)
 }
@@ -68,23 +81,25 @@ func (r *GatewayClassReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	if gwc.Spec.ControllerName != vars.GatewayClassControllerName {
+	if gwc.Spec.ControllerName != controllerNameOrDefault(r.ControllerName) {
 		return ctrl.Result{}, nil
 	}
 
+	result := ctrl.Result{RequeueAfter: r.ResyncPeriod}
 	if !r.isAccepted(gwc) {
 		log.Info("marking GatwayClass as accepted", "name", gwc.Name)
-		return ctrl.Result{}, r.accept(ctx, gwc)
+		return result, r.accept(ctx, gwc)
 	}
 
-	return ctrl.Result{}, nil
-
+	return result, nil
 }
 
+// isAccepted indicates whether the GatewayClass has an Accepted condition
+// which is true and up to date with the current generation of the object.
 func (r *GatewayClassReconciler) isAccepted(gwc *gatewayv1beta1.GatewayClass) bool {
 	for _, cond := range gwc.Status.Conditions {
 		if cond.Type == string(gatewayv1beta1.GatewayClassConditionStatusAccepted) {
-			if cond.Status == metav1.ConditionTrue {
+			if cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == gwc.Generation {
 				return true
 			}
 		}
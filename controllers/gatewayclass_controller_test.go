@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	controllerruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+func TestGatewayClassReconciler_reacceptance(t *testing.T) {
+	ctx := context.Background()
+	gwc := &gatewayv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass", Generation: 1},
+		Spec: gatewayv1beta1.GatewayClassSpec{
+			ControllerName: vars.GatewayClassControllerName,
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(gwc).
+		WithStatusSubresource(gwc).
+		Build()
+	reconciler := GatewayClassReconciler{
+		Client:       fakeClient,
+		ResyncPeriod: time.Minute,
+	}
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gwc)}
+
+	requireAccepted := func() {
+		t.Helper()
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, result.RequeueAfter)
+
+		require.NoError(t, fakeClient.Get(ctx, req.NamespacedName, gwc))
+		accepted := meta.FindStatusCondition(gwc.Status.Conditions, string(gatewayv1beta1.GatewayClassConditionStatusAccepted))
+		require.NotNil(t, accepted)
+		require.Equal(t, metav1.ConditionTrue, accepted.Status)
+		require.Equal(t, gwc.Generation, accepted.ObservedGeneration)
+	}
+
+	t.Log("accepting the GatewayClass")
+	requireAccepted()
+
+	t.Log("clearing the GatewayClass status")
+	gwc.Status.Conditions = nil
+	require.NoError(t, fakeClient.Status().Update(ctx, gwc))
+	requireAccepted()
+
+	t.Log("leaving the Accepted condition behind the GatewayClass generation")
+	gwc.Status.Conditions[0].ObservedGeneration = 0
+	require.NoError(t, fakeClient.Status().Update(ctx, gwc))
+	requireAccepted()
+}
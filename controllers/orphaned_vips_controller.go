@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+)
+
+// DefaultOrphanedVIPsPruneInterval is the default period between two prunes
+// of the orphaned dataplane VIPs.
+const DefaultOrphanedVIPsPruneInterval = 5 * time.Minute
+
+// OrphanedVIPsReconciler periodically compares the VIPs programmed into the
+// dataplane with the VIPs of the existing TCPRoutes and UDPRoutes, and
+// deletes those which don't correspond to any route anymore. The route
+// controllers only delete the VIP of a route when it's deleted, so a VIP is
+// left behind if that fails on some dataplane instances.
+type OrphanedVIPsReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// Pruner deletes the VIPs which aren't desired from the dataplane.
+	Pruner dataplane.VIPsPruner
+
+	// Interval is the period between two prunes. Defaults to
+	// DefaultOrphanedVIPsPruneInterval if zero.
+	Interval time.Duration
+
+	// WatchNamespaces restricts the routes whose VIPs are desired to the
+	// provided namespaces. All namespaces are watched if empty.
+	WatchNamespaces []string
+}
+
+// SetupWithManager adds the reconciler to the provided controller manager, it
+// only runs on the leader.
+func (r *OrphanedVIPsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Log = log.FromContext(context.Background())
+	return mgr.Add(r)
+}
+
+// Start prunes the orphaned VIPs every interval until the context is done.
+func (r *OrphanedVIPsReconciler) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval == 0 {
+		interval = DefaultOrphanedVIPsPruneInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.prune(ctx); err != nil {
+				r.Log.Error(err, "OrphanedVIPsReconciler", "status", "failed to prune orphaned VIPs")
+			}
+		}
+	}
+}
+
+// prune deletes the dataplane VIPs which don't correspond to any route.
+func (r *OrphanedVIPsReconciler) prune(ctx context.Context) error {
+	desired, err := r.desiredVIPs(ctx)
+	if err != nil {
+		return err
+	}
+
+	pruned, err := r.Pruner.PruneVIPs(ctx, desired)
+	if pruned > 0 {
+		r.Log.Info("OrphanedVIPsReconciler", "status", "pruned orphaned VIPs", "count", pruned)
+	}
+	return err
+}
+
+// desiredVIPs returns the VIPs of all the existing TCPRoutes and UDPRoutes,
+// including the routes being deleted, as their VIPs are deleted by the route
+// controllers.
+func (r *OrphanedVIPsReconciler) desiredVIPs(ctx context.Context) ([]*dataplane.Vip, error) {
+	var desired []*dataplane.Vip
+	gateways := map[types.NamespacedName]*gatewayv1beta1.Gateway{}
+	addRouteVIPs := func(routeNamespace string, refs []gatewayv1alpha2.ParentReference, protocol dataplane.Vip_Protocol) error {
+		for _, ref := range refs {
+			if ref.Port == nil {
+				continue
+			}
+			key := types.NamespacedName{Namespace: routeNamespace, Name: string(ref.Name)}
+			if ref.Namespace != nil {
+				key.Namespace = string(*ref.Namespace)
+			}
+
+			gateway, ok := gateways[key]
+			if !ok {
+				gateway = new(gatewayv1beta1.Gateway)
+				if err := r.Client.Get(ctx, key, gateway); err != nil {
+					if !errors.IsNotFound(err) {
+						return err
+					}
+					gateway = nil
+				}
+				gateways[key] = gateway
+			}
+			if gateway == nil {
+				continue
+			}
+
+			gatewayIP, err := dataplane.GetGatewayIP(gateway)
+			if err != nil {
+				continue
+			}
+			vip, err := dataplane.GatewayVip(gateway, gatewayIP, uint32(*ref.Port), protocol)
+			if err != nil {
+				// no VIP is programmed for Gateway addresses the dataplane
+				// doesn't support.
+				continue
+			}
+			desired = append(desired, vip)
+		}
+		return nil
+	}
+
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := r.Client.List(ctx, tcproutes); err != nil {
+		return nil, err
+	}
+	for _, tcproute := range tcproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+			continue
+		}
+		if err := addRouteVIPs(tcproute.Namespace, tcproute.Spec.ParentRefs, dataplane.Vip_TCP); err != nil {
+			return nil, err
+		}
+	}
+
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := r.Client.List(ctx, udproutes); err != nil {
+		return nil, err
+	}
+	for _, udproute := range udproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+			continue
+		}
+		if err := addRouteVIPs(udproute.Namespace, udproute.Spec.ParentRefs, dataplane.Vip_UDP); err != nil {
+			return nil, err
+		}
+	}
+
+	return desired, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+)
+
+// fakeVIPsPruner records the desired VIPs it's asked to keep.
+type fakeVIPsPruner struct {
+	desired []*dataplane.Vip
+}
+
+func (f *fakeVIPsPruner) PruneVIPs(_ context.Context, desired []*dataplane.Vip, _ ...grpc.CallOption) (int, error) {
+	f.desired = desired
+	return 0, nil
+}
+
+func TestOrphanedVIPsReconciler_prune(t *testing.T) {
+	tcproute := newTestTCPRoute("tcproute", time.Now())
+	udproute := newTestUDPRoute("udproute", time.Now())
+	udproute.Spec.ParentRefs[0].Port = ptr.To(gatewayv1alpha2.PortNumber(9875))
+	// a route attached to a Gateway which doesn't exist has no VIP.
+	orphan := newTestTCPRoute("tcproute-no-gateway", time.Now())
+	orphan.Spec.ParentRefs[0].Name = "missing-gateway"
+
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(append(newTCPRouteTestObjects(), tcproute, udproute, orphan)...).
+		Build()
+	pruner := &fakeVIPsPruner{}
+	reconciler := OrphanedVIPsReconciler{Client: fakeClient, Pruner: pruner}
+
+	require.NoError(t, reconciler.prune(context.Background()))
+	require.ElementsMatch(t, []*dataplane.Vip{
+		{Ip: 0xac1200f0, Port: 8080},
+		{Ip: 0xac1200f0, Port: 9875, Protocol: dataplane.Vip_UDP},
+	}, pruner.desired)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// PprofPath is the path prefix of the pprof endpoints.
+const PprofPath = "/debug/pprof/"
+
+// DefaultPprofBindAddress is the default address the pprof endpoints bind to,
+// a loopback address as the profiles aren't protected.
+const DefaultPprofBindAddress = "127.0.0.1:6060"
+
+// NewPprofServer returns the server of the net/http/pprof endpoints of the
+// control plane bound to the provided address, or nil if they're disabled. It
+// serves its own mux, so that the profiles aren't exposed on the metrics or
+// admin endpoints.
+func NewPprofServer(enabled bool, addr string) *AdminServer {
+	if !enabled {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(PprofPath, pprof.Index)
+	mux.HandleFunc(PprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(PprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(PprofPath+"trace", pprof.Trace)
+	return &AdminServer{Addr: addr, Handler: mux}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPprofServer(t *testing.T) {
+	t.Log("the pprof endpoints are not served unless enabled")
+	require.Nil(t, NewPprofServer(false, DefaultPprofBindAddress))
+
+	t.Log("the pprof endpoints are served on the provided address once enabled")
+	server := NewPprofServer(true, "127.0.0.1:6061")
+	require.NotNil(t, server)
+	require.Equal(t, "127.0.0.1:6061", server.Addr)
+	require.False(t, server.NeedLeaderElection())
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, get(PprofPath))
+	require.Equal(t, http.StatusOK, get(PprofPath+"cmdline"))
+	require.Equal(t, http.StatusOK, get(PprofPath+"heap"))
+
+	t.Log("the pprof mux serves nothing else")
+	require.Equal(t, http.StatusNotFound, get(ResyncPath))
+	require.Equal(t, http.StatusNotFound, get("/metrics"))
+}
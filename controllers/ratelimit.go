@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+const (
+	// DefaultRateLimiterBaseDelay is the default delay after which a request
+	// which failed to be reconciled is reconciled again, it's doubled on each
+	// consecutive failure.
+	DefaultRateLimiterBaseDelay = 5 * time.Millisecond
+
+	// DefaultRateLimiterMaxDelay is the default maximum delay after which a
+	// request which failed to be reconciled is reconciled again.
+	DefaultRateLimiterMaxDelay = 1000 * time.Second
+
+	// DefaultRateLimiterBucketSize is the default number of requests which
+	// can be queued in a burst, before being limited to rateLimiterQPS.
+	DefaultRateLimiterBucketSize = 100
+
+	// rateLimiterQPS is the overall rate at which requests are queued once the
+	// bucket is empty.
+	rateLimiterQPS = 10
+)
+
+// RateLimiterConfig configures the rate limiter of the workqueue of a
+// controller, which limits how frequently the requests are reconciled: the
+// delay before a request which failed is reconciled again grows
+// exponentially from BaseDelay to MaxDelay, and the overall rate of requests
+// is limited by a token bucket of BucketSize tokens. The controller-runtime
+// default rate limiter, which has the default values, is used if it's zero.
+type RateLimiterConfig struct {
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	BucketSize int
+}
+
+// rateLimiter returns the configured workqueue rate limiter, or nil if the
+// configuration is zero.
+func (c RateLimiterConfig) rateLimiter() workqueue.RateLimiter {
+	if c == (RateLimiterConfig{}) {
+		return nil
+	}
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(c.BaseDelay, c.MaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(rateLimiterQPS), c.BucketSize)},
+	)
+}
+
+// controllerOptions returns the options of a controller with the provided
+// rate limiter configuration.
+func controllerOptions(rateLimiter RateLimiterConfig) controller.Options {
+	return controller.Options{RateLimiter: rateLimiter.rateLimiter()}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerOptions_rateLimiter(t *testing.T) {
+	t.Log("the controller-runtime default rate limiter is used without configuration")
+	require.Nil(t, controllerOptions(RateLimiterConfig{}).RateLimiter)
+
+	t.Log("a custom rate limiter is applied to the controller options")
+	rateLimiter := controllerOptions(RateLimiterConfig{
+		BaseDelay:  time.Second,
+		MaxDelay:   5 * time.Second,
+		BucketSize: 1000,
+	}).RateLimiter
+	require.NotNil(t, rateLimiter)
+
+	t.Log("the delay before a failed request is retried grows exponentially up to the maximum delay")
+	require.Equal(t, time.Second, rateLimiter.When("request"))
+	require.Equal(t, 2*time.Second, rateLimiter.When("request"))
+	require.Equal(t, 4*time.Second, rateLimiter.When("request"))
+	require.Equal(t, 5*time.Second, rateLimiter.When("request"))
+	require.Equal(t, 4, rateLimiter.NumRequeues("request"))
+
+	t.Log("the delay is reset once the request is reconciled")
+	rateLimiter.Forget("request")
+	require.Equal(t, time.Second, rateLimiter.When("request"))
+	require.Equal(t, time.Second, rateLimiter.When("other-request"))
+}
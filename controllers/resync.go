@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ResyncPath is the path of the admin endpoint triggering a resync of the
+// dataplane configuration.
+const ResyncPath = "/resync"
+
+// errNoDataPlaneDaemonSet is returned when a resync is requested but the
+// dataplane DaemonSet doesn't exist.
+var errNoDataPlaneDaemonSet = errors.New("no dataplane DaemonSet found")
+
+// Resyncer triggers a full re-push of the dataplane configuration.
+type Resyncer interface {
+	Resync(ctx context.Context) error
+}
+
+// Resync triggers a full re-push of the dataplane configuration, without
+// restarting the control plane. It sends the same event as when dataplane
+// instances are added: the route controllers forget the Targets they pushed
+// and enqueue all their routes, which are then pushed whole to all the
+// dataplane instances.
+func (r *DataplaneReconciler) Resync(ctx context.Context) error {
+	daemonsets := new(appsv1.DaemonSetList)
+	if err := r.List(ctx, daemonsets); err != nil {
+		return err
+	}
+
+	for i := range daemonsets.Items {
+		ds := &daemonsets.Items[i]
+		if !r.daemonsetHasMatchingAnnotations(ds) {
+			continue
+		}
+		select {
+		case r.updates <- event.GenericEvent{Object: ds}:
+		default:
+			// an event is already pending, which resyncs all the routes too.
+		}
+		return nil
+	}
+	return errNoDataPlaneDaemonSet
+}
+
+// NewResyncHandler returns an HTTP handler triggering a resync with the
+// provided Resyncer on POST requests. If the provided token isn't empty, the
+// requests must carry it as a bearer token. The routes are only reconciled by
+// the leader, so the requests fail with 503 until the provided elected
+// channel, usually the controller manager's, is closed.
+func NewResyncHandler(resyncer Resyncer, token string, elected <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" {
+			provided, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		select {
+		case <-elected:
+		default:
+			http.Error(w, "not the leader, the resync must be sent to the leader replica", http.StatusServiceUnavailable)
+			return
+		}
+
+		if err := resyncer.Resync(req.Context()); err != nil {
+			log.FromContext(req.Context()).Error(err, "resync failed")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.FromContext(req.Context()).Info("dataplane resync triggered")
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// ReadAdminToken returns the token of the admin endpoints from the provided
+// file, without surrounding whitespace. A file without a token is an error,
+// rather than silently disabling the authentication.
+func ReadAdminToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("the admin token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// ValidateAdminAddress returns an error if the admin endpoints would be served
+// without authentication on the provided address, which is only allowed on a
+// loopback address.
+func ValidateAdminAddress(addr, token string) error {
+	if token != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("the admin endpoints can't be bound to %s without a token, bind them to a loopback address or set an admin token file", addr)
+}
+
+// AdminServer serves the admin endpoints of the control plane, it's added to
+// the controller manager to run on all the replicas.
+type AdminServer struct {
+	// Addr is the address the server listens on. It should be a loopback
+	// address, unless a token protects the endpoints.
+	Addr string
+	// Handler serves the admin endpoints.
+	Handler http.Handler
+}
+
+// Start serves the admin endpoints until the context is done.
+func (s *AdminServer) Start(ctx context.Context) error {
+	server := &http.Server{Addr: s.Addr, Handler: s.Handler, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return server.Shutdown(context.Background())
+	case err := <-errs:
+		return err
+	}
+}
+
+// NeedLeaderElection returns false, the admin endpoints are served by all the
+// replicas, the handlers which must only run on the leader check it.
+func (s *AdminServer) NeedLeaderElection() bool {
+	return false
+}
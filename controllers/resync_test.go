@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	controllerruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+func TestResyncHandler(t *testing.T) {
+	ctx := context.Background()
+	objs := newTCPRouteTestObjects()
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
+				Name:     "tcp-9090",
+				Protocol: gatewayv1beta1.TCPProtocolType,
+				Port:     9090,
+			})
+		}
+	}
+	routeA, routeB := newTestTCPRoute("route-a", time.Now()), newTestTCPRoute("route-b", time.Now())
+	routeB.Spec.ParentRefs[0].Port = ptr.To(gatewayv1alpha2.PortNumber(9090))
+	reconciler, backends := newTestTCPRouteReconciler(append(objs, routeA, routeB)...)
+	dataplaneReconciler := NewDataplaneReconciler(reconciler.Client, scheme.Scheme, nil)
+	elected := make(chan struct{})
+	handler := NewResyncHandler(dataplaneReconciler, "test-token", elected)
+
+	resync := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, ResyncPath, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	reconcileAll := func(reqs ...reconcile.Request) {
+		for _, req := range reqs {
+			_, err := reconciler.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+	}
+	routeReqs := []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routeA)},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routeB)},
+	}
+
+	t.Log("unchanged routes are not pushed again")
+	reconcileAll(routeReqs...)
+	reconcileAll(routeReqs...)
+	require.Len(t, backends.updates, 2)
+
+	t.Log("resyncs require the token")
+	require.Equal(t, http.StatusUnauthorized, resync(""))
+	require.Equal(t, http.StatusUnauthorized, resync("wrong-token"))
+
+	t.Log("resyncs are rejected until the replica is elected leader")
+	require.Equal(t, http.StatusServiceUnavailable, resync("test-token"))
+	close(elected)
+
+	t.Log("resyncs fail without a dataplane DaemonSet")
+	require.Equal(t, http.StatusInternalServerError, resync("test-token"))
+
+	t.Log("a resync re-pushes all the routes")
+	require.NoError(t, reconciler.Client.Create(ctx, &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: vars.DefaultNamespace, Name: vars.DefaultDataPlaneDaemonSetName},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{
+				"app":       vars.DefaultDataPlaneAppLabel,
+				"component": vars.DefaultDataPlaneComponentLabel,
+			}},
+		},
+	}))
+	require.Equal(t, http.StatusAccepted, resync("test-token"))
+	evt := <-dataplaneReconciler.GetUpdates()
+	reqs := reconciler.mapDataPlaneDaemonsetToTCPRoutes(ctx, evt.Object)
+	require.ElementsMatch(t, routeReqs, reqs)
+	reconcileAll(reqs...)
+	require.Len(t, backends.updates, 4)
+	require.ElementsMatch(t, []uint32{8080, 9090}, []uint32{backends.updates[2].Vip.Port, backends.updates[3].Vip.Port})
+}
+
+func TestReadAdminToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+
+	require.NoError(t, os.WriteFile(path, []byte("test-token\n"), 0o600))
+	token, err := ReadAdminToken(path)
+	require.NoError(t, err)
+	require.Equal(t, "test-token", token)
+
+	t.Log("a token file without a token doesn't disable the authentication")
+	require.NoError(t, os.WriteFile(path, []byte(" \n"), 0o600))
+	_, err = ReadAdminToken(path)
+	require.Error(t, err)
+
+	_, err = ReadAdminToken(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
+
+func TestValidateAdminAddress(t *testing.T) {
+	for _, tt := range []struct {
+		addr  string
+		token string
+		valid bool
+	}{
+		{addr: "127.0.0.1:8082", valid: true},
+		{addr: "[::1]:8082", valid: true},
+		{addr: "localhost:8082", valid: true},
+		{addr: "0.0.0.0:8082", valid: false},
+		{addr: ":8082", valid: false},
+		{addr: "10.0.0.1:8082", valid: false},
+		{addr: "0.0.0.0:8082", token: "test-token", valid: true},
+		{addr: "invalid", valid: false},
+	} {
+		err := ValidateAdminAddress(tt.addr, tt.token)
+		if tt.valid {
+			require.NoError(t, err, tt.addr)
+		} else {
+			require.Error(t, err, tt.addr)
+		}
+	}
+}
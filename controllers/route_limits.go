@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// MaxRoutesPerGatewayParameter is the key of the GatewayClass parameters
+// which limits the number of routes attached to each Gateway of the class, to
+// protect the capacity of the dataplane maps. The routes beyond the limit, the
+// newest ones, are not accepted. There's no limit if it's unset or zero.
+//
+// The parameters of a GatewayClass are the data of the ConfigMap referenced
+// by its parametersRef. Other kinds of parametersRef, and ConfigMaps which
+// don't exist, are ignored.
+const MaxRoutesPerGatewayParameter = "maxRoutesPerGateway"
+
+// gatewayClassParametersConfigMap returns the ConfigMap referenced by the
+// parametersRef of the provided GatewayClass, if it references one.
+func gatewayClassParametersConfigMap(gwc *gatewayv1beta1.GatewayClass) (types.NamespacedName, bool) {
+	ref := gwc.Spec.ParametersRef
+	if ref == nil || ref.Group != "" || ref.Kind != "ConfigMap" || ref.Namespace == nil {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: string(*ref.Namespace), Name: ref.Name}, true
+}
+
+// gatewayClassParameters returns the parameters of the provided GatewayClass,
+// which are the data of the ConfigMap referenced by its parametersRef, or nil
+// if it has none. The ConfigMap is read with the provided reader, which must
+// not be restricted to the watched namespaces, as the ConfigMap can be in any
+// namespace.
+func gatewayClassParameters(ctx context.Context, c client.Reader, gwc *gatewayv1beta1.GatewayClass) (map[string]string, error) {
+	key, ok := gatewayClassParametersConfigMap(gwc)
+	if !ok {
+		return nil, nil
+	}
+
+	cm := new(corev1.ConfigMap)
+	if err := c.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get the parameters of GatewayClass %s: %w", gwc.Name, err)
+	}
+	return cm.Data, nil
+}
+
+// maxRoutesPerGateway returns the maximum number of routes attached to the
+// provided Gateway, according to the parameters of its GatewayClass, which
+// are read with paramsReader. It returns zero if there's no limit.
+func maxRoutesPerGateway(ctx context.Context, c, paramsReader client.Reader, gw *gatewayv1beta1.Gateway) (int, error) {
+	gwc := new(gatewayv1beta1.GatewayClass)
+	if err := c.Get(ctx, types.NamespacedName{Name: string(gw.Spec.GatewayClassName)}, gwc); err != nil {
+		return 0, err
+	}
+	params, err := gatewayClassParameters(ctx, paramsReader, gwc)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := params[MaxRoutesPerGatewayParameter]
+	if !ok {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid %s parameter %q of GatewayClass %s, it must be a non-negative integer", MaxRoutesPerGatewayParameter, value, gwc.Name)
+	}
+	return limit, nil
+}
+
+// attachedRoute is a route of any kind attached to a Gateway.
+type attachedRoute struct {
+	kind   string
+	route  metav1.Object
+	status gatewayv1alpha2.RouteStatus
+}
+
+// attachedRouteTakesPrecedence returns true if route a takes precedence over
+// route b when they compete for the routes allowed on a Gateway, like
+// routeTakesPrecedence. Ties between routes of different kinds with the same
+// namespace and name are broken by kind.
+func attachedRouteTakesPrecedence(a, b attachedRoute) bool {
+	if a.route.GetNamespace() == b.route.GetNamespace() && a.route.GetName() == b.route.GetName() {
+		aCreated, bCreated := a.route.GetCreationTimestamp(), b.route.GetCreationTimestamp()
+		if aCreated.Equal(&bCreated) {
+			return a.kind < b.kind
+		}
+	}
+	return routeTakesPrecedence(a.route, b.route)
+}
+
+// gatewayAttachedRoutes returns the TCPRoutes and UDPRoutes in the watched
+// namespaces which are attached to the provided Gateway and not being deleted.
+// They're listed with the routeParentGatewayKey index.
+func gatewayAttachedRoutes(ctx context.Context, c client.Reader, watchNamespaces []string, gw *gatewayv1beta1.Gateway) ([]attachedRoute, error) {
+	var routes []attachedRoute
+	attached := func(obj metav1.Object, refs []gatewayv1alpha2.ParentReference) bool {
+		if obj.GetDeletionTimestamp() != nil || !isNamespaceWatched(watchNamespaces, obj.GetNamespace()) {
+			return false
+		}
+		_, ok := parentRefForGateway(obj.GetNamespace(), refs, gw)
+		return ok
+	}
+
+	byGateway := client.MatchingFields{routeParentGatewayKey: client.ObjectKeyFromObject(gw).String()}
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := c.List(ctx, tcproutes, byGateway); err != nil {
+		return nil, err
+	}
+	for i := range tcproutes.Items {
+		if tcproute := &tcproutes.Items[i]; attached(tcproute, tcproute.Spec.ParentRefs) {
+			routes = append(routes, attachedRoute{kind: TCPRouteKind, route: tcproute, status: tcproute.Status.RouteStatus})
+		}
+	}
+
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := c.List(ctx, udproutes, byGateway); err != nil {
+		return nil, err
+	}
+	for i := range udproutes.Items {
+		if udproute := &udproutes.Items[i]; attached(udproute, udproute.Spec.ParentRefs) {
+			routes = append(routes, attachedRoute{kind: UDPRouteKind, route: udproute, status: udproute.Status.RouteStatus})
+		}
+	}
+
+	return routes, nil
+}
+
+// exceedsGatewayRouteLimit indicates whether the provided route is beyond the
+// maximum number of routes attached to the provided Gateway, because as many
+// routes which take precedence over it are attached to the Gateway. The routes
+// which are paused, or not accepted by the provided controller for another
+// reason than the limit, don't count. It also returns the limit, which is zero
+// if there's none. The GatewayClass parameters are read with paramsReader.
+func exceedsGatewayRouteLimit(ctx context.Context, c, paramsReader client.Reader, watchNamespaces []string, controllerName gatewayv1alpha2.GatewayController, kind string, route metav1.Object, gw *gatewayv1beta1.Gateway) (int, bool, error) {
+	limit, err := maxRoutesPerGateway(ctx, c, paramsReader, gw)
+	if err != nil || limit == 0 {
+		return 0, false, err
+	}
+
+	routes, err := gatewayAttachedRoutes(ctx, c, watchNamespaces, gw)
+	if err != nil {
+		return 0, false, err
+	}
+	current := attachedRoute{kind: kind, route: route}
+	preceding := 0
+	for _, other := range routes {
+		if other.kind == kind && other.route.GetUID() == route.GetUID() {
+			continue
+		}
+		if isRoutePaused(other.route) || routeNotAcceptedWithReason(other.status, controllerName, RouteReasonConflict, gatewayv1alpha2.RouteReasonUnsupportedValue) {
+			continue
+		}
+		if attachedRouteTakesPrecedence(other, current) {
+			preceding++
+		}
+	}
+	return limit, preceding >= limit, nil
+}
+
+// routeHasTooManyRoutes indicates whether the provided route status reports
+// that the route isn't accepted by the provided controller because its
+// Gateway has too many routes.
+func routeHasTooManyRoutes(status gatewayv1alpha2.RouteStatus, controllerName gatewayv1alpha2.GatewayController) bool {
+	return routeNotAcceptedWithReason(status, controllerName, RouteReasonTooManyRoutes)
+}
+
+// routeNotAcceptedWithReason indicates whether the provided route status
+// reports that the route isn't accepted by the provided controller, for one
+// of the provided reasons.
+func routeNotAcceptedWithReason(status gatewayv1alpha2.RouteStatus, controllerName gatewayv1alpha2.GatewayController, reasons ...gatewayv1alpha2.RouteConditionReason) bool {
+	for _, parent := range status.Parents {
+		if parent.ControllerName != controllerName {
+			continue
+		}
+		accepted := meta.FindStatusCondition(parent.Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+		if accepted == nil || accepted.Status != metav1.ConditionFalse {
+			continue
+		}
+		for _, reason := range reasons {
+			if accepted.Reason == string(reason) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gatewayClassParametersGateways returns the Gateways, as indexed by
+// routeParentGatewayKey, whose routes limit might have changed with the
+// provided GatewayClass, or ConfigMap referenced as the parameters of
+// GatewayClasses.
+func gatewayClassParametersGateways(ctx context.Context, c client.Reader, obj client.Object) ([]string, error) {
+	classes := map[string]struct{}{}
+	switch obj := obj.(type) {
+	case *gatewayv1beta1.GatewayClass:
+		classes[obj.Name] = struct{}{}
+	case *corev1.ConfigMap:
+		gwcs := new(gatewayv1beta1.GatewayClassList)
+		if err := c.List(ctx, gwcs); err != nil {
+			return nil, err
+		}
+		for i := range gwcs.Items {
+			if key, ok := gatewayClassParametersConfigMap(&gwcs.Items[i]); ok && key == client.ObjectKeyFromObject(obj) {
+				classes[gwcs.Items[i].Name] = struct{}{}
+			}
+		}
+	}
+	if len(classes) == 0 {
+		return nil, nil
+	}
+
+	gateways := new(gatewayv1beta1.GatewayList)
+	if err := c.List(ctx, gateways); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for i := range gateways.Items {
+		if _, ok := classes[string(gateways.Items[i].Spec.GatewayClassName)]; ok {
+			keys = append(keys, client.ObjectKeyFromObject(&gateways.Items[i]).String())
+		}
+	}
+	return keys, nil
+}
+
+// changedRouteParentGateways returns the Gateways referenced by the provided
+// TCPRoute or UDPRoute, as indexed by routeParentGatewayKey.
+func changedRouteParentGateways(obj client.Object) []string {
+	switch route := obj.(type) {
+	case *gatewayv1alpha2.TCPRoute:
+		return routeParentGateways(route.Namespace, route.Spec.ParentRefs)
+	case *gatewayv1alpha2.UDPRoute:
+		return routeParentGateways(route.Namespace, route.Spec.ParentRefs)
+	default:
+		return nil
+	}
+}
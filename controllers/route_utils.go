@@ -0,0 +1,569 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
+)
+
+const (
+	// PausedAnnotation can be set to "true" on a route to freeze its dataplane
+	// configuration: while paused the route is not compiled or pushed to the
+	// dataplane, and whatever configuration was pushed before is left intact.
+	PausedAnnotation = "blixt/paused"
+
+	// RouteConditionPaused indicates whether reconciliation of the route's
+	// dataplane configuration is paused by the PausedAnnotation.
+	RouteConditionPaused gatewayv1alpha2.RouteConditionType = "Paused"
+
+	// RouteReasonPaused is used with the Paused condition when the route has
+	// the PausedAnnotation set.
+	RouteReasonPaused gatewayv1alpha2.RouteConditionReason = "Paused"
+
+	// RouteReasonResumed is used with the Paused condition when the
+	// PausedAnnotation has been removed from a previously paused route.
+	RouteReasonResumed gatewayv1alpha2.RouteConditionReason = "Resumed"
+
+	// RouteConditionProgrammed indicates whether the route's configuration has
+	// been pushed to the dataplane.
+	RouteConditionProgrammed gatewayv1alpha2.RouteConditionType = "Programmed"
+
+	// RouteReasonProgrammed is used with the Programmed condition when the
+	// route has been pushed to the dataplane.
+	RouteReasonProgrammed gatewayv1alpha2.RouteConditionReason = "Programmed"
+
+	// RouteReasonNoHealthyBackends is used with the Programmed condition when
+	// the VIP of a UDPRoute has been pushed to the dataplane without backends,
+	// as none of them is healthy.
+	RouteReasonNoHealthyBackends gatewayv1alpha2.RouteConditionReason = "NoHealthyBackends"
+
+	// RouteReasonNoDataPlane is used with the Programmed condition when there
+	// is no dataplane instance available for the route to be pushed to.
+	RouteReasonNoDataPlane gatewayv1alpha2.RouteConditionReason = "NoDataPlane"
+
+	// RouteReasonGatewayNotReady is used with the Programmed condition when
+	// the Gateway of the route has no address yet.
+	RouteReasonGatewayNotReady gatewayv1alpha2.RouteConditionReason = "GatewayNotReady"
+
+	// RouteReasonExternalNameNotResolved is used with the ResolvedRefs
+	// condition when the external name of an ExternalName Service backend
+	// could not be resolved.
+	RouteReasonExternalNameNotResolved gatewayv1alpha2.RouteConditionReason = "ExternalNameNotResolved"
+
+	// RouteReasonConflict is used with the Accepted condition when a route
+	// resolves to the same Gateway VIP (IP and port) as another route which
+	// takes precedence over it.
+	RouteReasonConflict gatewayv1alpha2.RouteConditionReason = "RouteConflict"
+
+	// RouteReasonTooManyRoutes is used with the Accepted condition when the
+	// Gateway of a route already has as many routes attached as its
+	// GatewayClass allows, with the MaxRoutesPerGatewayParameter, which take
+	// precedence over it.
+	RouteReasonTooManyRoutes gatewayv1alpha2.RouteConditionReason = "TooManyRoutes"
+)
+
+// routeParentGatewayKey indexes the routes by the Gateways they reference in
+// their parentRefs, so that the routes of a Gateway are listed without listing
+// all the routes of the cluster.
+const routeParentGatewayKey = ".spec.parentRefs.gateway"
+
+// tcpRouteParentGateways is the routeParentGatewayKey indexer for TCPRoutes.
+func tcpRouteParentGateways(obj client.Object) []string {
+	tcproute, ok := obj.(*gatewayv1alpha2.TCPRoute)
+	if !ok {
+		return nil
+	}
+	return routeParentGateways(tcproute.Namespace, tcproute.Spec.ParentRefs)
+}
+
+// udpRouteParentGateways is the routeParentGatewayKey indexer for UDPRoutes.
+func udpRouteParentGateways(obj client.Object) []string {
+	udproute, ok := obj.(*gatewayv1alpha2.UDPRoute)
+	if !ok {
+		return nil
+	}
+	return routeParentGateways(udproute.Namespace, udproute.Spec.ParentRefs)
+}
+
+// routeBackendServiceKey indexes the routes by the Services their BackendRefs
+// refer to, so that the routes of a Service are listed without listing all the
+// routes of the cluster.
+const routeBackendServiceKey = ".spec.rules.backendRefs.service"
+
+// tcpRouteBackendServices is the routeBackendServiceKey indexer for TCPRoutes.
+func tcpRouteBackendServices(obj client.Object) []string {
+	tcproute, ok := obj.(*gatewayv1alpha2.TCPRoute)
+	if !ok {
+		return nil
+	}
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range tcproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	return routeBackendServices(tcproute.Namespace, backendRefs)
+}
+
+// udpRouteBackendServices is the routeBackendServiceKey indexer for UDPRoutes.
+func udpRouteBackendServices(obj client.Object) []string {
+	udproute, ok := obj.(*gatewayv1alpha2.UDPRoute)
+	if !ok {
+		return nil
+	}
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range udproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	return routeBackendServices(udproute.Namespace, backendRefs)
+}
+
+// routeBackendConfigMapKey indexes the routes by the ConfigMaps listing the
+// static endpoints their BackendRefs refer to.
+const routeBackendConfigMapKey = ".spec.rules.backendRefs.configMap"
+
+// tcpRouteBackendConfigMaps is the routeBackendConfigMapKey indexer for
+// TCPRoutes.
+func tcpRouteBackendConfigMaps(obj client.Object) []string {
+	tcproute, ok := obj.(*gatewayv1alpha2.TCPRoute)
+	if !ok {
+		return nil
+	}
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range tcproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	return routeBackendConfigMaps(tcproute.Namespace, backendRefs)
+}
+
+// udpRouteBackendConfigMaps is the routeBackendConfigMapKey indexer for
+// UDPRoutes.
+func udpRouteBackendConfigMaps(obj client.Object) []string {
+	udproute, ok := obj.(*gatewayv1alpha2.UDPRoute)
+	if !ok {
+		return nil
+	}
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range udproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	return routeBackendConfigMaps(udproute.Namespace, backendRefs)
+}
+
+// routeBackendServices returns the namespaced names of the Services referenced
+// by the provided BackendRefs, once each. BackendRefs which aren't resolved
+// as Services, such as ConfigMaps, are ignored.
+func routeBackendServices(routeNamespace string, refs []gatewayv1alpha2.BackendRef) []string {
+	return routeBackendObjects(routeNamespace, refs, func(source dataplane.EndpointSource) bool {
+		_, ok := source.(dataplane.ServiceEndpointSource)
+		return ok
+	})
+}
+
+// routeBackendConfigMaps returns the namespaced names of the ConfigMaps
+// listing the static endpoints referenced by the provided BackendRefs, once
+// each.
+func routeBackendConfigMaps(routeNamespace string, refs []gatewayv1alpha2.BackendRef) []string {
+	return routeBackendObjects(routeNamespace, refs, func(source dataplane.EndpointSource) bool {
+		_, ok := source.(dataplane.ConfigMapEndpointSource)
+		return ok
+	})
+}
+
+// routeBackendObjects returns the namespaced names of the objects referenced
+// by the provided BackendRefs whose EndpointSource matches, once each.
+func routeBackendObjects(routeNamespace string, refs []gatewayv1alpha2.BackendRef, matches func(dataplane.EndpointSource) bool) []string {
+	var objects []string
+	seen := map[string]struct{}{}
+	for _, ref := range refs {
+		if !matches(dataplane.EndpointSourceFor(ref)) {
+			continue
+		}
+		key := types.NamespacedName{Namespace: routeNamespace, Name: string(ref.Name)}
+		if ref.Namespace != nil {
+			key.Namespace = string(*ref.Namespace)
+		}
+		if _, ok := seen[key.String()]; ok {
+			continue
+		}
+		seen[key.String()] = struct{}{}
+		objects = append(objects, key.String())
+	}
+	return objects
+}
+
+// routeParentGateways returns the namespaced names of the Gateways referenced
+// by the provided parentRefs, once each.
+func routeParentGateways(routeNamespace string, refs []gatewayv1alpha2.ParentReference) []string {
+	var gateways []string
+	seen := map[string]struct{}{}
+	for _, ref := range refs {
+		key := types.NamespacedName{Namespace: routeNamespace, Name: string(ref.Name)}
+		if ref.Namespace != nil {
+			key.Namespace = string(*ref.Namespace)
+		}
+		if _, ok := seen[key.String()]; ok {
+			continue
+		}
+		seen[key.String()] = struct{}{}
+		gateways = append(gateways, key.String())
+	}
+	return gateways
+}
+
+// parentRefForGateway returns the ParentReference from the provided list which
+// refers to the provided Gateway, if any.
+func parentRefForGateway(routeNamespace string, refs []gatewayv1alpha2.ParentReference, gw *gatewayv1beta1.Gateway) (gatewayv1alpha2.ParentReference, bool) {
+	for _, ref := range refs {
+		namespace := routeNamespace
+		if ref.Namespace != nil {
+			namespace = string(*ref.Namespace)
+		}
+		if string(ref.Name) == gw.Name && namespace == gw.Namespace {
+			return ref, true
+		}
+	}
+	return gatewayv1alpha2.ParentReference{}, false
+}
+
+// parentRefSelectsListener indicates whether the provided ParentReference
+// selects the provided listener by its port and, if set, its section name.
+// The protocol of the listener depends on the kind of the route, so it must be
+// verified by the caller: a Gateway can have a TCP and a UDP listener sharing
+// the same port.
+func parentRefSelectsListener(ref gatewayv1alpha2.ParentReference, listener gatewayv1beta1.Listener) bool {
+	if ref.SectionName != nil && *ref.SectionName != listener.Name {
+		return false
+	}
+	return ref.Port != nil && *ref.Port == listener.Port
+}
+
+// routeUsesGatewayPort indicates whether a route with the provided namespace
+// and ParentReferences attaches to the provided Gateway on the provided port,
+// which would result in the route being programmed on the same VIP.
+func routeUsesGatewayPort(routeNamespace string, refs []gatewayv1alpha2.ParentReference, gw *gatewayv1beta1.Gateway, port uint32) bool {
+	ref, ok := parentRefForGateway(routeNamespace, refs, gw)
+	if !ok || ref.Port == nil {
+		return false
+	}
+	return uint32(*ref.Port) == port
+}
+
+// routesShareGatewayPort indicates whether two routes with the provided
+// namespaces and ParentReferences attach to the same Gateway on the same port,
+// so that they would be programmed on the same VIP.
+func routesShareGatewayPort(aNamespace string, aRefs []gatewayv1alpha2.ParentReference, bNamespace string, bRefs []gatewayv1alpha2.ParentReference) bool {
+	for _, a := range aRefs {
+		if a.Port == nil {
+			continue
+		}
+		gw := &gatewayv1beta1.Gateway{}
+		gw.Namespace, gw.Name = aNamespace, string(a.Name)
+		if a.Namespace != nil {
+			gw.Namespace = string(*a.Namespace)
+		}
+		if routeUsesGatewayPort(bNamespace, bRefs, gw, uint32(*a.Port)) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeTakesPrecedence returns true if route a takes precedence over route b
+// when both would be programmed on the same VIP. Following the Gateway API
+// conflict resolution rules the oldest route wins, and ties are broken by
+// alphabetical order of "{namespace}/{name}".
+func routeTakesPrecedence(a, b metav1.Object) bool {
+	aCreated, bCreated := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !aCreated.Equal(&bCreated) {
+		return aCreated.Before(&bCreated)
+	}
+	return a.GetNamespace()+"/"+a.GetName() < b.GetNamespace()+"/"+b.GetName()
+}
+
+// setRouteCondition sets the provided condition on the RouteParentStatus
+// belonging to the provided controller for the provided ParentReference, adding
+// the parent status if it isn't present yet. It returns true if the status
+// changed.
+func setRouteCondition(status *gatewayv1alpha2.RouteStatus, controllerName gatewayv1alpha2.GatewayController, parentRef gatewayv1alpha2.ParentReference, cond metav1.Condition) bool {
+	for i := range status.Parents {
+		parent := &status.Parents[i]
+		if parent.ControllerName == controllerName && reflect.DeepEqual(parent.ParentRef, parentRef) {
+			return meta.SetStatusCondition(&parent.Conditions, cond)
+		}
+	}
+
+	parent := gatewayv1alpha2.RouteParentStatus{
+		ParentRef:      parentRef,
+		ControllerName: controllerName,
+	}
+	meta.SetStatusCondition(&parent.Conditions, cond)
+	status.Parents = append(status.Parents, parent)
+	return true
+}
+
+// isRoutePaused indicates whether the provided route has the PausedAnnotation.
+func isRoutePaused(obj metav1.Object) bool {
+	return obj.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// routeWasPaused indicates whether the provided route status reports that the
+// route is currently paused by the provided controller.
+func routeWasPaused(status gatewayv1alpha2.RouteStatus, controllerName gatewayv1alpha2.GatewayController) bool {
+	for _, parent := range status.Parents {
+		if parent.ControllerName == controllerName &&
+			meta.IsStatusConditionTrue(parent.Conditions, string(RouteConditionPaused)) {
+			return true
+		}
+	}
+	return false
+}
+
+// backendRefsResolvedCondition returns the ResolvedRefs condition of a route
+// with the provided BackendRefs. It's only true when all the BackendRefs
+// resolve, including their ports, otherwise its message enumerates those which
+// don't.
+func backendRefsResolvedCondition(ctx context.Context, c client.Client, resolver *dataplane.ExternalNameResolver, route client.Object, refs []gatewayv1alpha2.BackendRef) (metav1.Condition, error) {
+	var unresolved []string
+	for _, ref := range refs {
+		key := types.NamespacedName{Namespace: route.GetNamespace(), Name: string(ref.Name)}
+		if ref.Namespace != nil {
+			key.Namespace = string(*ref.Namespace)
+		}
+
+		var obj client.Object = new(corev1.Service)
+		kind := "Service"
+		if _, ok := dataplane.EndpointSourceFor(ref).(dataplane.ConfigMapEndpointSource); ok {
+			obj, kind = new(corev1.ConfigMap), "ConfigMap"
+		}
+		if err := c.Get(ctx, key, obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return metav1.Condition{}, err
+			}
+			unresolved = append(unresolved, fmt.Sprintf("%s %s: not found", kind, key))
+		}
+	}
+	portErrs, err := dataplane.UnresolvedBackendPorts(ctx, c, resolver, route, refs)
+	if err != nil {
+		return metav1.Condition{}, err
+	}
+	for _, portErr := range portErrs {
+		unresolved = append(unresolved, portErr.Error())
+	}
+
+	if len(unresolved) > 0 {
+		return metav1.Condition{
+			Type:    string(gatewayv1alpha2.RouteConditionResolvedRefs),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(gatewayv1alpha2.RouteReasonBackendNotFound),
+			Message: fmt.Sprintf("%d of the %d backend references could not be resolved: %s", len(unresolved), len(refs), strings.Join(unresolved, ", ")),
+		}, nil
+	}
+	return metav1.Condition{
+		Type:    string(gatewayv1alpha2.RouteConditionResolvedRefs),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1alpha2.RouteReasonResolvedRefs),
+		Message: "all the backend references have been resolved",
+	}, nil
+}
+
+// releaseUnmanagedRoute deletes the VIPs of a route which is not managed by
+// this controller anymore from the dataplane, and removes its
+// DataPlaneFinalizer so that it can be deleted. This happens for instance when
+// the GatewayClass of its Gateway is handed over to another controller. The
+// VIPs of the Gateways which are gone can't be determined, they're left to the
+// OrphanedVIPsReconciler.
+func releaseUnmanagedRoute(ctx context.Context, c client.Client, updater dataplane.BackendsUpdater, pushed *pushedTargets, routeKind string, route client.Object, refs []gatewayv1alpha2.ParentReference) error {
+	protocol := dataplane.Vip_TCP
+	if routeKind == UDPRouteKind {
+		protocol = dataplane.Vip_UDP
+	}
+	for _, ref := range refs {
+		if ref.Port == nil {
+			continue
+		}
+		key := types.NamespacedName{Namespace: route.GetNamespace(), Name: string(ref.Name)}
+		if ref.Namespace != nil {
+			key.Namespace = string(*ref.Namespace)
+		}
+
+		gateway := new(gatewayv1beta1.Gateway)
+		if err := c.Get(ctx, key, gateway); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		gatewayIP, err := dataplane.GetGatewayIP(gateway)
+		if err != nil {
+			continue
+		}
+		vip, err := dataplane.GatewayVip(gateway, gatewayIP, uint32(*ref.Port), protocol)
+		if err != nil {
+			continue
+		}
+		if _, err := updater.Delete(ctx, vip); err != nil {
+			return err
+		}
+		pushed.forget(vip)
+	}
+
+	pushed.forgetRoute(client.ObjectKeyFromObject(route))
+	metrics.RouteBackends.DeleteLabelValues(route.GetNamespace(), route.GetName(), routeKind)
+	return removeDataPlaneFinalizer(ctx, c, route)
+}
+
+// referenceGrantAppliesToRoute indicates whether the provided ReferenceGrant
+// allows a route of the provided kind and namespace to reference any of the
+// provided backends.
+func referenceGrantAppliesToRoute(grant *gatewayv1beta1.ReferenceGrant, routeKind, routeNamespace string, backendRefs []gatewayv1alpha2.BackendRef) bool {
+	fromMatches := false
+	for _, from := range grant.Spec.From {
+		if string(from.Group) == gatewayv1beta1.GroupName && string(from.Kind) == routeKind && string(from.Namespace) == routeNamespace {
+			fromMatches = true
+			break
+		}
+	}
+	if !fromMatches {
+		return false
+	}
+
+	for _, ref := range backendRefs {
+		if ref.Namespace == nil || string(*ref.Namespace) != grant.Namespace {
+			continue
+		}
+		group, kind := "", "Service"
+		if ref.Group != nil {
+			group = string(*ref.Group)
+		}
+		if ref.Kind != nil {
+			kind = string(*ref.Kind)
+		}
+		for _, to := range grant.Spec.To {
+			if string(to.Group) == group && string(to.Kind) == kind && (to.Name == nil || *to.Name == ref.Name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// deletionGraceRemaining returns how long the provided object, which is being
+// deleted, should still be kept in the dataplane given the provided deletion
+// grace period. It returns zero once the grace period has elapsed.
+func deletionGraceRemaining(obj metav1.Object, gracePeriod time.Duration) time.Duration {
+	deletionTimestamp := obj.GetDeletionTimestamp()
+	if deletionTimestamp == nil || gracePeriod <= 0 {
+		return 0
+	}
+	if remaining := time.Until(deletionTimestamp.Add(gracePeriod)); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// isGatewayIPNotReady indicates whether the provided error was caused by the
+// Gateway not having been assigned an IP address yet.
+func isGatewayIPNotReady(err error) bool {
+	return errors.Is(err, dataplane.ErrGatewayIPNotReady)
+}
+
+// isUnsupportedIPFamily indicates whether the provided error was caused by a
+// Gateway address the dataplane can't program, such as an IPv6 address.
+func isUnsupportedIPFamily(err error) bool {
+	return errors.Is(err, dataplane.ErrUnsupportedIPFamily)
+}
+
+// isNoDataPlaneClients indicates whether the provided error was caused by no
+// dataplane instance being available to push configuration to.
+func isNoDataPlaneClients(err error) bool {
+	return errors.Is(err, dataplane.ErrNoDataPlaneClients)
+}
+
+// isDataPlanePodIPNotAssigned indicates whether the provided error was caused
+// by a ready dataplane Pod not having been assigned an IP address yet.
+func isDataPlanePodIPNotAssigned(err error) bool {
+	return errors.Is(err, dataplane.ErrPodIPNotAssigned)
+}
+
+// isDataPlanePodNotServing indicates whether the provided error was caused
+// by a ready dataplane Pod not reporting SERVING to its health check yet.
+func isDataPlanePodNotServing(err error) bool {
+	return errors.Is(err, dataplane.ErrPodNotServing)
+}
+
+// isEndpointsNotReady indicates whether the provided error was caused by the
+// Endpoints of a backend having no ready addresses yet.
+func isEndpointsNotReady(err error) bool {
+	return errors.Is(err, dataplane.ErrEndpointsNotReady)
+}
+
+// isPartialUpdateFailure indicates whether the provided error was caused by
+// an update failing on some of the dataplane instances only.
+func isPartialUpdateFailure(err error) bool {
+	var partialErr *dataplane.PartialUpdateError
+	return errors.As(err, &partialErr)
+}
+
+// isExternalNameNotResolved indicates whether the provided error was caused by
+// the external name of an ExternalName Service backend not being resolved.
+func isExternalNameNotResolved(err error) bool {
+	return errors.Is(err, dataplane.ErrExternalNameNotResolved)
+}
+
+// isUnsupportedRouteValue indicates whether the provided error was caused by
+// a value of the route which can't be programmed, such as the rules of a route
+// referencing the same backend with different weights or an invalid
+// annotation. Retrying won't help until the route is fixed.
+func isUnsupportedRouteValue(err error) bool {
+	return errors.Is(err, dataplane.ErrConflictingBackendRefs) ||
+		errors.Is(err, dataplane.ErrInvalidMaxConnections) ||
+		errors.Is(err, dataplane.ErrInvalidBackendPortOverride) ||
+		errors.Is(err, dataplane.ErrInvalidTCPIdleTimeout) ||
+		errors.Is(err, dataplane.ErrInvalidAction)
+}
+
+// compileFailureReason classifies an error returned when compiling a route to
+// dataplane Targets into one of the reasons reported by the metrics.
+func compileFailureReason(err error) string {
+	switch {
+	case apierrors.IsNotFound(err):
+		return metrics.CompileFailureReasonBackendNotFound
+	case errors.Is(err, dataplane.ErrNoHealthyBackends):
+		return metrics.CompileFailureReasonNoHealthyBackends
+	case errors.Is(err, dataplane.ErrGatewayIPNotReady):
+		return metrics.CompileFailureReasonGatewayNotReady
+	default:
+		return metrics.CompileFailureReasonOther
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+const (
+	// TCPRouteKind is the kind of the Gateway API TCPRoute.
+	TCPRouteKind = "TCPRoute"
+
+	// UDPRouteKind is the kind of the Gateway API UDPRoute.
+	UDPRouteKind = "UDPRoute"
+
+	// HTTPRouteKind is the kind of the Gateway API HTTPRoute, which is only
+	// advertised by the HTTP and HTTPS listeners when enabled (see
+	// GatewayReconciler.EnableHTTPRoute).
+	HTTPRouteKind = "HTTPRoute"
+)
+
+// newRouteForKind returns an empty route of the provided kind, in the version
+// the controllers operate on. These kinds are only served as v1alpha2 by the
+// Gateway API release Blixt is built against, this is the single place to
+// switch them to a newer version once they graduate.
+func newRouteForKind(kind string) (client.Object, error) {
+	switch kind {
+	case TCPRouteKind:
+		return new(gatewayv1alpha2.TCPRoute), nil
+	case UDPRouteKind:
+		return new(gatewayv1alpha2.UDPRoute), nil
+	default:
+		return nil, fmt.Errorf("unsupported route kind %s", kind)
+	}
+}
+
+// isSupportedRouteKind indicates whether routes of the provided kind can be
+// attached to the Gateways managed by this controller.
+func isSupportedRouteKind(kind string) bool {
+	_, err := newRouteForKind(kind)
+	return err == nil
+}
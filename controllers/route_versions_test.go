@@ -0,0 +1,40 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestNewRouteForKind(t *testing.T) {
+	route, err := newRouteForKind(TCPRouteKind)
+	require.NoError(t, err)
+	require.IsType(t, new(gatewayv1alpha2.TCPRoute), route)
+
+	route, err = newRouteForKind(UDPRouteKind)
+	require.NoError(t, err)
+	require.IsType(t, new(gatewayv1alpha2.UDPRoute), route)
+
+	t.Log("unsupported route kinds are rejected")
+	_, err = newRouteForKind(HTTPRouteKind)
+	require.Error(t, err)
+	require.False(t, isSupportedRouteKind(HTTPRouteKind))
+	require.True(t, isSupportedRouteKind(TCPRouteKind))
+}
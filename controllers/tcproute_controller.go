@@ -18,31 +18,36 @@ package controllers
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
-	"github.com/kubernetes-sigs/blixt/pkg/vars"
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
 )
 
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=tcproutes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=tcproutes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=tcproutes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=pods/status,verbs=get
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch
@@ -55,15 +60,79 @@ type TCPRouteReconciler struct {
 
 	log                        logr.Logger
 	ClientReconcileRequestChan <-chan event.GenericEvent
-	BackendsClientManager      *dataplane.BackendsClientManager
+	BackendsClientManager      dataplane.BackendsUpdater
+
+	// WatchNamespaces restricts the controller to objects in the provided
+	// namespaces. All namespaces are watched if empty.
+	WatchNamespaces []string
+
+	// ControllerName is the GatewayClass controller name identifying the
+	// resources managed by this controller. Defaults to
+	// vars.GatewayClassControllerName if empty.
+	ControllerName gatewayv1beta1.GatewayController
+
+	// DeletionGracePeriod is how long a deleted TCPRoute is kept in the
+	// dataplane before being removed from it.
+	DeletionGracePeriod time.Duration
+
+	// PartialUpdateRequeueInterval is the period after which a TCPRoute
+	// whose Targets failed to be pushed to some of the dataplane instances is
+	// reconciled again, to retry the push on those instances only. Defaults
+	// to DefaultPartialUpdateRequeueInterval if zero.
+	PartialUpdateRequeueInterval time.Duration
+
+	// RateLimiter configures the rate limiter of the workqueue of the
+	// controller, the controller-runtime default is used if it's zero.
+	RateLimiter RateLimiterConfig
+
+	// ExternalNameResolver resolves the external names of the ExternalName
+	// Service backends. Defaults to a resolver using the system's DNS
+	// resolver if nil.
+	ExternalNameResolver *dataplane.ExternalNameResolver
+
+	// APIReader reads the ConfigMaps holding the GatewayClass parameters
+	// without the cache, which only holds the watched namespaces while the
+	// ConfigMaps can be in any namespace. The Client is used if nil.
+	APIReader client.Reader
+
+	// pushedTargets tracks the Targets pushed for the TCPRoutes, so that
+	// backend changes are pushed incrementally.
+	pushedTargets *pushedTargets
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *TCPRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.log = log.FromContext(context.Background())
+	r.pushedTargets = newPushedTargets()
+	if r.ExternalNameResolver == nil {
+		r.ExternalNameResolver = dataplane.NewExternalNameResolver(dataplane.NetHostResolver{}, dataplane.DefaultExternalNameRefreshInterval)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gatewayv1alpha2.TCPRoute{}, routeParentGatewayKey, tcpRouteParentGateways); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gatewayv1alpha2.TCPRoute{}, routeBackendServiceKey, tcpRouteBackendServices); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gatewayv1alpha2.TCPRoute{}, routeBackendConfigMapKey, tcpRouteBackendConfigMaps); err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controllerOptions(r.RateLimiter)).
 		For(&gatewayv1alpha2.TCPRoute{}).
+		Watches(
+			&gatewayv1alpha2.TCPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.mapTCPRouteToConflictingTCPRoutes),
+		).
+		Watches(
+			&gatewayv1alpha2.TCPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.mapRouteToTooManyTCPRoutes),
+		).
+		Watches(
+			&gatewayv1alpha2.UDPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.mapRouteToTooManyTCPRoutes),
+		).
 		WatchesRawSource(
 			&source.Channel{Source: r.ClientReconcileRequestChan},
 			handler.EnqueueRequestsFromMapFunc(r.mapDataPlaneDaemonsetToTCPRoutes),
@@ -72,11 +141,48 @@ func (r *TCPRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&gatewayv1beta1.Gateway{},
 			handler.EnqueueRequestsFromMapFunc(r.mapGatewayToTCPRoutes),
 		).
+		Watches(
+			&gatewayv1beta1.ReferenceGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.mapReferenceGrantToTCPRoutes),
+		).
+		Watches(
+			&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.mapServiceToTCPRoutes),
+		).
+		Watches(
+			&corev1.Endpoints{},
+			handler.EnqueueRequestsFromMapFunc(r.mapServiceToTCPRoutes),
+		).
+		Watches(
+			&gatewayv1beta1.GatewayClass{},
+			handler.EnqueueRequestsFromMapFunc(r.mapGatewayClassParametersToTCPRoutes),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapGatewayClassParametersToTCPRoutes),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapConfigMapToTCPRoutes),
+		).
 		Complete(r)
 }
 
 // Reconcile reconciles TCPRoute object
-func (r *TCPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *TCPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	defer func() {
+		if err == nil {
+			metrics.LastSuccessfulReconcile.WithLabelValues("TCPRoute").SetToCurrentTime()
+		}
+	}()
+
+	// the cache only holds the watched namespaces, this also ignores the
+	// TCPRoutes of other namespaces which would be enqueued by a mapping.
+	if !isNamespaceWatched(r.WatchNamespaces, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
 	tcproute := new(gatewayv1alpha2.TCPRoute)
 	if err := r.Get(ctx, req.NamespacedName, tcproute); err != nil {
 		if errors.IsNotFound(err) {
@@ -92,7 +198,14 @@ func (r *TCPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 	if !isManaged {
+		if controllerutil.ContainsFinalizer(tcproute, DataPlaneFinalizer) {
+			// the TCPRoute was programmed by this controller, but its Gateway
+			// isn't managed by it anymore.
+			r.log.Info("TCPRoute is not managed anymore, removing it from the dataplane", "namespace", tcproute.Namespace, "name", tcproute.Name)
+			return ctrl.Result{}, releaseUnmanagedRoute(ctx, r.Client, r.BackendsClientManager, r.pushedTargets, TCPRouteKind, tcproute, tcproute.Spec.ParentRefs)
+		}
 		// TODO: enable orphan checking https://github.com/kubernetes-sigs/blixt/issues/47
+		r.pushedTargets.forgetRoute(req.NamespacedName)
 		return ctrl.Result{}, nil
 	}
 
@@ -109,22 +222,174 @@ func (r *TCPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, setDataPlaneFinalizer(ctx, r.Client, tcproute)
 	}
 
-	// if the TCPRoute is being deleted, remove it from the DataPlane
-	// TODO: enable deletion grace period https://github.com/Kong/blixt/issues/48
+	// ensure that no other TCPRoute which takes precedence is already
+	// programmed on the same Gateway VIP, otherwise we'd overwrite its backends.
+	conflict, err := r.getConflictingTCPRoute(ctx, tcproute, gateway)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// if the TCPRoute is being deleted, remove it from the DataPlane once its
+	// deletion grace period has elapsed
 	if tcproute.DeletionTimestamp != nil {
+		if conflict != nil {
+			// the VIP is owned by another TCPRoute, so there's no dataplane
+			// configuration to remove for this one.
+			return ctrl.Result{}, removeDataPlaneFinalizer(ctx, r.Client, tcproute)
+		}
+		if remaining := deletionGraceRemaining(tcproute, r.DeletionGracePeriod); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
 		return ctrl.Result{}, r.ensureTCPRouteDeletedInDataPlane(ctx, tcproute, gateway)
 	}
 
+	if isRoutePaused(tcproute) {
+		r.log.Info("TCPRoute is paused, skipping dataplane configuration", "namespace", tcproute.Namespace, "name", tcproute.Name)
+		return ctrl.Result{}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+			Type:    string(RouteConditionPaused),
+			Status:  metav1.ConditionTrue,
+			Reason:  string(RouteReasonPaused),
+			Message: fmt.Sprintf("the TCPRoute has the %s annotation, its dataplane configuration is left unchanged", PausedAnnotation),
+		})
+	}
+
+	if conflict != nil {
+		r.log.Info("TCPRoute conflicts with another TCPRoute on the same Gateway VIP, skipping dataplane configuration",
+			"namespace", tcproute.Namespace, "name", tcproute.Name, "conflict", client.ObjectKeyFromObject(conflict).String())
+		return ctrl.Result{}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+			Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(RouteReasonConflict),
+			Message: fmt.Sprintf("the Gateway VIP is already in use by TCPRoute %s", client.ObjectKeyFromObject(conflict)),
+		})
+	}
+
+	limit, tooManyRoutes, err := exceedsGatewayRouteLimit(ctx, r.Client, r.paramsReader(), r.WatchNamespaces, controllerNameOrDefault(r.ControllerName), TCPRouteKind, tcproute, gateway)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if tooManyRoutes {
+		r.log.Info("TCPRoute exceeds the maximum number of routes of its Gateway, skipping dataplane configuration",
+			"namespace", tcproute.Namespace, "name", tcproute.Name, "limit", limit)
+		if !routeHasTooManyRoutes(tcproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName)) {
+			// the TCPRoute might have been programmed before the limit was
+			// lowered or older routes were attached to the Gateway.
+			if err := r.deleteTCPRouteVIP(ctx, tcproute, gateway); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+			Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(RouteReasonTooManyRoutes),
+			Message: fmt.Sprintf("the Gateway already has the maximum of %d routes attached", limit),
+		})
+	}
+
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range tcproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	resolvedRefs, err := backendRefsResolvedCondition(ctx, r.Client, r.ExternalNameResolver, tcproute, backendRefs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// in all other cases ensure the TCPRoute is configured in the dataplane
 	if err := r.ensureTCPRouteConfiguredInDataPlane(ctx, tcproute, gateway); err != nil {
-		if err.Error() == "endpoints not ready" {
+		if isEndpointsNotReady(err) {
 			r.log.Info("endpoints not yet ready for TCPRoute, retrying", "namespace", tcproute.Namespace, "name", tcproute.Name)
 			return ctrl.Result{RequeueAfter: time.Second}, nil
 		}
+		if isPartialUpdateFailure(err) {
+			// the dataplane instances which applied the update are not sent
+			// it again, only the ones which failed are.
+			r.log.Info("TCPRoute update failed on some dataplane instances, retrying on them", "namespace", tcproute.Namespace, "name", tcproute.Name, "error", err.Error())
+			return ctrl.Result{RequeueAfter: partialUpdateRequeueInterval(r.PartialUpdateRequeueInterval)}, nil
+		}
+		if isGatewayIPNotReady(err) {
+			// the Gateway's LoadBalancer Service is still being allocated an
+			// address, updates to the Gateway will re-enqueue the TCPRoute.
+			r.log.Info("Gateway has no address yet for TCPRoute, waiting for it", "namespace", tcproute.Namespace, "name", tcproute.Name)
+			return ctrl.Result{}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+				Type:    string(RouteConditionProgrammed),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(RouteReasonGatewayNotReady),
+				Message: "waiting for the Gateway to be assigned an address",
+			})
+		}
+		if isNoDataPlaneClients(err) {
+			r.log.Info("no dataplane available for TCPRoute, retrying", "namespace", tcproute.Namespace, "name", tcproute.Name)
+			return ctrl.Result{RequeueAfter: time.Second}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+				Type:    string(RouteConditionProgrammed),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(RouteReasonNoDataPlane),
+				Message: "waiting for a dataplane instance to be available",
+			})
+		}
+		if isExternalNameNotResolved(err) {
+			r.log.Info("could not resolve ExternalName backend for TCPRoute, retrying", "namespace", tcproute.Namespace, "name", tcproute.Name, "error", err.Error())
+			return ctrl.Result{RequeueAfter: r.ExternalNameResolver.RefreshInterval}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+				Type:    string(gatewayv1alpha2.RouteConditionResolvedRefs),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(RouteReasonExternalNameNotResolved),
+				Message: err.Error(),
+			})
+		}
+		if isUnsupportedRouteValue(err) {
+			// retrying won't help until the TCPRoute is fixed, which
+			// re-enqueues it.
+			r.log.Info("TCPRoute has an unsupported value", "namespace", tcproute.Namespace, "name", tcproute.Name, "error", err.Error())
+			return ctrl.Result{}, r.updateTCPRouteStatus(ctx, tcproute, gateway, metav1.Condition{
+				Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(gatewayv1alpha2.RouteReasonUnsupportedValue),
+				Message: err.Error(),
+			})
+		}
+		if resolvedRefs.Status == metav1.ConditionFalse {
+			r.log.Info("some backends of TCPRoute could not be resolved", "namespace", tcproute.Namespace, "name", tcproute.Name, "message", resolvedRefs.Message)
+			if err := r.updateTCPRouteStatus(ctx, tcproute, gateway, resolvedRefs); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	weights := dataplane.NormalizeBackendWeights(tcproute.Namespace, backendRefs)
+
+	// the addresses of ExternalName backends are resolved again when they
+	// expire, which requires compiling the TCPRoute again.
+	refreshAfter, err := r.ExternalNameResolver.BackendsRefreshAfter(ctx, r.Client, tcproute.Namespace, backendRefs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	programmed := fmt.Sprintf("the TCPRoute has been programmed in the dataplane, backend weights: %s", dataplane.FormatBackendWeights(weights))
+	if resolvedRefs.Status == metav1.ConditionFalse {
+		programmed += ", the unresolved backends were skipped"
+	}
+	conds := []metav1.Condition{{
+		Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1alpha2.RouteReasonAccepted),
+		Message: "the TCPRoute has been programmed in the dataplane",
+	}, {
+		Type:    string(RouteConditionProgrammed),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(RouteReasonProgrammed),
+		Message: programmed,
+	}, resolvedRefs}
+	if routeWasPaused(tcproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName)) {
+		conds = append(conds, metav1.Condition{
+			Type:    string(RouteConditionPaused),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(RouteReasonResumed),
+			Message: "the TCPRoute is no longer paused",
+		})
+	}
+
+	return ctrl.Result{RequeueAfter: refreshAfter}, r.updateTCPRouteStatus(ctx, tcproute, gateway, conds...)
 }
 
 // isTCPRouteManaged verifies wether a provided TCPRoute is managed by this
@@ -151,15 +416,11 @@ func (r *TCPRouteReconciler) isTCPRouteManaged(ctx context.Context, tcproute gat
 		}
 
 		//Get GatewayClass for the Gateway and match to our name of controler
-		gwc := new(gatewayv1beta1.GatewayClass)
-		if err := r.Get(ctx, types.NamespacedName{Name: string(gw.Spec.GatewayClassName), Namespace: ns}, gwc); err != nil {
-			if !errors.IsNotFound(err) {
-				return false, nil, err
-			}
-			continue
+		managed, err := isGatewayClassManaged(ctx, r.Client, gw.Spec.GatewayClassName, r.ControllerName)
+		if err != nil {
+			return false, nil, err
 		}
-
-		if gwc.Spec.ControllerName != vars.GatewayClassControllerName {
+		if !managed {
 			// not managed by this implementation, check the next parent ref
 			continue
 		}
@@ -188,10 +449,10 @@ func (r *TCPRouteReconciler) isTCPRouteManaged(ctx context.Context, tcproute gat
 }
 
 // verifyListener verifies that the provided gateway has at least one listener
-// matching the provided ParentReference.
+// matching the provided ParentReference, with a protocol supporting TCPRoutes.
 func (r *TCPRouteReconciler) verifyListener(_ context.Context, gw *gatewayv1beta1.Gateway, tcprouteSpec gatewayv1alpha2.ParentReference) error {
 	for _, listener := range gw.Spec.Listeners {
-		if (listener.Protocol == gatewayv1beta1.TCPProtocolType) && (listener.Port == gatewayv1beta1.PortNumber(*tcprouteSpec.Port)) {
+		if (listener.Protocol == gatewayv1beta1.TCPProtocolType || isTLSPassthroughListener(listener)) && isListenerPortValid(listener.Port) && parentRefSelectsListener(tcprouteSpec, listener) {
 			return nil
 		}
 	}
@@ -200,53 +461,138 @@ func (r *TCPRouteReconciler) verifyListener(_ context.Context, gw *gatewayv1beta
 
 func (r *TCPRouteReconciler) ensureTCPRouteConfiguredInDataPlane(ctx context.Context, tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) error {
 	// build the dataplane configuration from the TCPRoute and its Gateway
-	targets, err := dataplane.CompileTCPRouteToDataPlaneBackend(ctx, r.Client, tcproute, gateway)
+	targets, err := dataplane.CompileTCPRouteToDataPlaneBackend(ctx, r.Client, r.ExternalNameResolver, tcproute, gateway)
 	if err != nil {
+		reason := compileFailureReason(err)
+		metrics.RouteCompileFailures.WithLabelValues("TCPRoute", reason).Inc()
+		if reason == metrics.CompileFailureReasonNoHealthyBackends {
+			metrics.RouteBackends.WithLabelValues(tcproute.Namespace, tcproute.Name, "TCPRoute").Set(0)
+		}
 		return err
 	}
 
-	if _, err = r.BackendsClientManager.Update(ctx, targets); err != nil {
+	result, err := r.pushedTargets.push(ctx, r.BackendsClientManager, client.ObjectKeyFromObject(tcproute), targets)
+	if err != nil {
 		return err
 	}
+	metrics.RouteBackends.WithLabelValues(tcproute.Namespace, tcproute.Name, "TCPRoute").Set(float64(len(targets.Targets)))
 
-	r.log.Info("successful data-plane UPDATE")
+	r.log.Info("successful data-plane UPDATE", "pods", result.Succeeded())
 
 	return nil
 }
 
 func (r *TCPRouteReconciler) ensureTCPRouteDeletedInDataPlane(ctx context.Context, tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) error {
+	if err := r.deleteTCPRouteVIP(ctx, tcproute, gateway); err != nil {
+		return err
+	}
+	return removeDataPlaneFinalizer(ctx, r.Client, tcproute)
+}
+
+// deleteTCPRouteVIP deletes the VIP of the provided TCPRoute from the
+// dataplane, if it could have been programmed.
+func (r *TCPRouteReconciler) deleteTCPRouteVIP(ctx context.Context, tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) error {
 	// get the gateway IP and port.
-	gwIP, err := dataplane.GetGatewayIP(gateway)
+	vip, err := tcpRouteVip(tcproute, gateway)
+	if isGatewayIPNotReady(err) || isUnsupportedIPFamily(err) {
+		// the TCPRoute was never programmed without a Gateway IPv4 address,
+		// so there's nothing to delete from the dataplane.
+		r.log.Info("Gateway has no IPv4 address, skipping data-plane DELETE", "namespace", tcproute.Namespace, "name", tcproute.Name, "reason", err.Error())
+		r.pushedTargets.forgetRoute(client.ObjectKeyFromObject(tcproute))
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	gatewayIP := binary.BigEndian.Uint32(gwIP.To4())
-	gwPort, err := dataplane.GetGatewayPort(gateway, tcproute.Spec.ParentRefs)
+
+	// delete the target from the dataplane
+	result, err := r.BackendsClientManager.Delete(ctx, vip)
 	if err != nil {
 		return err
 	}
+	r.pushedTargets.forget(vip)
+	metrics.RouteBackends.DeleteLabelValues(tcproute.Namespace, tcproute.Name, "TCPRoute")
+
+	r.log.Info("successful data-plane DELETE", "pods", result.Succeeded())
 
-	vip := dataplane.Vip{
-		Ip:   gatewayIP,
-		Port: gwPort,
+	return nil
+}
+
+// paramsReader returns the reader of the GatewayClass parameters.
+func (r *TCPRouteReconciler) paramsReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
 	}
+	return r.Client
+}
 
-	// delete the target from the dataplane
-	if _, err = r.BackendsClientManager.Delete(ctx, &vip); err != nil {
-		return err
+// tcpRouteVip returns the dataplane Vip (the Gateway IP and listener port) the
+// provided TCPRoute is programmed on.
+func tcpRouteVip(tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) (*dataplane.Vip, error) {
+	gwIP, err := dataplane.GetGatewayIP(gateway)
+	if err != nil {
+		return nil, err
+	}
+	gwPort, err := dataplane.GetGatewayPort(gateway, tcproute.Spec.ParentRefs)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataplane.GatewayVip(gateway, gwIP, gwPort, dataplane.Vip_TCP)
+}
+
+// getConflictingTCPRoute returns the TCPRoute which takes precedence over
+// the provided one, if any other TCPRoute is attached to the same Gateway
+// listener and would therefore be programmed on the same VIP.
+func (r *TCPRouteReconciler) getConflictingTCPRoute(ctx context.Context, tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) (*gatewayv1alpha2.TCPRoute, error) {
+	port, err := dataplane.GetGatewayPort(gateway, tcproute.Spec.ParentRefs)
+	if err != nil {
+		return nil, err
 	}
 
-	r.log.Info("successful data-plane DELETE")
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := r.Client.List(ctx, tcproutes, client.MatchingFields{
+		routeParentGatewayKey: client.ObjectKeyFromObject(gateway).String(),
+	}); err != nil {
+		return nil, err
+	}
 
-	oldFinalizers := tcproute.GetFinalizers()
-	newFinalizers := make([]string, 0, len(oldFinalizers)-1)
-	for _, finalizer := range oldFinalizers {
-		if finalizer != DataPlaneFinalizer {
-			newFinalizers = append(newFinalizers, finalizer)
+	var conflict *gatewayv1alpha2.TCPRoute
+	for i := range tcproutes.Items {
+		other := &tcproutes.Items[i]
+		if other.UID == tcproute.UID || other.DeletionTimestamp != nil || !isNamespaceWatched(r.WatchNamespaces, other.Namespace) {
+			continue
+		}
+		if !routeUsesGatewayPort(other.Namespace, other.Spec.ParentRefs, gateway, port) {
+			continue
+		}
+		if routeTakesPrecedence(other, tcproute) && (conflict == nil || routeTakesPrecedence(other, conflict)) {
+			conflict = other
 		}
 	}
-	tcproute.SetFinalizers(newFinalizers)
 
-	return r.Client.Update(ctx, tcproute)
+	return conflict, nil
+}
+
+// updateTCPRouteStatus sets the provided conditions on the TCPRoute's
+// status for the provided Gateway, and patches the status if it changed.
+func (r *TCPRouteReconciler) updateTCPRouteStatus(ctx context.Context, tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway, conds ...metav1.Condition) error {
+	parentRef, ok := parentRefForGateway(tcproute.Namespace, tcproute.Spec.ParentRefs, gateway)
+	if !ok {
+		return nil
+	}
+
+	oldTCPRoute := tcproute.DeepCopy()
+	changed := false
+	for _, cond := range conds {
+		cond.ObservedGeneration = tcproute.Generation
+		if setRouteCondition(&tcproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName), parentRef, cond) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
 
+	return r.Status().Patch(ctx, tcproute, client.MergeFrom(oldTCPRoute))
 }
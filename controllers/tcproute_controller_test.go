@@ -0,0 +1,1258 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	controllerruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+// newTCPRouteTestObjects returns a managed GatewayClass, a ready Gateway with a
+// TCP listener on port 8080, and a backend Service with its Endpoints.
+func newTCPRouteTestObjects() []controllerruntimeclient.Object {
+	return []controllerruntimeclient.Object{
+		&gatewayv1beta1.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+			Spec: gatewayv1beta1.GatewayClassSpec{
+				ControllerName: vars.GatewayClassControllerName,
+			},
+		},
+		&gatewayv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+			Spec: gatewayv1beta1.GatewaySpec{
+				GatewayClassName: "test-gatewayclass",
+				Listeners: []gatewayv1beta1.Listener{{
+					Name:     "tcp",
+					Protocol: gatewayv1beta1.TCPProtocolType,
+					Port:     8080,
+				}},
+			},
+			Status: gatewayv1beta1.GatewayStatus{
+				Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+					Type:  &ipAddrType,
+					Value: "172.18.0.240",
+				}},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{IP: "10.244.0.10"}},
+				Ports:     []corev1.EndpointPort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			}},
+		},
+	}
+}
+
+// newTestTCPRoute returns a TCPRoute attached to the test Gateway's TCP
+// listener, forwarding to the test backend Service.
+func newTestTCPRoute(name string, created time.Time) *gatewayv1alpha2.TCPRoute {
+	return &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test-namespace",
+			UID:               types.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+			Finalizers:        []string{DataPlaneFinalizer},
+		},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{
+					Name: "test-gateway",
+					Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+				}},
+			},
+			Rules: []gatewayv1alpha2.TCPRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{{
+					BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+						Name: "test-backend",
+						Port: ptr.To(gatewayv1alpha2.PortNumber(80)),
+					},
+				}},
+			}},
+		},
+	}
+}
+
+// fakeBackendsUpdater records the Targets and Vips pushed to the dataplane,
+// updates fail with updateErr if it's set.
+type fakeBackendsUpdater struct {
+	updates    []*dataplane.Targets
+	podUpdates []fakePodUpdate
+	deletes    []*dataplane.Vip
+	adds       []*dataplane.Targets
+	removes    []*dataplane.Targets
+	updateErr  error
+}
+
+// fakePodUpdate records the Targets pushed to some dataplane Pods only.
+type fakePodUpdate struct {
+	targets *dataplane.Targets
+	pods    []string
+}
+
+func (f *fakeBackendsUpdater) Update(_ context.Context, in *dataplane.Targets, _ ...grpc.CallOption) (*dataplane.UpdateResult, error) {
+	f.updates = append(f.updates, in)
+	return nil, f.updateErr
+}
+
+func (f *fakeBackendsUpdater) UpdatePods(_ context.Context, in *dataplane.Targets, pods []string, _ ...grpc.CallOption) (*dataplane.UpdateResult, error) {
+	f.podUpdates = append(f.podUpdates, fakePodUpdate{targets: in, pods: pods})
+	return nil, f.updateErr
+}
+
+// Sync records the Targets as updates: the fake dataplane has nothing
+// programmed, so syncing pushes the whole backend set like Update.
+func (f *fakeBackendsUpdater) Sync(_ context.Context, in *dataplane.Targets, _ ...grpc.CallOption) (*dataplane.UpdateResult, error) {
+	f.updates = append(f.updates, in)
+	return nil, f.updateErr
+}
+
+func (f *fakeBackendsUpdater) Delete(_ context.Context, in *dataplane.Vip, _ ...grpc.CallOption) (*dataplane.UpdateResult, error) {
+	f.deletes = append(f.deletes, in)
+	return nil, nil
+}
+
+func (f *fakeBackendsUpdater) AddBackend(_ context.Context, in *dataplane.Targets, _ ...grpc.CallOption) (*dataplane.UpdateResult, error) {
+	f.adds = append(f.adds, in)
+	return nil, f.updateErr
+}
+
+func (f *fakeBackendsUpdater) RemoveBackend(_ context.Context, in *dataplane.Targets, _ ...grpc.CallOption) (*dataplane.UpdateResult, error) {
+	f.removes = append(f.removes, in)
+	return nil, f.updateErr
+}
+
+// withSharedPortListeners replaces the listeners of the test Gateway with a
+// TCP and a UDP listener sharing the same port.
+func withSharedPortListeners(objs []controllerruntimeclient.Object) []controllerruntimeclient.Object {
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway.Spec.Listeners = []gatewayv1beta1.Listener{
+				{Name: "tcp", Protocol: gatewayv1beta1.TCPProtocolType, Port: 8080},
+				{Name: "udp", Protocol: gatewayv1beta1.UDPProtocolType, Port: 8080},
+			}
+		}
+	}
+	return objs
+}
+
+// withMaxRoutesPerGateway sets the MaxRoutesPerGatewayParameter of the test
+// GatewayClass, with a ConfigMap referenced by its parametersRef.
+func withMaxRoutesPerGateway(objs []controllerruntimeclient.Object, limit int) []controllerruntimeclient.Object {
+	for _, obj := range objs {
+		if gwc, ok := obj.(*gatewayv1beta1.GatewayClass); ok {
+			gwc.Spec.ParametersRef = &gatewayv1beta1.ParametersReference{
+				Kind:      "ConfigMap",
+				Name:      "test-gatewayclass-parameters",
+				Namespace: ptr.To(gatewayv1beta1.Namespace("test-namespace")),
+			}
+		}
+	}
+	return append(objs, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass-parameters", Namespace: "test-namespace"},
+		Data:       map[string]string{MaxRoutesPerGatewayParameter: fmt.Sprint(limit)},
+	})
+}
+
+func newTestTCPRouteReconciler(objs ...controllerruntimeclient.Object) (TCPRouteReconciler, *fakeBackendsUpdater) {
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(objs...).
+		WithIndex(&gatewayv1alpha2.TCPRoute{}, routeParentGatewayKey, tcpRouteParentGateways).
+		WithIndex(&gatewayv1alpha2.TCPRoute{}, routeBackendServiceKey, tcpRouteBackendServices).
+		WithIndex(&gatewayv1alpha2.TCPRoute{}, routeBackendConfigMapKey, tcpRouteBackendConfigMaps).
+		WithIndex(&gatewayv1alpha2.UDPRoute{}, routeParentGatewayKey, udpRouteParentGateways).
+		Build()
+
+	backends := &fakeBackendsUpdater{}
+	return TCPRouteReconciler{
+		Client:                fakeClient,
+		BackendsClientManager: backends,
+		ExternalNameResolver:  dataplane.NewExternalNameResolver(dataplane.NetHostResolver{}, dataplane.DefaultExternalNameRefreshInterval),
+		pushedTargets:         newPushedTargets(),
+	}, backends
+}
+
+func TestTCPRouteReconciler_vipConflict(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	for _, tt := range []struct {
+		name     string
+		routes   []*gatewayv1alpha2.TCPRoute
+		expected string
+	}{
+		{
+			name: "the oldest route wins",
+			routes: []*gatewayv1alpha2.TCPRoute{
+				newTestTCPRoute("route-a", now),
+				newTestTCPRoute("route-b", now.Add(-time.Minute)),
+			},
+			expected: "route-b",
+		},
+		{
+			name: "routes created at the same time are ordered by namespace/name",
+			routes: []*gatewayv1alpha2.TCPRoute{
+				newTestTCPRoute("route-b", now),
+				newTestTCPRoute("route-a", now),
+			},
+			expected: "route-a",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			objs := newTCPRouteTestObjects()
+			for _, route := range tt.routes {
+				objs = append(objs, route)
+			}
+			reconciler, _ := newTestTCPRouteReconciler(objs...)
+
+			// reconcile every route twice in both orders, the result must not
+			// depend on which route happened to be reconciled last.
+			for i := 0; i < 2; i++ {
+				for _, route := range tt.routes {
+					_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+					require.NoError(t, err)
+				}
+				for j := len(tt.routes) - 1; j >= 0; j-- {
+					_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(tt.routes[j])})
+					require.NoError(t, err)
+				}
+			}
+
+			for _, route := range tt.routes {
+				tcproute := new(gatewayv1alpha2.TCPRoute)
+				require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(route), tcproute))
+				require.Len(t, tcproute.Status.Parents, 1)
+				accepted := meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+				require.NotNil(t, accepted)
+				if route.Name == tt.expected {
+					require.Equal(t, metav1.ConditionTrue, accepted.Status)
+				} else {
+					require.Equal(t, metav1.ConditionFalse, accepted.Status)
+					require.Equal(t, string(RouteReasonConflict), accepted.Reason)
+					require.Contains(t, accepted.Message, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestTCPRouteReconciler_conflictWinnerDeleted(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	winner, loser := newTestTCPRoute("route-old", now.Add(-time.Minute)), newTestTCPRoute("route-new", now)
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), winner, loser)...)
+	accepted := func(route *gatewayv1alpha2.TCPRoute) *metav1.Condition {
+		tcproute := new(gatewayv1alpha2.TCPRoute)
+		require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(route), tcproute))
+		require.Len(t, tcproute.Status.Parents, 1)
+		return meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+	}
+
+	for _, route := range []*gatewayv1alpha2.TCPRoute{loser, winner} {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+		require.NoError(t, err)
+	}
+	require.Equal(t, metav1.ConditionFalse, accepted(loser).Status)
+	require.Len(t, backends.updates, 1)
+
+	t.Log("the routes on the same VIP are enqueued when the oldest route changes")
+	loserReq := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(loser)}
+	require.Equal(t, []reconcile.Request{loserReq}, reconciler.mapTCPRouteToConflictingTCPRoutes(ctx, winner))
+
+	t.Log("the next oldest route is programmed once the oldest route is deleted")
+	require.NoError(t, reconciler.Client.Delete(ctx, winner))
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(winner)})
+	require.NoError(t, err)
+	require.Len(t, backends.deletes, 1)
+	_, err = reconciler.Reconcile(ctx, loserReq)
+	require.NoError(t, err)
+	require.Equal(t, metav1.ConditionTrue, accepted(loser).Status)
+	require.Len(t, backends.updates, 2)
+}
+
+func TestTCPRouteReconciler_backendsMetric(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-metrics", time.Now())
+	objs := newTCPRouteTestObjects()
+	for _, obj := range objs {
+		if endpoints, ok := obj.(*corev1.Endpoints); ok {
+			endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, corev1.EndpointAddress{IP: "10.244.0.11"})
+		}
+	}
+	reconciler, _ := newTestTCPRouteReconciler(append(objs, route)...)
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+	require.NoError(t, err)
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.RouteBackends.WithLabelValues(route.Namespace, route.Name, "TCPRoute")))
+
+	endpoints := &corev1.Endpoints{}
+	require.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Namespace: "test-namespace", Name: "test-backend"}, endpoints))
+	endpoints.Subsets = nil
+	require.NoError(t, reconciler.Client.Update(ctx, endpoints))
+
+	failures := testutil.ToFloat64(metrics.RouteCompileFailures.WithLabelValues("TCPRoute", metrics.CompileFailureReasonOther))
+	_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+	require.Error(t, err)
+	require.Equal(t, failures+1, testutil.ToFloat64(metrics.RouteCompileFailures.WithLabelValues("TCPRoute", metrics.CompileFailureReasonOther)))
+}
+
+func TestTCPRouteReconciler_resolvedRefs(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-resolved-refs", time.Now())
+	route.Spec.Rules[0].BackendRefs = append(route.Spec.Rules[0].BackendRefs,
+		gatewayv1alpha2.BackendRef{BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+			Name: "missing-backend",
+			Port: ptr.To(gatewayv1alpha2.PortNumber(80)),
+		}},
+		gatewayv1alpha2.BackendRef{BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+			Kind:      ptr.To(gatewayv1alpha2.Kind("ConfigMap")),
+			Name:      "missing-endpoints",
+			Namespace: ptr.To(gatewayv1alpha2.Namespace("other-namespace")),
+		}},
+	)
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+	resolvedRefs := func() *metav1.Condition {
+		tcproute := new(gatewayv1alpha2.TCPRoute)
+		require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+		require.Len(t, tcproute.Status.Parents, 1)
+		return meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionResolvedRefs))
+	}
+
+	t.Log("the unresolved backends are listed in the ResolvedRefs condition")
+	_, err := reconciler.Reconcile(ctx, req)
+	require.Error(t, err)
+	require.Empty(t, backends.updates)
+	cond := resolvedRefs()
+	require.NotNil(t, cond)
+	require.Equal(t, metav1.ConditionFalse, cond.Status)
+	require.Equal(t, string(gatewayv1alpha2.RouteReasonBackendNotFound), cond.Reason)
+	require.Equal(t, "2 of the 3 backend references could not be resolved: "+
+		"Service test-namespace/missing-backend: not found, ConfigMap other-namespace/missing-endpoints: not found", cond.Message)
+
+	t.Log("ResolvedRefs stays false while a backend which receives no traffic is missing")
+	tcproute := new(gatewayv1alpha2.TCPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	tcproute.Spec.Rules[0].BackendRefs = tcproute.Spec.Rules[0].BackendRefs[:2]
+	tcproute.Spec.Rules[0].BackendRefs[1].Weight = ptr.To(int32(0))
+	require.NoError(t, reconciler.Client.Update(ctx, tcproute))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+	cond = resolvedRefs()
+	require.Equal(t, metav1.ConditionFalse, cond.Status)
+	require.Equal(t, "1 of the 2 backend references could not be resolved: Service test-namespace/missing-backend: not found", cond.Message)
+
+	t.Log("ResolvedRefs is true once all the backends resolve")
+	require.NoError(t, reconciler.Client.Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing-backend", Namespace: "test-namespace"},
+	}))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond = resolvedRefs()
+	require.Equal(t, metav1.ConditionTrue, cond.Status)
+	require.Equal(t, string(gatewayv1alpha2.RouteReasonResolvedRefs), cond.Reason)
+
+	t.Log("a backend whose Service has no matching port is skipped, and reported in ResolvedRefs")
+	require.NoError(t, reconciler.Client.Create(ctx, &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing-backend", Namespace: "test-namespace"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.244.0.20"}},
+			Ports:     []corev1.EndpointPort{{Port: 8080}},
+		}},
+	}))
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	tcproute.Spec.Rules[0].BackendRefs[1].Weight = nil
+	require.NoError(t, reconciler.Client.Update(ctx, tcproute))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond = resolvedRefs()
+	require.Equal(t, metav1.ConditionFalse, cond.Status)
+	require.Contains(t, cond.Message, "1 of the 2 backend references could not be resolved: backend missing-backend: backend port not found")
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	programmed := meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(RouteConditionProgrammed))
+	require.Equal(t, metav1.ConditionTrue, programmed.Status)
+	require.Contains(t, programmed.Message, "the unresolved backends were skipped")
+	require.Equal(t, []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}}, backends.updates[len(backends.updates)-1].Targets)
+}
+
+func TestTCPRouteReconciler_paused(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-paused", time.Now())
+	route.Annotations = map[string]string{PausedAnnotation: "true"}
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, backends.updates, "a paused route must not be pushed to the dataplane")
+
+	tcproute := new(gatewayv1alpha2.TCPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	require.Len(t, tcproute.Status.Parents, 1)
+	paused := meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(RouteConditionPaused))
+	require.NotNil(t, paused)
+	require.Equal(t, metav1.ConditionTrue, paused.Status)
+
+	delete(tcproute.Annotations, PausedAnnotation)
+	require.NoError(t, reconciler.Client.Update(ctx, tcproute))
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1, "an unpaused route must be pushed to the dataplane")
+
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	paused = meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(RouteConditionPaused))
+	require.NotNil(t, paused)
+	require.Equal(t, metav1.ConditionFalse, paused.Status)
+	require.Equal(t, string(RouteReasonResumed), paused.Reason)
+	require.True(t, meta.IsStatusConditionTrue(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted)))
+}
+
+func TestTCPRouteReconciler_mapReferenceGrantToTCPRoutes(t *testing.T) {
+	crossNamespaceRoute := newTestTCPRoute("cross-namespace", time.Now())
+	crossNamespaceRoute.Spec.Rules[0].BackendRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("backend-namespace"))
+	localRoute := newTestTCPRoute("local", time.Now())
+	otherNamespaceRoute := newTestTCPRoute("other-namespace", time.Now())
+	otherNamespaceRoute.Namespace = "other-namespace"
+	otherNamespaceRoute.Spec.Rules[0].BackendRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("backend-namespace"))
+
+	reconciler, _ := newTestTCPRouteReconciler(crossNamespaceRoute, localRoute, otherNamespaceRoute)
+
+	grant := &gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-grant", Namespace: "backend-namespace"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{
+				Group:     gatewayv1beta1.GroupName,
+				Kind:      "TCPRoute",
+				Namespace: "test-namespace",
+			}},
+			To: []gatewayv1beta1.ReferenceGrantTo{{
+				Group: "",
+				Kind:  "Service",
+			}},
+		},
+	}
+
+	reqs := reconciler.mapReferenceGrantToTCPRoutes(context.Background(), grant)
+	require.Equal(t, []reconcile.Request{{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(crossNamespaceRoute)}}, reqs)
+
+	grant.Spec.To[0].Name = ptr.To(gatewayv1beta1.ObjectName("another-backend"))
+	require.Empty(t, reconciler.mapReferenceGrantToTCPRoutes(context.Background(), grant))
+}
+
+func TestTCPRouteReconciler_dataPlaneFinalizer(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-finalizer", time.Now())
+	route.Finalizers = []string{"example.com/other-finalizer"}
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	tcproute := new(gatewayv1alpha2.TCPRoute)
+	for i := 0; i < 3; i++ {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		// adding the finalizer again, even from a stale copy, must not duplicate it
+		require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+		require.NoError(t, setDataPlaneFinalizer(ctx, reconciler.Client, tcproute))
+		require.Equal(t, []string{"example.com/other-finalizer", DataPlaneFinalizer}, tcproute.Finalizers)
+	}
+
+	require.NoError(t, reconciler.Client.Delete(ctx, tcproute))
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.deletes, 1)
+
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	require.Equal(t, []string{"example.com/other-finalizer"}, tcproute.Finalizers)
+}
+
+func TestTCPRouteReconciler_gatewayWithoutAddress(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-no-address", time.Now())
+	objs := newTCPRouteTestObjects()
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway.Status.Addresses = nil
+		}
+	}
+	reconciler, backends := newTestTCPRouteReconciler(append(objs, route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("the TCPRoute isn't programmed, and waits for the Gateway to be updated with an address")
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, result)
+	require.Empty(t, backends.updates)
+
+	tcproute := new(gatewayv1alpha2.TCPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	require.Len(t, tcproute.Status.Parents, 1)
+	programmed := meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(RouteConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionFalse, programmed.Status)
+	require.Equal(t, string(RouteReasonGatewayNotReady), programmed.Reason)
+
+	t.Log("the never programmed TCPRoute is deleted without deleting its VIP")
+	require.NoError(t, reconciler.Client.Delete(ctx, tcproute))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, backends.deletes)
+	err = reconciler.Client.Get(ctx, req.NamespacedName, tcproute)
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestTCPRouteReconciler_ipv6GatewayAddress(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-ipv6", time.Now())
+	objs := newTCPRouteTestObjects()
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway.Status.Addresses[0].Value = "fd00::f0"
+		}
+	}
+	reconciler, backends := newTestTCPRouteReconciler(append(objs, route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("a TCPRoute of a Gateway with an IPv6 address is deleted without deleting a VIP")
+	require.NoError(t, reconciler.Client.Delete(ctx, route))
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, backends.deletes)
+	err = reconciler.Client.Get(ctx, req.NamespacedName, new(gatewayv1alpha2.TCPRoute))
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestTCPRouteReconciler_unsupportedValue(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		annotation string
+		value      string
+	}{
+		{name: "invalid max connections", annotation: dataplane.MaxConnectionsAnnotation, value: "-1"},
+		{name: "invalid backend port override", annotation: dataplane.BackendPortOverrideAnnotation, value: "70000"},
+		{name: "invalid TCP idle timeout", annotation: dataplane.TCPIdleTimeoutAnnotation, value: "forever"},
+		{name: "invalid action", annotation: dataplane.ActionAnnotation, value: "Deny"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			route := newTestTCPRoute("route-unsupported-value", time.Now())
+			route.Annotations = map[string]string{tt.annotation: tt.value}
+			reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+			req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+			t.Log("the TCPRoute isn't accepted, and isn't retried until it's fixed")
+			result, err := reconciler.Reconcile(ctx, req)
+			require.NoError(t, err)
+			require.Zero(t, result)
+			require.Empty(t, backends.updates)
+
+			tcproute := new(gatewayv1alpha2.TCPRoute)
+			require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+			require.Len(t, tcproute.Status.Parents, 1)
+			accepted := meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+			require.NotNil(t, accepted)
+			require.Equal(t, metav1.ConditionFalse, accepted.Status)
+			require.Equal(t, string(gatewayv1alpha2.RouteReasonUnsupportedValue), accepted.Reason)
+			require.Contains(t, accepted.Message, tt.annotation)
+		})
+	}
+}
+
+func TestTCPRouteReconciler_noDataPlaneClients(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-no-dataplane", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	backends.updateErr = dataplane.ErrNoDataPlaneClients
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	requireProgrammed := func(status metav1.ConditionStatus, reason gatewayv1alpha2.RouteConditionReason) {
+		t.Helper()
+		tcproute := new(gatewayv1alpha2.TCPRoute)
+		require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+		require.Len(t, tcproute.Status.Parents, 1)
+		programmed := meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(RouteConditionProgrammed))
+		require.NotNil(t, programmed)
+		require.Equal(t, status, programmed.Status)
+		require.Equal(t, string(reason), programmed.Reason)
+	}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NotZero(t, result.RequeueAfter)
+	requireProgrammed(metav1.ConditionFalse, RouteReasonNoDataPlane)
+
+	t.Log("a dataplane instance becomes available")
+	backends.updateErr = nil
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+	requireProgrammed(metav1.ConditionTrue, RouteReasonProgrammed)
+}
+
+func TestTCPRouteReconciler_lastSuccessfulReconcileMetric(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-last-reconcile", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+	lastSuccess := func() float64 {
+		return testutil.ToFloat64(metrics.LastSuccessfulReconcile.WithLabelValues("TCPRoute"))
+	}
+
+	before := lastSuccess()
+	time.Sleep(10 * time.Millisecond)
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	afterSuccess := lastSuccess()
+	require.Greater(t, afterSuccess, before)
+
+	t.Log("a failed reconcile doesn't advance the timestamp")
+	backends.updateErr = errors.New("dataplane unavailable")
+	// the backends are unchanged, so they're only pushed again once forgotten.
+	reconciler.pushedTargets.reset()
+	time.Sleep(10 * time.Millisecond)
+	_, err = reconciler.Reconcile(ctx, req)
+	require.Error(t, err)
+	require.Equal(t, afterSuccess, lastSuccess())
+}
+
+func TestTCPRouteReconciler_incrementalBackendUpdates(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-incremental", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}
+	setEndpoints := func(ips ...string) {
+		endpoints := &corev1.Endpoints{}
+		require.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Namespace: "test-namespace", Name: "test-backend"}, endpoints))
+		endpoints.Subsets[0].Addresses = nil
+		for _, ip := range ips {
+			endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, corev1.EndpointAddress{IP: ip})
+		}
+		require.NoError(t, reconciler.Client.Update(ctx, endpoints))
+	}
+
+	t.Log("the whole backend set is pushed for a new VIP")
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+
+	t.Log("a single pod scale-up adds its backend without replacing the backend set")
+	setEndpoints("10.244.0.10", "10.244.0.11")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+	require.Equal(t, []*dataplane.Targets{{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000b, Dport: 80}}}}, backends.adds)
+	require.Empty(t, backends.removes)
+
+	t.Log("a scale-down removes the backend")
+	setEndpoints("10.244.0.11")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+	require.Len(t, backends.adds, 1)
+	require.Equal(t, []*dataplane.Targets{{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}}}}, backends.removes)
+
+	t.Log("unchanged backends aren't pushed again")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+	require.Len(t, backends.adds, 1)
+	require.Len(t, backends.removes, 1)
+
+	t.Log("the whole backend set is pushed again after a failed push")
+	backends.updateErr = errors.New("dataplane unavailable")
+	setEndpoints("10.244.0.11", "10.244.0.12")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.Error(t, err)
+	backends.updateErr = nil
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 2)
+
+	t.Log("the whole backend set is pushed again when the max connections change")
+	tcproute := new(gatewayv1alpha2.TCPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	tcproute.Annotations = map[string]string{dataplane.MaxConnectionsAnnotation: "10"}
+	require.NoError(t, reconciler.Client.Update(ctx, tcproute))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 3)
+	require.Equal(t, ptr.To(uint32(10)), backends.updates[2].Targets[0].MaxConnections)
+
+	t.Log("the whole backend set is pushed again when the TCP idle timeout changes")
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	tcproute.Annotations[dataplane.TCPIdleTimeoutAnnotation] = "5m"
+	require.NoError(t, reconciler.Client.Update(ctx, tcproute))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 4)
+	require.Equal(t, ptr.To(uint32(300)), backends.updates[3].TcpIdleTimeoutSeconds)
+}
+
+func TestTCPRouteReconciler_backendMovedToAnotherNode(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-moved", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+
+	t.Log("the backend Pod is recreated on another node, with an address of that node's Pod CIDR")
+	endpoints := &corev1.Endpoints{}
+	require.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Namespace: "test-namespace", Name: "test-backend"}, endpoints))
+	endpoints.Subsets[0].Addresses = []corev1.EndpointAddress{{IP: "10.244.1.10", NodeName: ptr.To("other-node")}}
+	require.NoError(t, reconciler.Client.Update(ctx, endpoints))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	t.Log("the new address is pushed without an interface, which the dataplane resolves for it, and the old one is removed")
+	require.Equal(t, []*dataplane.Targets{{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4010a, Dport: 80}}}}, backends.adds)
+	require.Nil(t, backends.adds[0].Targets[0].Ifindex)
+	require.Equal(t, []*dataplane.Targets{{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}}}}, backends.removes)
+}
+
+func TestTCPRouteReconciler_partialUpdateFailure(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-partial-failure", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	reconciler.PartialUpdateRequeueInterval = time.Minute
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("the update fails on one of the dataplane pods")
+	backends.updateErr = &dataplane.PartialUpdateError{Pods: []string{"dataplane-b"}, Err: errors.New("dataplane unavailable")}
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, result.RequeueAfter)
+	require.Len(t, backends.updates, 1)
+
+	t.Log("the update is only retried on the failed pod")
+	backends.updateErr = &dataplane.PartialUpdateError{Pods: []string{"dataplane-b"}, Err: errors.New("dataplane unavailable")}
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, result.RequeueAfter)
+	require.Len(t, backends.updates, 1)
+	require.Len(t, backends.podUpdates, 1)
+	require.Equal(t, []string{"dataplane-b"}, backends.podUpdates[0].pods)
+	require.Equal(t, backends.updates[0], backends.podUpdates[0].targets)
+
+	backends.updateErr = nil
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+	require.Len(t, backends.updates, 1)
+	require.Len(t, backends.podUpdates, 2)
+	require.Equal(t, []string{"dataplane-b"}, backends.podUpdates[1].pods)
+
+	t.Log("unchanged backends aren't pushed again once all the pods applied them")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+	require.Len(t, backends.podUpdates, 2)
+}
+
+func TestTCPRouteReconciler_maxRoutesPerGateway(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	objs := withMaxRoutesPerGateway(newTCPRouteTestObjects(), 3)
+	var routes []*gatewayv1alpha2.TCPRoute
+	for i, created := range []time.Time{now, now.Add(-time.Minute), now, now.Add(-2 * time.Minute)} {
+		// each route has its own listener, so that they don't conflict.
+		port := gatewayv1alpha2.PortNumber(9000 + i)
+		route := newTestTCPRoute(fmt.Sprintf("route-%d", i), created)
+		route.Spec.ParentRefs[0].Port = ptr.To(port)
+		routes = append(routes, route)
+		objs = append(objs, route)
+	}
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			for i := range routes {
+				gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
+					Name:     gatewayv1beta1.SectionName(fmt.Sprintf("tcp-%d", 9000+i)),
+					Protocol: gatewayv1beta1.TCPProtocolType,
+					Port:     gatewayv1beta1.PortNumber(9000 + i),
+				})
+			}
+		}
+	}
+	reconciler, backends := newTestTCPRouteReconciler(objs...)
+	accepted := func(route *gatewayv1alpha2.TCPRoute) *metav1.Condition {
+		tcproute := new(gatewayv1alpha2.TCPRoute)
+		require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(route), tcproute))
+		require.Len(t, tcproute.Status.Parents, 1)
+		return meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+	}
+	reconcileAll := func(routes ...*gatewayv1alpha2.TCPRoute) {
+		for _, route := range routes {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+			require.NoError(t, err)
+		}
+	}
+
+	t.Log("the newest routes beyond the limit are rejected, whatever the reconcile order")
+	for _, order := range [][]int{{2, 0, 1, 3}, {3, 1, 0, 2}} {
+		for _, i := range order {
+			reconcileAll(routes[i])
+		}
+		for _, route := range routes[:2] {
+			require.Equal(t, metav1.ConditionTrue, accepted(route).Status, route.Name)
+		}
+		require.Equal(t, metav1.ConditionTrue, accepted(routes[3]).Status)
+		rejected := accepted(routes[2])
+		require.Equal(t, metav1.ConditionFalse, rejected.Status)
+		require.Equal(t, string(RouteReasonTooManyRoutes), rejected.Reason)
+		require.Contains(t, rejected.Message, "3 routes")
+	}
+	require.Len(t, backends.updates, 3)
+
+	t.Log("the rejected routes are enqueued when a route of the Gateway is deleted")
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[2])},
+	}, reconciler.mapRouteToTooManyTCPRoutes(ctx, routes[1]))
+	require.NoError(t, reconciler.Client.Delete(ctx, routes[1]))
+	reconcileAll(routes[2])
+	require.Equal(t, metav1.ConditionTrue, accepted(routes[2]).Status)
+	require.Len(t, backends.updates, 4)
+
+	t.Log("lowering the limit enqueues the routes of the Gateway, including the one being deleted")
+	cm := new(corev1.ConfigMap)
+	require.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Namespace: "test-namespace", Name: "test-gatewayclass-parameters"}, cm))
+	cm.Data[MaxRoutesPerGatewayParameter] = "1"
+	require.NoError(t, reconciler.Client.Update(ctx, cm))
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[0])},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[1])},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[2])},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[3])},
+	}, reconciler.mapGatewayClassParametersToTCPRoutes(ctx, cm))
+
+	t.Log("the programmed routes beyond the new limit are removed from the dataplane, once")
+	backends.deletes = nil
+	reconcileAll(routes[0], routes[2], routes[3], routes[0])
+	require.Equal(t, metav1.ConditionTrue, accepted(routes[3]).Status)
+	require.Equal(t, string(RouteReasonTooManyRoutes), accepted(routes[0]).Reason)
+	require.Equal(t, string(RouteReasonTooManyRoutes), accepted(routes[2]).Reason)
+	require.ElementsMatch(t, []*dataplane.Vip{
+		{Ip: 0xac1200f0, Port: 9000, Protocol: dataplane.Vip_TCP},
+		{Ip: 0xac1200f0, Port: 9002, Protocol: dataplane.Vip_TCP},
+	}, backends.deletes)
+}
+
+func TestTCPRouteReconciler_maxRoutesPerGatewayIgnoresRejectedRoutes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	objs := withMaxRoutesPerGateway(newTCPRouteTestObjects(), 2)
+	newRoute := func(name string, age time.Duration, port int) *gatewayv1alpha2.TCPRoute {
+		route := newTestTCPRoute(name, now.Add(-age))
+		route.Spec.ParentRefs[0].Port = ptr.To(gatewayv1alpha2.PortNumber(port))
+		objs = append(objs, route)
+		return route
+	}
+	paused := newRoute("route-paused", 5*time.Minute, 9000)
+	paused.Annotations = map[string]string{PausedAnnotation: "true"}
+	unsupported := newRoute("route-unsupported", 4*time.Minute, 9001)
+	unsupported.Annotations = map[string]string{dataplane.ActionAnnotation: "Deny"}
+	winner := newRoute("route-winner", 3*time.Minute, 9002)
+	loser := newRoute("route-loser", 2*time.Minute, 9002)
+	valid := newRoute("route-valid", time.Minute, 9003)
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			for port := 9000; port <= 9003; port++ {
+				gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
+					Name:     gatewayv1beta1.SectionName(fmt.Sprintf("tcp-%d", port)),
+					Protocol: gatewayv1beta1.TCPProtocolType,
+					Port:     gatewayv1beta1.PortNumber(port),
+				})
+			}
+		}
+	}
+	reconciler, _ := newTestTCPRouteReconciler(objs...)
+	accepted := func(route *gatewayv1alpha2.TCPRoute) *metav1.Condition {
+		tcproute := new(gatewayv1alpha2.TCPRoute)
+		require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(route), tcproute))
+		require.Len(t, tcproute.Status.Parents, 1)
+		return meta.FindStatusCondition(tcproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+	}
+
+	for _, route := range []*gatewayv1alpha2.TCPRoute{paused, unsupported, winner, loser, valid} {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+		require.NoError(t, err)
+	}
+	require.Equal(t, string(gatewayv1alpha2.RouteReasonUnsupportedValue), accepted(unsupported).Reason)
+	require.Equal(t, string(RouteReasonConflict), accepted(loser).Reason)
+	require.Equal(t, metav1.ConditionTrue, accepted(winner).Status)
+
+	t.Log("the older paused and rejected routes don't count towards the limit")
+	require.Equal(t, metav1.ConditionTrue, accepted(valid).Status)
+}
+
+func TestTCPRouteReconciler_ignoredGatewayClassParameters(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		parametersRef *gatewayv1beta1.ParametersReference
+	}{
+		{
+			name:          "parametersRef of another kind",
+			parametersRef: &gatewayv1beta1.ParametersReference{Group: "example.com", Kind: "Parameters", Name: "test-parameters"},
+		},
+		{
+			name: "missing ConfigMap",
+			parametersRef: &gatewayv1beta1.ParametersReference{
+				Kind:      "ConfigMap",
+				Name:      "missing-parameters",
+				Namespace: ptr.To(gatewayv1beta1.Namespace("test-namespace")),
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			route := newTestTCPRoute("route-ignored-parameters", time.Now())
+			objs := newTCPRouteTestObjects()
+			for _, obj := range objs {
+				if gwc, ok := obj.(*gatewayv1beta1.GatewayClass); ok {
+					gwc.Spec.ParametersRef = tt.parametersRef
+				}
+			}
+			reconciler, backends := newTestTCPRouteReconciler(append(objs, route)...)
+
+			t.Log("the TCPRoute is programmed without a routes limit")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+			require.NoError(t, err)
+			require.Len(t, backends.updates, 1)
+		})
+	}
+}
+
+func TestTCPRouteReconciler_watchNamespaces(t *testing.T) {
+	watchedRoute := newTestTCPRoute("route-watched", time.Now())
+	unwatchedRoute := newTestTCPRoute("route-unwatched", time.Now())
+	unwatchedRoute.Namespace = "other-namespace"
+	unwatchedRoute.Spec.ParentRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("test-namespace"))
+	objs := newTCPRouteTestObjects()
+	reconciler, _ := newTestTCPRouteReconciler(append(objs, watchedRoute, unwatchedRoute)...)
+
+	var gateway *gatewayv1beta1.Gateway
+	for _, obj := range objs {
+		if gw, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway = gw
+		}
+	}
+	require.NotNil(t, gateway)
+
+	t.Log("routes in all namespaces are enqueued by default")
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(watchedRoute)},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(unwatchedRoute)},
+	}, reconciler.mapGatewayToTCPRoutes(context.Background(), gateway))
+
+	t.Log("routes outside of the watched namespaces are not enqueued")
+	reconciler.WatchNamespaces = []string{"test-namespace"}
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(watchedRoute)},
+	}, reconciler.mapGatewayToTCPRoutes(context.Background(), gateway))
+
+	t.Log("routes in any of multiple watched namespaces are enqueued")
+	reconciler.WatchNamespaces = []string{"test-namespace", "other-namespace"}
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(watchedRoute)},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(unwatchedRoute)},
+	}, reconciler.mapGatewayToTCPRoutes(context.Background(), gateway))
+}
+
+func TestTCPRouteReconciler_reconcileWatchNamespaces(t *testing.T) {
+	ctx := context.Background()
+	watchedRoute := newTestTCPRoute("route-watched", time.Now())
+	unwatchedRoute := newTestTCPRoute("route-unwatched", time.Now())
+	unwatchedRoute.Namespace = "unwatched-namespace"
+	unwatchedRoute.Spec.ParentRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("test-namespace"))
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), watchedRoute, unwatchedRoute)...)
+	reconciler.WatchNamespaces = []string{"team-a", "test-namespace"}
+
+	t.Log("a route outside of the watched namespaces is ignored")
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(unwatchedRoute)})
+	require.NoError(t, err)
+	require.Empty(t, backends.updates)
+	route := new(gatewayv1alpha2.TCPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(unwatchedRoute), route))
+	require.Empty(t, route.Status.Parents)
+
+	t.Log("a route in one of the watched namespaces is reconciled")
+	_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(watchedRoute)})
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+}
+
+func TestTCPRouteReconciler_mapGatewayToTCPRoutes(t *testing.T) {
+	gatewayRoute := newTestTCPRoute("route-gateway", time.Now())
+	otherGatewayRoute := newTestTCPRoute("route-other-gateway", time.Now())
+	otherGatewayRoute.Spec.ParentRefs[0].Name = "other-gateway"
+	otherNamespaceRoute := newTestTCPRoute("route-other-namespace", time.Now())
+	otherNamespaceRoute.Spec.ParentRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("other-namespace"))
+	objs := newTCPRouteTestObjects()
+	reconciler, _ := newTestTCPRouteReconciler(append(objs, gatewayRoute, otherGatewayRoute, otherNamespaceRoute)...)
+
+	var gateway *gatewayv1beta1.Gateway
+	for _, obj := range objs {
+		if gw, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway = gw
+		}
+	}
+	require.NotNil(t, gateway)
+
+	t.Log("a Gateway change only enqueues the routes which reference it")
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gatewayRoute)},
+	}, reconciler.mapGatewayToTCPRoutes(context.Background(), gateway))
+
+	t.Log("routes referencing the Gateway several times are enqueued once")
+	gatewayRoute.Spec.ParentRefs = append(gatewayRoute.Spec.ParentRefs, gatewayRoute.Spec.ParentRefs[0])
+	gatewayRoute.Spec.ParentRefs[1].Port = ptr.To(gatewayv1alpha2.PortNumber(9090))
+	require.NoError(t, reconciler.Client.Update(context.Background(), gatewayRoute))
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(gatewayRoute)},
+	}, reconciler.mapGatewayToTCPRoutes(context.Background(), gateway))
+}
+
+func TestTCPRouteReconciler_mapServiceToTCPRoutes(t *testing.T) {
+	backendRoute := newTestTCPRoute("route-backend", time.Now())
+	otherBackendRoute := newTestTCPRoute("route-other-backend", time.Now())
+	otherBackendRoute.Spec.Rules[0].BackendRefs[0].Name = "other-backend"
+	crossNamespaceRoute := newTestTCPRoute("route-cross-namespace", time.Now())
+	crossNamespaceRoute.Namespace = "other-namespace"
+	crossNamespaceRoute.Spec.Rules[0].BackendRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("test-namespace"))
+	configMapRoute := newTestTCPRoute("route-configmap", time.Now())
+	configMapRoute.Spec.Rules[0].BackendRefs[0].Kind = ptr.To(gatewayv1alpha2.Kind("ConfigMap"))
+	reconciler, _ := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), backendRoute, otherBackendRoute, crossNamespaceRoute, configMapRoute)...)
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"}}
+	endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"}}
+
+	t.Log("the index returns the routes referencing the Service, including from other namespaces")
+	routes := new(gatewayv1alpha2.TCPRouteList)
+	require.NoError(t, reconciler.Client.List(context.Background(), routes, controllerruntimeclient.MatchingFields{
+		routeBackendServiceKey: "test-namespace/test-backend",
+	}))
+	var names []string
+	for _, route := range routes.Items {
+		names = append(names, route.Name)
+	}
+	require.ElementsMatch(t, []string{"route-backend", "route-cross-namespace"}, names)
+
+	t.Log("changes to the Service or its Endpoints enqueue the routes referencing it")
+	expected := []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(backendRoute)},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(crossNamespaceRoute)},
+	}
+	require.ElementsMatch(t, expected, reconciler.mapServiceToTCPRoutes(context.Background(), svc))
+	require.ElementsMatch(t, expected, reconciler.mapServiceToTCPRoutes(context.Background(), endpoints))
+
+	t.Log("routes outside of the watched namespaces are not enqueued")
+	reconciler.WatchNamespaces = []string{"test-namespace"}
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(backendRoute)},
+	}, reconciler.mapServiceToTCPRoutes(context.Background(), svc))
+}
+
+func TestTCPRouteReconciler_mapConfigMapToTCPRoutes(t *testing.T) {
+	serviceRoute := newTestTCPRoute("route-service", time.Now())
+	configMapRoute := newTestTCPRoute("route-configmap", time.Now())
+	configMapRoute.Spec.Rules[0].BackendRefs[0].Kind = ptr.To(gatewayv1alpha2.Kind("ConfigMap"))
+	crossNamespaceRoute := newTestTCPRoute("route-cross-namespace", time.Now())
+	crossNamespaceRoute.Namespace = "other-namespace"
+	crossNamespaceRoute.Spec.Rules[0].BackendRefs[0].Kind = ptr.To(gatewayv1alpha2.Kind("ConfigMap"))
+	crossNamespaceRoute.Spec.Rules[0].BackendRefs[0].Namespace = ptr.To(gatewayv1alpha2.Namespace("test-namespace"))
+	reconciler, _ := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), serviceRoute, configMapRoute, crossNamespaceRoute)...)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"}}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test-backend", Namespace: "test-namespace"}}
+
+	t.Log("changes to the ConfigMap enqueue the routes listing its endpoints as a backend")
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(configMapRoute)},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(crossNamespaceRoute)},
+	}, reconciler.mapConfigMapToTCPRoutes(context.Background(), cm))
+
+	t.Log("the routes referencing a Service of the same name aren't")
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(serviceRoute)},
+	}, reconciler.mapServiceToTCPRoutes(context.Background(), svc))
+
+	t.Log("routes outside of the watched namespaces are not enqueued")
+	reconciler.WatchNamespaces = []string{"test-namespace"}
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(configMapRoute)},
+	}, reconciler.mapConfigMapToTCPRoutes(context.Background(), cm))
+}
+
+func TestTCPRouteReconciler_foreignGatewayClass(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-foreign", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.updates, 1)
+
+	t.Log("the GatewayClass is handed over to another controller")
+	gatewayClass := new(gatewayv1beta1.GatewayClass)
+	require.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Name: "test-gatewayclass"}, gatewayClass))
+	gatewayClass.Spec.ControllerName = "example.com/other-controller"
+	require.NoError(t, reconciler.Client.Update(ctx, gatewayClass))
+
+	t.Log("the route is deleted, which is blocked by its finalizer")
+	require.NoError(t, reconciler.Client.Delete(ctx, route))
+	tcproute := new(gatewayv1alpha2.TCPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, tcproute))
+	require.Contains(t, tcproute.Finalizers, DataPlaneFinalizer)
+
+	t.Log("the route is removed from the dataplane and its finalizer is removed")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.deletes, 1)
+	require.Equal(t, backends.updates[0].Vip, backends.deletes[0])
+	err = reconciler.Client.Get(ctx, req.NamespacedName, tcproute)
+	require.True(t, apierrors.IsNotFound(err), "the route should be deleted once its finalizer is removed")
+}
+
+func TestTCPRouteReconciler_deletionGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-grace", time.Now())
+	reconciler, backends := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	reconciler.DeletionGracePeriod = time.Hour
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	require.NoError(t, reconciler.Client.Delete(ctx, route))
+
+	t.Log("the route is kept in the dataplane during the deletion grace period")
+	res, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, backends.deletes)
+	require.Greater(t, res.RequeueAfter, time.Duration(0))
+	require.LessOrEqual(t, res.RequeueAfter, time.Hour)
+
+	t.Log("the route is removed from the dataplane once the grace period elapsed")
+	reconciler.DeletionGracePeriod = 0
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.deletes, 1)
+}
+
+func TestTCPRouteReconciler_sharedListenerPort(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		sectionName *gatewayv1alpha2.SectionName
+		managed     bool
+	}{
+		{
+			name:    "the TCPRoute attaches to the TCP listener sharing the port",
+			managed: true,
+		},
+		{
+			name:        "the TCPRoute attaches to the TCP listener selected by its section name",
+			sectionName: ptr.To(gatewayv1alpha2.SectionName("tcp")),
+			managed:     true,
+		},
+		{
+			name:        "the TCPRoute doesn't attach to the UDP listener sharing the port",
+			sectionName: ptr.To(gatewayv1alpha2.SectionName("udp")),
+			managed:     false,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			route := newTestTCPRoute("route-shared-port", time.Now())
+			route.Spec.ParentRefs[0].SectionName = tt.sectionName
+			reconciler, backends := newTestTCPRouteReconciler(append(withSharedPortListeners(newTCPRouteTestObjects()), route)...)
+
+			managed, _, err := reconciler.isTCPRouteManaged(ctx, *route)
+			require.NoError(t, err)
+			require.Equal(t, tt.managed, managed)
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+			require.NoError(t, err)
+			if !tt.managed {
+				require.Empty(t, backends.updates)
+				return
+			}
+			require.Len(t, backends.updates, 1)
+			require.Equal(t, uint32(8080), backends.updates[0].Vip.Port)
+		})
+	}
+}
+
+func TestTCPRouteReconciler_clusterScopedGatewayClass(t *testing.T) {
+	ctx := context.Background()
+	route := newTestTCPRoute("route-cluster-scoped", time.Now())
+	reconciler, _ := newTestTCPRouteReconciler(append(newTCPRouteTestObjects(), route)...)
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(controllerruntimeclient.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c controllerruntimeclient.WithWatch, key controllerruntimeclient.ObjectKey, obj controllerruntimeclient.Object, opts ...controllerruntimeclient.GetOption) error {
+			if _, ok := obj.(*gatewayv1beta1.GatewayClass); ok && key.Namespace != "" {
+				return apierrors.NewNotFound(gatewayv1beta1.Resource("gatewayclasses"), key.Name)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	t.Log("the GatewayClass of the route's Gateway is looked up without the route's namespace")
+	managed, gateway, err := reconciler.isTCPRouteManaged(ctx, *route)
+	require.NoError(t, err)
+	require.True(t, managed)
+	require.Equal(t, "test-gateway", gateway.Name)
+}
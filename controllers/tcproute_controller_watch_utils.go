@@ -54,6 +54,9 @@ func (r *TCPRouteReconciler) mapDataPlaneDaemonsetToTCPRoutes(ctx context.Contex
 		return
 	}
 
+	// the dataplane instances which were added need the whole backend sets.
+	r.pushedTargets.reset()
+
 	tcproutes := &gatewayv1alpha2.TCPRouteList{}
 	if err := r.Client.List(ctx, tcproutes); err != nil {
 		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
@@ -62,6 +65,9 @@ func (r *TCPRouteReconciler) mapDataPlaneDaemonsetToTCPRoutes(ctx context.Contex
 	}
 
 	for _, tcproute := range tcproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+			continue
+		}
 		reqs = append(reqs, reconcile.Request{
 			NamespacedName: types.NamespacedName{
 				Namespace: tcproute.Namespace,
@@ -73,9 +79,11 @@ func (r *TCPRouteReconciler) mapDataPlaneDaemonsetToTCPRoutes(ctx context.Contex
 	return
 }
 
-// mapGatewayToTCPRoutes enqueues reconcilation for all TCPRoutes whenever
-// an event occurs on a relevant Gateway.
-func (r *TCPRouteReconciler) mapGatewayToTCPRoutes(_ context.Context, obj client.Object) (reqs []reconcile.Request) {
+// mapGatewayToTCPRoutes enqueues reconcilation for the TCPRoutes which
+// reference a Gateway whenever an event occurs on it. The TCPRoutes are
+// listed with the routeParentGatewayKey index, so that the routes of other
+// Gateways aren't listed nor enqueued.
+func (r *TCPRouteReconciler) mapGatewayToTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
 	gateway, ok := obj.(*gatewayv1beta1.Gateway)
 	if !ok {
 		r.log.Error(fmt.Errorf("invalid type in map func"), "failed to map gateways to tcproutes", "expected", "*gatewayv1beta1.Gateway", "received", reflect.TypeOf(obj))
@@ -83,19 +91,219 @@ func (r *TCPRouteReconciler) mapGatewayToTCPRoutes(_ context.Context, obj client
 	}
 
 	tcproutes := new(gatewayv1alpha2.TCPRouteList)
-	if err := r.Client.List(context.Background(), tcproutes); err != nil {
+	if err := r.Client.List(ctx, tcproutes, client.MatchingFields{
+		routeParentGatewayKey: client.ObjectKeyFromObject(gateway).String(),
+	}); err != nil {
 		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
 		r.log.Error(err, "could not enqueue TCPRoutes for Gateway update")
 		return
 	}
 
 	for _, tcproute := range tcproutes.Items {
-		for _, parentRef := range tcproute.Spec.ParentRefs {
-			namespace := tcproute.Namespace
-			if parentRef.Namespace != nil {
-				namespace = string(*parentRef.Namespace)
+		if !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: tcproute.Namespace,
+			Name:      tcproute.Name,
+		}})
+	}
+
+	return
+}
+
+// mapServiceToTCPRoutes enqueues reconcilation for the TCPRoutes which
+// reference a Service as a backend whenever an event occurs on the Service or
+// on its Endpoints, which have the same name, so that changes to the backends
+// are pushed to the dataplane. The TCPRoutes are listed with the
+// routeBackendServiceKey index.
+func (r *TCPRouteReconciler) mapServiceToTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := r.Client.List(ctx, tcproutes, client.MatchingFields{
+		routeBackendServiceKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.log.Error(err, "could not enqueue TCPRoutes for Service update")
+		return
+	}
+
+	for _, tcproute := range tcproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: tcproute.Namespace,
+			Name:      tcproute.Name,
+		}})
+	}
+
+	return
+}
+
+// mapConfigMapToTCPRoutes enqueues reconcilation for the TCPRoutes which
+// reference a ConfigMap listing static endpoints as a backend whenever an
+// event occurs on the ConfigMap, so that changes to the endpoints are pushed
+// to the dataplane. The TCPRoutes are listed with the
+// routeBackendConfigMapKey index.
+func (r *TCPRouteReconciler) mapConfigMapToTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	tcproutes := new(gatewayv1alpha2.TCPRouteList)
+	if err := r.Client.List(ctx, tcproutes, client.MatchingFields{
+		routeBackendConfigMapKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.log.Error(err, "could not enqueue TCPRoutes for ConfigMap update")
+		return
+	}
+
+	for _, tcproute := range tcproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: tcproute.Namespace,
+			Name:      tcproute.Name,
+		}})
+	}
+
+	return
+}
+
+// mapReferenceGrantToTCPRoutes enqueues reconcilation for the TCPRoutes in the
+// namespaces a ReferenceGrant grants access from, which reference backends in
+// the ReferenceGrant's namespace. This ensures that cross-namespace references
+// are resolved as soon as the grant which permits them is created.
+func (r *TCPRouteReconciler) mapReferenceGrantToTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	grant, ok := obj.(*gatewayv1beta1.ReferenceGrant)
+	if !ok {
+		r.log.Error(fmt.Errorf("invalid type in map func"), "failed to map referencegrants to tcproutes", "expected", "*gatewayv1beta1.ReferenceGrant", "received", reflect.TypeOf(obj))
+		return
+	}
+
+	namespaces := map[string]struct{}{}
+	for _, from := range grant.Spec.From {
+		if string(from.Group) == gatewayv1beta1.GroupName && from.Kind == TCPRouteKind {
+			namespaces[string(from.Namespace)] = struct{}{}
+		}
+	}
+
+	for namespace := range namespaces {
+		if !isNamespaceWatched(r.WatchNamespaces, namespace) {
+			continue
+		}
+		tcproutes := new(gatewayv1alpha2.TCPRouteList)
+		if err := r.Client.List(ctx, tcproutes, client.InNamespace(namespace)); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue TCPRoutes for ReferenceGrant update")
+			return
+		}
+
+		for _, tcproute := range tcproutes.Items {
+			var backendRefs []gatewayv1alpha2.BackendRef
+			for _, rule := range tcproute.Spec.Rules {
+				backendRefs = append(backendRefs, rule.BackendRefs...)
+			}
+			if referenceGrantAppliesToRoute(grant, TCPRouteKind, tcproute.Namespace, backendRefs) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: tcproute.Namespace,
+					Name:      tcproute.Name,
+				}})
+			}
+		}
+	}
+
+	return
+}
+
+// mapTCPRouteToConflictingTCPRoutes enqueues reconcilation for the other
+// TCPRoutes programmed on the same Gateway VIP as the provided TCPRoute
+// whenever it changes or is deleted, so that the route which takes
+// precedence on the VIP is programmed and the others are marked conflicting.
+func (r *TCPRouteReconciler) mapTCPRouteToConflictingTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	changed, ok := obj.(*gatewayv1alpha2.TCPRoute)
+	if !ok {
+		r.log.Error(fmt.Errorf("invalid type in map func"), "failed to map tcproutes to conflicting tcproutes", "expected", "*gatewayv1alpha2.TCPRoute", "received", reflect.TypeOf(obj))
+		return
+	}
+
+	// only the TCPRoutes attached to the same Gateways can share a VIP.
+	seen := map[types.UID]struct{}{changed.UID: {}}
+	for _, gateway := range routeParentGateways(changed.Namespace, changed.Spec.ParentRefs) {
+		tcproutes := new(gatewayv1alpha2.TCPRouteList)
+		if err := r.Client.List(ctx, tcproutes, client.MatchingFields{routeParentGatewayKey: gateway}); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue conflicting TCPRoutes for TCPRoute update")
+			return
+		}
+
+		for _, tcproute := range tcproutes.Items {
+			if _, ok := seen[tcproute.UID]; ok || !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+				continue
+			}
+			if routesShareGatewayPort(changed.Namespace, changed.Spec.ParentRefs, tcproute.Namespace, tcproute.Spec.ParentRefs) {
+				seen[tcproute.UID] = struct{}{}
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: tcproute.Namespace,
+					Name:      tcproute.Name,
+				}})
+			}
+		}
+	}
+
+	return
+}
+
+// mapGatewayClassParametersToTCPRoutes enqueues reconcilation for the
+// TCPRoutes attached to the Gateways whose routes limit might have changed,
+// whenever an event occurs on their GatewayClass or on the ConfigMap holding
+// its parameters.
+func (r *TCPRouteReconciler) mapGatewayClassParametersToTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	gateways, err := gatewayClassParametersGateways(ctx, r.Client, obj)
+	if err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.log.Error(err, "could not enqueue TCPRoutes for GatewayClass parameters update")
+		return
+	}
+
+	for _, gateway := range gateways {
+		tcproutes := new(gatewayv1alpha2.TCPRouteList)
+		if err := r.Client.List(ctx, tcproutes, client.MatchingFields{routeParentGatewayKey: gateway}); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue TCPRoutes for GatewayClass parameters update")
+			return
+		}
+
+		for _, tcproute := range tcproutes.Items {
+			if !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+				continue
+			}
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: tcproute.Namespace,
+				Name:      tcproute.Name,
+			}})
+		}
+	}
+
+	return
+}
+
+// mapRouteToTooManyTCPRoutes enqueues reconcilation for the TCPRoutes which
+// weren't accepted because their Gateway had too many routes, whenever an
+// event occurs on a TCPRoute or an UDPRoute attached to the same Gateway, as
+// it might make room for them.
+func (r *TCPRouteReconciler) mapRouteToTooManyTCPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	for _, gateway := range changedRouteParentGateways(obj) {
+		tcproutes := new(gatewayv1alpha2.TCPRouteList)
+		if err := r.Client.List(ctx, tcproutes, client.MatchingFields{routeParentGatewayKey: gateway}); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue TCPRoutes exceeding the routes limit for route update")
+			return
+		}
+
+		for _, tcproute := range tcproutes.Items {
+			if tcproute.UID == obj.GetUID() || !isNamespaceWatched(r.WatchNamespaces, tcproute.Namespace) {
+				continue
 			}
-			if parentRef.Name == gatewayv1alpha2.ObjectName(gateway.Name) && namespace == gateway.Namespace {
+			if routeHasTooManyRoutes(tcproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName)) {
 				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
 					Namespace: tcproute.Namespace,
 					Name:      tcproute.Name,
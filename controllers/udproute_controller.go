@@ -18,31 +18,36 @@ package controllers
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
-	"github.com/kubernetes-sigs/blixt/pkg/vars"
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
 )
 
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=udproutes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=udproutes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=udproutes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=pods/status,verbs=get
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch
@@ -55,15 +60,79 @@ type UDPRouteReconciler struct {
 
 	log                        logr.Logger
 	ClientReconcileRequestChan <-chan event.GenericEvent
-	BackendsClientManager      *dataplane.BackendsClientManager
+	BackendsClientManager      dataplane.BackendsUpdater
+
+	// WatchNamespaces restricts the controller to objects in the provided
+	// namespaces. All namespaces are watched if empty.
+	WatchNamespaces []string
+
+	// ControllerName is the GatewayClass controller name identifying the
+	// resources managed by this controller. Defaults to
+	// vars.GatewayClassControllerName if empty.
+	ControllerName gatewayv1beta1.GatewayController
+
+	// DeletionGracePeriod is how long a deleted UDPRoute is kept in the
+	// dataplane before being removed from it.
+	DeletionGracePeriod time.Duration
+
+	// PartialUpdateRequeueInterval is the period after which a UDPRoute
+	// whose Targets failed to be pushed to some of the dataplane instances is
+	// reconciled again, to retry the push on those instances only. Defaults
+	// to DefaultPartialUpdateRequeueInterval if zero.
+	PartialUpdateRequeueInterval time.Duration
+
+	// RateLimiter configures the rate limiter of the workqueue of the
+	// controller, the controller-runtime default is used if it's zero.
+	RateLimiter RateLimiterConfig
+
+	// ExternalNameResolver resolves the external names of the ExternalName
+	// Service backends. Defaults to a resolver using the system's DNS
+	// resolver if nil.
+	ExternalNameResolver *dataplane.ExternalNameResolver
+
+	// APIReader reads the ConfigMaps holding the GatewayClass parameters
+	// without the cache, which only holds the watched namespaces while the
+	// ConfigMaps can be in any namespace. The Client is used if nil.
+	APIReader client.Reader
+
+	// pushedTargets tracks the Targets pushed for the UDPRoutes, so that
+	// backend changes are pushed incrementally.
+	pushedTargets *pushedTargets
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *UDPRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.log = log.FromContext(context.Background())
+	r.pushedTargets = newPushedTargets()
+	if r.ExternalNameResolver == nil {
+		r.ExternalNameResolver = dataplane.NewExternalNameResolver(dataplane.NetHostResolver{}, dataplane.DefaultExternalNameRefreshInterval)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gatewayv1alpha2.UDPRoute{}, routeParentGatewayKey, udpRouteParentGateways); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gatewayv1alpha2.UDPRoute{}, routeBackendServiceKey, udpRouteBackendServices); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &gatewayv1alpha2.UDPRoute{}, routeBackendConfigMapKey, udpRouteBackendConfigMaps); err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controllerOptions(r.RateLimiter)).
 		For(&gatewayv1alpha2.UDPRoute{}).
+		Watches(
+			&gatewayv1alpha2.UDPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.mapUDPRouteToConflictingUDPRoutes),
+		).
+		Watches(
+			&gatewayv1alpha2.TCPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.mapRouteToTooManyUDPRoutes),
+		).
+		Watches(
+			&gatewayv1alpha2.UDPRoute{},
+			handler.EnqueueRequestsFromMapFunc(r.mapRouteToTooManyUDPRoutes),
+		).
 		WatchesRawSource(
 			&source.Channel{Source: r.ClientReconcileRequestChan},
 			handler.EnqueueRequestsFromMapFunc(r.mapDataPlaneDaemonsetToUDPRoutes),
@@ -72,11 +141,48 @@ func (r *UDPRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&gatewayv1beta1.Gateway{},
 			handler.EnqueueRequestsFromMapFunc(r.mapGatewayToUDPRoutes),
 		).
+		Watches(
+			&gatewayv1beta1.ReferenceGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.mapReferenceGrantToUDPRoutes),
+		).
+		Watches(
+			&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.mapServiceToUDPRoutes),
+		).
+		Watches(
+			&corev1.Endpoints{},
+			handler.EnqueueRequestsFromMapFunc(r.mapServiceToUDPRoutes),
+		).
+		Watches(
+			&gatewayv1beta1.GatewayClass{},
+			handler.EnqueueRequestsFromMapFunc(r.mapGatewayClassParametersToUDPRoutes),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapGatewayClassParametersToUDPRoutes),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.mapConfigMapToUDPRoutes),
+		).
 		Complete(r)
 }
 
 // Reconcile reconciles UDPRoute object
-func (r *UDPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *UDPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	defer func() {
+		if err == nil {
+			metrics.LastSuccessfulReconcile.WithLabelValues("UDPRoute").SetToCurrentTime()
+		}
+	}()
+
+	// the cache only holds the watched namespaces, this also ignores the
+	// UDPRoutes of other namespaces which would be enqueued by a mapping.
+	if !isNamespaceWatched(r.WatchNamespaces, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
 	udproute := new(gatewayv1alpha2.UDPRoute)
 	if err := r.Get(ctx, req.NamespacedName, udproute); err != nil {
 		if errors.IsNotFound(err) {
@@ -92,7 +198,14 @@ func (r *UDPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 	if !isManaged {
+		if controllerutil.ContainsFinalizer(udproute, DataPlaneFinalizer) {
+			// the UDPRoute was programmed by this controller, but its Gateway
+			// isn't managed by it anymore.
+			r.log.Info("UDPRoute is not managed anymore, removing it from the dataplane", "namespace", udproute.Namespace, "name", udproute.Name)
+			return ctrl.Result{}, releaseUnmanagedRoute(ctx, r.Client, r.BackendsClientManager, r.pushedTargets, UDPRouteKind, udproute, udproute.Spec.ParentRefs)
+		}
 		// TODO: enable orphan checking https://github.com/kubernetes-sigs/blixt/issues/47
+		r.pushedTargets.forgetRoute(req.NamespacedName)
 		return ctrl.Result{}, nil
 	}
 
@@ -109,22 +222,180 @@ func (r *UDPRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, setDataPlaneFinalizer(ctx, r.Client, udproute)
 	}
 
-	// if the UDPRoute is being deleted, remove it from the DataPlane
-	// TODO: enable deletion grace period https://github.com/kubernetes-sigs/blixt/issues/48
+	// ensure that no other UDPRoute which takes precedence is already
+	// programmed on the same Gateway VIP, otherwise we'd overwrite its backends.
+	conflict, err := r.getConflictingUDPRoute(ctx, udproute, gateway)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// if the UDPRoute is being deleted, remove it from the DataPlane once its
+	// deletion grace period has elapsed
 	if udproute.DeletionTimestamp != nil {
+		if conflict != nil {
+			// the VIP is owned by another UDPRoute, so there's no dataplane
+			// configuration to remove for this one.
+			return ctrl.Result{}, removeDataPlaneFinalizer(ctx, r.Client, udproute)
+		}
+		if remaining := deletionGraceRemaining(udproute, r.DeletionGracePeriod); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
 		return ctrl.Result{}, r.ensureUDPRouteDeletedInDataPlane(ctx, udproute, gateway)
 	}
 
+	if isRoutePaused(udproute) {
+		r.log.Info("UDPRoute is paused, skipping dataplane configuration", "namespace", udproute.Namespace, "name", udproute.Name)
+		return ctrl.Result{}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+			Type:    string(RouteConditionPaused),
+			Status:  metav1.ConditionTrue,
+			Reason:  string(RouteReasonPaused),
+			Message: fmt.Sprintf("the UDPRoute has the %s annotation, its dataplane configuration is left unchanged", PausedAnnotation),
+		})
+	}
+
+	if conflict != nil {
+		r.log.Info("UDPRoute conflicts with another UDPRoute on the same Gateway VIP, skipping dataplane configuration",
+			"namespace", udproute.Namespace, "name", udproute.Name, "conflict", client.ObjectKeyFromObject(conflict).String())
+		return ctrl.Result{}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+			Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(RouteReasonConflict),
+			Message: fmt.Sprintf("the Gateway VIP is already in use by UDPRoute %s", client.ObjectKeyFromObject(conflict)),
+		})
+	}
+
+	limit, tooManyRoutes, err := exceedsGatewayRouteLimit(ctx, r.Client, r.paramsReader(), r.WatchNamespaces, controllerNameOrDefault(r.ControllerName), UDPRouteKind, udproute, gateway)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if tooManyRoutes {
+		r.log.Info("UDPRoute exceeds the maximum number of routes of its Gateway, skipping dataplane configuration",
+			"namespace", udproute.Namespace, "name", udproute.Name, "limit", limit)
+		if !routeHasTooManyRoutes(udproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName)) {
+			// the UDPRoute might have been programmed before the limit was
+			// lowered or older routes were attached to the Gateway.
+			if err := r.deleteUDPRouteVIP(ctx, udproute, gateway); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+			Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(RouteReasonTooManyRoutes),
+			Message: fmt.Sprintf("the Gateway already has the maximum of %d routes attached", limit),
+		})
+	}
+
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range udproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	resolvedRefs, err := backendRefsResolvedCondition(ctx, r.Client, r.ExternalNameResolver, udproute, backendRefs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// in all other cases ensure the UDPRoute is configured in the dataplane
-	if err := r.ensureUDPRouteConfiguredInDataPlane(ctx, udproute, gateway); err != nil {
-		if err.Error() == "endpoints not ready" {
+	targets, err := r.ensureUDPRouteConfiguredInDataPlane(ctx, udproute, gateway)
+	if err != nil {
+		if isEndpointsNotReady(err) {
 			r.log.Info("endpoints not yet ready for UDPRoute, retrying", "namespace", udproute.Namespace, "name", udproute.Name)
 			return ctrl.Result{RequeueAfter: time.Second}, nil
 		}
+		if isPartialUpdateFailure(err) {
+			// the dataplane instances which applied the update are not sent
+			// it again, only the ones which failed are.
+			r.log.Info("UDPRoute update failed on some dataplane instances, retrying on them", "namespace", udproute.Namespace, "name", udproute.Name, "error", err.Error())
+			return ctrl.Result{RequeueAfter: partialUpdateRequeueInterval(r.PartialUpdateRequeueInterval)}, nil
+		}
+		if isGatewayIPNotReady(err) {
+			// the Gateway's LoadBalancer Service is still being allocated an
+			// address, updates to the Gateway will re-enqueue the UDPRoute.
+			r.log.Info("Gateway has no address yet for UDPRoute, waiting for it", "namespace", udproute.Namespace, "name", udproute.Name)
+			return ctrl.Result{}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+				Type:    string(RouteConditionProgrammed),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(RouteReasonGatewayNotReady),
+				Message: "waiting for the Gateway to be assigned an address",
+			})
+		}
+		if isNoDataPlaneClients(err) {
+			r.log.Info("no dataplane available for UDPRoute, retrying", "namespace", udproute.Namespace, "name", udproute.Name)
+			return ctrl.Result{RequeueAfter: time.Second}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+				Type:    string(RouteConditionProgrammed),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(RouteReasonNoDataPlane),
+				Message: "waiting for a dataplane instance to be available",
+			})
+		}
+		if isExternalNameNotResolved(err) {
+			r.log.Info("could not resolve ExternalName backend for UDPRoute, retrying", "namespace", udproute.Namespace, "name", udproute.Name, "error", err.Error())
+			return ctrl.Result{RequeueAfter: r.ExternalNameResolver.RefreshInterval}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+				Type:    string(gatewayv1alpha2.RouteConditionResolvedRefs),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(RouteReasonExternalNameNotResolved),
+				Message: err.Error(),
+			})
+		}
+		if isUnsupportedRouteValue(err) {
+			// retrying won't help until the UDPRoute is fixed, which
+			// re-enqueues it.
+			r.log.Info("UDPRoute has an unsupported value", "namespace", udproute.Namespace, "name", udproute.Name, "error", err.Error())
+			return ctrl.Result{}, r.updateUDPRouteStatus(ctx, udproute, gateway, metav1.Condition{
+				Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(gatewayv1alpha2.RouteReasonUnsupportedValue),
+				Message: err.Error(),
+			})
+		}
+		if resolvedRefs.Status == metav1.ConditionFalse {
+			r.log.Info("some backends of UDPRoute could not be resolved", "namespace", udproute.Namespace, "name", udproute.Name, "message", resolvedRefs.Message)
+			if err := r.updateUDPRouteStatus(ctx, udproute, gateway, resolvedRefs); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, err
+	}
+
+	weights := dataplane.NormalizeBackendWeights(udproute.Namespace, backendRefs)
+
+	// the addresses of ExternalName backends are resolved again when they
+	// expire, which requires compiling the UDPRoute again.
+	refreshAfter, err := r.ExternalNameResolver.BackendsRefreshAfter(ctx, r.Client, udproute.Namespace, backendRefs)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	programmedReason := RouteReasonProgrammed
+	programmed := fmt.Sprintf("the UDPRoute has been programmed in the dataplane, backend weights: %s", dataplane.FormatBackendWeights(weights))
+	if len(targets.Targets) == 0 {
+		programmedReason = RouteReasonNoHealthyBackends
+		programmed = "the UDPRoute has been programmed in the dataplane without backends as none is healthy, its datagrams are answered with ICMP port unreachable"
+	}
+	if resolvedRefs.Status == metav1.ConditionFalse {
+		programmed += ", the unresolved backends were skipped"
+	}
+	conds := []metav1.Condition{{
+		Type:    string(gatewayv1alpha2.RouteConditionAccepted),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1alpha2.RouteReasonAccepted),
+		Message: "the UDPRoute has been programmed in the dataplane",
+	}, {
+		Type:    string(RouteConditionProgrammed),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(programmedReason),
+		Message: programmed,
+	}, resolvedRefs}
+	if routeWasPaused(udproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName)) {
+		conds = append(conds, metav1.Condition{
+			Type:    string(RouteConditionPaused),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(RouteReasonResumed),
+			Message: "the UDPRoute is no longer paused",
+		})
+	}
+
+	return ctrl.Result{RequeueAfter: refreshAfter}, r.updateUDPRouteStatus(ctx, udproute, gateway, conds...)
 }
 
 // isUDPRouteManaged verifies wether a provided UDPRoute is managed by this
@@ -151,15 +422,11 @@ func (r *UDPRouteReconciler) isUDPRouteManaged(ctx context.Context, udproute gat
 		}
 
 		//Get GatewayClass for the Gateway and match to our name of controler
-		gwc := new(gatewayv1beta1.GatewayClass)
-		if err := r.Get(ctx, types.NamespacedName{Name: string(gw.Spec.GatewayClassName), Namespace: ns}, gwc); err != nil {
-			if !errors.IsNotFound(err) {
-				return false, nil, err
-			}
-			continue
+		managed, err := isGatewayClassManaged(ctx, r.Client, gw.Spec.GatewayClassName, r.ControllerName)
+		if err != nil {
+			return false, nil, err
 		}
-
-		if gwc.Spec.ControllerName != vars.GatewayClassControllerName {
+		if !managed {
 			// not managed by this implementation, check the next parent ref
 			continue
 		}
@@ -188,64 +455,160 @@ func (r *UDPRouteReconciler) isUDPRouteManaged(ctx context.Context, udproute gat
 }
 
 // verifyListener verifies that the provided gateway has at least one listener
-// matching the provided ParentReference.
+// matching the provided ParentReference, with a protocol supporting UDPRoutes.
 func (r *UDPRouteReconciler) verifyListener(_ context.Context, gw *gatewayv1beta1.Gateway, udprouteSpec gatewayv1alpha2.ParentReference) error {
 	for _, listener := range gw.Spec.Listeners {
-		if (listener.Protocol == gatewayv1beta1.UDPProtocolType) && (listener.Port == gatewayv1beta1.PortNumber(*udprouteSpec.Port)) {
+		if (listener.Protocol == gatewayv1beta1.UDPProtocolType) && isListenerPortValid(listener.Port) && parentRefSelectsListener(udprouteSpec, listener) {
 			return nil
 		}
 	}
 	return fmt.Errorf("No matching Gateway listener found for defined Parentref")
 }
 
-func (r *UDPRouteReconciler) ensureUDPRouteConfiguredInDataPlane(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) error {
+// ensureUDPRouteConfiguredInDataPlane pushes the dataplane configuration of
+// the provided UDPRoute, and returns the pushed Targets.
+func (r *UDPRouteReconciler) ensureUDPRouteConfiguredInDataPlane(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) (*dataplane.Targets, error) {
 	// build the dataplane configuration from the UDPRoute and its Gateway
-	targets, err := dataplane.CompileUDPRouteToDataPlaneBackend(ctx, r.Client, udproute, gateway)
+	targets, err := dataplane.CompileUDPRouteToDataPlaneBackend(ctx, r.Client, r.ExternalNameResolver, udproute, gateway)
+	if err != nil {
+		reason := compileFailureReason(err)
+		metrics.RouteCompileFailures.WithLabelValues("UDPRoute", reason).Inc()
+		if reason != metrics.CompileFailureReasonNoHealthyBackends {
+			return nil, err
+		}
+
+		// the VIP is still programmed when there are no healthy backends, but
+		// with an empty backend list so that the dataplane answers datagrams
+		// sent to it with ICMP port unreachable instead of forwarding them to
+		// backends which are gone.
+		vip, err := udpRouteVip(udproute, gateway)
+		if err != nil {
+			return nil, err
+		}
+		targets = &dataplane.Targets{Vip: vip}
+	}
+
+	result, err := r.pushedTargets.push(ctx, r.BackendsClientManager, client.ObjectKeyFromObject(udproute), targets)
 	if err != nil {
+		return nil, err
+	}
+	metrics.RouteBackends.WithLabelValues(udproute.Namespace, udproute.Name, "UDPRoute").Set(float64(len(targets.Targets)))
+
+	r.log.Info("successful data-plane UPDATE", "pods", result.Succeeded())
+
+	return targets, nil
+}
+
+func (r *UDPRouteReconciler) ensureUDPRouteDeletedInDataPlane(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) error {
+	if err := r.deleteUDPRouteVIP(ctx, udproute, gateway); err != nil {
 		return err
 	}
+	return removeDataPlaneFinalizer(ctx, r.Client, udproute)
+}
 
-	if _, err = r.BackendsClientManager.Update(ctx, targets); err != nil {
+// deleteUDPRouteVIP deletes the VIP of the provided UDPRoute from the
+// dataplane, if it could have been programmed.
+func (r *UDPRouteReconciler) deleteUDPRouteVIP(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) error {
+	vip, err := udpRouteVip(udproute, gateway)
+	if isGatewayIPNotReady(err) || isUnsupportedIPFamily(err) {
+		// the UDPRoute was never programmed without a Gateway IPv4 address,
+		// so there's nothing to delete from the dataplane.
+		r.log.Info("Gateway has no IPv4 address, skipping data-plane DELETE", "namespace", udproute.Namespace, "name", udproute.Name, "reason", err.Error())
+		r.pushedTargets.forgetRoute(client.ObjectKeyFromObject(udproute))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// delete the target from the dataplane
+	result, err := r.BackendsClientManager.Delete(ctx, vip)
+	if err != nil {
 		return err
 	}
+	r.pushedTargets.forget(vip)
+	metrics.RouteBackends.DeleteLabelValues(udproute.Namespace, udproute.Name, "UDPRoute")
 
-	r.log.Info("successful data-plane UPDATE")
+	r.log.Info("successful data-plane DELETE", "pods", result.Succeeded())
 
 	return nil
 }
 
-func (r *UDPRouteReconciler) ensureUDPRouteDeletedInDataPlane(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) error {
-	// get the gateway IP and port.
+// paramsReader returns the reader of the GatewayClass parameters.
+func (r *UDPRouteReconciler) paramsReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// udpRouteVip returns the dataplane Vip (the Gateway IP and listener port) the
+// provided UDPRoute is programmed on.
+func udpRouteVip(udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) (*dataplane.Vip, error) {
 	gwIP, err := dataplane.GetGatewayIP(gateway)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	gatewayIP := binary.BigEndian.Uint32(gwIP.To4())
 	gwPort, err := dataplane.GetGatewayPort(gateway, udproute.Spec.ParentRefs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	vip := dataplane.Vip{
-		Ip:   gatewayIP,
-		Port: gwPort,
+	return dataplane.GatewayVip(gateway, gwIP, gwPort, dataplane.Vip_UDP)
+}
+
+// getConflictingUDPRoute returns the UDPRoute which takes precedence over
+// the provided one, if any other UDPRoute is attached to the same Gateway
+// listener and would therefore be programmed on the same VIP.
+func (r *UDPRouteReconciler) getConflictingUDPRoute(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) (*gatewayv1alpha2.UDPRoute, error) {
+	port, err := dataplane.GetGatewayPort(gateway, udproute.Spec.ParentRefs)
+	if err != nil {
+		return nil, err
 	}
 
-	// delete the target from the dataplane
-	if _, err = r.BackendsClientManager.Delete(ctx, &vip); err != nil {
-		return err
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := r.Client.List(ctx, udproutes, client.MatchingFields{
+		routeParentGatewayKey: client.ObjectKeyFromObject(gateway).String(),
+	}); err != nil {
+		return nil, err
+	}
+
+	var conflict *gatewayv1alpha2.UDPRoute
+	for i := range udproutes.Items {
+		other := &udproutes.Items[i]
+		if other.UID == udproute.UID || other.DeletionTimestamp != nil || !isNamespaceWatched(r.WatchNamespaces, other.Namespace) {
+			continue
+		}
+		if !routeUsesGatewayPort(other.Namespace, other.Spec.ParentRefs, gateway, port) {
+			continue
+		}
+		if routeTakesPrecedence(other, udproute) && (conflict == nil || routeTakesPrecedence(other, conflict)) {
+			conflict = other
+		}
 	}
 
-	r.log.Info("successful data-plane DELETE")
+	return conflict, nil
+}
 
-	oldFinalizers := udproute.GetFinalizers()
-	newFinalizers := make([]string, 0, len(oldFinalizers)-1)
-	for _, finalizer := range oldFinalizers {
-		if finalizer != DataPlaneFinalizer {
-			newFinalizers = append(newFinalizers, finalizer)
+// updateUDPRouteStatus sets the provided conditions on the UDPRoute's
+// status for the provided Gateway, and patches the status if it changed.
+func (r *UDPRouteReconciler) updateUDPRouteStatus(ctx context.Context, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway, conds ...metav1.Condition) error {
+	parentRef, ok := parentRefForGateway(udproute.Namespace, udproute.Spec.ParentRefs, gateway)
+	if !ok {
+		return nil
+	}
+
+	oldUDPRoute := udproute.DeepCopy()
+	changed := false
+	for _, cond := range conds {
+		cond.ObservedGeneration = udproute.Generation
+		if setRouteCondition(&udproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName), parentRef, cond) {
+			changed = true
 		}
 	}
-	udproute.SetFinalizers(newFinalizers)
+	if !changed {
+		return nil
+	}
 
-	return r.Client.Update(ctx, udproute)
+	return r.Status().Patch(ctx, udproute, client.MergeFrom(oldUDPRoute))
 }
@@ -0,0 +1,579 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	controllerruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/internal/dataplane/client/dataplanetest"
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+// newUDPRouteTestObjects returns the same objects as newTCPRouteTestObjects,
+// with the Gateway listener and backend Service using UDP instead.
+func newUDPRouteTestObjects() []controllerruntimeclient.Object {
+	objs := newTCPRouteTestObjects()
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *gatewayv1beta1.Gateway:
+			o.Spec.Listeners[0].Name = "udp"
+			o.Spec.Listeners[0].Protocol = gatewayv1beta1.UDPProtocolType
+		case *corev1.Service:
+			o.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+		case *corev1.Endpoints:
+			o.Subsets[0].Ports[0].Protocol = corev1.ProtocolUDP
+		}
+	}
+	return objs
+}
+
+// newTestUDPRoute returns a UDPRoute attached to the test Gateway's UDP
+// listener, forwarding to the test backend Service.
+func newTestUDPRoute(name string, created time.Time) *gatewayv1alpha2.UDPRoute {
+	return &gatewayv1alpha2.UDPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test-namespace",
+			UID:               types.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+			Finalizers:        []string{DataPlaneFinalizer},
+		},
+		Spec: gatewayv1alpha2.UDPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{
+					Name: "test-gateway",
+					Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+				}},
+			},
+			Rules: []gatewayv1alpha2.UDPRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{{
+					BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+						Name: "test-backend",
+						Port: ptr.To(gatewayv1alpha2.PortNumber(80)),
+					},
+				}},
+			}},
+		},
+	}
+}
+
+func newTestUDPRouteReconciler(objs ...controllerruntimeclient.Object) (UDPRouteReconciler, *fakeBackendsUpdater) {
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(objs...).
+		WithIndex(&gatewayv1alpha2.UDPRoute{}, routeParentGatewayKey, udpRouteParentGateways).
+		WithIndex(&gatewayv1alpha2.UDPRoute{}, routeBackendServiceKey, udpRouteBackendServices).
+		WithIndex(&gatewayv1alpha2.UDPRoute{}, routeBackendConfigMapKey, udpRouteBackendConfigMaps).
+		WithIndex(&gatewayv1alpha2.TCPRoute{}, routeParentGatewayKey, tcpRouteParentGateways).
+		Build()
+
+	backends := &fakeBackendsUpdater{}
+	return UDPRouteReconciler{
+		Client:                fakeClient,
+		BackendsClientManager: backends,
+		ExternalNameResolver:  dataplane.NewExternalNameResolver(dataplane.NetHostResolver{}, dataplane.DefaultExternalNameRefreshInterval),
+		pushedTargets:         newPushedTargets(),
+	}, backends
+}
+
+// newTestDataPlane returns a BackendsClientManager connected to an in-memory
+// dataplane, which is stopped at the end of the test.
+func newTestDataPlane(t *testing.T) (*dataplane.BackendsClientManager, *dataplanetest.Server) {
+	server := dataplanetest.NewServer()
+	server.Start()
+	t.Cleanup(server.Stop)
+
+	manager, err := dataplane.NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, dataplane.KeepaliveConfig{})
+	require.NoError(t, err)
+	manager.SetContextDialer(server.Dialer())
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "dataplane", Namespace: vars.DefaultNamespace},
+		Status:     corev1.PodStatus{PodIP: "10.244.0.2"},
+	}
+	_, err = manager.SetClientsList(map[types.NamespacedName]corev1.Pod{{Namespace: pod.Namespace, Name: pod.Name}: pod})
+	require.NoError(t, err)
+	t.Cleanup(manager.Close)
+
+	return manager, server
+}
+
+func TestUDPRouteReconciler_dataplaneLifecycle(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-lifecycle", time.Now())
+	route.Finalizers = nil
+	reconciler, _ := newTestUDPRouteReconciler(append(newUDPRouteTestObjects(), route)...)
+	manager, server := newTestDataPlane(t)
+	reconciler.BackendsClientManager = manager
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080, Protocol: dataplane.Vip_UDP}
+
+	t.Log("the finalizer is set before anything is pushed to the dataplane")
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	udproute := new(gatewayv1alpha2.UDPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, udproute))
+	require.Contains(t, udproute.Finalizers, DataPlaneFinalizer)
+	require.Empty(t, server.Updates())
+
+	t.Log("the compiled Targets are then pushed to the dataplane")
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	gateway := new(gatewayv1beta1.Gateway)
+	require.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Namespace: "test-namespace", Name: "test-gateway"}, gateway))
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, udproute))
+	expected, err := dataplane.CompileUDPRouteToDataPlaneBackend(ctx, reconciler.Client, reconciler.ExternalNameResolver, udproute, gateway)
+	require.NoError(t, err)
+	require.Equal(t, []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}}, expected.Targets)
+	programmed, ok := server.Backends(vip)
+	require.True(t, ok)
+	require.True(t, proto.Equal(expected, programmed))
+
+	t.Log("once the UDPRoute is deleted, its VIP is deleted from the dataplane and the finalizer removed")
+	require.NoError(t, reconciler.Client.Delete(ctx, udproute))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, server.Deletes(), 1)
+	require.True(t, proto.Equal(vip, server.Deletes()[0]))
+	_, ok = server.Backends(vip)
+	require.False(t, ok)
+	err = reconciler.Client.Get(ctx, req.NamespacedName, udproute)
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestUDPRouteReconciler_deleteNeverProgrammed(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-never-programmed", time.Now())
+	reconciler, _ := newTestUDPRouteReconciler(append(newUDPRouteTestObjects(), route)...)
+	manager, server := newTestDataPlane(t)
+	reconciler.BackendsClientManager = manager
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("deleting a UDPRoute whose VIP was never programmed succeeds and removes the finalizer")
+	require.NoError(t, reconciler.Client.Delete(ctx, route))
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, server.Updates())
+	require.Len(t, server.Deletes(), 1)
+	err = reconciler.Client.Get(ctx, req.NamespacedName, new(gatewayv1alpha2.UDPRoute))
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestUDPRouteReconciler_ipv6GatewayAddress(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-ipv6", time.Now())
+	objs := newUDPRouteTestObjects()
+	for _, obj := range objs {
+		if gateway, ok := obj.(*gatewayv1beta1.Gateway); ok {
+			gateway.Status.Addresses[0].Value = "fd00::f0"
+		}
+	}
+	reconciler, backends := newTestUDPRouteReconciler(append(objs, route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("a UDPRoute of a Gateway with an IPv6 address is deleted without deleting a VIP")
+	require.NoError(t, reconciler.Client.Delete(ctx, route))
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, backends.deletes)
+	err = reconciler.Client.Get(ctx, req.NamespacedName, new(gatewayv1alpha2.UDPRoute))
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestUDPRouteReconciler_endpointsNotReady(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-endpoints-not-ready", time.Now())
+	objs := newUDPRouteTestObjects()
+	var endpoints *corev1.Endpoints
+	for _, obj := range objs {
+		if e, ok := obj.(*corev1.Endpoints); ok {
+			endpoints = e
+			endpoints.Subsets[0].NotReadyAddresses = endpoints.Subsets[0].Addresses
+			endpoints.Subsets[0].Addresses = nil
+		}
+	}
+	reconciler, _ := newTestUDPRouteReconciler(append(objs, route)...)
+	manager, server := newTestDataPlane(t)
+	reconciler.BackendsClientManager = manager
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080, Protocol: dataplane.Vip_UDP}
+
+	t.Log("the UDPRoute is requeued while the endpoints are not ready")
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, result.RequeueAfter)
+	require.Empty(t, server.Updates())
+
+	t.Log("the backends are programmed once the endpoints are ready")
+	require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(endpoints), endpoints))
+	endpoints.Subsets[0].Addresses = endpoints.Subsets[0].NotReadyAddresses
+	endpoints.Subsets[0].NotReadyAddresses = nil
+	require.NoError(t, reconciler.Client.Update(ctx, endpoints))
+	require.Contains(t, reconciler.mapServiceToUDPRoutes(ctx, endpoints), req)
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	programmed, ok := server.Backends(vip)
+	require.True(t, ok)
+	require.Len(t, programmed.GetTargets(), 1)
+	require.True(t, proto.Equal(&dataplane.Target{Daddr: 0x0af4000a, Dport: 80}, programmed.GetTargets()[0]))
+}
+
+func TestUDPRouteReconciler_noHealthyBackends(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-no-backends", time.Now())
+	objs := newUDPRouteTestObjects()
+	for _, obj := range objs {
+		if endpoints, ok := obj.(*corev1.Endpoints); ok {
+			endpoints.Subsets = nil
+		}
+	}
+	reconciler, backends := newTestUDPRouteReconciler(append(objs, route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	// the VIP must be programmed without any backends, for the dataplane to
+	// answer with ICMP port unreachable.
+	require.Len(t, backends.updates, 1)
+	require.NotNil(t, backends.updates[0].Vip)
+	require.Equal(t, uint32(0xac1200f0), backends.updates[0].Vip.Ip)
+	require.Equal(t, uint32(8080), backends.updates[0].Vip.Port)
+	require.Empty(t, backends.updates[0].Targets)
+
+	// the UDPRoute is Programmed, but its status tells that it has no backends.
+	udproute := new(gatewayv1alpha2.UDPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, udproute))
+	require.Len(t, udproute.Status.Parents, 1)
+	programmed := meta.FindStatusCondition(udproute.Status.Parents[0].Conditions, string(RouteConditionProgrammed))
+	require.NotNil(t, programmed)
+	require.Equal(t, metav1.ConditionTrue, programmed.Status)
+	require.Equal(t, string(RouteReasonNoHealthyBackends), programmed.Reason)
+}
+
+func TestUDPRouteReconciler_releaseUnmanaged(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-unmanaged", time.Now())
+	objs := newUDPRouteTestObjects()
+	for _, obj := range objs {
+		if gwc, ok := obj.(*gatewayv1beta1.GatewayClass); ok {
+			gwc.Spec.ControllerName = "example.com/other-controller"
+		}
+	}
+	reconciler, backends := newTestUDPRouteReconciler(append(objs, route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("the UDP VIP of a UDPRoute handed over to another controller is deleted from the dataplane")
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, backends.deletes, 1)
+	require.True(t, proto.Equal(&dataplane.Vip{Ip: 0xac1200f0, Port: 8080, Protocol: dataplane.Vip_UDP}, backends.deletes[0]))
+	udproute := new(gatewayv1alpha2.UDPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, udproute))
+	require.NotContains(t, udproute.Finalizers, DataPlaneFinalizer)
+}
+
+func TestUDPRouteReconciler_unsupportedValue(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-unsupported-value", time.Now())
+	route.Annotations = map[string]string{dataplane.ActionAnnotation: "Deny"}
+	reconciler, backends := newTestUDPRouteReconciler(append(newUDPRouteTestObjects(), route)...)
+	req := reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)}
+
+	t.Log("the UDPRoute isn't accepted, and isn't retried until it's fixed")
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, result)
+	require.Empty(t, backends.updates)
+
+	udproute := new(gatewayv1alpha2.UDPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, req.NamespacedName, udproute))
+	require.Len(t, udproute.Status.Parents, 1)
+	accepted := meta.FindStatusCondition(udproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+	require.NotNil(t, accepted)
+	require.Equal(t, metav1.ConditionFalse, accepted.Status)
+	require.Equal(t, string(gatewayv1alpha2.RouteReasonUnsupportedValue), accepted.Reason)
+	require.Contains(t, accepted.Message, dataplane.ActionAnnotation)
+}
+
+func TestUDPRouteReconciler_resolvedRefs(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-resolved-refs", time.Now())
+	route.Spec.Rules[0].BackendRefs = append(route.Spec.Rules[0].BackendRefs, gatewayv1alpha2.BackendRef{
+		BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+			Name: "missing-backend",
+			Port: ptr.To(gatewayv1alpha2.PortNumber(80)),
+		},
+	})
+	reconciler, backends := newTestUDPRouteReconciler(append(newUDPRouteTestObjects(), route)...)
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+	require.Error(t, err)
+	require.Empty(t, backends.updates)
+
+	udproute := new(gatewayv1alpha2.UDPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(route), udproute))
+	require.Len(t, udproute.Status.Parents, 1)
+	cond := meta.FindStatusCondition(udproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionResolvedRefs))
+	require.NotNil(t, cond)
+	require.Equal(t, metav1.ConditionFalse, cond.Status)
+	require.Equal(t, "1 of the 2 backend references could not be resolved: Service test-namespace/missing-backend: not found", cond.Message)
+}
+
+func TestUDPRouteReconciler_vipConflict(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	routes := []*gatewayv1alpha2.UDPRoute{
+		newTestUDPRoute("route-b", now),
+		newTestUDPRoute("route-c", now.Add(-time.Minute)),
+		newTestUDPRoute("route-a", now),
+	}
+	objs := newUDPRouteTestObjects()
+	for _, route := range routes {
+		objs = append(objs, route)
+	}
+	reconciler, backends := newTestUDPRouteReconciler(objs...)
+
+	for _, route := range routes {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+		require.NoError(t, err)
+	}
+
+	t.Log("only the oldest route is programmed, the others are conflicting")
+	require.Len(t, backends.updates, 1)
+	for _, route := range routes {
+		udproute := new(gatewayv1alpha2.UDPRoute)
+		require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(route), udproute))
+		require.Len(t, udproute.Status.Parents, 1)
+		accepted := meta.FindStatusCondition(udproute.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+		require.NotNil(t, accepted)
+		if route.Name == "route-c" {
+			require.Equal(t, metav1.ConditionTrue, accepted.Status)
+			continue
+		}
+		require.Equal(t, metav1.ConditionFalse, accepted.Status)
+		require.Equal(t, string(RouteReasonConflict), accepted.Reason)
+		require.Contains(t, accepted.Message, "route-c")
+	}
+
+	t.Log("changes to a route enqueue the other routes on the same VIP")
+	reqs := reconciler.mapUDPRouteToConflictingUDPRoutes(ctx, routes[1])
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[0])},
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(routes[2])},
+	}, reqs)
+}
+
+func TestUDPRouteReconciler_maxRoutesPerGateway(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	tcproute := newTestTCPRoute("route-tcp", now.Add(-time.Minute))
+	udproute := newTestUDPRoute("route-udp", now)
+	reconciler, backends := newTestUDPRouteReconciler(append(withMaxRoutesPerGateway(newUDPRouteTestObjects(), 1), tcproute, udproute)...)
+
+	t.Log("the routes of all kinds attached to the Gateway count towards the limit")
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(udproute)})
+	require.NoError(t, err)
+	require.Empty(t, backends.updates)
+	route := new(gatewayv1alpha2.UDPRoute)
+	require.NoError(t, reconciler.Client.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(udproute), route))
+	require.Len(t, route.Status.Parents, 1)
+	accepted := meta.FindStatusCondition(route.Status.Parents[0].Conditions, string(gatewayv1alpha2.RouteConditionAccepted))
+	require.NotNil(t, accepted)
+	require.Equal(t, metav1.ConditionFalse, accepted.Status)
+	require.Equal(t, string(RouteReasonTooManyRoutes), accepted.Reason)
+
+	t.Log("changes to the TCPRoute enqueue the rejected UDPRoute")
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(udproute)},
+	}, reconciler.mapRouteToTooManyUDPRoutes(ctx, tcproute))
+}
+
+func TestUDPRouteReconciler_isUDPRouteManaged(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		controllerName gatewayv1beta1.GatewayController
+		mutate         func(route *gatewayv1alpha2.UDPRoute, gatewayClass *gatewayv1beta1.GatewayClass)
+		getErr         error
+		managed        bool
+		expectErr      bool
+	}{
+		{
+			name:    "a UDPRoute referencing a UDP listener of a managed Gateway is managed",
+			managed: true,
+		},
+		{
+			name: "a UDPRoute without a port in its parentRef is not managed",
+			mutate: func(route *gatewayv1alpha2.UDPRoute, _ *gatewayv1beta1.GatewayClass) {
+				route.Spec.ParentRefs[0].Port = nil
+			},
+		},
+		{
+			name: "a UDPRoute referencing a port without listener is not managed",
+			mutate: func(route *gatewayv1alpha2.UDPRoute, _ *gatewayv1beta1.GatewayClass) {
+				route.Spec.ParentRefs[0].Port = ptr.To(gatewayv1alpha2.PortNumber(9090))
+			},
+		},
+		{
+			name: "a UDPRoute referencing a Gateway which doesn't exist is not managed",
+			mutate: func(route *gatewayv1alpha2.UDPRoute, _ *gatewayv1beta1.GatewayClass) {
+				route.Spec.ParentRefs[0].Name = "missing-gateway"
+			},
+		},
+		{
+			name: "a UDPRoute referencing a Gateway of another controller's GatewayClass is not managed",
+			mutate: func(_ *gatewayv1alpha2.UDPRoute, gatewayClass *gatewayv1beta1.GatewayClass) {
+				gatewayClass.Spec.ControllerName = "example.com/other-controller"
+			},
+		},
+		{
+			name:           "a UDPRoute referencing a Gateway of the configured controller's GatewayClass is managed",
+			controllerName: "example.com/other-controller",
+			mutate: func(_ *gatewayv1alpha2.UDPRoute, gatewayClass *gatewayv1beta1.GatewayClass) {
+				gatewayClass.Spec.ControllerName = "example.com/other-controller"
+			},
+			managed: true,
+		},
+		{
+			name:      "failing to get the Gateway is an error",
+			getErr:    apierrors.NewServiceUnavailable("cache not synced"),
+			expectErr: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			route := newTestUDPRoute("route-managed", time.Now())
+			objs := newUDPRouteTestObjects()
+			for _, obj := range objs {
+				if gatewayClass, ok := obj.(*gatewayv1beta1.GatewayClass); ok && tt.mutate != nil {
+					tt.mutate(route, gatewayClass)
+				}
+			}
+			reconciler, _ := newTestUDPRouteReconciler(append(objs, route)...)
+			reconciler.ControllerName = tt.controllerName
+			if tt.getErr != nil {
+				reconciler.Client = interceptor.NewClient(reconciler.Client.(controllerruntimeclient.WithWatch), interceptor.Funcs{
+					Get: func(ctx context.Context, c controllerruntimeclient.WithWatch, key controllerruntimeclient.ObjectKey, obj controllerruntimeclient.Object, opts ...controllerruntimeclient.GetOption) error {
+						if _, ok := obj.(*gatewayv1beta1.Gateway); ok {
+							return tt.getErr
+						}
+						return c.Get(ctx, key, obj, opts...)
+					},
+				})
+			}
+
+			managed, gateway, err := reconciler.isUDPRouteManaged(ctx, *route)
+			if tt.expectErr {
+				require.ErrorIs(t, err, tt.getErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.managed, managed)
+			if tt.managed {
+				require.Equal(t, "test-gateway", gateway.Name)
+			} else {
+				require.Nil(t, gateway)
+			}
+		})
+	}
+}
+
+func TestUDPRouteReconciler_clusterScopedGatewayClass(t *testing.T) {
+	ctx := context.Background()
+	route := newTestUDPRoute("route-cluster-scoped", time.Now())
+	reconciler, _ := newTestUDPRouteReconciler(append(newUDPRouteTestObjects(), route)...)
+	reconciler.Client = interceptor.NewClient(reconciler.Client.(controllerruntimeclient.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c controllerruntimeclient.WithWatch, key controllerruntimeclient.ObjectKey, obj controllerruntimeclient.Object, opts ...controllerruntimeclient.GetOption) error {
+			if _, ok := obj.(*gatewayv1beta1.GatewayClass); ok && key.Namespace != "" {
+				return apierrors.NewNotFound(gatewayv1beta1.Resource("gatewayclasses"), key.Name)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+
+	t.Log("the GatewayClass of the route's Gateway is looked up without the route's namespace")
+	managed, gateway, err := reconciler.isUDPRouteManaged(ctx, *route)
+	require.NoError(t, err)
+	require.True(t, managed)
+	require.Equal(t, "test-gateway", gateway.Name)
+}
+
+func TestUDPRouteReconciler_sharedListenerPort(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		sectionName *gatewayv1alpha2.SectionName
+		managed     bool
+	}{
+		{
+			name:    "the UDPRoute attaches to the UDP listener sharing the port",
+			managed: true,
+		},
+		{
+			name:        "the UDPRoute attaches to the UDP listener selected by its section name",
+			sectionName: ptr.To(gatewayv1alpha2.SectionName("udp")),
+			managed:     true,
+		},
+		{
+			name:        "the UDPRoute doesn't attach to the TCP listener sharing the port",
+			sectionName: ptr.To(gatewayv1alpha2.SectionName("tcp")),
+			managed:     false,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			route := newTestUDPRoute("route-shared-port", time.Now())
+			route.Spec.ParentRefs[0].SectionName = tt.sectionName
+			reconciler, backends := newTestUDPRouteReconciler(append(withSharedPortListeners(newUDPRouteTestObjects()), route)...)
+
+			managed, _, err := reconciler.isUDPRouteManaged(ctx, *route)
+			require.NoError(t, err)
+			require.Equal(t, tt.managed, managed)
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: controllerruntimeclient.ObjectKeyFromObject(route)})
+			require.NoError(t, err)
+			if !tt.managed {
+				require.Empty(t, backends.updates)
+				return
+			}
+			require.Len(t, backends.updates, 1)
+			require.Equal(t, uint32(8080), backends.updates[0].Vip.Port)
+		})
+	}
+}
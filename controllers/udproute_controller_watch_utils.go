@@ -54,6 +54,9 @@ func (r *UDPRouteReconciler) mapDataPlaneDaemonsetToUDPRoutes(ctx context.Contex
 		return
 	}
 
+	// the dataplane instances which were added need the whole backend sets.
+	r.pushedTargets.reset()
+
 	udproutes := &gatewayv1alpha2.UDPRouteList{}
 	if err := r.Client.List(ctx, udproutes); err != nil {
 		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
@@ -62,6 +65,9 @@ func (r *UDPRouteReconciler) mapDataPlaneDaemonsetToUDPRoutes(ctx context.Contex
 	}
 
 	for _, udproute := range udproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+			continue
+		}
 		reqs = append(reqs, reconcile.Request{
 			NamespacedName: types.NamespacedName{
 				Namespace: udproute.Namespace,
@@ -73,9 +79,11 @@ func (r *UDPRouteReconciler) mapDataPlaneDaemonsetToUDPRoutes(ctx context.Contex
 	return
 }
 
-// mapGatewayToUDPRoutes enqueues reconcilation for all UDPRoutes whenever
-// an event occurs on a relevant Gateway.
-func (r *UDPRouteReconciler) mapGatewayToUDPRoutes(_ context.Context, obj client.Object) (reqs []reconcile.Request) {
+// mapGatewayToUDPRoutes enqueues reconcilation for the UDPRoutes which
+// reference a Gateway whenever an event occurs on it. The UDPRoutes are
+// listed with the routeParentGatewayKey index, so that the routes of other
+// Gateways aren't listed nor enqueued.
+func (r *UDPRouteReconciler) mapGatewayToUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
 	gateway, ok := obj.(*gatewayv1beta1.Gateway)
 	if !ok {
 		r.log.Error(fmt.Errorf("invalid type in map func"), "failed to map gateways to udproutes", "expected", "*gatewayv1beta1.Gateway", "received", reflect.TypeOf(obj))
@@ -83,19 +91,219 @@ func (r *UDPRouteReconciler) mapGatewayToUDPRoutes(_ context.Context, obj client
 	}
 
 	udproutes := new(gatewayv1alpha2.UDPRouteList)
-	if err := r.Client.List(context.Background(), udproutes); err != nil {
+	if err := r.Client.List(ctx, udproutes, client.MatchingFields{
+		routeParentGatewayKey: client.ObjectKeyFromObject(gateway).String(),
+	}); err != nil {
 		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
 		r.log.Error(err, "could not enqueue UDPRoutes for Gateway update")
 		return
 	}
 
 	for _, udproute := range udproutes.Items {
-		for _, parentRef := range udproute.Spec.ParentRefs {
-			namespace := udproute.Namespace
-			if parentRef.Namespace != nil {
-				namespace = string(*parentRef.Namespace)
+		if !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: udproute.Namespace,
+			Name:      udproute.Name,
+		}})
+	}
+
+	return
+}
+
+// mapServiceToUDPRoutes enqueues reconcilation for the UDPRoutes which
+// reference a Service as a backend whenever an event occurs on the Service or
+// on its Endpoints, which have the same name, so that changes to the backends
+// are pushed to the dataplane. The UDPRoutes are listed with the
+// routeBackendServiceKey index.
+func (r *UDPRouteReconciler) mapServiceToUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := r.Client.List(ctx, udproutes, client.MatchingFields{
+		routeBackendServiceKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.log.Error(err, "could not enqueue UDPRoutes for Service update")
+		return
+	}
+
+	for _, udproute := range udproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: udproute.Namespace,
+			Name:      udproute.Name,
+		}})
+	}
+
+	return
+}
+
+// mapConfigMapToUDPRoutes enqueues reconcilation for the UDPRoutes which
+// reference a ConfigMap listing static endpoints as a backend whenever an
+// event occurs on the ConfigMap, so that changes to the endpoints are pushed
+// to the dataplane. The UDPRoutes are listed with the
+// routeBackendConfigMapKey index.
+func (r *UDPRouteReconciler) mapConfigMapToUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	udproutes := new(gatewayv1alpha2.UDPRouteList)
+	if err := r.Client.List(ctx, udproutes, client.MatchingFields{
+		routeBackendConfigMapKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.log.Error(err, "could not enqueue UDPRoutes for ConfigMap update")
+		return
+	}
+
+	for _, udproute := range udproutes.Items {
+		if !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: udproute.Namespace,
+			Name:      udproute.Name,
+		}})
+	}
+
+	return
+}
+
+// mapReferenceGrantToUDPRoutes enqueues reconcilation for the UDPRoutes in the
+// namespaces a ReferenceGrant grants access from, which reference backends in
+// the ReferenceGrant's namespace. This ensures that cross-namespace references
+// are resolved as soon as the grant which permits them is created.
+func (r *UDPRouteReconciler) mapReferenceGrantToUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	grant, ok := obj.(*gatewayv1beta1.ReferenceGrant)
+	if !ok {
+		r.log.Error(fmt.Errorf("invalid type in map func"), "failed to map referencegrants to udproutes", "expected", "*gatewayv1beta1.ReferenceGrant", "received", reflect.TypeOf(obj))
+		return
+	}
+
+	namespaces := map[string]struct{}{}
+	for _, from := range grant.Spec.From {
+		if string(from.Group) == gatewayv1beta1.GroupName && from.Kind == UDPRouteKind {
+			namespaces[string(from.Namespace)] = struct{}{}
+		}
+	}
+
+	for namespace := range namespaces {
+		if !isNamespaceWatched(r.WatchNamespaces, namespace) {
+			continue
+		}
+		udproutes := new(gatewayv1alpha2.UDPRouteList)
+		if err := r.Client.List(ctx, udproutes, client.InNamespace(namespace)); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue UDPRoutes for ReferenceGrant update")
+			return
+		}
+
+		for _, udproute := range udproutes.Items {
+			var backendRefs []gatewayv1alpha2.BackendRef
+			for _, rule := range udproute.Spec.Rules {
+				backendRefs = append(backendRefs, rule.BackendRefs...)
+			}
+			if referenceGrantAppliesToRoute(grant, UDPRouteKind, udproute.Namespace, backendRefs) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: udproute.Namespace,
+					Name:      udproute.Name,
+				}})
+			}
+		}
+	}
+
+	return
+}
+
+// mapUDPRouteToConflictingUDPRoutes enqueues reconcilation for the other
+// UDPRoutes programmed on the same Gateway VIP as the provided UDPRoute
+// whenever it changes or is deleted, so that the route which takes
+// precedence on the VIP is programmed and the others are marked conflicting.
+func (r *UDPRouteReconciler) mapUDPRouteToConflictingUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	changed, ok := obj.(*gatewayv1alpha2.UDPRoute)
+	if !ok {
+		r.log.Error(fmt.Errorf("invalid type in map func"), "failed to map udproutes to conflicting udproutes", "expected", "*gatewayv1alpha2.UDPRoute", "received", reflect.TypeOf(obj))
+		return
+	}
+
+	// only the UDPRoutes attached to the same Gateways can share a VIP.
+	seen := map[types.UID]struct{}{changed.UID: {}}
+	for _, gateway := range routeParentGateways(changed.Namespace, changed.Spec.ParentRefs) {
+		udproutes := new(gatewayv1alpha2.UDPRouteList)
+		if err := r.Client.List(ctx, udproutes, client.MatchingFields{routeParentGatewayKey: gateway}); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue conflicting UDPRoutes for UDPRoute update")
+			return
+		}
+
+		for _, udproute := range udproutes.Items {
+			if _, ok := seen[udproute.UID]; ok || !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+				continue
+			}
+			if routesShareGatewayPort(changed.Namespace, changed.Spec.ParentRefs, udproute.Namespace, udproute.Spec.ParentRefs) {
+				seen[udproute.UID] = struct{}{}
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: udproute.Namespace,
+					Name:      udproute.Name,
+				}})
+			}
+		}
+	}
+
+	return
+}
+
+// mapGatewayClassParametersToUDPRoutes enqueues reconcilation for the
+// UDPRoutes attached to the Gateways whose routes limit might have changed,
+// whenever an event occurs on their GatewayClass or on the ConfigMap holding
+// its parameters.
+func (r *UDPRouteReconciler) mapGatewayClassParametersToUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	gateways, err := gatewayClassParametersGateways(ctx, r.Client, obj)
+	if err != nil {
+		// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+		r.log.Error(err, "could not enqueue UDPRoutes for GatewayClass parameters update")
+		return
+	}
+
+	for _, gateway := range gateways {
+		udproutes := new(gatewayv1alpha2.UDPRouteList)
+		if err := r.Client.List(ctx, udproutes, client.MatchingFields{routeParentGatewayKey: gateway}); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue UDPRoutes for GatewayClass parameters update")
+			return
+		}
+
+		for _, udproute := range udproutes.Items {
+			if !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+				continue
+			}
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: udproute.Namespace,
+				Name:      udproute.Name,
+			}})
+		}
+	}
+
+	return
+}
+
+// mapRouteToTooManyUDPRoutes enqueues reconcilation for the UDPRoutes which
+// weren't accepted because their Gateway had too many routes, whenever an
+// event occurs on a TCPRoute or an UDPRoute attached to the same Gateway, as
+// it might make room for them.
+func (r *UDPRouteReconciler) mapRouteToTooManyUDPRoutes(ctx context.Context, obj client.Object) (reqs []reconcile.Request) {
+	for _, gateway := range changedRouteParentGateways(obj) {
+		udproutes := new(gatewayv1alpha2.UDPRouteList)
+		if err := r.Client.List(ctx, udproutes, client.MatchingFields{routeParentGatewayKey: gateway}); err != nil {
+			// TODO: https://github.com/kubernetes-sigs/controller-runtime/issues/1996
+			r.log.Error(err, "could not enqueue UDPRoutes exceeding the routes limit for route update")
+			return
+		}
+
+		for _, udproute := range udproutes.Items {
+			if udproute.UID == obj.GetUID() || !isNamespaceWatched(r.WatchNamespaces, udproute.Namespace) {
+				continue
 			}
-			if parentRef.Name == gatewayv1alpha2.ObjectName(gateway.Name) && namespace == gateway.Namespace {
+			if routeHasTooManyRoutes(udproute.Status.RouteStatus, controllerNameOrDefault(r.ControllerName)) {
 				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
 					Namespace: udproute.Namespace,
 					Name:      udproute.Name,
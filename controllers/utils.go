@@ -19,7 +19,13 @@ package controllers
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
 )
 
 const (
@@ -29,7 +35,71 @@ const (
 )
 
 func setDataPlaneFinalizer(ctx context.Context, c client.Client, obj client.Object) error {
-	finalizers := obj.GetFinalizers()
-	obj.SetFinalizers(append(finalizers, DataPlaneFinalizer))
+	if !controllerutil.AddFinalizer(obj, DataPlaneFinalizer) {
+		return nil
+	}
+	return c.Update(ctx, obj)
+}
+
+// removeDataPlaneFinalizer removes the DataPlaneFinalizer from the provided
+// object, updating it only if the finalizer was present.
+func removeDataPlaneFinalizer(ctx context.Context, c client.Client, obj client.Object) error {
+	if !controllerutil.RemoveFinalizer(obj, DataPlaneFinalizer) {
+		return nil
+	}
 	return c.Update(ctx, obj)
 }
+
+// controllerNameOrDefault returns the provided GatewayClass controller name,
+// or the default GatewayClassControllerName if it's empty.
+func controllerNameOrDefault(name gatewayv1beta1.GatewayController) gatewayv1beta1.GatewayController {
+	if name == "" {
+		return vars.GatewayClassControllerName
+	}
+	return name
+}
+
+// isGatewayClassManaged indicates whether the GatewayClass with the provided
+// name exists and is managed by the provided controller name. A missing
+// GatewayClass isn't managed, any other error is returned. The reader should
+// be the cached client of the manager, as this is called for every Gateway
+// and route event.
+func isGatewayClassManaged(ctx context.Context, c client.Reader, name gatewayv1beta1.ObjectName, controllerName gatewayv1beta1.GatewayController) (bool, error) {
+	gatewayClass := new(gatewayv1beta1.GatewayClass)
+	if err := c.Get(ctx, client.ObjectKey{Name: string(name)}, gatewayClass); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return gatewayClass.Spec.ControllerName == controllerNameOrDefault(controllerName), nil
+}
+
+// isGatewayClassAccepted indicates whether the GatewayClass with the provided
+// name exists and has been accepted by the GatewayClass reconciler. A missing
+// GatewayClass isn't accepted, any other error is returned.
+func isGatewayClassAccepted(ctx context.Context, c client.Reader, name gatewayv1beta1.ObjectName) (bool, error) {
+	gatewayClass := new(gatewayv1beta1.GatewayClass)
+	if err := c.Get(ctx, client.ObjectKey{Name: string(name)}, gatewayClass); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return meta.IsStatusConditionTrue(gatewayClass.Status.Conditions, string(gatewayv1beta1.GatewayClassConditionStatusAccepted)), nil
+}
+
+// isNamespaceWatched indicates whether objects in the provided namespace are
+// in scope for a controller restricted to the provided watch namespaces. All
+// namespaces are in scope if no watch namespaces are provided.
+func isNamespaceWatched(watchNamespaces []string, namespace string) bool {
+	if len(watchNamespaces) == 0 {
+		return true
+	}
+	for _, watched := range watchNamespaces {
+		if watched == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	controllerruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestRemoveDataPlaneFinalizer(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		finalizers []string
+		expected   []string
+	}{
+		{
+			name:       "the finalizer is removed",
+			finalizers: []string{DataPlaneFinalizer},
+		},
+		{
+			name: "nothing happens if the finalizer is absent",
+		},
+		{
+			name:       "other finalizers are retained",
+			finalizers: []string{"example.com/before", DataPlaneFinalizer, "example.com/after"},
+			expected:   []string{"example.com/before", "example.com/after"},
+		},
+		{
+			name:       "only other finalizers",
+			finalizers: []string{"example.com/before", "example.com/after"},
+			expected:   []string{"example.com/before", "example.com/after"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			udproute := &gatewayv1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-udproute",
+					Namespace:  "test-namespace",
+					Finalizers: tt.finalizers,
+				},
+			}
+			fakeClient := fakectrlruntimeclient.
+				NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(udproute).
+				Build()
+
+			require.NoError(t, removeDataPlaneFinalizer(ctx, fakeClient, udproute))
+			require.ElementsMatch(t, tt.expected, udproute.Finalizers)
+
+			stored := &gatewayv1alpha2.UDPRoute{}
+			require.NoError(t, fakeClient.Get(ctx, controllerruntimeclient.ObjectKeyFromObject(udproute), stored))
+			require.ElementsMatch(t, tt.expected, stored.Finalizers)
+		})
+	}
+}
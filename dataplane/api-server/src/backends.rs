@@ -5,6 +5,39 @@ pub struct Vip {
     pub ip: u32,
     #[prost(uint32, tag = "2")]
     pub port: u32,
+    /// protocol is the transport protocol of the traffic to the VIP, a TCP and
+    /// a UDP VIP can share the same address and port.
+    #[prost(enumeration = "vip::Protocol", tag = "3")]
+    pub protocol: i32,
+}
+/// Nested message and enum types in `Vip`.
+pub mod vip {
+    #[derive(Clone, Copy, Debug, PartialEq, Eq, Hash, PartialOrd, Ord, ::prost::Enumeration)]
+    #[repr(i32)]
+    pub enum Protocol {
+        Tcp = 0,
+        Udp = 1,
+    }
+    impl Protocol {
+        /// String value of the enum field names used in the ProtoBuf definition.
+        ///
+        /// The values are not transformed in any way and thus are considered stable
+        /// (if the ProtoBuf definition does not change) and safe for programmatic use.
+        pub fn as_str_name(&self) -> &'static str {
+            match self {
+                Protocol::Tcp => "TCP",
+                Protocol::Udp => "UDP",
+            }
+        }
+        /// Creates an enum from field names used in the ProtoBuf definition.
+        pub fn from_str_name(value: &str) -> ::core::option::Option<Self> {
+            match value {
+                "TCP" => Some(Self::Tcp),
+                "UDP" => Some(Self::Udp),
+                _ => None,
+            }
+        }
+    }
 }
 #[allow(clippy::derive_partial_eq_without_eq)]
 #[derive(Clone, PartialEq, ::prost::Message)]
@@ -15,6 +48,11 @@ pub struct Target {
     pub dport: u32,
     #[prost(uint32, optional, tag = "3")]
     pub ifindex: ::core::option::Option<u32>,
+    /// max_connections is the maximum number of concurrent connections the
+    /// backend receives, new connections are sent to the other backends once
+    /// it's reached. The backend has no limit if it's unset or zero.
+    #[prost(uint32, optional, tag = "4")]
+    pub max_connections: ::core::option::Option<u32>,
 }
 #[allow(clippy::derive_partial_eq_without_eq)]
 #[derive(Clone, PartialEq, ::prost::Message)]
@@ -23,6 +61,51 @@ pub struct Targets {
     pub vip: ::core::option::Option<Vip>,
     #[prost(message, repeated, tag = "2")]
     pub targets: ::prost::alloc::vec::Vec<Target>,
+    /// tcp_idle_timeout_seconds is the time after which the TCP connections to
+    /// the VIP which had no traffic are evicted from the connection tracking.
+    /// The connections are only evicted once closed if it's unset or zero.
+    #[prost(uint32, optional, tag = "3")]
+    pub tcp_idle_timeout_seconds: ::core::option::Option<u32>,
+    /// action is applied to the new connections and datagrams to the VIP, the
+    /// established TCP connections are kept until they're closed.
+    #[prost(enumeration = "targets::Action", tag = "4")]
+    pub action: i32,
+}
+/// Nested message and enum types in `Targets`.
+pub mod targets {
+    #[derive(Clone, Copy, Debug, PartialEq, Eq, Hash, PartialOrd, Ord, ::prost::Enumeration)]
+    #[repr(i32)]
+    pub enum Action {
+        /// FORWARD load balances the traffic to the VIP across the targets.
+        Forward = 0,
+        /// REJECT answers the traffic to the VIP with ICMP port unreachable
+        /// messages, the targets are ignored.
+        Reject = 1,
+        /// DROP silently drops the traffic to the VIP, the targets are ignored.
+        Drop = 2,
+    }
+    impl Action {
+        /// String value of the enum field names used in the ProtoBuf definition.
+        ///
+        /// The values are not transformed in any way and thus are considered stable
+        /// (if the ProtoBuf definition does not change) and safe for programmatic use.
+        pub fn as_str_name(&self) -> &'static str {
+            match self {
+                Action::Forward => "FORWARD",
+                Action::Reject => "REJECT",
+                Action::Drop => "DROP",
+            }
+        }
+        /// Creates an enum from field names used in the ProtoBuf definition.
+        pub fn from_str_name(value: &str) -> ::core::option::Option<Self> {
+            match value {
+                "FORWARD" => Some(Self::Forward),
+                "REJECT" => Some(Self::Reject),
+                "DROP" => Some(Self::Drop),
+                _ => None,
+            }
+        }
+    }
 }
 #[allow(clippy::derive_partial_eq_without_eq)]
 #[derive(Clone, PartialEq, ::prost::Message)]
@@ -42,6 +125,95 @@ pub struct InterfaceIndexConfirmation {
     #[prost(uint32, tag = "1")]
     pub ifindex: u32,
 }
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct ListBackendsRequest {}
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct BackendsList {
+    #[prost(message, repeated, tag = "1")]
+    pub backends: ::prost::alloc::vec::Vec<Targets>,
+}
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct StatusRequest {}
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct DataplaneStatus {
+    /// interface is the name of the interface the programs are attached to.
+    #[prost(string, tag = "1")]
+    pub interface: ::prost::alloc::string::String,
+    #[prost(uint32, tag = "2")]
+    pub ifindex: u32,
+    /// attach_mode is how the programs were attached: "tc" when the dataplane
+    /// attached them to the TC ingress and egress hooks itself, or "bpfd" when
+    /// they were loaded and attached by bpfd.
+    #[prost(string, tag = "3")]
+    pub attach_mode: ::prost::alloc::string::String,
+    /// vips is the number of VIPs programmed into the dataplane.
+    #[prost(uint32, tag = "4")]
+    pub vips: u32,
+    /// draining indicates whether the dataplane is draining, in which case it
+    /// refuses new connections.
+    #[prost(bool, tag = "5")]
+    pub draining: bool,
+}
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct DrainRequest {
+    /// grace_period_seconds is how long the established connections are kept
+    /// before the dataplane exits.
+    #[prost(uint32, tag = "1")]
+    pub grace_period_seconds: u32,
+}
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct WatchBackendsRequest {}
+#[allow(clippy::derive_partial_eq_without_eq)]
+#[derive(Clone, PartialEq, ::prost::Message)]
+pub struct BackendsEvent {
+    #[prost(enumeration = "backends_event::Type", tag = "1")]
+    pub r#type: i32,
+    #[prost(message, repeated, tag = "2")]
+    pub backends: ::prost::alloc::vec::Vec<Targets>,
+}
+/// Nested message and enum types in `BackendsEvent`.
+pub mod backends_event {
+    #[derive(Clone, Copy, Debug, PartialEq, Eq, Hash, PartialOrd, Ord, ::prost::Enumeration)]
+    #[repr(i32)]
+    pub enum Type {
+        /// SNAPSHOT events carry all the programmed backends. They're sent
+        /// first, and again whenever the watcher fell behind and missed
+        /// changes.
+        Snapshot = 0,
+        /// UPDATED events carry the backends of a VIP which was programmed.
+        Updated = 1,
+        /// DELETED events carry the VIP which was deleted, without targets.
+        Deleted = 2,
+    }
+    impl Type {
+        /// String value of the enum field names used in the ProtoBuf definition.
+        ///
+        /// The values are not transformed in any way and thus are considered stable
+        /// (if the ProtoBuf definition does not change) and safe for programmatic use.
+        pub fn as_str_name(&self) -> &'static str {
+            match self {
+                Type::Snapshot => "SNAPSHOT",
+                Type::Updated => "UPDATED",
+                Type::Deleted => "DELETED",
+            }
+        }
+        /// Creates an enum from field names used in the ProtoBuf definition.
+        pub fn from_str_name(value: &str) -> ::core::option::Option<Self> {
+            match value {
+                "SNAPSHOT" => Some(Self::Snapshot),
+                "UPDATED" => Some(Self::Updated),
+                "DELETED" => Some(Self::Deleted),
+                _ => None,
+            }
+        }
+    }
+}
 /// Generated client implementations.
 pub mod backends_client {
     #![allow(unused_variables, dead_code, missing_docs, clippy::let_unit_value)]
@@ -178,6 +350,111 @@ pub mod backends_client {
                 .insert(GrpcMethod::new("backends.backends", "Delete"));
             self.inner.unary(req, path, codec).await
         }
+        pub async fn list_backends(
+            &mut self,
+            request: impl tonic::IntoRequest<super::ListBackendsRequest>,
+        ) -> std::result::Result<tonic::Response<super::BackendsList>, tonic::Status> {
+            self.inner.ready().await.map_err(|e| {
+                tonic::Status::new(
+                    tonic::Code::Unknown,
+                    format!("Service was not ready: {}", e.into()),
+                )
+            })?;
+            let codec = tonic::codec::ProstCodec::default();
+            let path = http::uri::PathAndQuery::from_static("/backends.backends/ListBackends");
+            let mut req = request.into_request();
+            req.extensions_mut()
+                .insert(GrpcMethod::new("backends.backends", "ListBackends"));
+            self.inner.unary(req, path, codec).await
+        }
+        pub async fn add_backend(
+            &mut self,
+            request: impl tonic::IntoRequest<super::Targets>,
+        ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status> {
+            self.inner.ready().await.map_err(|e| {
+                tonic::Status::new(
+                    tonic::Code::Unknown,
+                    format!("Service was not ready: {}", e.into()),
+                )
+            })?;
+            let codec = tonic::codec::ProstCodec::default();
+            let path = http::uri::PathAndQuery::from_static("/backends.backends/AddBackend");
+            let mut req = request.into_request();
+            req.extensions_mut()
+                .insert(GrpcMethod::new("backends.backends", "AddBackend"));
+            self.inner.unary(req, path, codec).await
+        }
+        pub async fn remove_backend(
+            &mut self,
+            request: impl tonic::IntoRequest<super::Targets>,
+        ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status> {
+            self.inner.ready().await.map_err(|e| {
+                tonic::Status::new(
+                    tonic::Code::Unknown,
+                    format!("Service was not ready: {}", e.into()),
+                )
+            })?;
+            let codec = tonic::codec::ProstCodec::default();
+            let path = http::uri::PathAndQuery::from_static("/backends.backends/RemoveBackend");
+            let mut req = request.into_request();
+            req.extensions_mut()
+                .insert(GrpcMethod::new("backends.backends", "RemoveBackend"));
+            self.inner.unary(req, path, codec).await
+        }
+        pub async fn get_status(
+            &mut self,
+            request: impl tonic::IntoRequest<super::StatusRequest>,
+        ) -> std::result::Result<tonic::Response<super::DataplaneStatus>, tonic::Status> {
+            self.inner.ready().await.map_err(|e| {
+                tonic::Status::new(
+                    tonic::Code::Unknown,
+                    format!("Service was not ready: {}", e.into()),
+                )
+            })?;
+            let codec = tonic::codec::ProstCodec::default();
+            let path = http::uri::PathAndQuery::from_static("/backends.backends/GetStatus");
+            let mut req = request.into_request();
+            req.extensions_mut()
+                .insert(GrpcMethod::new("backends.backends", "GetStatus"));
+            self.inner.unary(req, path, codec).await
+        }
+        pub async fn drain(
+            &mut self,
+            request: impl tonic::IntoRequest<super::DrainRequest>,
+        ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status> {
+            self.inner.ready().await.map_err(|e| {
+                tonic::Status::new(
+                    tonic::Code::Unknown,
+                    format!("Service was not ready: {}", e.into()),
+                )
+            })?;
+            let codec = tonic::codec::ProstCodec::default();
+            let path = http::uri::PathAndQuery::from_static("/backends.backends/Drain");
+            let mut req = request.into_request();
+            req.extensions_mut()
+                .insert(GrpcMethod::new("backends.backends", "Drain"));
+            self.inner.unary(req, path, codec).await
+        }
+        pub async fn watch_backends(
+            &mut self,
+            request: impl tonic::IntoRequest<super::WatchBackendsRequest>,
+        ) -> std::result::Result<
+            tonic::Response<tonic::codec::Streaming<super::BackendsEvent>>,
+            tonic::Status,
+        > {
+            self.inner.ready().await.map_err(|e| {
+                tonic::Status::new(
+                    tonic::Code::Unknown,
+                    format!("Service was not ready: {}", e.into()),
+                )
+            })?;
+            let codec = tonic::codec::ProstCodec::default();
+            let path = http::uri::PathAndQuery::from_static("/backends.backends/WatchBackends");
+            let mut req = request.into_request();
+            req.extensions_mut()
+                .insert(GrpcMethod::new("backends.backends", "WatchBackends"));
+            self.inner.server_streaming(req, path, codec).await
+        }
     }
 }
 /// Generated server implementations.
@@ -199,6 +476,35 @@ pub mod backends_server {
             &self,
             request: tonic::Request<super::Vip>,
         ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status>;
+        async fn list_backends(
+            &self,
+            request: tonic::Request<super::ListBackendsRequest>,
+        ) -> std::result::Result<tonic::Response<super::BackendsList>, tonic::Status>;
+        async fn add_backend(
+            &self,
+            request: tonic::Request<super::Targets>,
+        ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status>;
+        async fn remove_backend(
+            &self,
+            request: tonic::Request<super::Targets>,
+        ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status>;
+        async fn get_status(
+            &self,
+            request: tonic::Request<super::StatusRequest>,
+        ) -> std::result::Result<tonic::Response<super::DataplaneStatus>, tonic::Status>;
+        async fn drain(
+            &self,
+            request: tonic::Request<super::DrainRequest>,
+        ) -> std::result::Result<tonic::Response<super::Confirmation>, tonic::Status>;
+        /// Server streaming response type for the WatchBackends method.
+        type WatchBackendsStream: tonic::codegen::tokio_stream::Stream<
+                Item = std::result::Result<super::BackendsEvent, tonic::Status>,
+            > + Send
+            + 'static;
+        async fn watch_backends(
+            &self,
+            request: tonic::Request<super::WatchBackendsRequest>,
+        ) -> std::result::Result<tonic::Response<Self::WatchBackendsStream>, tonic::Status>;
     }
     #[derive(Debug)]
     pub struct BackendsServer<T: Backends> {
@@ -386,6 +692,247 @@ pub mod backends_server {
                     };
                     Box::pin(fut)
                 }
+                "/backends.backends/ListBackends" => {
+                    #[allow(non_camel_case_types)]
+                    struct ListBackendsSvc<T: Backends>(pub Arc<T>);
+                    impl<T: Backends> tonic::server::UnaryService<super::ListBackendsRequest> for ListBackendsSvc<T> {
+                        type Response = super::BackendsList;
+                        type Future = BoxFuture<tonic::Response<Self::Response>, tonic::Status>;
+                        fn call(
+                            &mut self,
+                            request: tonic::Request<super::ListBackendsRequest>,
+                        ) -> Self::Future {
+                            let inner = Arc::clone(&self.0);
+                            let fut = async move {
+                                <T as Backends>::list_backends(&inner, request).await
+                            };
+                            Box::pin(fut)
+                        }
+                    }
+                    let accept_compression_encodings = self.accept_compression_encodings;
+                    let send_compression_encodings = self.send_compression_encodings;
+                    let max_decoding_message_size = self.max_decoding_message_size;
+                    let max_encoding_message_size = self.max_encoding_message_size;
+                    let inner = self.inner.clone();
+                    let fut = async move {
+                        let inner = inner.0;
+                        let method = ListBackendsSvc(inner);
+                        let codec = tonic::codec::ProstCodec::default();
+                        let mut grpc = tonic::server::Grpc::new(codec)
+                            .apply_compression_config(
+                                accept_compression_encodings,
+                                send_compression_encodings,
+                            )
+                            .apply_max_message_size_config(
+                                max_decoding_message_size,
+                                max_encoding_message_size,
+                            );
+                        let res = grpc.unary(method, req).await;
+                        Ok(res)
+                    };
+                    Box::pin(fut)
+                }
+                "/backends.backends/AddBackend" => {
+                    #[allow(non_camel_case_types)]
+                    struct AddBackendSvc<T: Backends>(pub Arc<T>);
+                    impl<T: Backends> tonic::server::UnaryService<super::Targets> for AddBackendSvc<T> {
+                        type Response = super::Confirmation;
+                        type Future = BoxFuture<tonic::Response<Self::Response>, tonic::Status>;
+                        fn call(
+                            &mut self,
+                            request: tonic::Request<super::Targets>,
+                        ) -> Self::Future {
+                            let inner = Arc::clone(&self.0);
+                            let fut =
+                                async move { <T as Backends>::add_backend(&inner, request).await };
+                            Box::pin(fut)
+                        }
+                    }
+                    let accept_compression_encodings = self.accept_compression_encodings;
+                    let send_compression_encodings = self.send_compression_encodings;
+                    let max_decoding_message_size = self.max_decoding_message_size;
+                    let max_encoding_message_size = self.max_encoding_message_size;
+                    let inner = self.inner.clone();
+                    let fut = async move {
+                        let inner = inner.0;
+                        let method = AddBackendSvc(inner);
+                        let codec = tonic::codec::ProstCodec::default();
+                        let mut grpc = tonic::server::Grpc::new(codec)
+                            .apply_compression_config(
+                                accept_compression_encodings,
+                                send_compression_encodings,
+                            )
+                            .apply_max_message_size_config(
+                                max_decoding_message_size,
+                                max_encoding_message_size,
+                            );
+                        let res = grpc.unary(method, req).await;
+                        Ok(res)
+                    };
+                    Box::pin(fut)
+                }
+                "/backends.backends/RemoveBackend" => {
+                    #[allow(non_camel_case_types)]
+                    struct RemoveBackendSvc<T: Backends>(pub Arc<T>);
+                    impl<T: Backends> tonic::server::UnaryService<super::Targets> for RemoveBackendSvc<T> {
+                        type Response = super::Confirmation;
+                        type Future = BoxFuture<tonic::Response<Self::Response>, tonic::Status>;
+                        fn call(
+                            &mut self,
+                            request: tonic::Request<super::Targets>,
+                        ) -> Self::Future {
+                            let inner = Arc::clone(&self.0);
+                            let fut = async move {
+                                <T as Backends>::remove_backend(&inner, request).await
+                            };
+                            Box::pin(fut)
+                        }
+                    }
+                    let accept_compression_encodings = self.accept_compression_encodings;
+                    let send_compression_encodings = self.send_compression_encodings;
+                    let max_decoding_message_size = self.max_decoding_message_size;
+                    let max_encoding_message_size = self.max_encoding_message_size;
+                    let inner = self.inner.clone();
+                    let fut = async move {
+                        let inner = inner.0;
+                        let method = RemoveBackendSvc(inner);
+                        let codec = tonic::codec::ProstCodec::default();
+                        let mut grpc = tonic::server::Grpc::new(codec)
+                            .apply_compression_config(
+                                accept_compression_encodings,
+                                send_compression_encodings,
+                            )
+                            .apply_max_message_size_config(
+                                max_decoding_message_size,
+                                max_encoding_message_size,
+                            );
+                        let res = grpc.unary(method, req).await;
+                        Ok(res)
+                    };
+                    Box::pin(fut)
+                }
+                "/backends.backends/GetStatus" => {
+                    #[allow(non_camel_case_types)]
+                    struct GetStatusSvc<T: Backends>(pub Arc<T>);
+                    impl<T: Backends> tonic::server::UnaryService<super::StatusRequest> for GetStatusSvc<T> {
+                        type Response = super::DataplaneStatus;
+                        type Future = BoxFuture<tonic::Response<Self::Response>, tonic::Status>;
+                        fn call(
+                            &mut self,
+                            request: tonic::Request<super::StatusRequest>,
+                        ) -> Self::Future {
+                            let inner = Arc::clone(&self.0);
+                            let fut =
+                                async move { <T as Backends>::get_status(&inner, request).await };
+                            Box::pin(fut)
+                        }
+                    }
+                    let accept_compression_encodings = self.accept_compression_encodings;
+                    let send_compression_encodings = self.send_compression_encodings;
+                    let max_decoding_message_size = self.max_decoding_message_size;
+                    let max_encoding_message_size = self.max_encoding_message_size;
+                    let inner = self.inner.clone();
+                    let fut = async move {
+                        let inner = inner.0;
+                        let method = GetStatusSvc(inner);
+                        let codec = tonic::codec::ProstCodec::default();
+                        let mut grpc = tonic::server::Grpc::new(codec)
+                            .apply_compression_config(
+                                accept_compression_encodings,
+                                send_compression_encodings,
+                            )
+                            .apply_max_message_size_config(
+                                max_decoding_message_size,
+                                max_encoding_message_size,
+                            );
+                        let res = grpc.unary(method, req).await;
+                        Ok(res)
+                    };
+                    Box::pin(fut)
+                }
+                "/backends.backends/Drain" => {
+                    #[allow(non_camel_case_types)]
+                    struct DrainSvc<T: Backends>(pub Arc<T>);
+                    impl<T: Backends> tonic::server::UnaryService<super::DrainRequest> for DrainSvc<T> {
+                        type Response = super::Confirmation;
+                        type Future = BoxFuture<tonic::Response<Self::Response>, tonic::Status>;
+                        fn call(
+                            &mut self,
+                            request: tonic::Request<super::DrainRequest>,
+                        ) -> Self::Future {
+                            let inner = Arc::clone(&self.0);
+                            let fut = async move { <T as Backends>::drain(&inner, request).await };
+                            Box::pin(fut)
+                        }
+                    }
+                    let accept_compression_encodings = self.accept_compression_encodings;
+                    let send_compression_encodings = self.send_compression_encodings;
+                    let max_decoding_message_size = self.max_decoding_message_size;
+                    let max_encoding_message_size = self.max_encoding_message_size;
+                    let inner = self.inner.clone();
+                    let fut = async move {
+                        let inner = inner.0;
+                        let method = DrainSvc(inner);
+                        let codec = tonic::codec::ProstCodec::default();
+                        let mut grpc = tonic::server::Grpc::new(codec)
+                            .apply_compression_config(
+                                accept_compression_encodings,
+                                send_compression_encodings,
+                            )
+                            .apply_max_message_size_config(
+                                max_decoding_message_size,
+                                max_encoding_message_size,
+                            );
+                        let res = grpc.unary(method, req).await;
+                        Ok(res)
+                    };
+                    Box::pin(fut)
+                }
+                "/backends.backends/WatchBackends" => {
+                    #[allow(non_camel_case_types)]
+                    struct WatchBackendsSvc<T: Backends>(pub Arc<T>);
+                    impl<T: Backends>
+                        tonic::server::ServerStreamingService<super::WatchBackendsRequest>
+                        for WatchBackendsSvc<T>
+                    {
+                        type Response = super::BackendsEvent;
+                        type ResponseStream = T::WatchBackendsStream;
+                        type Future =
+                            BoxFuture<tonic::Response<Self::ResponseStream>, tonic::Status>;
+                        fn call(
+                            &mut self,
+                            request: tonic::Request<super::WatchBackendsRequest>,
+                        ) -> Self::Future {
+                            let inner = Arc::clone(&self.0);
+                            let fut = async move {
+                                <T as Backends>::watch_backends(&inner, request).await
+                            };
+                            Box::pin(fut)
+                        }
+                    }
+                    let accept_compression_encodings = self.accept_compression_encodings;
+                    let send_compression_encodings = self.send_compression_encodings;
+                    let max_decoding_message_size = self.max_decoding_message_size;
+                    let max_encoding_message_size = self.max_encoding_message_size;
+                    let inner = self.inner.clone();
+                    let fut = async move {
+                        let inner = inner.0;
+                        let method = WatchBackendsSvc(inner);
+                        let codec = tonic::codec::ProstCodec::default();
+                        let mut grpc = tonic::server::Grpc::new(codec)
+                            .apply_compression_config(
+                                accept_compression_encodings,
+                                send_compression_encodings,
+                            )
+                            .apply_max_message_size_config(
+                                max_decoding_message_size,
+                                max_encoding_message_size,
+                            );
+                        let res = grpc.server_streaming(method, req).await;
+                        Ok(res)
+                    };
+                    Box::pin(fut)
+                }
                 _ => Box::pin(async move {
                     Ok(http::Response::builder()
                         .status(200)
@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
+*/
+
+use std::time::Duration;
+
+use anyhow::Error;
+use log::{error, info};
+
+use crate::server::BackendService;
+use common::LoadBalancerMapping;
+
+/// How often the TCP connections are checked for the idle timeout of their
+/// VIP, so connections are evicted up to this long after it.
+pub const IDLE_EVICTION_INTERVAL: Duration = Duration::from_secs(10);
+
+/// Indicates whether the provided TCP connection had no traffic for longer
+/// than the provided idle timeout, as of the provided time. UDP mappings are
+/// never idle, as they're not tracked per connection.
+pub fn is_idle(mapping: &LoadBalancerMapping, timeout_secs: u32, now_ns: u64) -> bool {
+    if mapping.tcp_state.is_none() || timeout_secs == 0 {
+        return false;
+    }
+    let timeout_ns = u64::from(timeout_secs) * 1_000_000_000;
+    now_ns.saturating_sub(mapping.last_seen_ns) > timeout_ns
+}
+
+/// Returns the time of the clock bpf_ktime_get_ns reads, which is the time
+/// since boot excluding suspend, in nanoseconds.
+pub fn monotonic_now_ns() -> Result<u64, Error> {
+    let mut ts = libc::timespec {
+        tv_sec: 0,
+        tv_nsec: 0,
+    };
+    if unsafe { libc::clock_gettime(libc::CLOCK_MONOTONIC, &mut ts) } != 0 {
+        return Err(std::io::Error::last_os_error().into());
+    }
+    Ok(ts.tv_sec as u64 * 1_000_000_000 + ts.tv_nsec as u64)
+}
+
+/// Periodically evicts the TCP connections which exceeded the idle timeout of
+/// their VIP, until the task is cancelled.
+pub async fn evict_idle_tcp_conns(server: BackendService) {
+    let mut interval = tokio::time::interval(IDLE_EVICTION_INTERVAL);
+    loop {
+        interval.tick().await;
+        let result = match monotonic_now_ns() {
+            Ok(now_ns) => server.evict_idle_tcp_conns(now_ns).await,
+            Err(err) => Err(err),
+        };
+        match result {
+            Ok(0) => {}
+            Ok(evicted) => info!("evicted {} idle TCP connections", evicted),
+            Err(err) => error!("failed to evict idle TCP connections: {}", err),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use common::{Backend, BackendKey, TCPState, PROTOCOL_TCP};
+
+    fn mapping(tcp_state: Option<TCPState>, last_seen_ns: u64) -> LoadBalancerMapping {
+        LoadBalancerMapping {
+            backend: Backend::default(),
+            backend_key: BackendKey {
+                ip: 0,
+                port: 0,
+                protocol: PROTOCOL_TCP,
+            },
+            tcp_state,
+            last_seen_ns,
+        }
+    }
+
+    #[test]
+    fn is_idle_after_the_timeout() {
+        let established = mapping(Some(TCPState::Established), 5_000_000_000);
+
+        assert!(!is_idle(&established, 60, 65_000_000_000));
+        assert!(is_idle(&established, 60, 65_000_000_001));
+        // connections without an idle timeout are never evicted.
+        assert!(!is_idle(&established, 0, u64::MAX));
+        // a last_seen_ns ahead of the clock isn't idle.
+        assert!(!is_idle(&established, 60, 0));
+    }
+
+    #[test]
+    fn udp_mappings_are_never_idle() {
+        assert!(!is_idle(&mapping(None, 0), 60, u64::MAX));
+    }
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
+*/
+
+use std::io::Write;
+use std::net::Ipv4Addr;
+
+use anyhow::Error;
+use log::{error, info};
+use tokio::signal::unix::{signal, SignalKind};
+
+use crate::server::BackendService;
+use common::{BackendKey, BackendList, ClientKey, LoadBalancerMapping, PROTOCOL_TCP, PROTOCOL_UDP};
+
+/// Waits for SIGUSR1 and dumps a snapshot of the dataplane's eBPF maps to
+/// stderr each time it is received.
+pub async fn dump_on_sigusr1(service: BackendService) -> Result<(), Error> {
+    let mut signals = signal(SignalKind::user_defined1())?;
+    while signals.recv().await.is_some() {
+        info!("received SIGUSR1, dumping dataplane diagnostics");
+        let snapshot = match service.snapshot().await {
+            Ok(snapshot) => snapshot,
+            Err(err) => {
+                error!("failed to snapshot dataplane maps: {}", err);
+                continue;
+            }
+        };
+        let mut stderr = std::io::stderr().lock();
+        if let Err(err) = write_diagnostics(&mut stderr, &snapshot) {
+            error!("failed to write dataplane diagnostics: {}", err);
+        }
+    }
+    Ok(())
+}
+
+/// A point-in-time copy of the contents of the dataplane's eBPF maps.
+#[derive(Default)]
+pub struct Snapshot {
+    pub backends: Vec<(BackendKey, BackendList)>,
+    pub gateway_indexes: Vec<(BackendKey, u16)>,
+    pub tcp_conns: Vec<(ClientKey, LoadBalancerMapping)>,
+}
+
+/// Renders the provided snapshot in a structured, line oriented format: one
+/// line per map entry, made up of space separated key=value pairs.
+pub fn write_diagnostics<W: Write>(w: &mut W, snapshot: &Snapshot) -> std::io::Result<()> {
+    writeln!(w, "--- blixt dataplane diagnostics ---")?;
+    for (key, list) in &snapshot.backends {
+        write!(
+            w,
+            "map=BACKENDS vip={}:{}/{} backends_len={}",
+            Ipv4Addr::from(key.ip),
+            key.port,
+            protocol_name(key.protocol),
+            list.backends_len
+        )?;
+        for backend in list.backends.iter().take(list.backends_len as usize) {
+            write!(
+                w,
+                " backend={}:{}/ifindex={}",
+                Ipv4Addr::from(backend.daddr),
+                backend.dport,
+                backend.ifindex
+            )?;
+        }
+        writeln!(w)?;
+    }
+    for (key, index) in &snapshot.gateway_indexes {
+        writeln!(
+            w,
+            "map=GATEWAY_INDEXES vip={}:{}/{} index={}",
+            Ipv4Addr::from(key.ip),
+            key.port,
+            protocol_name(key.protocol),
+            index
+        )?;
+    }
+    for (key, mapping) in &snapshot.tcp_conns {
+        writeln!(
+            w,
+            "map=LB_CONNECTIONS client={}:{} vip={}:{}/{} backend={}:{} tcp_state={:?}",
+            Ipv4Addr::from(key.ip),
+            key.port,
+            Ipv4Addr::from(mapping.backend_key.ip),
+            mapping.backend_key.port,
+            protocol_name(mapping.backend_key.protocol),
+            Ipv4Addr::from(mapping.backend.daddr),
+            mapping.backend.dport,
+            mapping.tcp_state
+        )?;
+    }
+    writeln!(w, "--- end of blixt dataplane diagnostics ---")
+}
+
+fn protocol_name(protocol: u32) -> &'static str {
+    match protocol {
+        PROTOCOL_TCP => "tcp",
+        PROTOCOL_UDP => "udp",
+        _ => "unknown",
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use common::{Backend, TCPState, ACTION_FORWARD, BACKENDS_ARRAY_CAPACITY};
+
+    #[test]
+    fn write_diagnostics_renders_map_contents() {
+        let key = BackendKey {
+            ip: Ipv4Addr::new(172, 18, 0, 240).into(),
+            port: 9875,
+            protocol: PROTOCOL_TCP,
+        };
+        let mut backends = [Backend::default(); BACKENDS_ARRAY_CAPACITY];
+        backends[0] = Backend {
+            daddr: Ipv4Addr::new(10, 244, 0, 5).into(),
+            dport: 9876,
+            ifindex: 4,
+            max_connections: 0,
+        };
+        backends[1] = Backend {
+            daddr: Ipv4Addr::new(10, 244, 0, 6).into(),
+            dport: 9876,
+            ifindex: 5,
+            max_connections: 0,
+        };
+        let snapshot = Snapshot {
+            backends: vec![(
+                key,
+                BackendList {
+                    backends,
+                    backends_len: 2,
+                    tcp_idle_timeout_secs: 0,
+                    action: ACTION_FORWARD,
+                },
+            )],
+            gateway_indexes: vec![(key, 1)],
+            tcp_conns: vec![(
+                ClientKey {
+                    ip: Ipv4Addr::new(172, 18, 0, 1).into(),
+                    port: 40000,
+                },
+                LoadBalancerMapping {
+                    backend: backends[0],
+                    backend_key: key,
+                    tcp_state: Some(TCPState::Established),
+                    last_seen_ns: 0,
+                },
+            )],
+        };
+
+        let mut out = Vec::new();
+        write_diagnostics(&mut out, &snapshot).unwrap();
+        let out = String::from_utf8(out).unwrap();
+
+        assert!(out.contains(
+            "map=BACKENDS vip=172.18.0.240:9875/tcp backends_len=2 \
+             backend=10.244.0.5:9876/ifindex=4 backend=10.244.0.6:9876/ifindex=5\n"
+        ));
+        assert!(out.contains("map=GATEWAY_INDEXES vip=172.18.0.240:9875/tcp index=1\n"));
+        assert!(out.contains(
+            "map=LB_CONNECTIONS client=172.18.0.1:40000 vip=172.18.0.240:9875/tcp \
+             backend=10.244.0.5:9876 tcp_state=Some(Established)\n"
+        ));
+    }
+}
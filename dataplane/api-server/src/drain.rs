@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
+*/
+
+use std::net::SocketAddr;
+use std::sync::atomic::{AtomicBool, Ordering};
+
+/// Tracks whether the dataplane is draining, which only happens once before
+/// it exits.
+#[derive(Debug, Default)]
+pub struct DrainState {
+    draining: AtomicBool,
+}
+
+impl DrainState {
+    /// Marks the dataplane as draining, and returns false if it already was.
+    pub fn start(&self) -> bool {
+        !self.draining.swap(true, Ordering::SeqCst)
+    }
+
+    pub fn is_draining(&self) -> bool {
+        self.draining.load(Ordering::SeqCst)
+    }
+}
+
+/// Indicates whether a drain requested by the provided peer is allowed. The
+/// API server listens on all the addresses of the node, so only the preStop
+/// hook of the dataplane Pod, connecting through the loopback address, may
+/// drain it.
+pub fn is_allowed(peer: Option<SocketAddr>) -> bool {
+    peer.map_or(false, |addr| addr.ip().is_loopback())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn drain_state_transitions_once() {
+        let state = DrainState::default();
+        assert!(!state.is_draining());
+
+        assert!(state.start());
+        assert!(state.is_draining());
+
+        // draining again doesn't restart the drain.
+        assert!(!state.start());
+        assert!(state.is_draining());
+    }
+
+    #[test]
+    fn drain_is_only_allowed_from_loopback() {
+        assert!(is_allowed(Some("127.0.0.1:52000".parse().unwrap())));
+        assert!(is_allowed(Some("[::1]:52000".parse().unwrap())));
+
+        assert!(!is_allowed(Some("10.244.0.5:52000".parse().unwrap())));
+        assert!(!is_allowed(None));
+    }
+}
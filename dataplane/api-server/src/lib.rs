@@ -5,17 +5,29 @@ SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 */
 
 pub mod backends;
+pub mod conntrack;
+pub mod diagnostics;
+pub mod drain;
 pub mod netutils;
+pub mod pprof;
 pub mod server;
+pub mod status;
 
 use std::net::{Ipv4Addr, SocketAddrV4};
 
 use anyhow::Error;
-use aya::maps::{HashMap, MapData};
+use aya::maps::{Array, HashMap, MapData};
+use log::error;
 use tonic::transport::Server;
 
 use backends::backends_server::BackendsServer;
 use common::{BackendKey, BackendList, ClientKey, LoadBalancerMapping};
+use status::Attachment;
+
+/// The default maximum size of the messages sent and received by the API, it's
+/// larger than the gRPC default of 4MiB so that VIPs with large backend sets
+/// can be programmed.
+pub const DEFAULT_MAX_MESSAGE_SIZE: usize = 16 * 1024 * 1024;
 
 pub async fn start(
     addr: Ipv4Addr,
@@ -23,14 +35,37 @@ pub async fn start(
     backends_map: HashMap<MapData, BackendKey, BackendList>,
     gateway_indexes_map: HashMap<MapData, BackendKey, u16>,
     tcp_conns_map: HashMap<MapData, ClientKey, LoadBalancerMapping>,
+    backend_conns_map: HashMap<MapData, BackendKey, u32>,
+    draining_map: Array<MapData, u32>,
+    attachment: Attachment,
+    max_message_size: usize,
 ) -> Result<(), Error> {
-    let (_, health_service) = tonic_health::server::health_reporter();
+    let (health_reporter, health_service) = tonic_health::server::health_reporter();
 
-    let server = server::BackendService::new(backends_map, gateway_indexes_map, tcp_conns_map);
+    let server = server::BackendService::new(
+        backends_map,
+        gateway_indexes_map,
+        tcp_conns_map,
+        backend_conns_map,
+        draining_map,
+        attachment,
+        health_reporter,
+    );
+    tokio::spawn(conntrack::evict_idle_tcp_conns(server.clone()));
+    let diagnostics_server = server.clone();
+    tokio::spawn(async move {
+        if let Err(err) = diagnostics::dump_on_sigusr1(diagnostics_server).await {
+            error!("failed to register SIGUSR1 diagnostics handler: {}", err);
+        }
+    });
     // TODO: mTLS https://github.com/Kong/blixt/issues/50
     Server::builder()
         .add_service(health_service)
-        .add_service(BackendsServer::new(server))
+        .add_service(
+            BackendsServer::new(server)
+                .max_decoding_message_size(max_message_size)
+                .max_encoding_message_size(max_message_size),
+        )
         .serve(SocketAddrV4::new(addr, port).into())
         .await?;
     Ok(())
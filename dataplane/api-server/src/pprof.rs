@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
+*/
+
+use std::convert::Infallible;
+use std::net::SocketAddr;
+use std::time::Duration;
+
+use anyhow::Error;
+use hyper::service::{make_service_fn, service_fn};
+use hyper::{Body, Method, Request, Response, Server, StatusCode};
+use log::info;
+use pprof::protos::Message;
+
+/// The path of the CPU profile endpoint, which serves profiles in the pprof
+/// protobuf format like the net/http/pprof endpoint of the same path.
+pub const PROFILE_PATH: &str = "/debug/pprof/profile";
+
+/// The default address the pprof endpoint binds to, a loopback address as the
+/// profiles aren't protected.
+pub const DEFAULT_BIND_ADDRESS: &str = "127.0.0.1:6060";
+
+/// The default duration of the CPU profiles, as for net/http/pprof.
+const DEFAULT_PROFILE_SECONDS: u64 = 30;
+
+/// The maximum duration of the CPU profiles.
+const MAX_PROFILE_SECONDS: u64 = 300;
+
+/// The frequency at which the CPU profiles sample the stacks.
+const PROFILE_FREQUENCY: i32 = 99;
+
+/// Serves the pprof endpoint of the dataplane on the provided address, on its
+/// own server so that the profiles aren't exposed on the API.
+pub async fn serve(addr: SocketAddr) -> Result<(), Error> {
+    let make_service = make_service_fn(|_| async { Ok::<_, Infallible>(service_fn(handle)) });
+    info!("serving pprof profiles on {}{}", addr, PROFILE_PATH);
+    Server::try_bind(&addr)?.serve(make_service).await?;
+    Ok(())
+}
+
+async fn handle(req: Request<Body>) -> Result<Response<Body>, Infallible> {
+    if req.method() != Method::GET || req.uri().path() != PROFILE_PATH {
+        return Ok(response(StatusCode::NOT_FOUND, Body::from("not found\n")));
+    }
+    let seconds = match profile_seconds(req.uri().query()) {
+        Ok(seconds) => seconds,
+        Err(err) => {
+            return Ok(response(
+                StatusCode::BAD_REQUEST,
+                Body::from(format!("{}\n", err)),
+            ))
+        }
+    };
+    match cpu_profile(Duration::from_secs(seconds)).await {
+        Ok(profile) => Ok(response(StatusCode::OK, Body::from(profile))),
+        Err(err) => Ok(response(
+            StatusCode::INTERNAL_SERVER_ERROR,
+            Body::from(format!("failed to profile the dataplane: {}\n", err)),
+        )),
+    }
+}
+
+fn response(status: StatusCode, body: Body) -> Response<Body> {
+    let mut response = Response::new(body);
+    *response.status_mut() = status;
+    response
+}
+
+// Returns the duration of the requested profile from the seconds query
+// parameter, if any.
+fn profile_seconds(query: Option<&str>) -> Result<u64, Error> {
+    let seconds = query
+        .unwrap_or_default()
+        .split('&')
+        .find_map(|param| param.strip_prefix("seconds="));
+    let seconds = match seconds {
+        Some(seconds) => seconds
+            .parse::<u64>()
+            .map_err(|_| anyhow::anyhow!("invalid seconds {:?}", seconds))?,
+        None => DEFAULT_PROFILE_SECONDS,
+    };
+    if seconds == 0 || seconds > MAX_PROFILE_SECONDS {
+        anyhow::bail!("seconds must be between 1 and {}", MAX_PROFILE_SECONDS);
+    }
+    Ok(seconds)
+}
+
+// Profiles the CPU usage of the dataplane for the provided duration, and
+// returns the profile encoded in the pprof protobuf format.
+async fn cpu_profile(duration: Duration) -> Result<Vec<u8>, Error> {
+    let guard = pprof::ProfilerGuardBuilder::default()
+        .frequency(PROFILE_FREQUENCY)
+        .blocklist(&["libc", "libgcc", "pthread", "vdso"])
+        .build()?;
+    tokio::time::sleep(duration).await;
+    let profile = guard.report().build()?.pprof()?;
+    Ok(profile.encode_to_vec())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn profile_seconds_defaults_and_bounds() {
+        assert_eq!(profile_seconds(None).unwrap(), DEFAULT_PROFILE_SECONDS);
+        assert_eq!(
+            profile_seconds(Some("debug=1")).unwrap(),
+            DEFAULT_PROFILE_SECONDS
+        );
+        assert_eq!(profile_seconds(Some("debug=1&seconds=5")).unwrap(), 5);
+        assert!(profile_seconds(Some("seconds=0")).is_err());
+        assert!(profile_seconds(Some("seconds=301")).is_err());
+        assert!(profile_seconds(Some("seconds=five")).is_err());
+    }
+
+    #[tokio::test]
+    async fn only_the_profile_endpoint_is_served() {
+        for (method, uri) in [
+            (Method::GET, "/debug/pprof/heap"),
+            (Method::GET, "/metrics"),
+            (Method::POST, PROFILE_PATH),
+        ] {
+            let req = Request::builder()
+                .method(method)
+                .uri(uri)
+                .body(Body::empty())
+                .unwrap();
+            let res = handle(req).await.unwrap();
+            assert_eq!(res.status(), StatusCode::NOT_FOUND, "{}", uri);
+        }
+
+        let req = Request::builder()
+            .uri(format!("{}?seconds=0", PROFILE_PATH))
+            .body(Body::empty())
+            .unwrap();
+        let res = handle(req).await.unwrap();
+        assert_eq!(res.status(), StatusCode::BAD_REQUEST);
+    }
+}
@@ -6,23 +6,58 @@ SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 
 use std::net::Ipv4Addr;
 use std::sync::Arc;
+use std::time::Duration;
 
 use anyhow::Error;
-use aya::maps::{HashMap, MapData, MapError};
-use tokio::sync::Mutex;
+use aya::maps::{Array, HashMap, MapData, MapError};
+use aya::Pod;
+use log::info;
+use tokio::sync::broadcast::error::RecvError;
+use tokio::sync::{broadcast, mpsc, Mutex};
+use tokio_stream::wrappers::ReceiverStream;
 use tonic::{Request, Response, Status};
+use tonic_health::server::HealthReporter;
+use tonic_health::ServingStatus;
 
+use crate::backends::backends_event::Type as BackendsEventType;
 use crate::backends::backends_server::Backends;
-use crate::backends::{Confirmation, InterfaceIndexConfirmation, PodIp, Targets, Vip};
+use crate::backends::targets::Action;
+use crate::backends::vip::Protocol;
+use crate::backends::{
+    BackendsEvent, BackendsList, Confirmation, DataplaneStatus, DrainRequest,
+    InterfaceIndexConfirmation, ListBackendsRequest, PodIp, StatusRequest, Target, Targets, Vip,
+    WatchBackendsRequest,
+};
+use crate::conntrack::is_idle;
+use crate::diagnostics::Snapshot;
+use crate::drain::{self, DrainState};
 use crate::netutils::{if_name_for_routing_ip, if_nametoindex};
+use crate::status::Attachment;
 use common::{
-    Backend, BackendKey, BackendList, ClientKey, LoadBalancerMapping, BACKENDS_ARRAY_CAPACITY,
+    Backend, BackendKey, BackendList, ClientKey, LoadBalancerMapping, ACTION_DROP, ACTION_FORWARD,
+    ACTION_REJECT, BACKENDS_ARRAY_CAPACITY, PROTOCOL_TCP, PROTOCOL_UDP,
 };
 
+// WATCH_EVENTS_CAPACITY is the number of backends events kept for the
+// WatchBackends streams, a stream falling further behind is sent a new
+// snapshot instead of the events it missed.
+const WATCH_EVENTS_CAPACITY: usize = 64;
+
+// WATCH_BUFFER_SIZE is the number of events buffered for each WatchBackends
+// stream while they're sent to the watcher.
+const WATCH_BUFFER_SIZE: usize = 16;
+
+#[derive(Clone)]
 pub struct BackendService {
     backends_map: Arc<Mutex<HashMap<MapData, BackendKey, BackendList>>>,
     gateway_indexes_map: Arc<Mutex<HashMap<MapData, BackendKey, u16>>>,
     tcp_conns_map: Arc<Mutex<HashMap<MapData, ClientKey, LoadBalancerMapping>>>,
+    backend_conns_map: Arc<Mutex<HashMap<MapData, BackendKey, u32>>>,
+    draining_map: Arc<Mutex<Array<MapData, u32>>>,
+    attachment: Attachment,
+    drain_state: Arc<DrainState>,
+    health_reporter: HealthReporter,
+    events: broadcast::Sender<BackendsEvent>,
 }
 
 impl BackendService {
@@ -30,12 +65,50 @@ impl BackendService {
         backends_map: HashMap<MapData, BackendKey, BackendList>,
         gateway_indexes_map: HashMap<MapData, BackendKey, u16>,
         tcp_conns_map: HashMap<MapData, ClientKey, LoadBalancerMapping>,
+        backend_conns_map: HashMap<MapData, BackendKey, u32>,
+        draining_map: Array<MapData, u32>,
+        attachment: Attachment,
+        health_reporter: HealthReporter,
     ) -> BackendService {
         BackendService {
             backends_map: Arc::new(Mutex::new(backends_map)),
             gateway_indexes_map: Arc::new(Mutex::new(gateway_indexes_map)),
             tcp_conns_map: Arc::new(Mutex::new(tcp_conns_map)),
+            backend_conns_map: Arc::new(Mutex::new(backend_conns_map)),
+            draining_map: Arc::new(Mutex::new(draining_map)),
+            attachment,
+            drain_state: Arc::new(DrainState::default()),
+            health_reporter,
+            events: broadcast::channel(WATCH_EVENTS_CAPACITY).0,
+        }
+    }
+
+    // publish sends an event with the provided backends to the WatchBackends
+    // streams, if any.
+    fn publish(&self, event_type: BackendsEventType, targets: Targets) {
+        let _ = self.events.send(BackendsEvent {
+            r#type: event_type as i32,
+            backends: vec![targets],
+        });
+    }
+
+    async fn programmed_backends(&self) -> Result<Vec<Targets>, Status> {
+        let mut backends = Vec::new();
+        for item in self.backends_map.lock().await.iter() {
+            let (key, backend_list) = match item {
+                Ok(item) => item,
+                Err(err) => return Err(Status::internal(format!("failure: {}", err))),
+            };
+            backends.push(targets_for(key, &backend_list));
         }
+        Ok(backends)
+    }
+
+    async fn snapshot_event(&self) -> Result<BackendsEvent, Status> {
+        Ok(BackendsEvent {
+            r#type: BackendsEventType::Snapshot as i32,
+            backends: self.programmed_backends().await?,
+        })
     }
 
     async fn insert(&self, key: BackendKey, bks: BackendList) -> Result<(), Error> {
@@ -44,6 +117,15 @@ impl BackendService {
         Ok(())
     }
 
+    async fn get(&self, key: BackendKey) -> Result<Option<BackendList>, Error> {
+        let backends_map = self.backends_map.lock().await;
+        match backends_map.get(&key, 0) {
+            Ok(bks) => Ok(Some(bks)),
+            Err(MapError::KeyNotFound) => Ok(None),
+            Err(err) => Err(err.into()),
+        }
+    }
+
     async fn insert_and_reset_index(&self, key: BackendKey, bks: BackendList) -> Result<(), Error> {
         self.insert(key, bks).await?;
         let mut gateway_indexes_map = self.gateway_indexes_map.lock().await;
@@ -51,16 +133,20 @@ impl BackendService {
         Ok(())
     }
 
-    async fn remove(&self, key: BackendKey) -> Result<(), Error> {
+    // remove deletes the backends of the provided key, it returns false if
+    // they didn't exist, which isn't an error so that deletes are idempotent.
+    async fn remove(&self, key: BackendKey) -> Result<bool, Error> {
         let mut backends_map = self.backends_map.lock().await;
-        backends_map.remove(&key)?;
+        let removed = remove_if_present(&mut backends_map, &key)?;
         let mut gateway_indexes_map = self.gateway_indexes_map.lock().await;
-        gateway_indexes_map.remove(&key)?;
+        remove_if_present(&mut gateway_indexes_map, &key)?;
 
         // Delete all entries in our tcp connection tracking map that this backend
         // key was related to. This is needed because the TCPRoute might have been
         // deleted with TCP connection(s) still open, so without the below logic
-        // they'll hang around forever.
+        // they'll hang around forever. Their backend connections are released
+        // too, otherwise the backends would stay at capacity once the VIP is
+        // programmed again.
         // Its better to do this rather than maintain a reverse index because the index
         // would need to be updated with each new connection. With remove being a less
         // frequently used operation, the performance cost is less visible.
@@ -69,28 +155,259 @@ impl BackendService {
             .iter()
             .collect::<Vec<Result<(ClientKey, LoadBalancerMapping), MapError>>>()
         {
-            match item {
-                Ok((
-                    client_key,
-                    LoadBalancerMapping {
-                        backend: _,
-                        backend_key,
-                        tcp_state: _,
-                    },
-                )) => {
-                    if backend_key == key {
-                        tcp_conns_map.remove(&client_key)?;
-                    };
-                }
-                Err(err) => return Err(err.into()),
+            let (client_key, mapping) = item?;
+            // the dataplane may have removed the connection in the meantime,
+            // in which case it already released its backend connection.
+            if mapping.backend_key != key || !remove_if_present(&mut tcp_conns_map, &client_key)? {
+                continue;
+            }
+            self.release_backend_connection(&mapping.backend).await?;
+        }
+        Ok(removed)
+    }
+
+    // evict_idle_tcp_conns deletes the TCP connections which had no traffic for
+    // longer than the idle timeout of their VIP, as of the provided time, and
+    // releases their backend connection. It returns the number of evicted
+    // connections.
+    pub async fn evict_idle_tcp_conns(&self, now_ns: u64) -> Result<usize, Error> {
+        let mut idle_timeouts = std::collections::HashMap::new();
+        for item in self.backends_map.lock().await.iter() {
+            let (key, backend_list) = item?;
+            if backend_list.tcp_idle_timeout_secs > 0 {
+                idle_timeouts.insert(
+                    (key.ip, key.port, key.protocol),
+                    backend_list.tcp_idle_timeout_secs,
+                );
+            }
+        }
+        if idle_timeouts.is_empty() {
+            return Ok(0);
+        }
+
+        let mut evicted = 0;
+        let mut tcp_conns_map = self.tcp_conns_map.lock().await;
+        for item in tcp_conns_map
+            .iter()
+            .collect::<Vec<Result<(ClientKey, LoadBalancerMapping), MapError>>>()
+        {
+            let (client_key, mapping) = item?;
+            let key = (
+                mapping.backend_key.ip,
+                mapping.backend_key.port,
+                mapping.backend_key.protocol,
+            );
+            let timeout_secs = match idle_timeouts.get(&key) {
+                Some(timeout_secs) => *timeout_secs,
+                None => continue,
             };
+            // the dataplane may have removed the connection in the meantime,
+            // in which case it already released its backend connection.
+            if !is_idle(&mapping, timeout_secs, now_ns)
+                || !remove_if_present(&mut tcp_conns_map, &client_key)?
+            {
+                continue;
+            }
+            self.release_backend_connection(&mapping.backend).await?;
+            evicted += 1;
+        }
+        Ok(evicted)
+    }
+
+    // release_backend_connection stops counting a connection to the provided
+    // backend, like the dataplane does when a connection is closed.
+    async fn release_backend_connection(&self, backend: &Backend) -> Result<(), Error> {
+        if backend.max_connections == 0 {
+            return Ok(());
+        }
+        let key = BackendKey {
+            ip: backend.daddr,
+            port: backend.dport,
+            protocol: PROTOCOL_TCP,
+        };
+        let mut backend_conns_map = self.backend_conns_map.lock().await;
+        match backend_conns_map.get(&key, 0) {
+            Ok(count) if count > 1 => backend_conns_map.insert(key, count - 1, 0)?,
+            Ok(_) => {
+                remove_if_present(&mut backend_conns_map, &key)?;
+            }
+            Err(MapError::KeyNotFound) => {}
+            Err(err) => return Err(err.into()),
         }
         Ok(())
     }
+
+    // snapshot copies the current contents of all maps, for diagnostics.
+    pub async fn snapshot(&self) -> Result<Snapshot, Error> {
+        let mut snapshot = Snapshot::default();
+        for item in self.backends_map.lock().await.iter() {
+            snapshot.backends.push(item?);
+        }
+        for item in self.gateway_indexes_map.lock().await.iter() {
+            snapshot.gateway_indexes.push(item?);
+        }
+        for item in self.tcp_conns_map.lock().await.iter() {
+            snapshot.tcp_conns.push(item?);
+        }
+        Ok(snapshot)
+    }
+}
+
+// remove_if_present removes the provided key from the map, it returns false
+// if the key wasn't in the map.
+fn remove_if_present<K: Pod, V: Pod>(
+    map: &mut HashMap<MapData, K, V>,
+    key: &K,
+) -> Result<bool, MapError> {
+    match map.remove(key) {
+        Ok(()) => Ok(true),
+        Err(err) if is_key_not_found(&err) => Ok(false),
+        Err(err) => Err(err),
+    }
+}
+
+// is_key_not_found indicates whether the provided map error was caused by a
+// missing key, which the delete syscall reports with ENOENT.
+fn is_key_not_found(err: &MapError) -> bool {
+    if matches!(err, MapError::KeyNotFound) {
+        return true;
+    }
+    let mut source = std::error::Error::source(err);
+    while let Some(err) = source {
+        if let Some(io_error) = err.downcast_ref::<std::io::Error>() {
+            return io_error.raw_os_error() == Some(libc::ENOENT);
+        }
+        source = err.source();
+    }
+    false
+}
+
+// target_ifindex returns the index of the interface the target is reachable
+// through, determining it from the routes if the target doesn't specify it.
+// It's determined again on each push and never cached by address, so a
+// backend Pod recreated on another node gets the interface of its new address.
+fn target_ifindex(target: &Target) -> Result<u32, Status> {
+    if let Some(ifindex) = target.ifindex {
+        return Ok(ifindex);
+    }
+
+    let ip_addr = Ipv4Addr::from(target.daddr);
+    let ifname = match if_name_for_routing_ip(ip_addr) {
+        Ok(ifname) => ifname,
+        Err(err) => {
+            return Err(Status::internal(format!(
+                "failed to determine ifname: {}",
+                err
+            )))
+        }
+    };
+
+    match if_nametoindex(ifname) {
+        Ok(ifindex) => Ok(ifindex),
+        Err(err) => Err(Status::internal(format!(
+            "failed to determine ifindex: {}",
+            err
+        ))),
+    }
+}
+
+// targets_for returns the Targets of the provided backends map entry.
+fn targets_for(key: BackendKey, backend_list: &BackendList) -> Targets {
+    let targets = backend_list.backends[..backend_list.backends_len as usize]
+        .iter()
+        .map(|backend| Target {
+            daddr: backend.daddr,
+            dport: backend.dport,
+            ifindex: Some(backend.ifindex as u32),
+            max_connections: match backend.max_connections {
+                0 => None,
+                max_connections => Some(max_connections),
+            },
+        })
+        .collect();
+    Targets {
+        vip: Some(Vip {
+            ip: key.ip,
+            port: key.port,
+            protocol: match key.protocol {
+                PROTOCOL_UDP => Protocol::Udp as i32,
+                _ => Protocol::Tcp as i32,
+            },
+        }),
+        targets,
+        tcp_idle_timeout_seconds: match backend_list.tcp_idle_timeout_secs {
+            0 => None,
+            tcp_idle_timeout_secs => Some(tcp_idle_timeout_secs),
+        },
+        action: match backend_list.action {
+            ACTION_REJECT => Action::Reject as i32,
+            ACTION_DROP => Action::Drop as i32,
+            _ => Action::Forward as i32,
+        },
+    }
+}
+
+// backend_key returns the key of the backends map of the provided VIP.
+fn backend_key(vip: &Vip) -> Result<BackendKey, Status> {
+    let protocol = match vip.protocol {
+        protocol if protocol == Protocol::Tcp as i32 => PROTOCOL_TCP,
+        protocol if protocol == Protocol::Udp as i32 => PROTOCOL_UDP,
+        protocol => {
+            return Err(Status::invalid_argument(format!(
+                "unknown protocol {}",
+                protocol
+            )))
+        }
+    };
+    Ok(BackendKey {
+        ip: vip.ip,
+        port: vip.port,
+        protocol,
+    })
+}
+
+// backend_list_action returns the BackendList action of the provided
+// Targets action.
+fn backend_list_action(action: i32) -> Result<u32, Status> {
+    match action {
+        action if action == Action::Forward as i32 => Ok(ACTION_FORWARD),
+        action if action == Action::Reject as i32 => Ok(ACTION_REJECT),
+        action if action == Action::Drop as i32 => Ok(ACTION_DROP),
+        action => Err(Status::invalid_argument(format!(
+            "unknown action {}",
+            action
+        ))),
+    }
+}
+
+// has_duplicate_backends indicates whether the provided backends repeat a
+// backend, as weighted backends do. AddBackend and RemoveBackend handle the
+// backends of a VIP as a set, so they can't patch such backends.
+fn has_duplicate_backends(backends: &[(u32, u32)]) -> bool {
+    backends
+        .iter()
+        .enumerate()
+        .any(|(i, backend)| backends[i + 1..].contains(backend))
+}
+
+fn weighted_backends(vip: &Vip) -> Status {
+    Status::failed_precondition(format!(
+        "vip {}:{} has weighted backends, which must be replaced with Update",
+        Ipv4Addr::from(vip.ip),
+        vip.port
+    ))
+}
+
+fn backends_capacity_exceeded() -> Status {
+    Status::resource_exhausted(
+        "BPF map value capacity exceeded, only 128 backends supported per Gateway",
+    )
 }
 
 #[tonic::async_trait]
 impl Backends for BackendService {
+    type WatchBackendsStream = ReceiverStream<Result<BackendsEvent, Status>>;
+
     async fn get_interface_index(
         &self,
         request: Request<PodIp>,
@@ -120,70 +437,48 @@ impl Backends for BackendService {
             None => return Err(Status::invalid_argument("missing vip ip and port")),
         };
 
-        let key = BackendKey {
-            ip: vip.ip,
-            port: vip.port,
-        };
+        let key = backend_key(&vip)?;
+        let action = backend_list_action(targets.action)?;
         let mut backends: [Backend; BACKENDS_ARRAY_CAPACITY] =
             [Backend::default(); BACKENDS_ARRAY_CAPACITY];
         let mut count: u16 = 0;
         let backend_targets = targets.targets;
 
         for backend_target in backend_targets {
-            let ifindex = match backend_target.ifindex {
-                Some(ifindex) => ifindex,
-                None => {
-                    let ip_addr = Ipv4Addr::from(backend_target.daddr);
-                    let ifname = match if_name_for_routing_ip(ip_addr) {
-                        Ok(ifname) => ifname,
-                        Err(err) => {
-                            return Err(Status::internal(format!(
-                                "failed to determine ifname: {}",
-                                err
-                            )))
-                        }
-                    };
-
-                    match if_nametoindex(ifname) {
-                        Ok(ifindex) => ifindex,
-                        Err(err) => {
-                            return Err(Status::internal(format!(
-                                "failed to determine ifindex: {}",
-                                err
-                            )))
-                        }
-                    }
-                }
-            };
+            let ifindex = target_ifindex(&backend_target)?;
 
             if (count as usize) < BACKENDS_ARRAY_CAPACITY {
                 let bk = Backend {
                     daddr: backend_target.daddr,
                     dport: backend_target.dport,
                     ifindex: ifindex as u16,
+                    max_connections: backend_target.max_connections.unwrap_or(0),
                 };
                 backends[count as usize] = bk;
                 count += 1;
             } else {
-                return Err(Status::resource_exhausted(
-                    "BPF map value capacity exceeded, only 128 backends supported per Gateway",
-                ));
+                return Err(backends_capacity_exceeded());
             }
         }
 
         let backend_list = BackendList {
             backends,
             backends_len: count,
+            tcp_idle_timeout_secs: targets.tcp_idle_timeout_seconds.unwrap_or(0),
+            action,
         };
         match self.insert_and_reset_index(key, backend_list).await {
-            Ok(_) => Ok(Response::new(Confirmation {
-                confirmation: format!(
-                    "success, vip {}:{} was updated with {} backends",
-                    Ipv4Addr::from(vip.ip),
-                    vip.port,
-                    count,
-                ),
-            })),
+            Ok(_) => {
+                self.publish(BackendsEventType::Updated, targets_for(key, &backend_list));
+                Ok(Response::new(Confirmation {
+                    confirmation: format!(
+                        "success, vip {}:{} was updated with {} backends",
+                        Ipv4Addr::from(vip.ip),
+                        vip.port,
+                        count,
+                    ),
+                }))
+            }
             Err(err) => Err(Status::internal(format!("failure: {}", err))),
         }
     }
@@ -191,23 +486,411 @@ impl Backends for BackendService {
     async fn delete(&self, request: Request<Vip>) -> Result<Response<Confirmation>, Status> {
         let vip = request.into_inner();
 
-        let key = BackendKey {
-            ip: vip.ip,
-            port: vip.port,
-        };
+        let key = backend_key(&vip)?;
 
         let addr_ddn = Ipv4Addr::from(vip.ip);
 
         match self.remove(key).await {
-            Ok(()) => Ok(Response::new(Confirmation {
-                confirmation: format!("success, vip {}:{} was deleted", addr_ddn, vip.port),
+            Ok(true) => {
+                self.publish(
+                    BackendsEventType::Deleted,
+                    Targets {
+                        vip: Some(vip.clone()),
+                        targets: Vec::new(),
+                        tcp_idle_timeout_seconds: None,
+                        action: Action::Forward as i32,
+                    },
+                );
+                Ok(Response::new(Confirmation {
+                    confirmation: format!("success, vip {}:{} was deleted", addr_ddn, vip.port),
+                }))
+            }
+            Ok(false) => Ok(Response::new(Confirmation {
+                confirmation: format!("success, vip {}:{} did not exist", addr_ddn, vip.port),
             })),
-            Err(err) if err.to_string().contains("syscall failed with code -1") => {
+            Err(err) => Err(Status::internal(format!("failure: {}", err))),
+        }
+    }
+
+    async fn list_backends(
+        &self,
+        _request: Request<ListBackendsRequest>,
+    ) -> Result<Response<BackendsList>, Status> {
+        Ok(Response::new(BackendsList {
+            backends: self.programmed_backends().await?,
+        }))
+    }
+
+    async fn add_backend(
+        &self,
+        request: Request<Targets>,
+    ) -> Result<Response<Confirmation>, Status> {
+        let targets = request.into_inner();
+
+        let vip = match targets.vip {
+            Some(vip) => vip,
+            None => return Err(Status::invalid_argument("missing vip ip and port")),
+        };
+
+        let key = backend_key(&vip)?;
+        let mut backend_list = match self.get(key).await {
+            Ok(Some(backend_list)) => backend_list,
+            Ok(None) => {
+                return Err(Status::not_found(format!(
+                    "vip {}:{} does not exist",
+                    Ipv4Addr::from(vip.ip),
+                    vip.port
+                )))
+            }
+            Err(err) => return Err(Status::internal(format!("failure: {}", err))),
+        };
+        let programmed: Vec<(u32, u32)> = backend_list.backends
+            [..backend_list.backends_len as usize]
+            .iter()
+            .map(|bk| (bk.daddr, bk.dport))
+            .collect();
+        let requested: Vec<(u32, u32)> = targets
+            .targets
+            .iter()
+            .map(|target| (target.daddr, target.dport))
+            .collect();
+        if has_duplicate_backends(&programmed) || has_duplicate_backends(&requested) {
+            return Err(weighted_backends(&vip));
+        }
+
+        let mut added: u16 = 0;
+        for backend_target in targets.targets {
+            let exists = backend_list.backends[..backend_list.backends_len as usize]
+                .iter()
+                .any(|bk| bk.daddr == backend_target.daddr && bk.dport == backend_target.dport);
+            if exists {
+                continue;
+            }
+
+            let ifindex = target_ifindex(&backend_target)?;
+            if (backend_list.backends_len as usize) >= BACKENDS_ARRAY_CAPACITY {
+                return Err(backends_capacity_exceeded());
+            }
+            backend_list.backends[backend_list.backends_len as usize] = Backend {
+                daddr: backend_target.daddr,
+                dport: backend_target.dport,
+                ifindex: ifindex as u16,
+                max_connections: backend_target.max_connections.unwrap_or(0),
+            };
+            backend_list.backends_len += 1;
+            added += 1;
+        }
+
+        // the round-robin index is kept, the dataplane falls back to the first
+        // backend if it's out of range.
+        match self.insert(key, backend_list).await {
+            Ok(_) => {
+                self.publish(BackendsEventType::Updated, targets_for(key, &backend_list));
+                Ok(Response::new(Confirmation {
+                    confirmation: format!(
+                        "success, {} backends were added to vip {}:{}",
+                        added,
+                        Ipv4Addr::from(vip.ip),
+                        vip.port,
+                    ),
+                }))
+            }
+            Err(err) => Err(Status::internal(format!("failure: {}", err))),
+        }
+    }
+
+    async fn remove_backend(
+        &self,
+        request: Request<Targets>,
+    ) -> Result<Response<Confirmation>, Status> {
+        let targets = request.into_inner();
+
+        let vip = match targets.vip {
+            Some(vip) => vip,
+            None => return Err(Status::invalid_argument("missing vip ip and port")),
+        };
+
+        let key = backend_key(&vip)?;
+        let current = match self.get(key).await {
+            Ok(Some(backend_list)) => backend_list,
+            Ok(None) => {
+                return Err(Status::not_found(format!(
+                    "vip {}:{} does not exist",
+                    Ipv4Addr::from(vip.ip),
+                    vip.port
+                )))
+            }
+            Err(err) => return Err(Status::internal(format!("failure: {}", err))),
+        };
+        let programmed: Vec<(u32, u32)> = current.backends[..current.backends_len as usize]
+            .iter()
+            .map(|bk| (bk.daddr, bk.dport))
+            .collect();
+        if has_duplicate_backends(&programmed) {
+            return Err(weighted_backends(&vip));
+        }
+
+        let mut backend_list = BackendList {
+            backends: [Backend::default(); BACKENDS_ARRAY_CAPACITY],
+            backends_len: 0,
+            tcp_idle_timeout_secs: current.tcp_idle_timeout_secs,
+            action: current.action,
+        };
+        for bk in current.backends[..current.backends_len as usize].iter() {
+            let removed = targets
+                .targets
+                .iter()
+                .any(|target| target.daddr == bk.daddr && target.dport == bk.dport);
+            if !removed {
+                backend_list.backends[backend_list.backends_len as usize] = *bk;
+                backend_list.backends_len += 1;
+            }
+        }
+        let removed = current.backends_len - backend_list.backends_len;
+
+        match self.insert(key, backend_list).await {
+            Ok(_) => {
+                self.publish(BackendsEventType::Updated, targets_for(key, &backend_list));
                 Ok(Response::new(Confirmation {
-                    confirmation: format!("success, vip {}:{} did not exist", addr_ddn, vip.port),
+                    confirmation: format!(
+                        "success, {} backends were removed from vip {}:{}",
+                        removed,
+                        Ipv4Addr::from(vip.ip),
+                        vip.port,
+                    ),
                 }))
             }
             Err(err) => Err(Status::internal(format!("failure: {}", err))),
         }
     }
+
+    async fn get_status(
+        &self,
+        _request: Request<StatusRequest>,
+    ) -> Result<Response<DataplaneStatus>, Status> {
+        let mut vips: u32 = 0;
+        for item in self.backends_map.lock().await.keys() {
+            if let Err(err) = item {
+                return Err(Status::internal(format!("failure: {}", err)));
+            }
+            vips += 1;
+        }
+
+        Ok(Response::new(
+            self.attachment.status(vips, self.drain_state.is_draining()),
+        ))
+    }
+
+    async fn drain(
+        &self,
+        request: Request<DrainRequest>,
+    ) -> Result<Response<Confirmation>, Status> {
+        if !drain::is_allowed(request.remote_addr()) {
+            return Err(Status::permission_denied(
+                "the dataplane can only be drained from its own node",
+            ));
+        }
+        let grace_period = Duration::from_secs(request.into_inner().grace_period_seconds as u64);
+        if !self.drain_state.start() {
+            return Ok(Response::new(Confirmation {
+                confirmation: "success, the dataplane is already draining".to_string(),
+            }));
+        }
+
+        // new connections are refused from now on, and the readiness probe
+        // fails so that the control plane stops programming this instance.
+        if let Err(err) = self.draining_map.lock().await.set(0, 1, 0) {
+            return Err(Status::internal(format!("failure: {}", err)));
+        }
+        self.health_reporter
+            .clone()
+            .set_service_status("", ServingStatus::NotServing)
+            .await;
+
+        info!("draining, exiting in {}s", grace_period.as_secs());
+        tokio::spawn(async move {
+            tokio::time::sleep(grace_period).await;
+            info!("drained, exiting");
+            std::process::exit(0);
+        });
+
+        Ok(Response::new(Confirmation {
+            confirmation: format!(
+                "success, draining for {}s before exiting",
+                grace_period.as_secs()
+            ),
+        }))
+    }
+
+    async fn watch_backends(
+        &self,
+        _request: Request<WatchBackendsRequest>,
+    ) -> Result<Response<Self::WatchBackendsStream>, Status> {
+        // subscribing before taking the snapshot, the changes made in between
+        // are sent again after it, which is harmless.
+        let mut events = self.events.subscribe();
+        let snapshot = self.snapshot_event().await?;
+
+        let (tx, rx) = mpsc::channel(WATCH_BUFFER_SIZE);
+        let service = self.clone();
+        tokio::spawn(async move {
+            let mut next = Ok(snapshot);
+            loop {
+                let failed = next.is_err();
+                if tx.send(next).await.is_err() || failed {
+                    // the watcher disconnected, or the stream failed.
+                    return;
+                }
+
+                let event = tokio::select! {
+                    _ = tx.closed() => return,
+                    event = events.recv() => event,
+                };
+                next = match event {
+                    Ok(event) => Ok(event),
+                    Err(RecvError::Lagged(missed)) => {
+                        info!(
+                            "watcher missed {} backends events, sending a snapshot",
+                            missed
+                        );
+                        service.snapshot_event().await
+                    }
+                    Err(RecvError::Closed) => return,
+                };
+            }
+        });
+
+        Ok(Response::new(ReceiverStream::new(rx)))
+    }
+}
+
+#[cfg(all(test, feature = "ebpf_tests"))]
+mod ebpf_tests {
+    use std::path::Path;
+
+    use aya::{include_bytes_aligned, Bpf};
+
+    use super::*;
+    use crate::status::AttachMode;
+
+    // loads the eBPF objects, so that the service is backed by real maps, or
+    // returns None if this host can't load them.
+    fn load_bpf() -> Option<Bpf> {
+        if unsafe { libc::geteuid() } != 0 {
+            eprintln!("skipping: loading eBPF maps requires root");
+            return None;
+        }
+        if !Path::new("/sys/fs/bpf").exists() {
+            eprintln!("skipping: BPF is not available on this host");
+            return None;
+        }
+
+        #[cfg(debug_assertions)]
+        let bpf = Bpf::load(include_bytes_aligned!(
+            "../../target/bpfel-unknown-none/debug/loader"
+        ));
+        #[cfg(not(debug_assertions))]
+        let bpf = Bpf::load(include_bytes_aligned!(
+            "../../target/bpfel-unknown-none/release/loader"
+        ));
+        Some(bpf.expect("failed to load the eBPF objects"))
+    }
+
+    fn backend_service(bpf: &mut Bpf) -> BackendService {
+        let map = |name: &str| {
+            bpf.take_map(name)
+                .unwrap_or_else(|| panic!("no map named {}", name))
+        };
+        BackendService::new(
+            HashMap::try_from(map("BACKENDS")).unwrap(),
+            HashMap::try_from(map("GATEWAY_INDEXES")).unwrap(),
+            HashMap::try_from(map("LB_CONNECTIONS")).unwrap(),
+            HashMap::try_from(map("BACKEND_CONNECTIONS")).unwrap(),
+            Array::try_from(map("DRAINING")).unwrap(),
+            Attachment {
+                interface: "lo".to_string(),
+                ifindex: 1,
+                mode: AttachMode::Tc,
+            },
+            tonic_health::server::health_reporter().0,
+        )
+    }
+
+    #[tokio::test]
+    async fn remove_releases_the_backend_connections() {
+        let mut bpf = match load_bpf() {
+            Some(bpf) => bpf,
+            None => return,
+        };
+        let server = backend_service(&mut bpf);
+
+        let key = BackendKey {
+            ip: u32::from(Ipv4Addr::new(172, 18, 0, 240)),
+            port: 8080,
+            protocol: PROTOCOL_TCP,
+        };
+        let backend = Backend {
+            daddr: u32::from(Ipv4Addr::new(10, 244, 0, 10)),
+            dport: 80,
+            ifindex: 1,
+            max_connections: 1,
+        };
+        let mut backends = [Backend::default(); BACKENDS_ARRAY_CAPACITY];
+        backends[0] = backend;
+        server
+            .insert_and_reset_index(
+                key,
+                BackendList {
+                    backends,
+                    backends_len: 1,
+                    tcp_idle_timeout_secs: 0,
+                    action: ACTION_FORWARD,
+                },
+            )
+            .await
+            .unwrap();
+
+        // a connection to the backend, counted like the dataplane does.
+        let backend_conn_key = BackendKey {
+            ip: backend.daddr,
+            port: backend.dport,
+            protocol: PROTOCOL_TCP,
+        };
+        server
+            .tcp_conns_map
+            .lock()
+            .await
+            .insert(
+                ClientKey {
+                    ip: u32::from(Ipv4Addr::new(10, 0, 0, 1)),
+                    port: 40000,
+                },
+                LoadBalancerMapping {
+                    backend,
+                    backend_key: key,
+                    tcp_state: None,
+                    last_seen_ns: 0,
+                },
+                0,
+            )
+            .unwrap();
+        server
+            .backend_conns_map
+            .lock()
+            .await
+            .insert(backend_conn_key, 1, 0)
+            .unwrap();
+
+        assert!(server.remove(key).await.unwrap());
+
+        assert_eq!(server.tcp_conns_map.lock().await.iter().count(), 0);
+        assert!(matches!(
+            server
+                .backend_conns_map
+                .lock()
+                .await
+                .get(&backend_conn_key, 0),
+            Err(MapError::KeyNotFound)
+        ));
+    }
 }
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
+*/
+
+use crate::backends::DataplaneStatus;
+
+/// How the dataplane programs were attached to the interface.
+#[derive(Clone, Copy, Debug, PartialEq, Eq)]
+pub enum AttachMode {
+    /// The loader attached the programs to the TC ingress and egress hooks.
+    Tc,
+    /// The programs were loaded and attached by bpfd.
+    Bpfd,
+}
+
+impl AttachMode {
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            AttachMode::Tc => "tc",
+            AttachMode::Bpfd => "bpfd",
+        }
+    }
+}
+
+/// The interface the dataplane programs are attached to, and how.
+#[derive(Clone, Debug)]
+pub struct Attachment {
+    pub interface: String,
+    pub ifindex: u32,
+    pub mode: AttachMode,
+}
+
+impl Attachment {
+    /// Returns the status of the dataplane with this attachment, the provided
+    /// number of programmed VIPs and draining state.
+    pub fn status(&self, vips: u32, draining: bool) -> DataplaneStatus {
+        DataplaneStatus {
+            interface: self.interface.clone(),
+            ifindex: self.ifindex,
+            attach_mode: self.mode.as_str().to_string(),
+            vips,
+            draining,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn status_reflects_the_attachment() {
+        let attachment = Attachment {
+            interface: "eth0".to_string(),
+            ifindex: 2,
+            mode: AttachMode::Tc,
+        };
+
+        let status = attachment.status(3, false);
+        assert_eq!(status.interface, "eth0");
+        assert_eq!(status.ifindex, 2);
+        assert_eq!(status.attach_mode, "tc");
+        assert_eq!(status.vips, 3);
+        assert!(!status.draining);
+
+        let attachment = Attachment {
+            mode: AttachMode::Bpfd,
+            ..attachment
+        };
+        assert_eq!(attachment.status(0, false).attach_mode, "bpfd");
+        assert!(attachment.status(0, true).draining);
+    }
+}
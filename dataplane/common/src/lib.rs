@@ -8,6 +8,20 @@ SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 
 pub const BACKENDS_ARRAY_CAPACITY: usize = 128;
 pub const BPF_MAPS_CAPACITY: u32 = 128;
+// LAST_SEEN_RESOLUTION_NS is how often the last_seen_ns of a TCP connection is
+// refreshed while it has traffic, so that the connection tracking map isn't
+// written on every packet.
+pub const LAST_SEEN_RESOLUTION_NS: u64 = 1_000_000_000;
+// ACTION_FORWARD, ACTION_REJECT and ACTION_DROP are the actions applied to the
+// new connections and datagrams to a VIP, they match the Targets.Action values
+// of the API.
+pub const ACTION_FORWARD: u32 = 0;
+pub const ACTION_REJECT: u32 = 1;
+pub const ACTION_DROP: u32 = 2;
+// PROTOCOL_TCP and PROTOCOL_UDP are the protocols of a BackendKey, they're the
+// IP protocol numbers of TCP and UDP.
+pub const PROTOCOL_TCP: u32 = 6;
+pub const PROTOCOL_UDP: u32 = 17;
 
 #[derive(Copy, Clone, Debug, Default)]
 #[repr(C)]
@@ -15,6 +29,9 @@ pub struct Backend {
     pub daddr: u32,
     pub dport: u32,
     pub ifindex: u16,
+    // max_connections is the maximum number of concurrent connections to the
+    // backend, zero means that the backend has no limit.
+    pub max_connections: u32,
 }
 
 #[cfg(feature = "user")]
@@ -25,6 +42,9 @@ unsafe impl aya::Pod for Backend {}
 pub struct BackendKey {
     pub ip: u32,
     pub port: u32,
+    // protocol is PROTOCOL_TCP or PROTOCOL_UDP, as a TCP and a UDP VIP can
+    // share the same address and port.
+    pub protocol: u32,
 }
 
 #[cfg(feature = "user")]
@@ -36,6 +56,13 @@ pub struct BackendList {
     pub backends: [Backend; BACKENDS_ARRAY_CAPACITY],
     // backends_len is the length of the backends array
     pub backends_len: u16,
+    // tcp_idle_timeout_secs is the time after which the TCP connections to the
+    // VIP which had no traffic are evicted from LB_CONNECTIONS, zero means
+    // that they're only evicted once closed.
+    pub tcp_idle_timeout_secs: u32,
+    // action is one of ACTION_FORWARD, ACTION_REJECT or ACTION_DROP, the
+    // backends are ignored unless it's ACTION_FORWARD.
+    pub action: u32,
 }
 
 #[cfg(feature = "user")]
@@ -74,6 +101,9 @@ pub struct LoadBalancerMapping {
     pub backend: Backend,
     pub backend_key: BackendKey,
     pub tcp_state: Option<TCPState>,
+    // last_seen_ns is the time of the last packet of the connection, as
+    // returned by bpf_ktime_get_ns, within LAST_SEEN_RESOLUTION_NS.
+    pub last_seen_ns: u64,
 }
 
 #[cfg(feature = "user")]
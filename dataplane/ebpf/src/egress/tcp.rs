@@ -8,15 +8,15 @@ use core::mem;
 
 use aya_ebpf::{
     bindings::{TC_ACT_OK, TC_ACT_PIPE},
-    helpers::bpf_csum_diff,
+    helpers::{bpf_csum_diff, bpf_ktime_get_ns},
     programs::TcContext,
 };
 use aya_log_ebpf::info;
-use common::ClientKey;
+use common::{ClientKey, LAST_SEEN_RESOLUTION_NS};
 use network_types::{eth::EthHdr, ip::Ipv4Hdr, tcp::TcpHdr};
 
 use crate::{
-    utils::{csum_fold_helper, ptr_at, update_tcp_conns},
+    utils::{csum_fold_helper, ptr_at, remove_tcp_conn, update_tcp_conns},
     LB_CONNECTIONS,
 };
 
@@ -78,13 +78,18 @@ pub fn handle_tcp_egress(ctx: TcContext) -> Result<i32, i64> {
     // If the packet has the RST flag set, it means the connection is being terminated, so remove it
     // from our map.
     if tcp_hdr_ref.rst() == 1 {
-        unsafe {
-            LB_CONNECTIONS.remove(&client_key)?;
-        }
+        remove_tcp_conn(&client_key, &lb_mapping.backend)?;
     }
 
+    // the traffic from the backend also keeps the connection from being
+    // evicted as idle.
     let mut mapping = *lb_mapping;
-    update_tcp_conns(tcp_hdr_ref, &client_key, &mut mapping)?;
+    let now = unsafe { bpf_ktime_get_ns() };
+    let refresh = now.saturating_sub(mapping.last_seen_ns) >= LAST_SEEN_RESOLUTION_NS;
+    if refresh {
+        mapping.last_seen_ns = now;
+    }
+    update_tcp_conns(tcp_hdr_ref, &client_key, &mut mapping, refresh)?;
 
     Ok(TC_ACT_PIPE)
 }
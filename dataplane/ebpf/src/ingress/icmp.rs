@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
+*/
+
+use core::mem;
+
+use aya_ebpf::{
+    bindings::TC_ACT_PIPE,
+    helpers::{bpf_csum_diff, bpf_skb_change_tail},
+    programs::TcContext,
+};
+use aya_log_ebpf::info;
+use network_types::{
+    eth::EthHdr,
+    icmp::IcmpHdr,
+    ip::{IpProto, Ipv4Hdr},
+};
+
+use crate::utils::{csum_fold_helper, ptr_at};
+
+const ICMP_TYPE_DEST_UNREACH: u8 = 3;
+const ICMP_CODE_PORT_UNREACH: u8 = 3;
+const ICMP_TTL: u8 = 64;
+
+// Number of bytes following the offending datagram's IP header which are
+// quoted back in the ICMP message, see RFC 792.
+const ICMP_QUOTED_LEN: usize = 8;
+
+// The L3 part of an ICMP port unreachable message: the IP header addressed to
+// the client, the ICMP header and the quoted headers of the offending datagram.
+#[repr(C)]
+struct PortUnreachable {
+    ip: Ipv4Hdr,
+    icmp: IcmpHdr,
+    quoted_ip: Ipv4Hdr,
+    quoted_l4: [u8; ICMP_QUOTED_LEN],
+}
+
+// Rewrites the UDP datagram or TCP segment in the provided context into an
+// ICMP port unreachable message addressed back to its sender, on behalf of the
+// VIP it was sent to. As the packet itself is replaced, exactly one ICMP
+// message is emitted per packet and nothing is forwarded to the backends.
+pub fn send_port_unreachable(ctx: &mut TcContext) -> Result<i32, i64> {
+    let ip_hdr: *const Ipv4Hdr = unsafe { ptr_at(ctx, EthHdr::LEN)? };
+    let quoted_l4: *const [u8; ICMP_QUOTED_LEN] =
+        unsafe { ptr_at(ctx, EthHdr::LEN + Ipv4Hdr::LEN)? };
+
+    let mut reply: PortUnreachable = unsafe { mem::zeroed() };
+    reply.quoted_ip = unsafe { *ip_hdr };
+    reply.quoted_l4 = unsafe { *quoted_l4 };
+
+    reply.ip = reply.quoted_ip;
+    reply.ip.tot_len = (mem::size_of::<PortUnreachable>() as u16).to_be();
+    reply.ip.id = 0;
+    reply.ip.frag_off = 0;
+    reply.ip.ttl = ICMP_TTL;
+    reply.ip.proto = IpProto::Icmp;
+    reply.ip.src_addr = reply.quoted_ip.dst_addr;
+    reply.ip.dst_addr = reply.quoted_ip.src_addr;
+    reply.ip.check = 0;
+
+    let ip_cksum = unsafe {
+        bpf_csum_diff(
+            mem::MaybeUninit::zeroed().assume_init(),
+            0,
+            &mut reply.ip as *mut Ipv4Hdr as *mut u32,
+            Ipv4Hdr::LEN as u32,
+            0,
+        )
+    } as u64;
+    reply.ip.check = csum_fold_helper(ip_cksum);
+
+    reply.icmp.type_ = ICMP_TYPE_DEST_UNREACH;
+    reply.icmp.code = ICMP_CODE_PORT_UNREACH;
+    reply.icmp.checksum = 0;
+
+    // the ICMP checksum covers the ICMP header and everything following it
+    let icmp_cksum = unsafe {
+        bpf_csum_diff(
+            mem::MaybeUninit::zeroed().assume_init(),
+            0,
+            &mut reply.icmp as *mut IcmpHdr as *mut u32,
+            (mem::size_of::<PortUnreachable>() - Ipv4Hdr::LEN) as u32,
+            0,
+        )
+    } as u64;
+    reply.icmp.checksum = csum_fold_helper(icmp_cksum);
+
+    info!(
+        ctx,
+        "No backends for svc ip: {:i}, replying with ICMP port unreachable to {:i}",
+        u32::from_be(reply.ip.src_addr),
+        u32::from_be(reply.ip.dst_addr),
+    );
+
+    // Resize the packet to fit the ICMP message exactly, the Ethernet header is
+    // left untouched so the kernel forwards the message back to the client.
+    let ret = unsafe {
+        bpf_skb_change_tail(
+            ctx.skb.skb,
+            (EthHdr::LEN + mem::size_of::<PortUnreachable>()) as u32,
+            0,
+        )
+    };
+    if ret != 0 {
+        info!(ctx, "Failed to resize the packet for the ICMP message");
+        return Ok(TC_ACT_PIPE);
+    }
+
+    ctx.store(EthHdr::LEN, &reply, 0)?;
+
+    Ok(TC_ACT_PIPE)
+}
@@ -4,5 +4,6 @@ Copyright 2023 The Kubernetes Authors.
 SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 */
 
+pub mod icmp;
 pub mod tcp;
 pub mod udp;
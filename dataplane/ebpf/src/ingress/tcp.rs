@@ -6,23 +6,32 @@ SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 
 use core::mem;
 
-use aya_ebpf::{bindings::TC_ACT_OK, helpers::bpf_redirect_neigh, programs::TcContext};
+use aya_ebpf::{
+    bindings::{TC_ACT_OK, TC_ACT_SHOT},
+    helpers::{bpf_ktime_get_ns, bpf_redirect_neigh},
+    programs::TcContext,
+};
 use aya_log_ebpf::{debug, info};
 
 use memoffset::offset_of;
 use network_types::{eth::EthHdr, ip::Ipv4Hdr, tcp::TcpHdr};
 
 use crate::{
-    utils::{ptr_at, set_ipv4_dest_port, set_ipv4_ip_dst, update_tcp_conns},
-    BACKENDS, GATEWAY_INDEXES, LB_CONNECTIONS,
+    ingress::icmp::send_port_unreachable,
+    utils::{
+        acquire_backend_connection, backend_at_capacity, ptr_at, remove_tcp_conn,
+        set_ipv4_dest_port, set_ipv4_ip_dst, update_tcp_conns,
+    },
+    BACKENDS, DRAINING, GATEWAY_INDEXES, LB_CONNECTIONS,
 };
 use common::{
-    Backend, BackendKey, ClientKey, LoadBalancerMapping, TCPState, BACKENDS_ARRAY_CAPACITY,
+    Backend, BackendKey, ClientKey, LoadBalancerMapping, TCPState, ACTION_DROP, ACTION_REJECT,
+    BACKENDS_ARRAY_CAPACITY, LAST_SEEN_RESOLUTION_NS, PROTOCOL_TCP,
 };
 
 const TCP_CSUM_OFF: u32 = (EthHdr::LEN + Ipv4Hdr::LEN + offset_of!(TcpHdr, check)) as u32;
 
-pub fn handle_tcp_ingress(ctx: TcContext) -> Result<i32, i64> {
+pub fn handle_tcp_ingress(mut ctx: TcContext) -> Result<i32, i64> {
     let ip_hdr: *mut Ipv4Hdr = unsafe { ptr_at(&ctx, EthHdr::LEN)? };
 
     let tcp_header_offset = EthHdr::LEN + Ipv4Hdr::LEN;
@@ -38,13 +47,17 @@ pub fn handle_tcp_ingress(ctx: TcContext) -> Result<i32, i64> {
         port: (u16::from_be(unsafe { (*tcp_hdr).source })) as u32,
     };
     // The backend that is responsible for handling this TCP connection.
-    let mut backend: Backend;
+    let backend: Backend;
     // The Gateway that the TCP connections is forwarded from.
     let backend_key: BackendKey;
     // Flag to check whether this is a new connection.
     let mut new_conn = false;
     // The state of this TCP connection.
     let mut tcp_state = Some(TCPState::default());
+    // The time of this packet, and of the last packet recorded for this
+    // connection, which the api-server uses to evict idle connections.
+    let now = unsafe { bpf_ktime_get_ns() };
+    let mut last_seen_ns = now;
 
     // Try to find the backend previously used for this connection. If not found, it means that
     // this is a new connection, so assign it the next backend in line.
@@ -52,44 +65,74 @@ pub fn handle_tcp_ingress(ctx: TcContext) -> Result<i32, i64> {
         backend = val.backend;
         backend_key = val.backend_key;
         tcp_state = val.tcp_state;
+        last_seen_ns = val.last_seen_ns;
     } else {
         new_conn = true;
 
         backend_key = BackendKey {
             ip: u32::from_be(original_daddr),
             port: (u16::from_be(original_dport)) as u32,
+            protocol: PROTOCOL_TCP,
         };
         let backend_list = unsafe { BACKENDS.get(&backend_key) }.ok_or(TC_ACT_OK)?;
+        // a draining dataplane only keeps forwarding the established
+        // connections.
+        if unsafe { DRAINING.get(0) }.is_some_and(|draining| *draining != 0) {
+            debug!(&ctx, "Refusing a new connection while draining");
+            return Ok(TC_ACT_SHOT);
+        }
+        // the route rejects or drops the new connections to the VIP instead
+        // of forwarding them to its backends.
+        match backend_list.action {
+            ACTION_REJECT => return send_port_unreachable(&mut ctx),
+            ACTION_DROP => return Ok(TC_ACT_SHOT),
+            _ => {}
+        }
         let backend_index = unsafe { GATEWAY_INDEXES.get(&backend_key) }.ok_or(TC_ACT_OK)?;
 
         debug!(&ctx, "Destination backend index: {}", *backend_index);
         debug!(&ctx, "Backends length: {}", backend_list.backends_len);
 
         // this check asserts that we don't use a "zero-value" Backend
-        if backend_list.backends_len <= *backend_index {
-            return Ok(TC_ACT_OK);
-        }
-        // the bpf verifier is aware of variables that are used as an index for
-        // an array and requires that we check the array boundaries against
-        // the index to ensure our access is in-bounds.
-        if *backend_index as usize >= BACKENDS_ARRAY_CAPACITY {
+        if backend_list.backends_len == 0 {
             return Ok(TC_ACT_OK);
         }
 
-        backend = backend_list.backends[0];
-        if let Some(val) = backend_list.backends.get(*backend_index as usize) {
-            backend = *val;
-        } else {
-            debug!(
-                &ctx,
-                "Failed to find backend in backends_list at index {}, falling back to 0th index; backends_len: {} ",
-                *backend_index,
-                backend_list.backends_len
-            )
+        // select the next backend in line which isn't at its max_connections
+        // limit. Backends are retrieved with get, as the bpf verifier requires
+        // that the array boundaries are checked against the index.
+        let mut index = *backend_index;
+        let mut selected: Option<(Backend, u16)> = None;
+        for attempt in 0..BACKENDS_ARRAY_CAPACITY {
+            if attempt >= backend_list.backends_len as usize {
+                break;
+            }
+            if index >= backend_list.backends_len {
+                index = 0;
+            }
+            if let Some(candidate) = backend_list.backends.get(index as usize) {
+                if !backend_at_capacity(candidate) {
+                    selected = Some((*candidate, index));
+                    break;
+                }
+            }
+            index += 1;
         }
+        let (selected_backend, selected_index) = match selected {
+            Some(selected) => selected,
+            None => {
+                debug!(
+                    &ctx,
+                    "All the backends reached their max connections; backends_len: {}",
+                    backend_list.backends_len
+                );
+                return Ok(TC_ACT_SHOT);
+            }
+        };
+        backend = selected_backend;
 
         // move the index to the next backend in our list
-        let mut next = *backend_index + 1;
+        let mut next = selected_index + 1;
         if next >= backend_list.backends_len {
             next = 0;
         }
@@ -115,18 +158,20 @@ pub fn handle_tcp_ingress(ctx: TcContext) -> Result<i32, i64> {
     // If the packet has the RST flag set, it means the connection is being terminated, so remove it
     // from our map.
     if tcp_hdr_ref.rst() == 1 {
-        unsafe {
-            LB_CONNECTIONS.remove(&client_key)?;
-        }
+        remove_tcp_conn(&client_key, &backend)?;
     }
 
     let mut lb_mapping = LoadBalancerMapping {
         backend,
         backend_key,
         tcp_state,
+        last_seen_ns: now,
     };
 
-    update_tcp_conns(tcp_hdr_ref, &client_key, &mut lb_mapping)?;
+    // the connection is recorded again once its last_seen_ns is stale, so
+    // that it's not evicted while it has traffic.
+    let refresh = now.saturating_sub(last_seen_ns) >= LAST_SEEN_RESOLUTION_NS;
+    update_tcp_conns(tcp_hdr_ref, &client_key, &mut lb_mapping, refresh)?;
 
     let backend_ip = backend.daddr.to_be();
     let ret = set_ipv4_ip_dst(&ctx, TCP_CSUM_OFF, &original_daddr, backend_ip);
@@ -154,6 +199,7 @@ pub fn handle_tcp_ingress(ctx: TcContext) -> Result<i32, i64> {
         unsafe {
             LB_CONNECTIONS.insert(&client_key, &lb_mapping, 0_u64)?;
         }
+        acquire_backend_connection(&backend)?;
 
         // since this is a new connection, there is nothing else to do, so exit early
         info!(&ctx, "redirect action: {}", action);
@@ -6,21 +6,29 @@ SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 
 use core::mem;
 
-use aya_ebpf::{bindings::TC_ACT_PIPE, helpers::bpf_redirect_neigh, programs::TcContext};
+use aya_ebpf::{
+    bindings::{TC_ACT_PIPE, TC_ACT_SHOT},
+    helpers::{bpf_ktime_get_ns, bpf_redirect_neigh},
+    programs::TcContext,
+};
 use aya_log_ebpf::{debug, info};
 
 use memoffset::offset_of;
 use network_types::{eth::EthHdr, ip::Ipv4Hdr, udp::UdpHdr};
 
 use crate::{
+    ingress::icmp::send_port_unreachable,
     utils::{ptr_at, set_ipv4_dest_port, set_ipv4_ip_dst},
     BACKENDS, GATEWAY_INDEXES, LB_CONNECTIONS,
 };
-use common::{BackendKey, ClientKey, LoadBalancerMapping, BACKENDS_ARRAY_CAPACITY};
+use common::{
+    BackendKey, ClientKey, LoadBalancerMapping, ACTION_DROP, ACTION_REJECT,
+    BACKENDS_ARRAY_CAPACITY, PROTOCOL_UDP,
+};
 
 const UDP_CSUM_OFF: u32 = (EthHdr::LEN + Ipv4Hdr::LEN + offset_of!(UdpHdr, check)) as u32;
 
-pub fn handle_udp_ingress(ctx: TcContext) -> Result<i32, i64> {
+pub fn handle_udp_ingress(mut ctx: TcContext) -> Result<i32, i64> {
     let ip_hdr: *mut Ipv4Hdr = unsafe { ptr_at(&ctx, EthHdr::LEN)? };
 
     let udp_header_offset = EthHdr::LEN + Ipv4Hdr::LEN;
@@ -33,8 +41,24 @@ pub fn handle_udp_ingress(ctx: TcContext) -> Result<i32, i64> {
     let backend_key = BackendKey {
         ip: u32::from_be(original_daddr),
         port: (u16::from_be(original_dport)) as u32,
+        protocol: PROTOCOL_UDP,
     };
     let backend_list = unsafe { BACKENDS.get(&backend_key) }.ok_or(TC_ACT_PIPE)?;
+
+    // the route rejects or drops the datagrams to the VIP instead of
+    // forwarding them to its backends.
+    match backend_list.action {
+        ACTION_REJECT => return send_port_unreachable(&mut ctx),
+        ACTION_DROP => return Ok(TC_ACT_SHOT),
+        _ => {}
+    }
+
+    // the VIP is programmed but none of its backends are healthy, let the
+    // client know right away rather than silently dropping the datagram.
+    if backend_list.backends_len == 0 {
+        return send_port_unreachable(&mut ctx);
+    }
+
     let backend_index = unsafe { GATEWAY_INDEXES.get(&backend_key) }.ok_or(TC_ACT_PIPE)?;
 
     info!(
@@ -86,6 +110,7 @@ pub fn handle_udp_ingress(ctx: TcContext) -> Result<i32, i64> {
             backend,
             backend_key,
             tcp_state: None,
+            last_seen_ns: bpf_ktime_get_ns(),
         };
         LB_CONNECTIONS.insert(&client_key, &lb_mapping, 0_u64)?;
     };
@@ -18,7 +18,7 @@ mod utils;
 use aya_ebpf::{
     bindings::{TC_ACT_OK, TC_ACT_PIPE, TC_ACT_SHOT},
     macros::{classifier, map},
-    maps::HashMap,
+    maps::{Array, HashMap},
     programs::TcContext,
 };
 
@@ -48,6 +48,17 @@ static mut GATEWAY_INDEXES: HashMap<BackendKey, u16> =
 static mut LB_CONNECTIONS: HashMap<ClientKey, LoadBalancerMapping> =
     HashMap::<ClientKey, LoadBalancerMapping>::with_max_entries(128, 0);
 
+// BACKEND_CONNECTIONS counts the active connections of the backends which have
+// a max_connections limit, keyed by the address and port of the backend.
+#[map(name = "BACKEND_CONNECTIONS")]
+static mut BACKEND_CONNECTIONS: HashMap<BackendKey, u32> =
+    HashMap::<BackendKey, u32>::with_max_entries(BPF_MAPS_CAPACITY, 0);
+
+// DRAINING holds a single flag which the api-server sets to 1 when the
+// dataplane is draining, so that new connections are refused.
+#[map(name = "DRAINING")]
+static mut DRAINING: Array<u32> = Array::<u32>::with_max_entries(1, 0);
+
 // -----------------------------------------------------------------------------
 // Ingress
 // -----------------------------------------------------------------------------
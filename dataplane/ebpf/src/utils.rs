@@ -14,8 +14,8 @@ use aya_log_ebpf::info;
 use core::mem;
 use network_types::{eth::EthHdr, ip::Ipv4Hdr, tcp::TcpHdr};
 
-use crate::LB_CONNECTIONS;
-use common::{ClientKey, LoadBalancerMapping, TCPState};
+use crate::{BACKEND_CONNECTIONS, LB_CONNECTIONS};
+use common::{Backend, BackendKey, ClientKey, LoadBalancerMapping, TCPState, PROTOCOL_TCP};
 
 use memoffset::offset_of;
 
@@ -111,23 +111,23 @@ pub fn process_tcp_state_transition(hdr: &TcpHdr, state: &mut TCPState) -> bool
 }
 
 // Modifies the map tracking TCP connections based on the current state
-// of the TCP connection and the incoming TCP packet's header.
+// of the TCP connection and the incoming TCP packet's header. The connection
+// is also recorded again if refresh is set, to update its last_seen_ns.
 #[inline(always)]
 pub fn update_tcp_conns(
     hdr: &TcpHdr,
     client_key: &ClientKey,
     lb_mapping: &mut LoadBalancerMapping,
+    refresh: bool,
 ) -> Result<(), i64> {
     if let Some(ref mut tcp_state) = lb_mapping.tcp_state {
         let transitioned = process_tcp_state_transition(hdr, tcp_state);
         if let TCPState::Closed = tcp_state {
-            unsafe {
-                return LB_CONNECTIONS.remove(client_key);
-            }
+            return remove_tcp_conn(client_key, &lb_mapping.backend);
         }
         // If the connection has not reached the Closed state yet, but it did transition to a new state,
         // then record the new state.
-        if transitioned {
+        if transitioned || refresh {
             unsafe {
                 return LB_CONNECTIONS.insert(client_key, lb_mapping, 0_u64);
             }
@@ -136,6 +136,62 @@ pub fn update_tcp_conns(
     Ok(())
 }
 
+// Stops tracking the provided connection and releases its connection to the
+// backend. Removing the connection fails if it's not tracked anymore, so its
+// backend connection is only released once.
+pub fn remove_tcp_conn(client_key: &ClientKey, backend: &Backend) -> Result<(), i64> {
+    unsafe {
+        LB_CONNECTIONS.remove(client_key)?;
+    }
+    if backend.max_connections == 0 {
+        return Ok(());
+    }
+
+    let key = backend_connections_key(backend);
+    match unsafe { BACKEND_CONNECTIONS.get(&key) } {
+        Some(count) if *count > 1 => unsafe {
+            BACKEND_CONNECTIONS.insert(&key, &(*count - 1), 0_u64)
+        },
+        Some(_) => unsafe { BACKEND_CONNECTIONS.remove(&key) },
+        None => Ok(()),
+    }
+}
+
+// Indicates whether the provided backend reached its max_connections limit.
+// The connections aren't counted atomically, so concurrent new connections
+// can exceed the limit slightly.
+pub fn backend_at_capacity(backend: &Backend) -> bool {
+    if backend.max_connections == 0 {
+        return false;
+    }
+    match unsafe { BACKEND_CONNECTIONS.get(&backend_connections_key(backend)) } {
+        Some(count) => *count >= backend.max_connections,
+        None => false,
+    }
+}
+
+// Counts a new connection to the provided backend, if it has a
+// max_connections limit.
+pub fn acquire_backend_connection(backend: &Backend) -> Result<(), i64> {
+    if backend.max_connections == 0 {
+        return Ok(());
+    }
+
+    let key = backend_connections_key(backend);
+    let count = unsafe { BACKEND_CONNECTIONS.get(&key) }
+        .copied()
+        .unwrap_or(0);
+    unsafe { BACKEND_CONNECTIONS.insert(&key, &(count + 1), 0_u64) }
+}
+
+fn backend_connections_key(backend: &Backend) -> BackendKey {
+    BackendKey {
+        ip: backend.daddr,
+        port: backend.dport,
+        protocol: PROTOCOL_TCP,
+    }
+}
+
 // inspired by https://github.com/torvalds/linux/blob/master/samples/bpf/tcbpf1_kern.c
 // update dst_addr in the ip_hdr
 // recalculate the checksums
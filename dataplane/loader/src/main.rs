@@ -4,33 +4,102 @@ Copyright 2023 The Kubernetes Authors.
 SPDX-License-Identifier: (GPL-2.0-only OR BSD-2-Clause)
 */
 
-use std::{net::Ipv4Addr, path::Path};
+use std::{
+    net::{Ipv4Addr, SocketAddr},
+    path::Path,
+    time::Duration,
+};
 
 use anyhow::Context;
+use api_server::backends::backends_client::BackendsClient;
+use api_server::backends::DrainRequest;
+use api_server::netutils::if_nametoindex;
 use api_server::start as start_api_server;
-use aya::maps::{HashMap, Map, MapData};
+use api_server::status::{AttachMode, Attachment};
+use aya::maps::{Array, HashMap, Map, MapData};
 use aya::programs::{tc, SchedClassifier, TcAttachType};
-use aya::{include_bytes_aligned, Bpf};
+use aya::{include_bytes_aligned, BpfLoader, VerifierLogLevel};
 use aya_log::BpfLogger;
-use clap::Parser;
+use clap::{Parser, ValueEnum};
 use common::{BackendKey, BackendList, ClientKey, LoadBalancerMapping};
-use log::{info, warn};
+use log::{error, info, warn};
 
 #[derive(Debug, Parser)]
 struct Opt {
     #[clap(short, long, default_value = "lo")]
     iface: String,
+    /// Drain the running dataplane instead of starting one, this is used as
+    /// the preStop hook of the dataplane Pods.
+    #[clap(long)]
+    drain: bool,
+    /// How long the established connections are kept when draining.
+    #[clap(long, default_value = "30")]
+    drain_grace_period_seconds: u32,
+    /// The maximum size in bytes of the messages sent and received by the API.
+    #[clap(long, default_value_t = api_server::DEFAULT_MAX_MESSAGE_SIZE)]
+    max_message_size: usize,
+    /// The verbosity of the eBPF verifier log, which is logged and returned
+    /// when the verifier rejects a program.
+    #[clap(long, value_enum, default_value_t = VerifierLogVerbosity::Debug)]
+    verifier_log_level: VerifierLogVerbosity,
+    /// Serve the CPU profiles of the dataplane under /debug/pprof/profile on
+    /// the --pprof-bind-address.
+    #[clap(long)]
+    enable_pprof: bool,
+    /// The address the pprof endpoint binds to when enabled. It's not
+    /// protected, so it should be a loopback address.
+    #[clap(long, default_value = api_server::pprof::DEFAULT_BIND_ADDRESS)]
+    pprof_bind_address: SocketAddr,
+}
+
+/// The port the API is served on, on all the addresses.
+const API_PORT: u16 = 9874;
+
+/// The verbosity of the eBPF verifier log.
+#[derive(Clone, Copy, Debug, PartialEq, Eq, ValueEnum)]
+enum VerifierLogVerbosity {
+    /// No verifier log is captured.
+    Disable,
+    /// The verifier log reports the rejected instructions, along with the
+    /// verification statistics.
+    Debug,
+    /// The verifier log reports the state of every verified instruction,
+    /// along with the verification statistics.
+    Verbose,
+}
+
+impl From<VerifierLogVerbosity> for VerifierLogLevel {
+    fn from(verbosity: VerifierLogVerbosity) -> Self {
+        match verbosity {
+            VerifierLogVerbosity::Disable => VerifierLogLevel::DISABLE,
+            VerifierLogVerbosity::Debug => VerifierLogLevel::DEBUG | VerifierLogLevel::STATS,
+            VerifierLogVerbosity::Verbose => VerifierLogLevel::VERBOSE | VerifierLogLevel::STATS,
+        }
+    }
 }
 
 #[tokio::main]
 async fn main() -> Result<(), anyhow::Error> {
     let opt = Opt::parse();
 
+    if opt.drain {
+        env_logger::init();
+        return drain(opt.drain_grace_period_seconds).await;
+    }
+
     // TODO(astoycos) Let's determine a better way to let processes know bpfd is up and running,
     // Maybe if we're not running as a privileged deployment ALWAYS wait for bpfd?.
     std::thread::sleep(std::time::Duration::from_secs(5));
     env_logger::init();
 
+    if let Some(addr) = pprof_address(&opt)? {
+        tokio::spawn(async move {
+            if let Err(err) = api_server::pprof::serve(addr).await {
+                error!("failed to serve the pprof endpoint: {}", err);
+            }
+        });
+    }
+
     // If bpfd loaded the programs just load the maps.
     let bpfd_maps = Path::new("/run/bpfd/fs/maps");
 
@@ -51,27 +120,48 @@ async fn main() -> Result<(), anyhow::Error> {
                 .expect("no maps named LB_CONNECTIONS"),
         )
         .try_into()?;
+        let backend_conns: HashMap<_, BackendKey, u32> = Map::HashMap(
+            MapData::from_pin(bpfd_maps.join("BACKEND_CONNECTIONS"))
+                .expect("no maps named BACKEND_CONNECTIONS"),
+        )
+        .try_into()?;
+        let draining: Array<_, u32> = Map::Array(
+            MapData::from_pin(bpfd_maps.join("DRAINING")).expect("no maps named DRAINING"),
+        )
+        .try_into()?;
 
         info!("starting api server");
         start_api_server(
             Ipv4Addr::new(0, 0, 0, 0),
-            9874,
+            API_PORT,
             backends,
             gateway_indexes,
             tcp_conns,
+            backend_conns,
+            draining,
+            Attachment {
+                ifindex: if_nametoindex(opt.iface.clone())?,
+                interface: opt.iface,
+                mode: AttachMode::Bpfd,
+            },
+            opt.max_message_size,
         )
         .await?;
     } else {
         info!("loading ebpf programs");
 
         #[cfg(debug_assertions)]
-        let mut bpf = Bpf::load(include_bytes_aligned!(
-            "../../target/bpfel-unknown-none/debug/loader"
-        ))?;
+        let mut bpf = BpfLoader::new()
+            .verifier_log_level(opt.verifier_log_level.into())
+            .load(include_bytes_aligned!(
+                "../../target/bpfel-unknown-none/debug/loader"
+            ))?;
         #[cfg(not(debug_assertions))]
-        let mut bpf = Bpf::load(include_bytes_aligned!(
-            "../../target/bpfel-unknown-none/release/loader"
-        ))?;
+        let mut bpf = BpfLoader::new()
+            .verifier_log_level(opt.verifier_log_level.into())
+            .load(include_bytes_aligned!(
+                "../../target/bpfel-unknown-none/release/loader"
+            ))?;
         if let Err(e) = BpfLogger::init(&mut bpf) {
             warn!("failed to initialize eBPF logger: {}", e);
         }
@@ -81,7 +171,9 @@ async fn main() -> Result<(), anyhow::Error> {
         let _ = tc::qdisc_add_clsact(&opt.iface);
         let ingress_program: &mut SchedClassifier =
             bpf.program_mut("tc_ingress").unwrap().try_into()?;
-        ingress_program.load()?;
+        ingress_program
+            .load()
+            .map_err(|err| program_load_error("tc_ingress", err))?;
         ingress_program
             .attach(&opt.iface, TcAttachType::Ingress)
             .context("failed to attach the ingress TC program")?;
@@ -90,7 +182,9 @@ async fn main() -> Result<(), anyhow::Error> {
 
         let egress_program: &mut SchedClassifier =
             bpf.program_mut("tc_egress").unwrap().try_into()?;
-        egress_program.load()?;
+        egress_program
+            .load()
+            .map_err(|err| program_load_error("tc_egress", err))?;
         egress_program
             .attach(&opt.iface, TcAttachType::Egress)
             .context("failed to attach the egress TC program")?;
@@ -106,13 +200,27 @@ async fn main() -> Result<(), anyhow::Error> {
             bpf.take_map("LB_CONNECTIONS")
                 .expect("no maps named LB_CONNECTIONS"),
         )?;
+        let backend_conns: HashMap<_, BackendKey, u32> = HashMap::try_from(
+            bpf.take_map("BACKEND_CONNECTIONS")
+                .expect("no maps named BACKEND_CONNECTIONS"),
+        )?;
+        let draining: Array<_, u32> =
+            Array::try_from(bpf.take_map("DRAINING").expect("no maps named DRAINING"))?;
 
         start_api_server(
             Ipv4Addr::new(0, 0, 0, 0),
-            9874,
+            API_PORT,
             backends,
             gateway_indexes,
             tcp_conns,
+            backend_conns,
+            draining,
+            Attachment {
+                ifindex: if_nametoindex(opt.iface.clone())?,
+                interface: opt.iface,
+                mode: AttachMode::Tc,
+            },
+            opt.max_message_size,
         )
         .await?;
     }
@@ -121,3 +229,178 @@ async fn main() -> Result<(), anyhow::Error> {
 
     Ok(())
 }
+
+// Returns the address the pprof endpoint is served on if it's enabled, which
+// must not be bound by the API already.
+fn pprof_address(opt: &Opt) -> Result<Option<SocketAddr>, anyhow::Error> {
+    if !opt.enable_pprof {
+        return Ok(None);
+    }
+    if opt.pprof_bind_address.port() == API_PORT {
+        anyhow::bail!(
+            "invalid --pprof-bind-address {}: the port is bound by the API",
+            opt.pprof_bind_address
+        );
+    }
+    Ok(Some(opt.pprof_bind_address))
+}
+
+// Logs the error returned when loading the program with the provided name and
+// wraps it. When the verifier rejects the program, the error includes the
+// whole verifier log, which is often too long to be read from the error
+// reported when the loader exits.
+fn program_load_error<E>(name: &str, err: E) -> anyhow::Error
+where
+    E: std::error::Error + Send + Sync + 'static,
+{
+    error!("failed to load the {} program: {}", name, err);
+    anyhow::Error::new(err).context(format!("failed to load the {} program", name))
+}
+
+// Drains the dataplane running in the same Pod, and waits for the grace period
+// so that the Pod is only terminated once the dataplane exited.
+async fn drain(grace_period_seconds: u32) -> Result<(), anyhow::Error> {
+    let mut client = BackendsClient::connect("http://127.0.0.1:9874").await?;
+    let confirmation = client
+        .drain(DrainRequest {
+            grace_period_seconds,
+        })
+        .await?
+        .into_inner();
+    info!("{}", confirmation.confirmation);
+
+    tokio::time::sleep(Duration::from_secs(grace_period_seconds as u64)).await;
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use std::fmt;
+
+    use aya::VerifierLogLevel;
+    use clap::Parser;
+
+    use super::{pprof_address, program_load_error, Opt, VerifierLogVerbosity};
+
+    // A load error reporting a verifier log, like aya's ProgramError::LoadError.
+    #[derive(Debug)]
+    struct RejectedProgram(&'static str);
+
+    impl fmt::Display for RejectedProgram {
+        fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+            write!(
+                f,
+                "the BPF_PROG_LOAD syscall failed. Verifier output: {}",
+                self.0
+            )
+        }
+    }
+
+    impl std::error::Error for RejectedProgram {}
+
+    #[test]
+    fn program_load_errors_include_the_verifier_log() {
+        let log = "0: (bf) r6 = r1\n1: (61) r0 = *(u32 *)(r6 +80)\ninvalid bpf_context access off=80 size=4\nprocessed 2 insns";
+        let err = program_load_error("tc_ingress", RejectedProgram(log));
+
+        assert_eq!(err.to_string(), "failed to load the tc_ingress program");
+        let reported = format!("{:#}", err);
+        assert!(
+            reported.contains(log),
+            "the verifier log is missing from: {}",
+            reported
+        );
+    }
+
+    #[test]
+    fn verifier_log_verbosities() {
+        for (verbosity, level) in [
+            (VerifierLogVerbosity::Disable, VerifierLogLevel::DISABLE),
+            (
+                VerifierLogVerbosity::Debug,
+                VerifierLogLevel::DEBUG | VerifierLogLevel::STATS,
+            ),
+            (
+                VerifierLogVerbosity::Verbose,
+                VerifierLogLevel::VERBOSE | VerifierLogLevel::STATS,
+            ),
+        ] {
+            assert_eq!(VerifierLogLevel::from(verbosity).bits(), level.bits());
+        }
+    }
+
+    #[test]
+    fn pprof_is_only_served_when_enabled() {
+        let opt = Opt::parse_from(["loader"]);
+        assert_eq!(pprof_address(&opt).unwrap(), None);
+
+        let opt = Opt::parse_from(["loader", "--pprof-bind-address", "127.0.0.1:6061"]);
+        assert_eq!(pprof_address(&opt).unwrap(), None);
+
+        let opt = Opt::parse_from(["loader", "--enable-pprof"]);
+        assert_eq!(
+            pprof_address(&opt).unwrap(),
+            Some("127.0.0.1:6060".parse().unwrap())
+        );
+
+        let opt = Opt::parse_from([
+            "loader",
+            "--enable-pprof",
+            "--pprof-bind-address",
+            "0.0.0.0:9874",
+        ]);
+        assert!(pprof_address(&opt).is_err());
+    }
+}
+
+#[cfg(all(test, feature = "ebpf_tests"))]
+mod ebpf_tests {
+    use std::path::Path;
+
+    use aya::programs::SchedClassifier;
+    use aya::{include_bytes_aligned, Bpf};
+
+    #[test]
+    fn ebpf_programs_pass_the_verifier() {
+        if unsafe { libc::geteuid() } != 0 {
+            eprintln!("skipping: loading eBPF programs requires root");
+            return;
+        }
+        if !Path::new("/sys/fs/bpf").exists() {
+            eprintln!("skipping: BPF is not available on this host");
+            return;
+        }
+
+        #[cfg(debug_assertions)]
+        let mut bpf = Bpf::load(include_bytes_aligned!(
+            "../../target/bpfel-unknown-none/debug/loader"
+        ))
+        .expect("failed to load the eBPF objects");
+        #[cfg(not(debug_assertions))]
+        let mut bpf = Bpf::load(include_bytes_aligned!(
+            "../../target/bpfel-unknown-none/release/loader"
+        ))
+        .expect("failed to load the eBPF objects");
+
+        for name in ["tc_ingress", "tc_egress"] {
+            let program: &mut SchedClassifier = bpf
+                .program_mut(name)
+                .unwrap_or_else(|| panic!("no program named {}", name))
+                .try_into()
+                .expect("unexpected program type");
+            program
+                .load()
+                .unwrap_or_else(|err| panic!("program {} was rejected: {}", name, err));
+        }
+
+        for name in [
+            "BACKENDS",
+            "GATEWAY_INDEXES",
+            "LB_CONNECTIONS",
+            "BACKEND_CONNECTIONS",
+            "DRAINING",
+        ] {
+            assert!(bpf.map(name).is_some(), "no map named {}", name);
+        }
+    }
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+// Config is the configuration of the control plane. It's loaded from a YAML
+// file, which is usually a ConfigMap mounted in the control plane Pod.
+type Config struct {
+	// ControllerName is the GatewayClass controller name which identifies the
+	// GatewayClasses (and their Gateways and routes) managed by this controller.
+	ControllerName string `json:"controllerName,omitempty"`
+
+	// WatchNamespace is a comma separated list of namespaces to watch Gateways
+	// and routes in. All namespaces are watched if empty.
+	WatchNamespace string `json:"watchNamespace,omitempty"`
+
+	// LBProvider is the provider of LoadBalancer Services for Gateways, one
+	// of: metallb, cloud, none.
+	LBProvider string `json:"lbProvider,omitempty"`
+
+	// DataplaneAPIPort is the port the dataplane API is served on.
+	DataplaneAPIPort int `json:"dataplaneAPIPort,omitempty"`
+
+	// DeletionGracePeriod is how long a deleted route is kept in the dataplane
+	// before being removed, so that in-flight traffic can drain.
+	DeletionGracePeriod metav1.Duration `json:"deletionGracePeriod,omitempty"`
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles
+	// of each controller.
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+
+	// LogVerbosity is the verbosity of the logs, higher values being more
+	// verbose.
+	LogVerbosity int `json:"logVerbosity,omitempty"`
+
+	// DataplaneNodeSelector restricts the dataplane Pods the VIPs are
+	// programmed on to those running on the Nodes with these labels. The VIPs
+	// are programmed on all the dataplane Pods if empty.
+	DataplaneNodeSelector map[string]string `json:"dataplaneNodeSelector,omitempty"`
+}
+
+// Default returns the default configuration, which matches the behavior of
+// the control plane when no configuration is provided.
+func Default() *Config {
+	return &Config{
+		ControllerName:          vars.GatewayClassControllerName,
+		LBProvider:              "metallb",
+		DataplaneAPIPort:        vars.DefaultDataPlaneAPIPort,
+		MaxConcurrentReconciles: 1,
+	}
+}
+
+// Load reads the configuration from the YAML file at the provided path. Any
+// field which isn't set in the file keeps its default value. An empty path
+// returns the default configuration.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Validate checks that the configuration is valid.
+func (c *Config) Validate() error {
+	var errs []error
+
+	domain, path, ok := strings.Cut(c.ControllerName, "/")
+	if !ok || domain == "" || path == "" {
+		errs = append(errs, fmt.Errorf("controllerName %q must be a domain prefixed path, e.g. example.com/blixt", c.ControllerName))
+	}
+	if c.LBProvider == "" {
+		errs = append(errs, errors.New("lbProvider must not be empty"))
+	}
+	if c.DataplaneAPIPort < 1 || c.DataplaneAPIPort > 65535 {
+		errs = append(errs, fmt.Errorf("dataplaneAPIPort %d must be between 1 and 65535", c.DataplaneAPIPort))
+	}
+	if c.DeletionGracePeriod.Duration < 0 {
+		errs = append(errs, fmt.Errorf("deletionGracePeriod %s must not be negative", c.DeletionGracePeriod.Duration))
+	}
+	if c.MaxConcurrentReconciles < 1 {
+		errs = append(errs, fmt.Errorf("maxConcurrentReconciles %d must be at least 1", c.MaxConcurrentReconciles))
+	}
+	if c.LogVerbosity < 0 {
+		errs = append(errs, fmt.Errorf("logVerbosity %d must not be negative", c.LogVerbosity))
+	}
+	if _, err := labels.ValidatedSelectorFromSet(c.DataplaneNodeSelector); err != nil {
+		errs = append(errs, fmt.Errorf("dataplaneNodeSelector is invalid: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// WatchNamespaces returns the namespaces listed in WatchNamespace.
+func (c *Config) WatchNamespaces() []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(c.WatchNamespace, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("no path returns the defaults", func(t *testing.T) {
+		cfg, err := Load("")
+		require.NoError(t, err)
+		assert.Equal(t, Default(), cfg)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("unset fields keep their defaults", func(t *testing.T) {
+		cfg, err := Load(writeConfigFile(t, `
+watchNamespace: team-a, team-b
+lbProvider: cloud
+deletionGracePeriod: 30s
+maxConcurrentReconciles: 4
+logVerbosity: 2
+`))
+		require.NoError(t, err)
+		assert.Equal(t, vars.GatewayClassControllerName, cfg.ControllerName)
+		assert.Equal(t, vars.DefaultDataPlaneAPIPort, cfg.DataplaneAPIPort)
+		assert.Equal(t, []string{"team-a", "team-b"}, cfg.WatchNamespaces())
+		assert.Equal(t, "cloud", cfg.LBProvider)
+		assert.Equal(t, 30*time.Second, cfg.DeletionGracePeriod.Duration)
+		assert.Equal(t, 4, cfg.MaxConcurrentReconciles)
+		assert.Equal(t, 2, cfg.LogVerbosity)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := Load(writeConfigFile(t, "lbProvidr: cloud\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := Load(writeConfigFile(t, "dataplaneAPIPort: 70000\n"))
+		assert.ErrorContains(t, err, "dataplaneAPIPort")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		errMsg string
+	}{
+		{
+			name:   "controllerName without a path",
+			modify: func(c *Config) { c.ControllerName = "blixt" },
+			errMsg: "controllerName",
+		},
+		{
+			name:   "empty lbProvider",
+			modify: func(c *Config) { c.LBProvider = "" },
+			errMsg: "lbProvider",
+		},
+		{
+			name:   "dataplaneAPIPort out of range",
+			modify: func(c *Config) { c.DataplaneAPIPort = 0 },
+			errMsg: "dataplaneAPIPort",
+		},
+		{
+			name:   "negative deletionGracePeriod",
+			modify: func(c *Config) { c.DeletionGracePeriod.Duration = -time.Second },
+			errMsg: "deletionGracePeriod",
+		},
+		{
+			name:   "maxConcurrentReconciles below 1",
+			modify: func(c *Config) { c.MaxConcurrentReconciles = 0 },
+			errMsg: "maxConcurrentReconciles",
+		},
+		{
+			name:   "negative logVerbosity",
+			modify: func(c *Config) { c.LogVerbosity = -1 },
+			errMsg: "logVerbosity",
+		},
+		{
+			name:   "invalid dataplaneNodeSelector label",
+			modify: func(c *Config) { c.DataplaneNodeSelector = map[string]string{"invalid label": "edge"} },
+			errMsg: "dataplaneNodeSelector",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			assert.ErrorContains(t, cfg.Validate(), tt.errMsg)
+		})
+	}
+}
@@ -21,6 +21,159 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Vip_Protocol int32
+
+const (
+	Vip_TCP Vip_Protocol = 0
+	Vip_UDP Vip_Protocol = 1
+)
+
+// Enum value maps for Vip_Protocol.
+var (
+	Vip_Protocol_name = map[int32]string{
+		0: "TCP",
+		1: "UDP",
+	}
+	Vip_Protocol_value = map[string]int32{
+		"TCP": 0,
+		"UDP": 1,
+	}
+)
+
+func (x Vip_Protocol) Enum() *Vip_Protocol {
+	p := new(Vip_Protocol)
+	*p = x
+	return p
+}
+
+func (x Vip_Protocol) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Vip_Protocol) Descriptor() protoreflect.EnumDescriptor {
+	return file_dataplane_api_server_proto_backends_proto_enumTypes[0].Descriptor()
+}
+
+func (Vip_Protocol) Type() protoreflect.EnumType {
+	return &file_dataplane_api_server_proto_backends_proto_enumTypes[0]
+}
+
+func (x Vip_Protocol) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Vip_Protocol.Descriptor instead.
+func (Vip_Protocol) EnumDescriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type Targets_Action int32
+
+const (
+	// FORWARD load balances the traffic to the VIP across the targets.
+	Targets_FORWARD Targets_Action = 0
+	// REJECT answers the traffic to the VIP with ICMP port unreachable
+	// messages, the targets are ignored.
+	Targets_REJECT Targets_Action = 1
+	// DROP silently drops the traffic to the VIP, the targets are ignored.
+	Targets_DROP Targets_Action = 2
+)
+
+// Enum value maps for Targets_Action.
+var (
+	Targets_Action_name = map[int32]string{
+		0: "FORWARD",
+		1: "REJECT",
+		2: "DROP",
+	}
+	Targets_Action_value = map[string]int32{
+		"FORWARD": 0,
+		"REJECT":  1,
+		"DROP":    2,
+	}
+)
+
+func (x Targets_Action) Enum() *Targets_Action {
+	p := new(Targets_Action)
+	*p = x
+	return p
+}
+
+func (x Targets_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Targets_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_dataplane_api_server_proto_backends_proto_enumTypes[1].Descriptor()
+}
+
+func (Targets_Action) Type() protoreflect.EnumType {
+	return &file_dataplane_api_server_proto_backends_proto_enumTypes[1]
+}
+
+func (x Targets_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Targets_Action.Descriptor instead.
+func (Targets_Action) EnumDescriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{2, 0}
+}
+
+type BackendsEvent_Type int32
+
+const (
+	// SNAPSHOT events carry all the programmed backends. They're sent
+	// first, and again whenever the watcher fell behind and missed
+	// changes.
+	BackendsEvent_SNAPSHOT BackendsEvent_Type = 0
+	// UPDATED events carry the backends of a VIP which was programmed.
+	BackendsEvent_UPDATED BackendsEvent_Type = 1
+	// DELETED events carry the VIP which was deleted, without targets.
+	BackendsEvent_DELETED BackendsEvent_Type = 2
+)
+
+// Enum value maps for BackendsEvent_Type.
+var (
+	BackendsEvent_Type_name = map[int32]string{
+		0: "SNAPSHOT",
+		1: "UPDATED",
+		2: "DELETED",
+	}
+	BackendsEvent_Type_value = map[string]int32{
+		"SNAPSHOT": 0,
+		"UPDATED":  1,
+		"DELETED":  2,
+	}
+)
+
+func (x BackendsEvent_Type) Enum() *BackendsEvent_Type {
+	p := new(BackendsEvent_Type)
+	*p = x
+	return p
+}
+
+func (x BackendsEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BackendsEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_dataplane_api_server_proto_backends_proto_enumTypes[2].Descriptor()
+}
+
+func (BackendsEvent_Type) Type() protoreflect.EnumType {
+	return &file_dataplane_api_server_proto_backends_proto_enumTypes[2]
+}
+
+func (x BackendsEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BackendsEvent_Type.Descriptor instead.
+func (BackendsEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{12, 0}
+}
+
 type Vip struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -28,6 +181,9 @@ type Vip struct {
 
 	Ip   uint32 `protobuf:"varint,1,opt,name=ip,proto3" json:"ip,omitempty"`
 	Port uint32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	// protocol is the transport protocol of the traffic to the VIP, a TCP and
+	// a UDP VIP can share the same address and port.
+	Protocol Vip_Protocol `protobuf:"varint,3,opt,name=protocol,proto3,enum=backends.Vip_Protocol" json:"protocol,omitempty"`
 }
 
 func (x *Vip) Reset() {
@@ -76,6 +232,13 @@ func (x *Vip) GetPort() uint32 {
 	return 0
 }
 
+func (x *Vip) GetProtocol() Vip_Protocol {
+	if x != nil {
+		return x.Protocol
+	}
+	return Vip_TCP
+}
+
 type Target struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -84,6 +247,10 @@ type Target struct {
 	Daddr   uint32  `protobuf:"varint,1,opt,name=daddr,proto3" json:"daddr,omitempty"`
 	Dport   uint32  `protobuf:"varint,2,opt,name=dport,proto3" json:"dport,omitempty"`
 	Ifindex *uint32 `protobuf:"varint,3,opt,name=ifindex,proto3,oneof" json:"ifindex,omitempty"`
+	// max_connections is the maximum number of concurrent connections the
+	// backend receives, new connections are sent to the other backends once
+	// it's reached. The backend has no limit if it's unset or zero.
+	MaxConnections *uint32 `protobuf:"varint,4,opt,name=max_connections,json=maxConnections,proto3,oneof" json:"max_connections,omitempty"`
 }
 
 func (x *Target) Reset() {
@@ -139,6 +306,13 @@ func (x *Target) GetIfindex() uint32 {
 	return 0
 }
 
+func (x *Target) GetMaxConnections() uint32 {
+	if x != nil && x.MaxConnections != nil {
+		return *x.MaxConnections
+	}
+	return 0
+}
+
 type Targets struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -146,6 +320,13 @@ type Targets struct {
 
 	Vip     *Vip      `protobuf:"bytes,1,opt,name=vip,proto3" json:"vip,omitempty"`
 	Targets []*Target `protobuf:"bytes,2,rep,name=targets,proto3" json:"targets,omitempty"`
+	// tcp_idle_timeout_seconds is the time after which the TCP connections to
+	// the VIP which had no traffic are evicted from the connection tracking.
+	// The connections are only evicted once closed if it's unset or zero.
+	TcpIdleTimeoutSeconds *uint32 `protobuf:"varint,3,opt,name=tcp_idle_timeout_seconds,json=tcpIdleTimeoutSeconds,proto3,oneof" json:"tcp_idle_timeout_seconds,omitempty"`
+	// action is applied to the new connections and datagrams to the VIP, the
+	// established TCP connections are kept until they're closed.
+	Action Targets_Action `protobuf:"varint,4,opt,name=action,proto3,enum=backends.Targets_Action" json:"action,omitempty"`
 }
 
 func (x *Targets) Reset() {
@@ -194,6 +375,20 @@ func (x *Targets) GetTargets() []*Target {
 	return nil
 }
 
+func (x *Targets) GetTcpIdleTimeoutSeconds() uint32 {
+	if x != nil && x.TcpIdleTimeoutSeconds != nil {
+		return *x.TcpIdleTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *Targets) GetAction() Targets_Action {
+	if x != nil {
+		return x.Action
+	}
+	return Targets_FORWARD
+}
+
 type Confirmation struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -335,27 +530,399 @@ func (x *InterfaceIndexConfirmation) GetIfindex() uint32 {
 	return 0
 }
 
+type ListBackendsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListBackendsRequest) Reset() {
+	*x = ListBackendsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListBackendsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBackendsRequest) ProtoMessage() {}
+
+func (x *ListBackendsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBackendsRequest.ProtoReflect.Descriptor instead.
+func (*ListBackendsRequest) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{6}
+}
+
+type BackendsList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Backends []*Targets `protobuf:"bytes,1,rep,name=backends,proto3" json:"backends,omitempty"`
+}
+
+func (x *BackendsList) Reset() {
+	*x = BackendsList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackendsList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackendsList) ProtoMessage() {}
+
+func (x *BackendsList) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackendsList.ProtoReflect.Descriptor instead.
+func (*BackendsList) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BackendsList) GetBackends() []*Targets {
+	if x != nil {
+		return x.Backends
+	}
+	return nil
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{8}
+}
+
+type DataplaneStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// interface is the name of the interface the programs are attached to.
+	Interface string `protobuf:"bytes,1,opt,name=interface,proto3" json:"interface,omitempty"`
+	Ifindex   uint32 `protobuf:"varint,2,opt,name=ifindex,proto3" json:"ifindex,omitempty"`
+	// attach_mode is how the programs were attached: "tc" when the dataplane
+	// attached them to the TC ingress and egress hooks itself, or "bpfd" when
+	// they were loaded and attached by bpfd.
+	AttachMode string `protobuf:"bytes,3,opt,name=attach_mode,json=attachMode,proto3" json:"attach_mode,omitempty"`
+	// vips is the number of VIPs programmed into the dataplane.
+	Vips uint32 `protobuf:"varint,4,opt,name=vips,proto3" json:"vips,omitempty"`
+	// draining indicates whether the dataplane is draining, in which case it
+	// refuses new connections.
+	Draining bool `protobuf:"varint,5,opt,name=draining,proto3" json:"draining,omitempty"`
+}
+
+func (x *DataplaneStatus) Reset() {
+	*x = DataplaneStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DataplaneStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataplaneStatus) ProtoMessage() {}
+
+func (x *DataplaneStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataplaneStatus.ProtoReflect.Descriptor instead.
+func (*DataplaneStatus) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DataplaneStatus) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *DataplaneStatus) GetIfindex() uint32 {
+	if x != nil {
+		return x.Ifindex
+	}
+	return 0
+}
+
+func (x *DataplaneStatus) GetAttachMode() string {
+	if x != nil {
+		return x.AttachMode
+	}
+	return ""
+}
+
+func (x *DataplaneStatus) GetVips() uint32 {
+	if x != nil {
+		return x.Vips
+	}
+	return 0
+}
+
+func (x *DataplaneStatus) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+type DrainRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// grace_period_seconds is how long the established connections are kept
+	// before the dataplane exits.
+	GracePeriodSeconds uint32 `protobuf:"varint,1,opt,name=grace_period_seconds,json=gracePeriodSeconds,proto3" json:"grace_period_seconds,omitempty"`
+}
+
+func (x *DrainRequest) Reset() {
+	*x = DrainRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DrainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainRequest) ProtoMessage() {}
+
+func (x *DrainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainRequest.ProtoReflect.Descriptor instead.
+func (*DrainRequest) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DrainRequest) GetGracePeriodSeconds() uint32 {
+	if x != nil {
+		return x.GracePeriodSeconds
+	}
+	return 0
+}
+
+type WatchBackendsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *WatchBackendsRequest) Reset() {
+	*x = WatchBackendsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchBackendsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBackendsRequest) ProtoMessage() {}
+
+func (x *WatchBackendsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBackendsRequest.ProtoReflect.Descriptor instead.
+func (*WatchBackendsRequest) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{11}
+}
+
+type BackendsEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     BackendsEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=backends.BackendsEvent_Type" json:"type,omitempty"`
+	Backends []*Targets         `protobuf:"bytes,2,rep,name=backends,proto3" json:"backends,omitempty"`
+}
+
+func (x *BackendsEvent) Reset() {
+	*x = BackendsEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BackendsEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackendsEvent) ProtoMessage() {}
+
+func (x *BackendsEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_dataplane_api_server_proto_backends_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackendsEvent.ProtoReflect.Descriptor instead.
+func (*BackendsEvent) Descriptor() ([]byte, []int) {
+	return file_dataplane_api_server_proto_backends_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BackendsEvent) GetType() BackendsEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return BackendsEvent_SNAPSHOT
+}
+
+func (x *BackendsEvent) GetBackends() []*Targets {
+	if x != nil {
+		return x.Backends
+	}
+	return nil
+}
+
 var File_dataplane_api_server_proto_backends_proto protoreflect.FileDescriptor
 
 var file_dataplane_api_server_proto_backends_proto_rawDesc = []byte{
 	0x0a, 0x29, 0x64, 0x61, 0x74, 0x61, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2d,
 	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x62, 0x61, 0x63,
 	0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x62, 0x61, 0x63,
-	0x6b, 0x65, 0x6e, 0x64, 0x73, 0x22, 0x29, 0x0a, 0x03, 0x56, 0x69, 0x70, 0x12, 0x0e, 0x0a, 0x02,
+	0x6b, 0x65, 0x6e, 0x64, 0x73, 0x22, 0x7b, 0x0a, 0x03, 0x56, 0x69, 0x70, 0x12, 0x0e, 0x0a, 0x02,
 	0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x69, 0x70, 0x12, 0x12, 0x0a, 0x04,
 	0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74,
-	0x22, 0x5f, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x61,
-	0x64, 0x64, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x64, 0x61, 0x64, 0x64, 0x72,
-	0x12, 0x14, 0x0a, 0x05, 0x64, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x05, 0x64, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1d, 0x0a, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64, 0x65,
-	0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64,
-	0x65, 0x78, 0x88, 0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x69, 0x66, 0x69, 0x6e, 0x64, 0x65,
-	0x78, 0x22, 0x56, 0x0a, 0x07, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x12, 0x1f, 0x0a, 0x03,
-	0x76, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x62, 0x61, 0x63, 0x6b,
-	0x65, 0x6e, 0x64, 0x73, 0x2e, 0x56, 0x69, 0x70, 0x52, 0x03, 0x76, 0x69, 0x70, 0x12, 0x2a, 0x0a,
-	0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
-	0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
-	0x52, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x22, 0x32, 0x0a, 0x0c, 0x43, 0x6f, 0x6e,
+	0x12, 0x32, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x56, 0x69,
+	0x70, 0x2e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x22, 0x1c, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x12, 0x07, 0x0a, 0x03, 0x54, 0x43, 0x50, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x55, 0x44, 0x50,
+	0x10, 0x01, 0x22, 0xa1, 0x01, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x64, 0x61, 0x64, 0x64, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x64, 0x61,
+	0x64, 0x64, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x64, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1d, 0x0a, 0x07, 0x69, 0x66, 0x69,
+	0x6e, 0x64, 0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x07, 0x69, 0x66,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x5f,
+	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0d, 0x48, 0x01, 0x52, 0x0e, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x88, 0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x69, 0x66, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x90, 0x02, 0x0a, 0x07, 0x54, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x73, 0x12, 0x1f, 0x0a, 0x03, 0x76, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x56, 0x69, 0x70, 0x52, 0x03,
+	0x76, 0x69, 0x70, 0x12, 0x2a, 0x0a, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e,
+	0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x52, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x12,
+	0x3c, 0x0a, 0x18, 0x74, 0x63, 0x70, 0x5f, 0x69, 0x64, 0x6c, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x48, 0x00, 0x52, 0x15, 0x74, 0x63, 0x70, 0x49, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12, 0x30, 0x0a,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e,
+	0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73,
+	0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22,
+	0x2b, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x4f, 0x52,
+	0x57, 0x41, 0x52, 0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54,
+	0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x44, 0x52, 0x4f, 0x50, 0x10, 0x02, 0x42, 0x1b, 0x0a, 0x19,
+	0x5f, 0x74, 0x63, 0x70, 0x5f, 0x69, 0x64, 0x6c, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x32, 0x0a, 0x0c, 0x43, 0x6f, 0x6e,
 	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x6f, 0x6e,
 	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
 	0x0c, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x17, 0x0a,
@@ -363,23 +930,80 @@ var file_dataplane_api_server_proto_backends_proto_rawDesc = []byte{
 	0x28, 0x0d, 0x52, 0x02, 0x69, 0x70, 0x22, 0x36, 0x0a, 0x1a, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66,
 	0x61, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61,
 	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x32, 0xbc,
-	0x01, 0x0a, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x4a, 0x0a, 0x11, 0x47,
-	0x65, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78,
-	0x12, 0x0f, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x50, 0x6f, 0x64, 0x49,
-	0x50, 0x1a, 0x24, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x49, 0x6e, 0x74,
-	0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x43, 0x6f, 0x6e, 0x66, 0x69,
-	0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x06, 0x55, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x12, 0x11, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54, 0x61, 0x72,
-	0x67, 0x65, 0x74, 0x73, 0x1a, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e,
-	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a, 0x06,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x0d, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
-	0x73, 0x2e, 0x56, 0x69, 0x70, 0x1a, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73,
-	0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x31, 0x5a,
-	0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x6f, 0x6e, 0x67,
-	0x2f, 0x62, 0x6c, 0x69, 0x78, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
-	0x64, 0x61, 0x74, 0x61, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x2f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x15,
+	0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3d, 0x0a, 0x0c, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x73, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e,
+	0x64, 0x73, 0x2e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x52, 0x08, 0x62, 0x61, 0x63, 0x6b,
+	0x65, 0x6e, 0x64, 0x73, 0x22, 0x0f, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x9a, 0x01, 0x0a, 0x0f, 0x44, 0x61, 0x74, 0x61, 0x70, 0x6c,
+	0x61, 0x6e, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x74,
+	0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x69, 0x66, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x5f, 0x6d, 0x6f, 0x64, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x4d, 0x6f,
+	0x64, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x69, 0x70, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x04, 0x76, 0x69, 0x70, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69,
+	0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69,
+	0x6e, 0x67, 0x22, 0x40, 0x0a, 0x0c, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x30, 0x0a, 0x14, 0x67, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x69,
+	0x6f, 0x64, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x12, 0x67, 0x72, 0x61, 0x63, 0x65, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x22, 0x16, 0x0a, 0x14, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x63,
+	0x6b, 0x65, 0x6e, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xa0, 0x01, 0x0a,
+	0x0d, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x30,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c, 0x2e, 0x62,
+	0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x12, 0x2d, 0x0a, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x73, 0x52, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x22,
+	0x2e, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x4e, 0x41, 0x50, 0x53,
+	0x48, 0x4f, 0x54, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x44,
+	0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x02, 0x32,
+	0xbe, 0x04, 0x0a, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x4a, 0x0a, 0x11,
+	0x47, 0x65, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65,
+	0x78, 0x12, 0x0f, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x50, 0x6f, 0x64,
+	0x49, 0x50, 0x1a, 0x24, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x49, 0x6e,
+	0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x06, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x12, 0x11, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x73, 0x1a, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73,
+	0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a,
+	0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x0d, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e,
+	0x64, 0x73, 0x2e, 0x56, 0x69, 0x70, 0x1a, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x73, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x45,
+	0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x1d,
+	0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x61,
+	0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
+	0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x73, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x0a, 0x41, 0x64, 0x64, 0x42, 0x61, 0x63, 0x6b,
+	0x65, 0x6e, 0x64, 0x12, 0x11, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x1a, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64,
+	0x73, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3a,
+	0x0a, 0x0d, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12,
+	0x11, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x54, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x73, 0x1a, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3f, 0x0a, 0x09, 0x47, 0x65,
+	0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x17, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e,
+	0x64, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x19, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x70, 0x6c, 0x61, 0x6e, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x37, 0x0a, 0x05, 0x44,
+	0x72, 0x61, 0x69, 0x6e, 0x12, 0x16, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e,
+	0x44, 0x72, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x62,
+	0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x4a, 0x0a, 0x0d, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x63,
+	0x6b, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x1e, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73,
+	0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73,
+	0x2e, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01,
+	0x42, 0x3c, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b,
+	0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x2d, 0x73, 0x69, 0x67, 0x73, 0x2f, 0x62,
+	0x6c, 0x69, 0x78, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x64, 0x61,
+	0x74, 0x61, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x2f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -394,29 +1018,57 @@ func file_dataplane_api_server_proto_backends_proto_rawDescGZIP() []byte {
 	return file_dataplane_api_server_proto_backends_proto_rawDescData
 }
 
-var file_dataplane_api_server_proto_backends_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_dataplane_api_server_proto_backends_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_dataplane_api_server_proto_backends_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_dataplane_api_server_proto_backends_proto_goTypes = []interface{}{
-	(*Vip)(nil),                        // 0: backends.Vip
-	(*Target)(nil),                     // 1: backends.Target
-	(*Targets)(nil),                    // 2: backends.Targets
-	(*Confirmation)(nil),               // 3: backends.Confirmation
-	(*PodIP)(nil),                      // 4: backends.PodIP
-	(*InterfaceIndexConfirmation)(nil), // 5: backends.InterfaceIndexConfirmation
+	(Vip_Protocol)(0),                  // 0: backends.Vip.Protocol
+	(Targets_Action)(0),                // 1: backends.Targets.Action
+	(BackendsEvent_Type)(0),            // 2: backends.BackendsEvent.Type
+	(*Vip)(nil),                        // 3: backends.Vip
+	(*Target)(nil),                     // 4: backends.Target
+	(*Targets)(nil),                    // 5: backends.Targets
+	(*Confirmation)(nil),               // 6: backends.Confirmation
+	(*PodIP)(nil),                      // 7: backends.PodIP
+	(*InterfaceIndexConfirmation)(nil), // 8: backends.InterfaceIndexConfirmation
+	(*ListBackendsRequest)(nil),        // 9: backends.ListBackendsRequest
+	(*BackendsList)(nil),               // 10: backends.BackendsList
+	(*StatusRequest)(nil),              // 11: backends.StatusRequest
+	(*DataplaneStatus)(nil),            // 12: backends.DataplaneStatus
+	(*DrainRequest)(nil),               // 13: backends.DrainRequest
+	(*WatchBackendsRequest)(nil),       // 14: backends.WatchBackendsRequest
+	(*BackendsEvent)(nil),              // 15: backends.BackendsEvent
 }
 var file_dataplane_api_server_proto_backends_proto_depIdxs = []int32{
-	0, // 0: backends.Targets.vip:type_name -> backends.Vip
-	1, // 1: backends.Targets.targets:type_name -> backends.Target
-	4, // 2: backends.backends.GetInterfaceIndex:input_type -> backends.PodIP
-	2, // 3: backends.backends.Update:input_type -> backends.Targets
-	0, // 4: backends.backends.Delete:input_type -> backends.Vip
-	5, // 5: backends.backends.GetInterfaceIndex:output_type -> backends.InterfaceIndexConfirmation
-	3, // 6: backends.backends.Update:output_type -> backends.Confirmation
-	3, // 7: backends.backends.Delete:output_type -> backends.Confirmation
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: backends.Vip.protocol:type_name -> backends.Vip.Protocol
+	3,  // 1: backends.Targets.vip:type_name -> backends.Vip
+	4,  // 2: backends.Targets.targets:type_name -> backends.Target
+	1,  // 3: backends.Targets.action:type_name -> backends.Targets.Action
+	5,  // 4: backends.BackendsList.backends:type_name -> backends.Targets
+	2,  // 5: backends.BackendsEvent.type:type_name -> backends.BackendsEvent.Type
+	5,  // 6: backends.BackendsEvent.backends:type_name -> backends.Targets
+	7,  // 7: backends.backends.GetInterfaceIndex:input_type -> backends.PodIP
+	5,  // 8: backends.backends.Update:input_type -> backends.Targets
+	3,  // 9: backends.backends.Delete:input_type -> backends.Vip
+	9,  // 10: backends.backends.ListBackends:input_type -> backends.ListBackendsRequest
+	5,  // 11: backends.backends.AddBackend:input_type -> backends.Targets
+	5,  // 12: backends.backends.RemoveBackend:input_type -> backends.Targets
+	11, // 13: backends.backends.GetStatus:input_type -> backends.StatusRequest
+	13, // 14: backends.backends.Drain:input_type -> backends.DrainRequest
+	14, // 15: backends.backends.WatchBackends:input_type -> backends.WatchBackendsRequest
+	8,  // 16: backends.backends.GetInterfaceIndex:output_type -> backends.InterfaceIndexConfirmation
+	6,  // 17: backends.backends.Update:output_type -> backends.Confirmation
+	6,  // 18: backends.backends.Delete:output_type -> backends.Confirmation
+	10, // 19: backends.backends.ListBackends:output_type -> backends.BackendsList
+	6,  // 20: backends.backends.AddBackend:output_type -> backends.Confirmation
+	6,  // 21: backends.backends.RemoveBackend:output_type -> backends.Confirmation
+	12, // 22: backends.backends.GetStatus:output_type -> backends.DataplaneStatus
+	6,  // 23: backends.backends.Drain:output_type -> backends.Confirmation
+	15, // 24: backends.backends.WatchBackends:output_type -> backends.BackendsEvent
+	16, // [16:25] is the sub-list for method output_type
+	7,  // [7:16] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_dataplane_api_server_proto_backends_proto_init() }
@@ -497,20 +1149,106 @@ func file_dataplane_api_server_proto_backends_proto_init() {
 				return nil
 			}
 		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListBackendsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackendsList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DataplaneStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DrainRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchBackendsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataplane_api_server_proto_backends_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BackendsEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_dataplane_api_server_proto_backends_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	file_dataplane_api_server_proto_backends_proto_msgTypes[2].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_dataplane_api_server_proto_backends_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      3,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_dataplane_api_server_proto_backends_proto_goTypes,
 		DependencyIndexes: file_dataplane_api_server_proto_backends_proto_depIdxs,
+		EnumInfos:         file_dataplane_api_server_proto_backends_proto_enumTypes,
 		MessageInfos:      file_dataplane_api_server_proto_backends_proto_msgTypes,
 	}.Build()
 	File_dataplane_api_server_proto_backends_proto = out.File
@@ -23,6 +23,12 @@ const (
 	Backends_GetInterfaceIndex_FullMethodName = "/backends.backends/GetInterfaceIndex"
 	Backends_Update_FullMethodName            = "/backends.backends/Update"
 	Backends_Delete_FullMethodName            = "/backends.backends/Delete"
+	Backends_ListBackends_FullMethodName      = "/backends.backends/ListBackends"
+	Backends_AddBackend_FullMethodName        = "/backends.backends/AddBackend"
+	Backends_RemoveBackend_FullMethodName     = "/backends.backends/RemoveBackend"
+	Backends_GetStatus_FullMethodName         = "/backends.backends/GetStatus"
+	Backends_Drain_FullMethodName             = "/backends.backends/Drain"
+	Backends_WatchBackends_FullMethodName     = "/backends.backends/WatchBackends"
 )
 
 // BackendsClient is the client API for Backends service.
@@ -32,6 +38,26 @@ type BackendsClient interface {
 	GetInterfaceIndex(ctx context.Context, in *PodIP, opts ...grpc.CallOption) (*InterfaceIndexConfirmation, error)
 	Update(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*Confirmation, error)
 	Delete(ctx context.Context, in *Vip, opts ...grpc.CallOption) (*Confirmation, error)
+	ListBackends(ctx context.Context, in *ListBackendsRequest, opts ...grpc.CallOption) (*BackendsList, error)
+	// AddBackend adds the provided targets to the backends of the VIP,
+	// without replacing the targets it already has. The backends are handled
+	// as a set, so weighted backends, which repeat a target, are rejected and
+	// must be replaced with Update.
+	AddBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*Confirmation, error)
+	// RemoveBackend removes the provided targets from the backends of the VIP.
+	// Like for AddBackend, weighted backends are rejected.
+	RemoveBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*Confirmation, error)
+	// GetStatus returns the attachment of the dataplane programs and the
+	// number of programmed VIPs, for diagnostics.
+	GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*DataplaneStatus, error)
+	// Drain makes the dataplane refuse new connections and report itself as
+	// not serving, then exit once the grace period elapsed. It's called
+	// before the dataplane Pod is terminated, and only accepted from the
+	// loopback address as the API is reachable from outside the node.
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*Confirmation, error)
+	// WatchBackends streams the programmed backends, starting with a
+	// snapshot of all of them followed by their changes.
+	WatchBackends(ctx context.Context, in *WatchBackendsRequest, opts ...grpc.CallOption) (Backends_WatchBackendsClient, error)
 }
 
 type backendsClient struct {
@@ -69,6 +95,83 @@ func (c *backendsClient) Delete(ctx context.Context, in *Vip, opts ...grpc.CallO
 	return out, nil
 }
 
+func (c *backendsClient) ListBackends(ctx context.Context, in *ListBackendsRequest, opts ...grpc.CallOption) (*BackendsList, error) {
+	out := new(BackendsList)
+	err := c.cc.Invoke(ctx, Backends_ListBackends_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendsClient) AddBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*Confirmation, error) {
+	out := new(Confirmation)
+	err := c.cc.Invoke(ctx, Backends_AddBackend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendsClient) RemoveBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*Confirmation, error) {
+	out := new(Confirmation)
+	err := c.cc.Invoke(ctx, Backends_RemoveBackend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendsClient) GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*DataplaneStatus, error) {
+	out := new(DataplaneStatus)
+	err := c.cc.Invoke(ctx, Backends_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendsClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*Confirmation, error) {
+	out := new(Confirmation)
+	err := c.cc.Invoke(ctx, Backends_Drain_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendsClient) WatchBackends(ctx context.Context, in *WatchBackendsRequest, opts ...grpc.CallOption) (Backends_WatchBackendsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Backends_ServiceDesc.Streams[0], Backends_WatchBackends_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendsWatchBackendsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Backends_WatchBackendsClient interface {
+	Recv() (*BackendsEvent, error)
+	grpc.ClientStream
+}
+
+type backendsWatchBackendsClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendsWatchBackendsClient) Recv() (*BackendsEvent, error) {
+	m := new(BackendsEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BackendsServer is the server API for Backends service.
 // All implementations must embed UnimplementedBackendsServer
 // for forward compatibility
@@ -76,6 +179,26 @@ type BackendsServer interface {
 	GetInterfaceIndex(context.Context, *PodIP) (*InterfaceIndexConfirmation, error)
 	Update(context.Context, *Targets) (*Confirmation, error)
 	Delete(context.Context, *Vip) (*Confirmation, error)
+	ListBackends(context.Context, *ListBackendsRequest) (*BackendsList, error)
+	// AddBackend adds the provided targets to the backends of the VIP,
+	// without replacing the targets it already has. The backends are handled
+	// as a set, so weighted backends, which repeat a target, are rejected and
+	// must be replaced with Update.
+	AddBackend(context.Context, *Targets) (*Confirmation, error)
+	// RemoveBackend removes the provided targets from the backends of the VIP.
+	// Like for AddBackend, weighted backends are rejected.
+	RemoveBackend(context.Context, *Targets) (*Confirmation, error)
+	// GetStatus returns the attachment of the dataplane programs and the
+	// number of programmed VIPs, for diagnostics.
+	GetStatus(context.Context, *StatusRequest) (*DataplaneStatus, error)
+	// Drain makes the dataplane refuse new connections and report itself as
+	// not serving, then exit once the grace period elapsed. It's called
+	// before the dataplane Pod is terminated, and only accepted from the
+	// loopback address as the API is reachable from outside the node.
+	Drain(context.Context, *DrainRequest) (*Confirmation, error)
+	// WatchBackends streams the programmed backends, starting with a
+	// snapshot of all of them followed by their changes.
+	WatchBackends(*WatchBackendsRequest, Backends_WatchBackendsServer) error
 	mustEmbedUnimplementedBackendsServer()
 }
 
@@ -92,6 +215,24 @@ func (UnimplementedBackendsServer) Update(context.Context, *Targets) (*Confirmat
 func (UnimplementedBackendsServer) Delete(context.Context, *Vip) (*Confirmation, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedBackendsServer) ListBackends(context.Context, *ListBackendsRequest) (*BackendsList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBackends not implemented")
+}
+func (UnimplementedBackendsServer) AddBackend(context.Context, *Targets) (*Confirmation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBackend not implemented")
+}
+func (UnimplementedBackendsServer) RemoveBackend(context.Context, *Targets) (*Confirmation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBackend not implemented")
+}
+func (UnimplementedBackendsServer) GetStatus(context.Context, *StatusRequest) (*DataplaneStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedBackendsServer) Drain(context.Context, *DrainRequest) (*Confirmation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drain not implemented")
+}
+func (UnimplementedBackendsServer) WatchBackends(*WatchBackendsRequest, Backends_WatchBackendsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBackends not implemented")
+}
 func (UnimplementedBackendsServer) mustEmbedUnimplementedBackendsServer() {}
 
 // UnsafeBackendsServer may be embedded to opt out of forward compatibility for this service.
@@ -159,6 +300,117 @@ func _Backends_Delete_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Backends_ListBackends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBackendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendsServer).ListBackends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backends_ListBackends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendsServer).ListBackends(ctx, req.(*ListBackendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backends_AddBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Targets)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendsServer).AddBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backends_AddBackend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendsServer).AddBackend(ctx, req.(*Targets))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backends_RemoveBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Targets)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendsServer).RemoveBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backends_RemoveBackend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendsServer).RemoveBackend(ctx, req.(*Targets))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backends_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendsServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backends_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendsServer).GetStatus(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backends_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendsServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backends_Drain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendsServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backends_WatchBackends_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchBackendsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BackendsServer).WatchBackends(m, &backendsWatchBackendsServer{stream})
+}
+
+type Backends_WatchBackendsServer interface {
+	Send(*BackendsEvent) error
+	grpc.ServerStream
+}
+
+type backendsWatchBackendsServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendsWatchBackendsServer) Send(m *BackendsEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Backends_ServiceDesc is the grpc.ServiceDesc for Backends service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -178,7 +430,33 @@ var Backends_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _Backends_Delete_Handler,
 		},
+		{
+			MethodName: "ListBackends",
+			Handler:    _Backends_ListBackends_Handler,
+		},
+		{
+			MethodName: "AddBackend",
+			Handler:    _Backends_AddBackend_Handler,
+		},
+		{
+			MethodName: "RemoveBackend",
+			Handler:    _Backends_RemoveBackend_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Backends_GetStatus_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _Backends_Drain_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBackends",
+			Handler:       _Backends_WatchBackends_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "dataplane/api-server/proto/backends.proto",
 }
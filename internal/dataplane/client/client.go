@@ -20,26 +20,171 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	"github.com/kubernetes-sigs/blixt/pkg/vars"
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
 )
 
+// ErrNoDataPlaneClients is returned when backends can't be pushed to the
+// dataplane because no dataplane instance is currently connected.
+var ErrNoDataPlaneClients = errors.New("no dataplane clients available")
+
+// ErrPodIPNotAssigned is returned when a ready dataplane Pod can't be
+// connected to yet because it wasn't assigned an IP address.
+var ErrPodIPNotAssigned = errors.New("dataplane pod has no IP assigned yet")
+
+// ErrPodNotServing is returned when a ready dataplane Pod isn't admitted to
+// the clients because its gRPC health check doesn't report SERVING yet.
+var ErrPodNotServing = errors.New("dataplane pod is not serving yet")
+
+// PartialUpdateError is returned when an update was applied by some of the
+// dataplane instances but failed on the others, which are the only ones the
+// update needs to be retried on, with UpdatePods.
+type PartialUpdateError struct {
+	// Pods are the names of the Pods of the dataplane instances on which the
+	// update failed.
+	Pods []string
+	// Err joins the errors of the failed updates.
+	Err error
+}
+
+func (e *PartialUpdateError) Error() string {
+	return fmt.Sprintf("update failed on dataplane pods %s: %v", strings.Join(e.Pods, ", "), e.Err)
+}
+
+func (e *PartialUpdateError) Unwrap() error {
+	return e.Err
+}
+
+// UpdateResult is the outcome of an update or a delete fanned out to the
+// dataplane instances, keyed by the name of their Pod. The instances which
+// were skipped, because they're ejected or the VIP isn't placed on them, are
+// not part of it.
+type UpdateResult struct {
+	mu sync.Mutex
+	// Confirmations are the confirmations of the instances which applied it.
+	Confirmations map[string]string
+	// Errors are the errors of the instances on which it failed.
+	Errors map[string]error
+}
+
+func newUpdateResult() *UpdateResult {
+	return &UpdateResult{Confirmations: map[string]string{}, Errors: map[string]error{}}
+}
+
+// record adds the outcome of the call to the dataplane instance of the
+// provided Pod, it's safe for concurrent use.
+func (r *UpdateResult) record(pod string, conf *Confirmation, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.Errors[pod] = err
+		return
+	}
+	r.Confirmations[pod] = conf.GetConfirmation()
+}
+
+// Succeeded returns the number of dataplane instances which applied the
+// update, it's zero for a nil result.
+func (r *UpdateResult) Succeeded() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.Confirmations)
+}
+
+// Failed returns the number of dataplane instances on which the update
+// failed, it's zero for a nil result.
+func (r *UpdateResult) Failed() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.Errors)
+}
+
+const (
+	// clientEjectionThreshold is the number of consecutive failed updates
+	// after which a client is ejected from the updates fan-out.
+	clientEjectionThreshold = 3
+
+	// defaultEjectedClientProbeInterval is the default minimum period between
+	// two probes of an ejected client.
+	defaultEjectedClientProbeInterval = 10 * time.Second
+)
+
+// DefaultMaxMessageSize is the default maximum size of the messages sent to
+// and received from the dataplane. It's larger than the gRPC default of 4MiB
+// so that VIPs with large backend sets can be programmed, and matches the
+// default of the dataplane.
+const DefaultMaxMessageSize = 16 * 1024 * 1024
+
+// DefaultMaxConcurrentCalls is the default maximum number of dataplane
+// instances called concurrently when fanning out an update or a delete, so
+// that large clusters don't get a goroutine and a gRPC call per dataplane
+// instance for each route update.
+const DefaultMaxConcurrentCalls = 32
+
 // clientInfo encapsulates the gathered information about a BackendsClient
 // along with the gRPC client connection.
 type clientInfo struct {
 	conn   *grpc.ClientConn
 	client BackendsClient
 	name   string
+	pod    *corev1.Pod
+	health *clientHealth
+	// programmed is the snapshot of the backends programmed into the
+	// dataplane, which Sync pushes the difference with.
+	programmed *programmedBackends
+}
+
+// clientHealth tracks the failures of a client to apply updates. It's guarded
+// by the BackendsClientManager's mutex.
+type clientHealth struct {
+	// failures is the number of consecutive failed updates.
+	failures int
+	// ejected indicates whether the client is excluded from the updates
+	// fan-out until a probe succeeds.
+	ejected bool
+	// lastProbe is the time the client was last probed while ejected.
+	lastProbe time.Time
+}
+
+// BackendsUpdater programs backends into the dataplane, it's implemented by
+// the BackendsClientManager and allows the route controllers to be tested
+// without dataplane connections.
+type BackendsUpdater interface {
+	Update(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error)
+	UpdatePods(ctx context.Context, in *Targets, pods []string, opts ...grpc.CallOption) (*UpdateResult, error)
+	Sync(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error)
+	Delete(ctx context.Context, in *Vip, opts ...grpc.CallOption) (*UpdateResult, error)
+	AddBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error)
+	RemoveBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error)
+}
+
+// VIPsPruner deletes the VIPs programmed into the dataplane which don't
+// correspond to any route anymore, it's implemented by the
+// BackendsClientManager.
+type VIPsPruner interface {
+	PruneVIPs(ctx context.Context, desired []*Vip, opts ...grpc.CallOption) (int, error)
 }
 
 // BackendsClientManager is managing the connections and interactions with
@@ -48,25 +193,143 @@ type BackendsClientManager struct {
 	log       logr.Logger
 	clientset *kubernetes.Clientset
 
+	apiPort   int
+	keepalive KeepaliveConfig
+
+	// probeInterval is the minimum period between two probes of an ejected
+	// client.
+	probeInterval time.Duration
+
+	// placement selects the clients each VIP is programmed on.
+	placement PlacementStrategy
+
+	// dialer replaces the dialer of the connections to the dataplane if set,
+	// for instance to connect to an in-memory server in tests.
+	dialer func(context.Context, string) (net.Conn, error)
+
+	// maxMessageSize is the maximum size of the messages sent to and received
+	// from the dataplane.
+	maxMessageSize int
+
+	// maxConcurrentCalls is the maximum number of dataplane instances called
+	// concurrently by a fan-out, there's no limit if it's lower than 1.
+	maxConcurrentCalls int
+
+	// healthCheck checks the serving status of the dataplane Pods before
+	// they're admitted to the clients, within healthCheckTimeout.
+	healthCheck        HealthChecker
+	healthCheckTimeout time.Duration
+
 	mu      sync.RWMutex
 	clients map[types.NamespacedName]clientInfo
+	// unreachable tracks the names of the ready Pods which could not be
+	// connected to.
+	unreachable map[types.NamespacedName]string
+	// desired tracks the Targets most recently pushed for each VIP.
+	desired map[vipKey]*Targets
+}
+
+// vipKey identifies a VIP in the tracked desired state.
+type vipKey struct {
+	ip       uint32
+	port     uint32
+	protocol Vip_Protocol
+}
+
+func newVipKey(vip *Vip) vipKey {
+	return vipKey{ip: vip.GetIp(), port: vip.GetPort(), protocol: vip.GetProtocol()}
 }
 
-// NewBackendsClientManager returns an initialized instance of BackendsClientManager.
-func NewBackendsClientManager(config *rest.Config) (*BackendsClientManager, error) {
+// KeepaliveConfig configures the gRPC keepalive of the connections to the
+// dataplane, so that stale connections get detected.
+type KeepaliveConfig struct {
+	// Time is the period of inactivity after which the connection is pinged.
+	// A zero value leaves the gRPC keepalive disabled.
+	Time time.Duration
+	// Timeout is how long to wait for a ping to be acknowledged before the
+	// connection is closed.
+	Timeout time.Duration
+	// PermitWithoutStream allows pings to be sent when there are no active RPCs.
+	PermitWithoutStream bool
+}
+
+// NewBackendsClientManager returns an initialized instance of BackendsClientManager,
+// which connects to the dataplane API on the provided port.
+func NewBackendsClientManager(config *rest.Config, apiPort int, keepaliveConfig KeepaliveConfig) (*BackendsClientManager, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &BackendsClientManager{
-		log:       log.FromContext(context.Background()),
-		clientset: clientset,
-		mu:        sync.RWMutex{},
-		clients:   map[types.NamespacedName]clientInfo{},
+		log:                log.FromContext(context.Background()),
+		clientset:          clientset,
+		apiPort:            apiPort,
+		keepalive:          keepaliveConfig,
+		probeInterval:      defaultEjectedClientProbeInterval,
+		maxMessageSize:     DefaultMaxMessageSize,
+		maxConcurrentCalls: DefaultMaxConcurrentCalls,
+		healthCheck:        GRPCHealthChecker,
+		healthCheckTimeout: defaultHealthCheckTimeout,
+		placement:          AllPodsPlacement{},
+		mu:                 sync.RWMutex{},
+		clients:            map[types.NamespacedName]clientInfo{},
+		unreachable:        map[types.NamespacedName]string{},
+		desired:            map[vipKey]*Targets{},
 	}, nil
 }
 
+// SetContextDialer sets the dialer used for the connections to the dataplane
+// Pods, which are opened with the default dialer otherwise. It must be set
+// before the clients list.
+func (c *BackendsClientManager) SetContextDialer(dialer func(context.Context, string) (net.Conn, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dialer = dialer
+}
+
+// SetMaxMessageSize sets the maximum size of the messages sent to and received
+// from the dataplane, which is DefaultMaxMessageSize otherwise. It must be set
+// before the clients list.
+func (c *BackendsClientManager) SetMaxMessageSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxMessageSize = size
+}
+
+// SetMaxConcurrentCalls sets the maximum number of dataplane instances called
+// concurrently when fanning out an update or a delete, which is
+// DefaultMaxConcurrentCalls otherwise. A value lower than 1 removes the limit.
+func (c *BackendsClientManager) SetMaxConcurrentCalls(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxConcurrentCalls = n
+}
+
+// SetPlacementStrategy sets the PlacementStrategy selecting the dataplane Pods
+// each VIP is programmed on. The VIPs already programmed on Pods which they
+// aren't placed on anymore are removed by PruneVIPs.
+func (c *BackendsClientManager) SetPlacementStrategy(strategy PlacementStrategy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.placement = strategy
+}
+
+// SetHealthChecker sets the HealthChecker checking the serving status of the
+// dataplane Pods before they're admitted to the clients, which is
+// GRPCHealthChecker otherwise. It must be set before the clients list.
+func (c *BackendsClientManager) SetHealthChecker(check HealthChecker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthCheck = check
+}
+
+// SetClientsList connects to the provided ready dataplane Pods, and closes
+// the connections to the Pods which aren't ready anymore. A Pod is only
+// admitted to the clients once its health check reports SERVING, as it can
+// be ready before its gRPC server and eBPF programs are, the Pods which
+// aren't serving yet are reported with ErrPodNotServing so that they're
+// retried. It returns true if the clients changed.
 func (c *BackendsClientManager) SetClientsList(readyPods map[types.NamespacedName]corev1.Pod) (bool, error) {
 	// TODO: close and connect to the different clients concurrently.
 	clientListUpdated := false
@@ -78,6 +341,8 @@ func (c *BackendsClientManager) SetClientsList(readyPods map[types.NamespacedNam
 			c.mu.Lock()
 			delete(c.clients, nn)
 			c.mu.Unlock()
+			metrics.DataPlaneClientEjected.DeleteLabelValues(backendInfo.name)
+			metrics.DataPlaneUnreachable.DeleteLabelValues(backendInfo.name)
 
 			if closeErr := backendInfo.conn.Close(); closeErr != nil {
 				err = errors.Join(err, closeErr)
@@ -86,6 +351,14 @@ func (c *BackendsClientManager) SetClientsList(readyPods map[types.NamespacedNam
 			clientListUpdated = true
 		}
 	}
+	c.mu.Lock()
+	for nn, name := range c.unreachable {
+		if _, ok := readyPods[nn]; !ok {
+			delete(c.unreachable, nn)
+			metrics.DataPlaneUnreachable.DeleteLabelValues(name)
+		}
+	}
+	c.mu.Unlock()
 
 	// Add new clients
 	for _, pod := range readyPods {
@@ -93,26 +366,48 @@ func (c *BackendsClientManager) SetClientsList(readyPods map[types.NamespacedNam
 		if _, ok := c.clients[key]; !ok {
 
 			if pod.Status.PodIP == "" {
+				// the Pod can become ready before its IP is reported, it's
+				// connected to once the clients list is set again.
+				c.log.Info("BackendsClientManager", "status", "waiting for pod IP", "pod", pod.GetName())
+				err = errors.Join(err, fmt.Errorf("%w: %s", ErrPodIPNotAssigned, key))
 				continue
 			}
 
-			endpoint := fmt.Sprintf("%s:%d", pod.Status.PodIP, vars.DefaultDataPlaneAPIPort)
+			endpoint := fmt.Sprintf("%s:%d", pod.Status.PodIP, c.apiPort)
 			c.log.Info("BackendsClientManager", "status", "connecting", "pod", pod.GetName(), "endpoint", endpoint)
 
-			conn, dialErr := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+			conn, dialErr := grpc.NewClient(endpoint, c.dialOptions()...)
 			if dialErr != nil {
 				c.log.Error(dialErr, "BackendsClientManager", "status", "connection failure", "pod", pod.GetName())
+				c.setUnreachable(key, pod.Name, true)
 				err = errors.Join(err, dialErr)
 				continue
 			}
 
+			// the connection is only established by the health check, as the
+			// client connects lazily.
+			healthErr := c.checkServing(conn)
+			c.setUnreachable(key, pod.Name, healthErr != nil && isConnectFailure(conn, healthErr))
+			if healthErr != nil {
+				c.log.Info("BackendsClientManager", "status", "waiting for pod to be serving", "pod", pod.GetName(), "error", healthErr.Error())
+				if closeErr := conn.Close(); closeErr != nil {
+					err = errors.Join(err, closeErr)
+				}
+				err = errors.Join(err, fmt.Errorf("%w: %s: %v", ErrPodNotServing, key, healthErr))
+				continue
+			}
+
 			c.mu.Lock()
 			c.clients[key] = clientInfo{
-				conn:   conn,
-				client: NewBackendsClient(conn),
-				name:   pod.Name,
+				conn:       conn,
+				client:     NewBackendsClient(conn),
+				name:       pod.Name,
+				pod:        pod.DeepCopy(),
+				health:     &clientHealth{},
+				programmed: &programmedBackends{},
 			}
 			c.mu.Unlock()
+			metrics.DataPlaneClientEjected.WithLabelValues(pod.Name).Set(0)
 
 			c.log.Info("BackendsClientManager", "status", "connected", "pod", pod.GetName())
 
@@ -120,9 +415,91 @@ func (c *BackendsClientManager) SetClientsList(readyPods map[types.NamespacedNam
 		}
 	}
 
+	if clientListUpdated {
+		// the route controllers push all the routes again once the clients
+		// changed, which are synced with the backends programmed by then.
+		for _, ci := range c.getClientsInfo() {
+			ci.programmed.invalidate()
+		}
+	}
+
 	return clientListUpdated, err
 }
 
+// setUnreachable records whether the provided dataplane Pod could be
+// connected to, counting each failure.
+func (c *BackendsClientManager) setUnreachable(key types.NamespacedName, name string, unreachable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !unreachable {
+		delete(c.unreachable, key)
+		metrics.DataPlaneUnreachable.WithLabelValues(name).Set(0)
+		return
+	}
+	c.unreachable[key] = name
+	metrics.DataPlaneConnectFailures.WithLabelValues(name).Inc()
+	metrics.DataPlaneUnreachable.WithLabelValues(name).Set(1)
+}
+
+// isConnectFailure indicates whether the provided error of a call on the
+// provided connection is caused by the dataplane not being reachable, rather
+// than by the dataplane failing the call.
+func isConnectFailure(conn *grpc.ClientConn, err error) bool {
+	if status.Code(err) == codes.Unavailable {
+		return true
+	}
+	return conn != nil && conn.GetState() == connectivity.TransientFailure
+}
+
+// checkServing returns an error unless the health check of the dataplane
+// served on the provided connection reports SERVING.
+func (c *BackendsClientManager) checkServing(conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.healthCheckTimeout)
+	defer cancel()
+	status, err := c.healthCheck(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health check reported %s", status)
+	}
+	return nil
+}
+
+// dialOptions returns the options used to connect to the dataplane.
+func (c *BackendsClientManager) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+		grpc.WithChainUnaryInterceptor(traceUnaryClientInterceptor),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(c.maxMessageSize),
+			grpc.MaxCallRecvMsgSize(c.maxMessageSize),
+		),
+	}
+	if params, ok := c.keepaliveParams(); ok {
+		opts = append(opts, grpc.WithKeepaliveParams(params))
+	}
+	if c.dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(c.dialer))
+	}
+	return opts
+}
+
+// keepaliveParams returns the gRPC keepalive parameters for the configured
+// KeepaliveConfig, if keepalive is enabled.
+func (c *BackendsClientManager) keepaliveParams() (keepalive.ClientParameters, bool) {
+	if c.keepalive.Time <= 0 {
+		return keepalive.ClientParameters{}, false
+	}
+	return keepalive.ClientParameters{
+		Time:                c.keepalive.Time,
+		Timeout:             c.keepalive.Timeout,
+		PermitWithoutStream: c.keepalive.PermitWithoutStream,
+	}, true
+}
+
 func (c *BackendsClientManager) Close() {
 	c.log.Info("BackendsClientManager", "status", "shutting down")
 
@@ -139,6 +516,12 @@ func (c *BackendsClientManager) Close() {
 		}(cc)
 
 		delete(c.clients, key)
+		metrics.DataPlaneClientEjected.DeleteLabelValues(cc.name)
+		metrics.DataPlaneUnreachable.DeleteLabelValues(cc.name)
+	}
+	for key, name := range c.unreachable {
+		delete(c.unreachable, key)
+		metrics.DataPlaneUnreachable.DeleteLabelValues(name)
 	}
 
 	wg.Wait()
@@ -146,38 +529,365 @@ func (c *BackendsClientManager) Close() {
 	c.log.Info("BackendsClientManager", "status", "shutdown completed")
 }
 
+// getClientsInfo returns the clients which are not ejected from the updates
+// fan-out.
 func (c *BackendsClientManager) getClientsInfo() []clientInfo {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	backends := make([]clientInfo, 0, len(c.clients))
 	for _, backendClient := range c.clients {
+		if backendClient.health.ejected {
+			continue
+		}
 		backends = append(backends, backendClient)
 	}
 
 	return backends
 }
 
-// Update sends an update request to all available BackendsClient servers concurrently.
-func (c *BackendsClientManager) Update(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*Confirmation, error) {
-	clientsInfo := c.getClientsInfo()
+// places indicates whether the provided VIP is programmed on the provided
+// client. It must be called with the mutex held.
+func (c *BackendsClientManager) places(vip *Vip, ci clientInfo) bool {
+	if c.placement == nil {
+		return true
+	}
+	pod := ci.pod
+	if pod == nil {
+		pod = &corev1.Pod{}
+	}
+	return c.placement.Places(vip, pod)
+}
+
+// getPlacedClientsInfo returns the clients which are not ejected from the
+// updates fan-out and which the provided VIP is placed on.
+func (c *BackendsClientManager) getPlacedClientsInfo(vip *Vip) []clientInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	backends := make([]clientInfo, 0, len(c.clients))
+	for _, backendClient := range c.clients {
+		if backendClient.health.ejected || !c.places(vip, backendClient) {
+			continue
+		}
+		backends = append(backends, backendClient)
+	}
+
+	return backends
+}
+
+// recordUpdateResult tracks the consecutive failed updates of the provided
+// client, and ejects it from the updates fan-out once they reach the
+// clientEjectionThreshold, so that a dataplane instance which keeps failing
+// doesn't fail every update. The connection is kept to probe it.
+func (c *BackendsClientManager) recordUpdateResult(ci clientInfo, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		ci.health.failures = 0
+		metrics.DataPlaneUnreachable.WithLabelValues(ci.name).Set(0)
+		return
+	}
+	if isConnectFailure(ci.conn, err) {
+		metrics.DataPlaneConnectFailures.WithLabelValues(ci.name).Inc()
+		metrics.DataPlaneUnreachable.WithLabelValues(ci.name).Set(1)
+	}
+
+	ci.health.failures++
+	if ci.health.failures >= clientEjectionThreshold && !ci.health.ejected {
+		ci.health.ejected = true
+		ci.health.lastProbe = time.Now()
+		metrics.DataPlaneClientEjected.WithLabelValues(ci.name).Set(1)
+		c.log.Info("BackendsClientManager", "status", "ejected", "pod", ci.name, "failures", ci.health.failures)
+	}
+}
+
+// probeEjectedClients probes the ejected clients which weren't probed for the
+// probe interval by pushing the whole desired state to them, and re-admits
+// those which apply it: as they missed updates while ejected, they're only
+// re-admitted once they're back in sync.
+func (c *BackendsClientManager) probeEjectedClients(ctx context.Context, opts ...grpc.CallOption) {
+	c.mu.Lock()
+	var probes []clientInfo
+	for _, ci := range c.clients {
+		if ci.health.ejected && time.Since(ci.health.lastProbe) >= c.probeInterval {
+			ci.health.lastProbe = time.Now()
+			probes = append(probes, ci)
+		}
+	}
+	desired := make(map[string][]*Targets, len(probes))
+	for _, ci := range probes {
+		for _, targets := range c.desired {
+			if c.places(targets.GetVip(), ci) {
+				desired[ci.name] = append(desired[ci.name], targets)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for _, ci := range probes {
+		if err := pushTargets(ctx, ci.client, desired[ci.name], opts...); err != nil {
+			c.log.V(1).Info("BackendsClientManager", "status", "probe failed", "pod", ci.name, "error", err.Error())
+			continue
+		}
+
+		c.mu.Lock()
+		ci.health.ejected = false
+		ci.health.failures = 0
+		c.mu.Unlock()
+		ci.programmed.invalidate()
+		metrics.DataPlaneClientEjected.WithLabelValues(ci.name).Set(0)
+		c.log.Info("BackendsClientManager", "status", "re-admitted", "pod", ci.name)
+	}
+}
+
+// pushTargets sends an update request with each of the provided Targets to
+// the provided client, stopping at the first failure.
+func pushTargets(ctx context.Context, client BackendsClient, targets []*Targets, opts ...grpc.CallOption) error {
+	for _, in := range targets {
+		if _, err := client.Update(ctx, in, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackDesiredTargets records the provided Targets as the desired state for
+// their VIP, and logs the backends which changed since the previous push.
+func (c *BackendsClientManager) trackDesiredTargets(in *Targets) {
+	key := newVipKey(in.GetVip())
+
+	c.mu.Lock()
+	previous := c.desired[key]
+	c.desired[key] = in
+	c.mu.Unlock()
+
+	if logger := c.log.V(1); logger.Enabled() {
+		added, removed := DiffTargets(previous.GetTargets(), in.GetTargets())
+		if len(added) > 0 || len(removed) > 0 {
+			logger.Info("BackendsClientManager", "operation", "update", "vip", in.GetVip().Key(),
+				"added", added, "removed", removed)
+		}
+	}
+}
+
+// trackDesiredDelta applies the provided added and removed backends to the
+// desired state of the provided VIP. VIPs which aren't tracked are left
+// untracked, as their full backend set is unknown.
+func (c *BackendsClientManager) trackDesiredDelta(vip *Vip, added, removed []*Target) {
+	key := newVipKey(vip)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous, ok := c.desired[key]
+	if !ok {
+		return
+	}
+	current := &Targets{
+		Vip:                   previous.GetVip(),
+		Targets:               ApplyTargetsDelta(previous.GetTargets(), added, removed),
+		TcpIdleTimeoutSeconds: previous.TcpIdleTimeoutSeconds,
+		Action:                previous.GetAction(),
+	}
+	c.desired[key] = current
+}
+
+// Update sends an update request to all available BackendsClient servers
+// which the VIP is placed on concurrently, up to the concurrency limit.
+// ErrNoDataPlaneClients is returned if there are none, as the Targets were
+// not actually programmed anywhere. Servers which are ejected after repeated
+// failures are skipped, and are probed to be re-admitted. If the update
+// fails on some of the servers only, a PartialUpdateError is returned. The
+// returned UpdateResult, which is never nil, holds the outcome on each of the
+// servers the update was sent to.
+func (c *BackendsClientManager) Update(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error) {
+	ctx, span := startSpan(ctx, "BackendsClientManager.Update", vipAttribute(in.GetVip()), attribute.Int("blixt.targets", len(in.GetTargets())))
+	c.trackDesiredTargets(in)
+	result, err := c.updateClients(ctx, in.GetVip(), nil, "update", func(ci clientInfo) (*Confirmation, error) {
+		return ci.client.Update(ctx, in, opts...)
+	}, opts...)
+	endSpan(span, err)
+	return result, err
+}
+
+// UpdatePods is like Update, but only sends the update request to the
+// BackendsClient servers of the provided Pods, for instance to retry an update
+// which failed on them with a PartialUpdateError. The Pods which aren't
+// available anymore, or which the VIP isn't placed on anymore, are skipped:
+// servers which become available again are sent the whole backend sets.
+func (c *BackendsClientManager) UpdatePods(ctx context.Context, in *Targets, pods []string, opts ...grpc.CallOption) (*UpdateResult, error) {
+	ctx, span := startSpan(ctx, "BackendsClientManager.UpdatePods", vipAttribute(in.GetVip()), attribute.Int("blixt.targets", len(in.GetTargets())))
+	c.trackDesiredTargets(in)
+	result, err := c.updateClients(ctx, in.GetVip(), pods, "update", func(ci clientInfo) (*Confirmation, error) {
+		return ci.client.Update(ctx, in, opts...)
+	}, opts...)
+	endSpan(span, err)
+	return result, err
+}
+
+// Sync is like Update, but only pushes to each server the difference between
+// the provided Targets and the backends it had programmed for the VIP, as
+// reported by its ListBackends: nothing if they're the same, or the added and
+// removed backends. This is used for the first push of each VIP after the
+// control plane starts or the servers change, so that the backends which are
+// already programmed aren't replaced, which would reset their load
+// balancing. The servers which don't have the VIP, or whose backends for it
+// can't be patched, are sent the whole backend set.
+//
+// The programmed backends are listed once per server, on the first Sync
+// after the servers changed, and those of a VIP are only used by its first
+// Sync: they're not up to date anymore once the VIP was pushed.
+func (c *BackendsClientManager) Sync(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error) {
+	ctx, span := startSpan(ctx, "BackendsClientManager.Sync", vipAttribute(in.GetVip()), attribute.Int("blixt.targets", len(in.GetTargets())))
+	c.trackDesiredTargets(in)
+	result, err := c.updateClients(ctx, in.GetVip(), nil, "sync", func(ci clientInfo) (*Confirmation, error) {
+		return syncClient(ctx, ci, in, opts...)
+	}, opts...)
+	endSpan(span, err)
+	return result, err
+}
+
+// syncClient pushes the difference between the provided Targets and the
+// backends programmed for their VIP to the provided client.
+func syncClient(ctx context.Context, ci clientInfo, in *Targets, opts ...grpc.CallOption) (*Confirmation, error) {
+	programmed, err := ci.programmed.take(ctx, ci.client, in.GetVip(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if programmed == nil {
+		return ci.client.Update(ctx, in, opts...)
+	}
+	added, removed, ok := syncDelta(programmed.GetTargets(), in.GetTargets())
+	// AddBackend and RemoveBackend don't change the settings of the VIP.
+	if !ok || programmed.GetAction() != in.GetAction() || programmed.GetTcpIdleTimeoutSeconds() != in.GetTcpIdleTimeoutSeconds() {
+		return ci.client.Update(ctx, in, opts...)
+	}
+
+	conf := &Confirmation{Confirmation: fmt.Sprintf("vip %s was already programmed", in.GetVip().Key())}
+	// the backends are added first, so that the VIP keeps backends.
+	if len(added) > 0 {
+		if conf, err = ci.client.AddBackend(ctx, &Targets{Vip: in.GetVip(), Targets: added}, opts...); err != nil {
+			return nil, err
+		}
+	}
+	if len(removed) > 0 {
+		if conf, err = ci.client.RemoveBackend(ctx, &Targets{Vip: in.GetVip(), Targets: removed}, opts...); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// AddBackend adds the provided Targets to the backends of their VIP on all
+// available BackendsClient servers concurrently, without replacing the
+// backends the VIP already has. Like for Update, ejected servers are skipped.
+func (c *BackendsClientManager) AddBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error) {
+	ctx, span := startSpan(ctx, "BackendsClientManager.AddBackend", vipAttribute(in.GetVip()), attribute.Int("blixt.targets", len(in.GetTargets())))
+	c.trackDesiredDelta(in.GetVip(), in.GetTargets(), nil)
+	result, err := c.updateClients(ctx, in.GetVip(), nil, "add", func(ci clientInfo) (*Confirmation, error) {
+		return ci.client.AddBackend(ctx, in, opts...)
+	}, opts...)
+	endSpan(span, err)
+	return result, err
+}
+
+// RemoveBackend removes the provided Targets from the backends of their VIP
+// on all available BackendsClient servers concurrently. Like for Update,
+// ejected servers are skipped.
+func (c *BackendsClientManager) RemoveBackend(ctx context.Context, in *Targets, opts ...grpc.CallOption) (*UpdateResult, error) {
+	ctx, span := startSpan(ctx, "BackendsClientManager.RemoveBackend", vipAttribute(in.GetVip()), attribute.Int("blixt.targets", len(in.GetTargets())))
+	c.trackDesiredDelta(in.GetVip(), nil, in.GetTargets())
+	result, err := c.updateClients(ctx, in.GetVip(), nil, "remove", func(ci clientInfo) (*Confirmation, error) {
+		return ci.client.RemoveBackend(ctx, in, opts...)
+	}, opts...)
+	endSpan(span, err)
+	return result, err
+}
+
+// updateClients probes the ejected clients, then sends the provided update to
+// the clients which are not ejected and which the provided VIP is placed on
+// concurrently, tracking their failures. If pods isn't nil, only the clients
+// of the provided Pods are updated. A PartialUpdateError is returned if the
+// update fails on some of the clients only.
+func (c *BackendsClientManager) updateClients(ctx context.Context, vip *Vip, pods []string, operation string, update func(clientInfo) (*Confirmation, error), opts ...grpc.CallOption) (*UpdateResult, error) {
+	result := newUpdateResult()
+	c.probeEjectedClients(ctx, opts...)
+	clientsInfo := c.getPlacedClientsInfo(vip)
+	if len(clientsInfo) == 0 {
+		return result, ErrNoDataPlaneClients
+	}
+	if pods != nil {
+		clientsInfo = slices.DeleteFunc(clientsInfo, func(ci clientInfo) bool {
+			return !slices.Contains(pods, ci.name)
+		})
+	}
+
+	var (
+		failedMu sync.Mutex
+		failed   []string
+	)
+	defer c.dropProgrammed(vip)
+	err := c.fanOut(clientsInfo, func(ci clientInfo) error {
+		conf, err := update(ci)
+		c.recordUpdateResult(ci, err)
+		result.record(ci.name, conf, err)
+		if err != nil {
+			c.log.Error(err, "BackendsClientManager", "operation", operation, "pod", ci.name)
+			failedMu.Lock()
+			failed = append(failed, ci.name)
+			failedMu.Unlock()
+			return err
+		}
+		c.log.Info("BackendsClientManager", "operation", operation, "pod", ci.name, "confirmation", conf.Confirmation)
+		return nil
+	})
+	if err != nil && len(failed) < len(clientsInfo) {
+		slices.Sort(failed)
+		return result, &PartialUpdateError{Pods: failed, Err: err}
+	}
+	return result, err
+}
+
+// dropProgrammed drops the backends programmed for the provided VIP from the
+// snapshots of all the clients, once the VIP was pushed to them.
+func (c *BackendsClientManager) dropProgrammed(vip *Vip) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, ci := range c.clients {
+		ci.programmed.drop(vip)
+	}
+}
+
+// fanOut calls the provided function with each of the provided clients
+// concurrently, with at most maxConcurrentCalls calls in flight, and returns
+// the errors of all the calls.
+func (c *BackendsClientManager) fanOut(clientsInfo []clientInfo, call func(clientInfo) error) error {
+	c.mu.RLock()
+	limit := c.maxConcurrentCalls
+	c.mu.RUnlock()
+	if limit < 1 || limit > len(clientsInfo) {
+		limit = len(clientsInfo)
+	}
 
 	var wg sync.WaitGroup
 	wg.Add(len(clientsInfo))
 
+	sem := make(chan struct{}, limit)
 	errs := make(chan error, len(clientsInfo))
 
 	for _, ci := range clientsInfo {
+		sem <- struct{}{}
 		go func(ci clientInfo) {
-			defer wg.Done()
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
 
-			conf, err := ci.client.Update(ctx, in, opts...)
-			if err != nil {
-				c.log.Error(err, "BackendsClientManager", "operation", "update", "pod", ci.name)
+			if err := call(ci); err != nil {
 				errs <- err
-				return
 			}
-			c.log.Info("BackendsClientManager", "operation", "update", "pod", ci.name, "confirmation", conf.Confirmation)
 		}(ci)
 	}
 
@@ -189,40 +899,182 @@ func (c *BackendsClientManager) Update(ctx context.Context, in *Targets, opts ..
 		err = errors.Join(err, e)
 	}
 
-	return nil, err
+	return err
 }
 
-// Delete sends an delete request to all available BackendsClient servers concurrently.
-func (c *BackendsClientManager) Delete(ctx context.Context, in *Vip, opts ...grpc.CallOption) (*Confirmation, error) {
+// Delete sends an delete request to all available BackendsClient servers
+// concurrently, up to the concurrency limit.
+// Unlike Update, having no servers is not an error: a dataplane instance
+// connecting later starts without any configuration for the VIP anyway.
+// Ejected servers are skipped, so they keep the configuration of VIPs deleted
+// while they were ejected until they're restarted. Deleting a VIP which isn't
+// programmed on a server succeeds, even if the server reports it NotFound.
+// Like for Update, the outcome on each server is returned in an UpdateResult.
+func (c *BackendsClientManager) Delete(ctx context.Context, in *Vip, opts ...grpc.CallOption) (*UpdateResult, error) {
+	ctx, span := startSpan(ctx, "BackendsClientManager.Delete", vipAttribute(in))
+	c.mu.Lock()
+	delete(c.desired, newVipKey(in))
+	c.mu.Unlock()
+	defer c.dropProgrammed(in)
 	clientsInfo := c.getClientsInfo()
 
-	var wg sync.WaitGroup
-	wg.Add(len(clientsInfo))
+	result := newUpdateResult()
+	err := c.fanOut(clientsInfo, func(ci clientInfo) error {
+		conf, err := ci.client.Delete(ctx, in, opts...)
+		if status.Code(err) == codes.NotFound {
+			// the VIP was never programmed on this server, or was already
+			// deleted from it.
+			conf, err = &Confirmation{Confirmation: "vip did not exist"}, nil
+		}
+		result.record(ci.name, conf, err)
+		if err != nil {
+			c.log.Error(err, "BackendsClientManager", "operation", "delete", "pod", ci.name)
+			return err
+		}
+		c.log.Info("BackendsClientManager", "operation", "delete", "pod", ci.name, "confirmation", conf.Confirmation)
+		return nil
+	})
+	endSpan(span, err)
 
-	errs := make(chan error, len(clientsInfo))
+	return result, err
+}
 
-	for _, ci := range clientsInfo {
-		go func(ci clientInfo) {
+// GetStatus returns the status of each of the available BackendsClient
+// servers, keyed by the name of their Pod, which reports how the dataplane
+// programs are attached. The statuses of the servers which could be queried
+// are returned along with the errors of the others.
+func (c *BackendsClientManager) GetStatus(ctx context.Context, opts ...grpc.CallOption) (map[string]*DataplaneStatus, error) {
+	statuses := map[string]*DataplaneStatus{}
+	var errs error
+	for _, ci := range c.getClientsInfo() {
+		status, err := ci.client.GetStatus(ctx, &StatusRequest{}, opts...)
+		if err != nil {
+			c.log.Error(err, "BackendsClientManager", "operation", "status", "pod", ci.name)
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", ci.name, err))
+			continue
+		}
+		statuses[ci.name] = status
+	}
+
+	return statuses, errs
+}
+
+// WatchedBackendsEvent is an event of the backends programmed into a
+// dataplane instance, as streamed by BackendsClientManager.WatchBackends.
+type WatchedBackendsEvent struct {
+	// Pod is the name of the Pod of the dataplane instance.
+	Pod string
+	// Event is the received event, it's nil if Err is set.
+	Event *BackendsEvent
+	// Err is the error which ended the stream of the dataplane instance.
+	Err error
+}
+
+// WatchBackends streams the backends programmed into the available
+// BackendsClient servers, multiplexing the streams of all the servers into the
+// returned channel. Each stream starts with a snapshot of the backends of the
+// server, followed by their changes. The channel is closed once all the
+// streams ended, either because the context is done or because of an error,
+// which is sent as the last event of the server. The streams aren't restarted,
+// and the servers which become available later aren't watched.
+//
+// The events are received from the servers only as fast as the channel is
+// consumed, a server whose stream falls behind sends a new snapshot.
+func (c *BackendsClientManager) WatchBackends(ctx context.Context, opts ...grpc.CallOption) <-chan WatchedBackendsEvent {
+	events := make(chan WatchedBackendsEvent)
+	var wg sync.WaitGroup
+	for _, ci := range c.getClientsInfo() {
+		ci := ci
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
+			c.watchClient(ctx, ci, events, opts...)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
 
-			conf, err := ci.client.Delete(ctx, in, opts...)
-			if err != nil {
-				c.log.Error(err, "BackendsClientManager", "operation", "delete", "pod", ci.name)
-				errs <- err
+	return events
+}
+
+// watchClient sends the events of the WatchBackends stream of the provided
+// client until the context is done or the stream fails.
+func (c *BackendsClientManager) watchClient(ctx context.Context, ci clientInfo, events chan<- WatchedBackendsEvent, opts ...grpc.CallOption) {
+	send := func(event WatchedBackendsEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	stream, err := ci.client.WatchBackends(ctx, &WatchBackendsRequest{}, opts...)
+	if err == nil {
+		for {
+			var event *BackendsEvent
+			if event, err = stream.Recv(); err != nil {
+				break
+			}
+			if !send(WatchedBackendsEvent{Pod: ci.name, Event: event}) {
 				return
 			}
-			c.log.Info("BackendsClientManager", "operation", "delete", "pod", ci.name, "confirmation", conf.Confirmation)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	c.log.Error(err, "BackendsClientManager", "operation", "watch", "pod", ci.name)
+	send(WatchedBackendsEvent{Pod: ci.name, Err: err})
+}
 
-		}(ci)
+// PruneVIPs deletes the VIPs which are programmed in the available
+// BackendsClient servers but aren't part of the provided desired VIPs, for
+// instance because the fan-out of a Delete partially failed, or which aren't
+// placed on the servers anymore. VIPs updated
+// through the manager and not deleted since are never pruned, so that VIPs
+// of routes created after the desired VIPs were computed are kept. It
+// returns the number of VIPs deleted, counting each server separately.
+func (c *BackendsClientManager) PruneVIPs(ctx context.Context, desired []*Vip, opts ...grpc.CallOption) (int, error) {
+	desiredSet := make(map[vipKey]struct{}, len(desired))
+	for _, vip := range desired {
+		desiredSet[newVipKey(vip)] = struct{}{}
 	}
 
-	wg.Wait()
-	close(errs)
+	var (
+		pruned int
+		errs   error
+	)
+	for _, ci := range c.getClientsInfo() {
+		list, err := ci.client.ListBackends(ctx, &ListBackendsRequest{}, opts...)
+		if err != nil {
+			c.log.Error(err, "BackendsClientManager", "operation", "list", "pod", ci.name)
+			errs = errors.Join(errs, err)
+			continue
+		}
 
-	var err error
-	for e := range errs {
-		err = errors.Join(err, e)
+		for _, targets := range list.GetBackends() {
+			vip := targets.GetVip()
+			key := newVipKey(vip)
+			c.mu.RLock()
+			_, tracked := c.desired[key]
+			placed := c.places(vip, ci)
+			c.mu.RUnlock()
+			if _, ok := desiredSet[key]; (ok || tracked) && placed {
+				continue
+			}
+
+			if _, err := ci.client.Delete(ctx, vip, opts...); err != nil {
+				c.log.Error(err, "BackendsClientManager", "operation", "prune", "pod", ci.name, "vip", vip.Key())
+				errs = errors.Join(errs, err)
+				continue
+			}
+			pruned++
+			c.log.Info("BackendsClientManager", "operation", "prune", "pod", ci.name, "vip", vip.Key())
+		}
 	}
 
-	return nil, err
+	return pruned, errs
 }
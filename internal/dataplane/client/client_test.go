@@ -0,0 +1,630 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubernetes-sigs/blixt/internal/metrics"
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+func TestBackendsClientManager_dialOptions(t *testing.T) {
+	t.Run("keepalive is disabled by default", func(t *testing.T) {
+		manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+		require.NoError(t, err)
+
+		_, ok := manager.keepaliveParams()
+		assert.False(t, ok)
+		assert.Len(t, manager.dialOptions(), 4)
+	})
+
+	t.Run("the configured keepalive parameters are used", func(t *testing.T) {
+		manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{
+			Time:                30 * time.Second,
+			Timeout:             5 * time.Second,
+			PermitWithoutStream: true,
+		})
+		require.NoError(t, err)
+
+		params, ok := manager.keepaliveParams()
+		require.True(t, ok)
+		assert.Equal(t, keepalive.ClientParameters{
+			Time:                30 * time.Second,
+			Timeout:             5 * time.Second,
+			PermitWithoutStream: true,
+		}, params)
+		assert.Len(t, manager.dialOptions(), 5)
+	})
+}
+
+func TestBackendsClientManager_noClients(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	vip := &Vip{Ip: 0xac1200f0, Port: 8080}
+
+	_, err = manager.Update(context.Background(), &Targets{
+		Vip:     vip,
+		Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}},
+	})
+	assert.ErrorIs(t, err, ErrNoDataPlaneClients)
+
+	_, err = manager.Delete(context.Background(), vip)
+	assert.NoError(t, err)
+}
+
+// fakeBackendsClient is a BackendsClient whose updates and deletes fail while
+// fail is set, as if it was unreachable if unavailable is set too, and which reports the provided backends as programmed and the provided
+// status, if any.
+type fakeBackendsClient struct {
+	BackendsClient
+
+	fail        bool
+	unavailable bool
+	updates     []*Targets
+	deletes     []*Vip
+	adds        []*Targets
+	removes     []*Targets
+	backends    []*Targets
+	status      *DataplaneStatus
+}
+
+func (f *fakeBackendsClient) Update(_ context.Context, in *Targets, _ ...grpc.CallOption) (*Confirmation, error) {
+	if f.fail && f.unavailable {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	if f.fail {
+		return nil, errors.New("eBPF map full")
+	}
+	f.updates = append(f.updates, in)
+	return &Confirmation{Confirmation: "updated"}, nil
+}
+
+func (f *fakeBackendsClient) Delete(_ context.Context, in *Vip, _ ...grpc.CallOption) (*Confirmation, error) {
+	if f.fail {
+		return nil, errors.New("eBPF map unavailable")
+	}
+	f.deletes = append(f.deletes, in)
+	return &Confirmation{Confirmation: "deleted"}, nil
+}
+
+func (f *fakeBackendsClient) AddBackend(_ context.Context, in *Targets, _ ...grpc.CallOption) (*Confirmation, error) {
+	f.adds = append(f.adds, in)
+	return &Confirmation{}, nil
+}
+
+func (f *fakeBackendsClient) RemoveBackend(_ context.Context, in *Targets, _ ...grpc.CallOption) (*Confirmation, error) {
+	f.removes = append(f.removes, in)
+	return &Confirmation{}, nil
+}
+
+func (f *fakeBackendsClient) ListBackends(_ context.Context, _ *ListBackendsRequest, _ ...grpc.CallOption) (*BackendsList, error) {
+	return &BackendsList{Backends: f.backends}, nil
+}
+
+func (f *fakeBackendsClient) GetStatus(_ context.Context, _ *StatusRequest, _ ...grpc.CallOption) (*DataplaneStatus, error) {
+	if f.status == nil {
+		return nil, errors.New("unimplemented")
+	}
+	return f.status, nil
+}
+
+func TestBackendsClientManager_clientEjection(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	healthy, failing := &fakeBackendsClient{}, &fakeBackendsClient{fail: true}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-healthy"}] = clientInfo{
+		client: healthy, name: "dataplane-healthy", health: &clientHealth{},
+	}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-failing"}] = clientInfo{
+		client: failing, name: "dataplane-failing", health: &clientHealth{},
+	}
+	manager.probeInterval = time.Hour
+	ejected := metrics.DataPlaneClientEjected.WithLabelValues("dataplane-failing")
+	targets := &Targets{
+		Vip:     &Vip{Ip: 0xac1200f0, Port: 8080},
+		Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}},
+	}
+
+	t.Log("the failing client is ejected after consecutive failures")
+	for i := 0; i < clientEjectionThreshold; i++ {
+		_, err = manager.Update(context.Background(), targets)
+		require.Error(t, err)
+	}
+	require.Equal(t, float64(1), testutil.ToFloat64(ejected))
+
+	t.Log("updates skip the ejected client until it's probed")
+	_, err = manager.Update(context.Background(), targets)
+	require.NoError(t, err)
+	require.Len(t, healthy.updates, clientEjectionThreshold+1)
+
+	t.Log("a failed probe keeps the client ejected")
+	manager.probeInterval = 0
+	_, err = manager.Update(context.Background(), targets)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(ejected))
+
+	t.Log("the client is re-admitted once a probe succeeds")
+	failing.fail = false
+	_, err = manager.Update(context.Background(), targets)
+	require.NoError(t, err)
+	require.Equal(t, float64(0), testutil.ToFloat64(ejected))
+	// the probe pushed the desired state, then the update was fanned out to it
+	require.Len(t, failing.updates, 2)
+}
+
+// concurrencyTrackingClient is a BackendsClient whose calls take some time,
+// tracking the number of calls in flight across all the clients sharing the
+// counters.
+type concurrencyTrackingClient struct {
+	BackendsClient
+
+	inFlight    *atomic.Int32
+	maxInFlight *atomic.Int32
+	calls       *atomic.Int32
+}
+
+func (f *concurrencyTrackingClient) call() {
+	f.calls.Add(1)
+	inFlight := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		maxInFlight := f.maxInFlight.Load()
+		if inFlight <= maxInFlight || f.maxInFlight.CompareAndSwap(maxInFlight, inFlight) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+}
+
+func (f *concurrencyTrackingClient) Update(_ context.Context, _ *Targets, _ ...grpc.CallOption) (*Confirmation, error) {
+	f.call()
+	return &Confirmation{}, nil
+}
+
+func (f *concurrencyTrackingClient) Delete(_ context.Context, _ *Vip, _ ...grpc.CallOption) (*Confirmation, error) {
+	f.call()
+	return &Confirmation{}, nil
+}
+
+// notFoundDeleteClient is a BackendsClient which reports the VIPs it's asked
+// to delete as NotFound.
+type notFoundDeleteClient struct {
+	BackendsClient
+}
+
+func (f *notFoundDeleteClient) Delete(_ context.Context, in *Vip, _ ...grpc.CallOption) (*Confirmation, error) {
+	return nil, status.Errorf(codes.NotFound, "vip %s does not exist", in.Addr())
+}
+
+func TestBackendsClientManager_deleteMissingVIP(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane"}] = clientInfo{
+		client: &notFoundDeleteClient{}, name: "dataplane", health: &clientHealth{},
+	}
+
+	t.Log("deleting a VIP which isn't programmed succeeds")
+	_, err = manager.Delete(context.Background(), &Vip{Ip: 0xac1200f0, Port: 8080})
+	require.NoError(t, err)
+}
+
+func TestBackendsClientManager_maxConcurrentCalls(t *testing.T) {
+	const clients, maxConcurrentCalls = 50, 5
+
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	manager.SetMaxConcurrentCalls(maxConcurrentCalls)
+	var inFlight, maxInFlight, calls atomic.Int32
+	for i := 0; i < clients; i++ {
+		name := fmt.Sprintf("dataplane-%d", i)
+		manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: name}] = clientInfo{
+			client: &concurrencyTrackingClient{inFlight: &inFlight, maxInFlight: &maxInFlight, calls: &calls},
+			name:   name,
+			health: &clientHealth{},
+		}
+	}
+	vip := &Vip{Ip: 0xac1200f0, Port: 8080}
+
+	t.Log("updates are fanned out concurrently up to the limit")
+	_, err = manager.Update(context.Background(), &Targets{Vip: vip, Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(clients), calls.Load())
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(maxConcurrentCalls))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+
+	t.Log("deletes are fanned out concurrently up to the limit")
+	calls.Store(0)
+	maxInFlight.Store(0)
+	_, err = manager.Delete(context.Background(), vip)
+	require.NoError(t, err)
+	assert.Equal(t, int32(clients), calls.Load())
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(maxConcurrentCalls))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+}
+
+func TestBackendsClientManager_incrementalUpdates(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	dp := &fakeBackendsClient{}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane"}] = clientInfo{
+		client: dp, name: "dataplane", health: &clientHealth{},
+	}
+	vip := &Vip{Ip: 0xac1200f0, Port: 8080}
+	backendA, backendB := &Target{Daddr: 0x0af4000a, Dport: 80}, &Target{Daddr: 0x0af4000b, Dport: 80}
+
+	_, err = manager.Update(context.Background(), &Targets{Vip: vip, Targets: []*Target{backendA}})
+	require.NoError(t, err)
+
+	t.Log("added and removed backends are sent to the clients as-is")
+	added := &Targets{Vip: vip, Targets: []*Target{backendB}}
+	_, err = manager.AddBackend(context.Background(), added)
+	require.NoError(t, err)
+	require.Equal(t, []*Targets{added}, dp.adds)
+	removed := &Targets{Vip: vip, Targets: []*Target{backendA}}
+	_, err = manager.RemoveBackend(context.Background(), removed)
+	require.NoError(t, err)
+	require.Equal(t, []*Targets{removed}, dp.removes)
+
+	t.Log("the desired state tracks the changes, for the probes of ejected clients")
+	require.Equal(t, []*Target{backendB}, manager.desired[newVipKey(vip)].Targets)
+}
+
+func TestBackendsClientManager_protocolVIPs(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane"}] = clientInfo{
+		client: &fakeBackendsClient{}, name: "dataplane", health: &clientHealth{},
+	}
+	tcpVip := &Vip{Ip: 0xac1200f0, Port: 8080, Protocol: Vip_TCP}
+	udpVip := &Vip{Ip: 0xac1200f0, Port: 8080, Protocol: Vip_UDP}
+
+	t.Log("a TCP and a UDP VIP on the same port are programmed separately")
+	_, err = manager.Update(context.Background(), &Targets{Vip: tcpVip, Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}}})
+	require.NoError(t, err)
+	_, err = manager.Update(context.Background(), &Targets{Vip: udpVip, Targets: []*Target{{Daddr: 0x0af4000b, Dport: 53}}})
+	require.NoError(t, err)
+	require.Len(t, manager.desired, 2)
+
+	t.Log("deleting the UDP VIP leaves the TCP VIP alone")
+	_, err = manager.Delete(context.Background(), udpVip)
+	require.NoError(t, err)
+	require.Len(t, manager.desired, 1)
+	require.Equal(t, []*Target{{Daddr: 0x0af4000a, Dport: 80}}, manager.desired[newVipKey(tcpVip)].Targets)
+}
+
+func TestBackendsClientManager_partialUpdateFailure(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	healthy, failing := &fakeBackendsClient{}, &fakeBackendsClient{fail: true}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-healthy"}] = clientInfo{
+		client: healthy, name: "dataplane-healthy", health: &clientHealth{},
+	}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-failing"}] = clientInfo{
+		client: failing, name: "dataplane-failing", health: &clientHealth{},
+	}
+	targets := &Targets{
+		Vip:     &Vip{Ip: 0xac1200f0, Port: 8080},
+		Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}},
+	}
+
+	t.Log("an update failing on some of the clients reports them")
+	_, err = manager.Update(context.Background(), targets)
+	var partialErr *PartialUpdateError
+	require.ErrorAs(t, err, &partialErr)
+	require.Equal(t, []string{"dataplane-failing"}, partialErr.Pods)
+	require.Len(t, healthy.updates, 1)
+
+	t.Log("the update is only retried on the failed clients")
+	failing.fail = false
+	_, err = manager.UpdatePods(context.Background(), targets, partialErr.Pods)
+	require.NoError(t, err)
+	require.Len(t, healthy.updates, 1)
+	require.Len(t, failing.updates, 1)
+
+	t.Log("an update failing on all the clients isn't a partial failure")
+	healthy.fail, failing.fail = true, true
+	_, err = manager.Update(context.Background(), targets)
+	require.Error(t, err)
+	require.False(t, errors.As(err, &partialErr))
+}
+
+func TestBackendsClientManager_updateResult(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-healthy"}] = clientInfo{
+		client: &fakeBackendsClient{}, name: "dataplane-healthy", health: &clientHealth{},
+	}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-failing"}] = clientInfo{
+		client: &fakeBackendsClient{fail: true}, name: "dataplane-failing", health: &clientHealth{},
+	}
+	vip := &Vip{Ip: 0xac1200f0, Port: 8080}
+
+	t.Log("the result of an update reports the outcome on each client")
+	result, err := manager.Update(context.Background(), &Targets{Vip: vip, Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}}})
+	var partialErr *PartialUpdateError
+	require.ErrorAs(t, err, &partialErr)
+	require.Equal(t, 1, result.Succeeded())
+	require.Equal(t, 1, result.Failed())
+	require.Equal(t, map[string]string{"dataplane-healthy": "updated"}, result.Confirmations)
+	require.ErrorContains(t, result.Errors["dataplane-failing"], "eBPF map full")
+
+	t.Log("the result of a delete reports VIPs which weren't programmed as deleted")
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-missing"}] = clientInfo{
+		client: &notFoundDeleteClient{}, name: "dataplane-missing", health: &clientHealth{},
+	}
+	result, err = manager.Delete(context.Background(), vip)
+	require.Error(t, err)
+	require.Equal(t, 2, result.Succeeded())
+	require.Equal(t, 1, result.Failed())
+	require.Equal(t, map[string]string{
+		"dataplane-healthy": "deleted",
+		"dataplane-missing": "vip did not exist",
+	}, result.Confirmations)
+	require.ErrorContains(t, result.Errors["dataplane-failing"], "eBPF map unavailable")
+
+	t.Log("the result is empty without clients")
+	manager.clients = map[types.NamespacedName]clientInfo{}
+	result, err = manager.Update(context.Background(), &Targets{Vip: vip})
+	require.ErrorIs(t, err, ErrNoDataPlaneClients)
+	require.Zero(t, result.Succeeded())
+	require.Zero(t, result.Failed())
+}
+
+func TestBackendsClientManager_placement(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	nodeLabels := map[string]map[string]string{
+		"node-a": {"gateway": "edge"},
+		"node-b": {"gateway": "edge"},
+		"node-c": {},
+	}
+	clients := map[string]*fakeBackendsClient{}
+	for _, node := range []string{"node-a", "node-b", "node-c"} {
+		name := "dataplane-" + node
+		clients[node] = &fakeBackendsClient{}
+		manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: name}] = clientInfo{
+			client: clients[node],
+			name:   name,
+			pod:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: corev1.PodSpec{NodeName: node}},
+			health: &clientHealth{},
+		}
+	}
+	edgeVIP := &Vip{Ip: 0xac1200f0, Port: 8080}
+	manager.SetPlacementStrategy(PlacementFunc(func(vip *Vip, pod *corev1.Pod) bool {
+		if vip.GetIp() != edgeVIP.GetIp() || vip.GetPort() != edgeVIP.GetPort() {
+			return true
+		}
+		return nodeLabels[pod.Spec.NodeName]["gateway"] == "edge"
+	}))
+
+	t.Log("the VIP is only programmed on the dataplane Pods of the labeled nodes")
+	_, err = manager.Update(context.Background(), &Targets{Vip: edgeVIP, Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}}})
+	require.NoError(t, err)
+	require.Len(t, clients["node-a"].updates, 1)
+	require.Len(t, clients["node-b"].updates, 1)
+	require.Empty(t, clients["node-c"].updates)
+
+	t.Log("other VIPs are still programmed on all the dataplane Pods")
+	_, err = manager.Update(context.Background(), &Targets{Vip: &Vip{Ip: 0xac1200f0, Port: 9090}})
+	require.NoError(t, err)
+	require.Len(t, clients["node-c"].updates, 1)
+
+	t.Log("the VIP is pruned from the dataplane Pods it isn't placed on anymore")
+	clients["node-c"].backends = []*Targets{{Vip: edgeVIP}}
+	pruned, err := manager.PruneVIPs(context.Background(), []*Vip{edgeVIP})
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+	require.Equal(t, []*Vip{edgeVIP}, clients["node-c"].deletes)
+}
+
+func TestNodeSelectorPlacement(t *testing.T) {
+	reader := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"gateway": "edge"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+	).Build()
+	placement := NodeSelectorPlacement{Reader: reader, Selector: labels.SelectorFromSet(labels.Set{"gateway": "edge"})}
+	vip := &Vip{Ip: 0xac1200f0, Port: 8080}
+	podOn := func(node string) *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: node}}
+	}
+
+	t.Log("VIPs are only placed on the dataplane Pods of the selected Nodes")
+	assert.True(t, placement.Places(vip, podOn("node-a")))
+	assert.False(t, placement.Places(vip, podOn("node-b")))
+
+	t.Log("Pods whose Node is unknown aren't placed on")
+	assert.False(t, placement.Places(vip, podOn("node-missing")))
+	assert.False(t, placement.Places(vip, podOn("")))
+}
+
+func TestBackendsClientManager_connectFailures(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	key := types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-unreachable"}
+	// the Pod IP makes an invalid target, so connecting to it fails
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Status:     corev1.PodStatus{PodIP: "%zz"},
+	}
+	failures := metrics.DataPlaneConnectFailures.WithLabelValues(key.Name)
+	initialFailures := testutil.ToFloat64(failures)
+
+	t.Log("each failed connection to the dataplane Pod is counted")
+	for i := 1; i <= 2; i++ {
+		updated, err := manager.SetClientsList(map[types.NamespacedName]corev1.Pod{key: pod})
+		require.Error(t, err)
+		require.False(t, updated)
+		require.Equal(t, initialFailures+float64(i), testutil.ToFloat64(failures))
+	}
+	require.Empty(t, manager.clients)
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.DataPlaneUnreachable.WithLabelValues(key.Name)))
+
+	t.Log("the Pod is no longer reported unreachable once it's not ready")
+	_, err = manager.SetClientsList(map[types.NamespacedName]corev1.Pod{})
+	require.NoError(t, err)
+	require.Empty(t, manager.unreachable)
+	require.False(t, metrics.DataPlaneUnreachable.DeleteLabelValues(key.Name))
+}
+
+func TestBackendsClientManager_unreachableServers(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	key := types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-lazy"}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Status:     corev1.PodStatus{PodIP: "10.244.0.20"},
+	}
+	failures := metrics.DataPlaneConnectFailures.WithLabelValues(key.Name)
+	unreachable := metrics.DataPlaneUnreachable.WithLabelValues(key.Name)
+	initialFailures := testutil.ToFloat64(failures)
+
+	t.Log("a dataplane Pod which the health check can't reach is counted as a connect failure")
+	manager.SetHealthChecker(func(context.Context, *grpc.ClientConn) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		return healthpb.HealthCheckResponse_UNKNOWN, status.Error(codes.Unavailable, "connection refused")
+	})
+	_, err = manager.SetClientsList(map[types.NamespacedName]corev1.Pod{key: pod})
+	require.ErrorIs(t, err, ErrPodNotServing)
+	require.Equal(t, initialFailures+1, testutil.ToFloat64(failures))
+	require.Equal(t, float64(1), testutil.ToFloat64(unreachable))
+	manager.mu.RLock()
+	require.Contains(t, manager.unreachable, key)
+	manager.mu.RUnlock()
+
+	t.Log("a reachable dataplane Pod which isn't serving yet isn't")
+	manager.SetHealthChecker(func(context.Context, *grpc.ClientConn) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	})
+	_, err = manager.SetClientsList(map[types.NamespacedName]corev1.Pod{key: pod})
+	require.ErrorIs(t, err, ErrPodNotServing)
+	require.Equal(t, initialFailures+1, testutil.ToFloat64(failures))
+	require.Equal(t, float64(0), testutil.ToFloat64(unreachable))
+
+	t.Log("the updates failing because the dataplane Pod is unreachable are counted too")
+	dataplane := &fakeBackendsClient{fail: true, unavailable: true}
+	manager.mu.Lock()
+	manager.clients[key] = clientInfo{client: dataplane, name: key.Name, health: &clientHealth{}}
+	manager.mu.Unlock()
+	targets := &Targets{
+		Vip:     &Vip{Ip: 0xac1200f0, Port: 8080},
+		Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}},
+	}
+	_, err = manager.Update(context.Background(), targets)
+	require.Error(t, err)
+	require.Equal(t, initialFailures+2, testutil.ToFloat64(failures))
+	require.Equal(t, float64(1), testutil.ToFloat64(unreachable))
+
+	t.Log("while the updates failed by the dataplane itself aren't")
+	dataplane.unavailable = false
+	_, err = manager.Update(context.Background(), targets)
+	require.Error(t, err)
+	require.Equal(t, initialFailures+2, testutil.ToFloat64(failures))
+
+	t.Log("the dataplane Pod is reported reachable again once an update succeeds")
+	dataplane.fail = false
+	_, err = manager.Update(context.Background(), targets)
+	require.NoError(t, err)
+	require.Equal(t, float64(0), testutil.ToFloat64(unreachable))
+}
+
+func TestBackendsClientManager_podIPNotAssigned(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	defer manager.Close()
+	key := types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-starting"}
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+	manager.SetHealthChecker(func(context.Context, *grpc.ClientConn) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		return healthpb.HealthCheckResponse_SERVING, nil
+	})
+
+	t.Log("a ready pod without an IP is reported so that it's retried")
+	updated, err := manager.SetClientsList(map[types.NamespacedName]corev1.Pod{key: pod})
+	require.ErrorIs(t, err, ErrPodIPNotAssigned)
+	require.False(t, updated)
+	require.Empty(t, manager.clients)
+
+	t.Log("the pod is connected to once it has an IP")
+	pod.Status.PodIP = "10.244.0.5"
+	updated, err = manager.SetClientsList(map[types.NamespacedName]corev1.Pod{key: pod})
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.Contains(t, manager.clients, key)
+}
+
+func TestBackendsClientManager_pruneVIPs(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	routeVIP := &Vip{Ip: 0xac1200f0, Port: 8080}
+	orphanedVIP := &Vip{Ip: 0xac1200f0, Port: 9090}
+	updatedVIP := &Vip{Ip: 0xac1200f0, Port: 9875}
+	dataplane := &fakeBackendsClient{
+		backends: []*Targets{
+			{Vip: routeVIP, Targets: []*Target{{Daddr: 0x0af4000a, Dport: 80}}},
+			{Vip: orphanedVIP, Targets: []*Target{{Daddr: 0x0af4000b, Dport: 80}}},
+		},
+	}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane"}] = clientInfo{
+		client: dataplane, name: "dataplane", health: &clientHealth{},
+	}
+
+	t.Log("a VIP updated after the desired VIPs were computed is kept")
+	_, err = manager.Update(context.Background(), &Targets{Vip: updatedVIP})
+	require.NoError(t, err)
+	dataplane.backends = append(dataplane.backends, &Targets{Vip: updatedVIP})
+
+	pruned, err := manager.PruneVIPs(context.Background(), []*Vip{routeVIP})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	assert.Equal(t, []*Vip{orphanedVIP}, dataplane.deletes)
+}
+
+func TestBackendsClientManager_getStatus(t *testing.T) {
+	manager, err := NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, KeepaliveConfig{})
+	require.NoError(t, err)
+	attached := &DataplaneStatus{Interface: "eth0", Ifindex: 2, AttachMode: "tc", Vips: 3}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-a"}] = clientInfo{
+		client: &fakeBackendsClient{status: attached}, name: "dataplane-a", health: &clientHealth{},
+	}
+	manager.clients[types.NamespacedName{Namespace: vars.DefaultNamespace, Name: "dataplane-b"}] = clientInfo{
+		client: &fakeBackendsClient{}, name: "dataplane-b", health: &clientHealth{},
+	}
+
+	t.Log("the statuses of the reachable servers are returned along with the errors of the others")
+	statuses, err := manager.GetStatus(context.Background())
+	require.ErrorContains(t, err, "dataplane-b")
+	assert.Equal(t, map[string]*DataplaneStatus{"dataplane-a": attached}, statuses)
+}
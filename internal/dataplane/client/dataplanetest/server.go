@@ -0,0 +1,322 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dataplanetest provides an in-memory dataplane Backends gRPC server,
+// so that the control plane can be tested against the real gRPC path without
+// a cluster and eBPF.
+package dataplanetest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+)
+
+const bufSize = 1024 * 1024
+
+// watchBufferSize is the number of events buffered for each WatchBackends
+// stream, a stream falling further behind is sent a new snapshot instead.
+const watchBufferSize = 16
+
+// Server is an in-memory implementation of the dataplane Backends gRPC
+// server. It records the calls it receives and keeps the programmed backends
+// like the dataplane does, without the eBPF maps.
+type Server struct {
+	dataplane.UnimplementedBackendsServer
+
+	mu       sync.Mutex
+	backends map[string]*dataplane.Targets
+	updates  []*dataplane.Targets
+	deletes  []*dataplane.Vip
+	adds     []*dataplane.Targets
+	removes  []*dataplane.Targets
+	draining bool
+	watchers map[*watcher]struct{}
+
+	listener     *bufconn.Listener
+	grpcServer   *grpc.Server
+	healthServer *health.Server
+}
+
+// watcher is a WatchBackends stream, its fields are guarded by the Server's
+// mutex.
+type watcher struct {
+	events chan *dataplane.BackendsEvent
+	// lagged indicates whether events were dropped because the buffer was
+	// full, in which case a snapshot is sent instead of the buffered events.
+	lagged bool
+}
+
+// NewServer returns a Server with no backends programmed.
+func NewServer() *Server {
+	return &Server{backends: map[string]*dataplane.Targets{}, watchers: map[*watcher]struct{}{}, healthServer: health.NewServer()}
+}
+
+// Start serves the Server on an in-memory listener until it's stopped. The
+// listener is connected to with the dialer returned by Dialer. Like the
+// dataplane, the Server accepts messages up to dataplane.DefaultMaxMessageSize,
+// and serves the gRPC health service, which reports SERVING unless
+// SetServing is called with false.
+func (s *Server) Start() {
+	s.listener = bufconn.Listen(bufSize)
+	s.grpcServer = grpc.NewServer(
+		grpc.MaxRecvMsgSize(dataplane.DefaultMaxMessageSize),
+		grpc.MaxSendMsgSize(dataplane.DefaultMaxMessageSize),
+	)
+	dataplane.RegisterBackendsServer(s.grpcServer, s)
+	healthpb.RegisterHealthServer(s.grpcServer, s.healthServer)
+	go func() {
+		_ = s.grpcServer.Serve(s.listener)
+	}()
+}
+
+// Stop stops serving the Server, closing the open connections.
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
+
+// SetServing sets the status reported by the health service of the Server,
+// SERVING if serving is true and NOT_SERVING otherwise.
+func (s *Server) SetServing(serving bool) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.healthServer.SetServingStatus("", status)
+}
+
+// Dialer returns a dialer connecting to the Server whatever the address, to
+// be used with BackendsClientManager.SetContextDialer.
+func (s *Server) Dialer() func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	}
+}
+
+// GetInterfaceIndex returns the loopback interface index.
+func (s *Server) GetInterfaceIndex(_ context.Context, _ *dataplane.PodIP) (*dataplane.InterfaceIndexConfirmation, error) {
+	return &dataplane.InterfaceIndexConfirmation{Ifindex: 1}, nil
+}
+
+// Update replaces the backends of the VIP.
+func (s *Server) Update(_ context.Context, in *dataplane.Targets) (*dataplane.Confirmation, error) {
+	if in.GetVip() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing vip ip and port")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates = append(s.updates, in)
+	s.backends[in.GetVip().Key()] = in
+	s.publish(dataplane.BackendsEvent_UPDATED, in)
+	return &dataplane.Confirmation{
+		Confirmation: fmt.Sprintf("success, vip %s was updated with %d backends", in.GetVip().Key(), len(in.GetTargets())),
+	}, nil
+}
+
+// Delete removes the VIP, deleting a missing VIP succeeds.
+func (s *Server) Delete(_ context.Context, in *dataplane.Vip) (*dataplane.Confirmation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletes = append(s.deletes, in)
+	if _, ok := s.backends[in.Key()]; !ok {
+		return &dataplane.Confirmation{Confirmation: fmt.Sprintf("success, vip %s did not exist", in.Key())}, nil
+	}
+	delete(s.backends, in.Key())
+	s.publish(dataplane.BackendsEvent_DELETED, &dataplane.Targets{Vip: in})
+	return &dataplane.Confirmation{Confirmation: fmt.Sprintf("success, vip %s was deleted", in.Key())}, nil
+}
+
+// ListBackends returns the programmed backends.
+func (s *Server) ListBackends(_ context.Context, _ *dataplane.ListBackendsRequest) (*dataplane.BackendsList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &dataplane.BackendsList{Backends: s.programmedBackends()}, nil
+}
+
+// programmedBackends returns the programmed backends, the Server's mutex must
+// be held.
+func (s *Server) programmedBackends() []*dataplane.Targets {
+	backends := make([]*dataplane.Targets, 0, len(s.backends))
+	for _, targets := range s.backends {
+		backends = append(backends, targets)
+	}
+	return backends
+}
+
+// AddBackend adds the provided backends to the VIP, which must exist and, like
+// the provided backends, must not be weighted.
+func (s *Server) AddBackend(_ context.Context, in *dataplane.Targets) (*dataplane.Confirmation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.backends[in.GetVip().Key()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "vip %s does not exist", in.GetVip().Key())
+	}
+	if dataplane.HasDuplicateTargets(current.GetTargets()) || dataplane.HasDuplicateTargets(in.GetTargets()) {
+		return nil, status.Errorf(codes.FailedPrecondition, "vip %s has weighted backends, which must be replaced with Update", in.GetVip().Key())
+	}
+	s.adds = append(s.adds, in)
+	s.backends[in.GetVip().Key()] = &dataplane.Targets{
+		Vip:                   current.GetVip(),
+		Targets:               dataplane.ApplyTargetsDelta(current.GetTargets(), in.GetTargets(), nil),
+		TcpIdleTimeoutSeconds: current.TcpIdleTimeoutSeconds,
+		Action:                current.GetAction(),
+	}
+	s.publish(dataplane.BackendsEvent_UPDATED, s.backends[in.GetVip().Key()])
+	return &dataplane.Confirmation{
+		Confirmation: fmt.Sprintf("success, %d backends were added to vip %s", len(in.GetTargets()), in.GetVip().Key()),
+	}, nil
+}
+
+// RemoveBackend removes the provided backends from the VIP, which must exist
+// and must not have weighted backends.
+func (s *Server) RemoveBackend(_ context.Context, in *dataplane.Targets) (*dataplane.Confirmation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.backends[in.GetVip().Key()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "vip %s does not exist", in.GetVip().Key())
+	}
+	if dataplane.HasDuplicateTargets(current.GetTargets()) {
+		return nil, status.Errorf(codes.FailedPrecondition, "vip %s has weighted backends, which must be replaced with Update", in.GetVip().Key())
+	}
+	s.removes = append(s.removes, in)
+	s.backends[in.GetVip().Key()] = &dataplane.Targets{
+		Vip:                   current.GetVip(),
+		Targets:               dataplane.ApplyTargetsDelta(current.GetTargets(), nil, in.GetTargets()),
+		TcpIdleTimeoutSeconds: current.TcpIdleTimeoutSeconds,
+		Action:                current.GetAction(),
+	}
+	s.publish(dataplane.BackendsEvent_UPDATED, s.backends[in.GetVip().Key()])
+	return &dataplane.Confirmation{
+		Confirmation: fmt.Sprintf("success, %d backends were removed from vip %s", len(in.GetTargets()), in.GetVip().Key()),
+	}, nil
+}
+
+// GetStatus reports the programs as attached to the loopback interface.
+func (s *Server) GetStatus(_ context.Context, _ *dataplane.StatusRequest) (*dataplane.DataplaneStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &dataplane.DataplaneStatus{Interface: "lo", Ifindex: 1, AttachMode: "tc", Vips: uint32(len(s.backends)), Draining: s.draining}, nil
+}
+
+// Drain marks the Server as draining, which is reported by GetStatus, and
+// reports NOT_SERVING to health checks like the dataplane. Unlike the
+// dataplane, it keeps serving after the grace period.
+func (s *Server) Drain(_ context.Context, _ *dataplane.DrainRequest) (*dataplane.Confirmation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draining = true
+	s.SetServing(false)
+	return &dataplane.Confirmation{Confirmation: "success, draining"}, nil
+}
+
+// WatchBackends streams a snapshot of the programmed backends followed by
+// their changes, until the client disconnects or the Server is stopped. Like
+// the dataplane, a stream which falls behind is sent a new snapshot.
+func (s *Server) WatchBackends(_ *dataplane.WatchBackendsRequest, stream dataplane.Backends_WatchBackendsServer) error {
+	w := &watcher{events: make(chan *dataplane.BackendsEvent, watchBufferSize)}
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	snapshot := &dataplane.BackendsEvent{Type: dataplane.BackendsEvent_SNAPSHOT, Backends: s.programmedBackends()}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, w)
+		s.mu.Unlock()
+	}()
+
+	event := snapshot
+	for {
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event = <-w.events:
+		}
+
+		s.mu.Lock()
+		if w.lagged {
+			for len(w.events) > 0 {
+				<-w.events
+			}
+			w.lagged = false
+			event = &dataplane.BackendsEvent{Type: dataplane.BackendsEvent_SNAPSHOT, Backends: s.programmedBackends()}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// publish sends an event with the provided backends to the WatchBackends
+// streams, the Server's mutex must be held.
+func (s *Server) publish(eventType dataplane.BackendsEvent_Type, targets *dataplane.Targets) {
+	for w := range s.watchers {
+		select {
+		case w.events <- &dataplane.BackendsEvent{Type: eventType, Backends: []*dataplane.Targets{targets}}:
+		default:
+			w.lagged = true
+		}
+	}
+}
+
+// Updates returns the Targets received by Update.
+func (s *Server) Updates() []*dataplane.Targets {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*dataplane.Targets{}, s.updates...)
+}
+
+// Deletes returns the VIPs received by Delete.
+func (s *Server) Deletes() []*dataplane.Vip {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*dataplane.Vip{}, s.deletes...)
+}
+
+// Adds returns the Targets received by AddBackend.
+func (s *Server) Adds() []*dataplane.Targets {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*dataplane.Targets{}, s.adds...)
+}
+
+// Removes returns the Targets received by RemoveBackend.
+func (s *Server) Removes() []*dataplane.Targets {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*dataplane.Targets{}, s.removes...)
+}
+
+// Backends returns the backends currently programmed for the provided VIP.
+func (s *Server) Backends(vip *dataplane.Vip) (*dataplane.Targets, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targets, ok := s.backends[vip.Key()]
+	return targets, ok
+}
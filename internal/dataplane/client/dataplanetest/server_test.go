@@ -0,0 +1,362 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataplanetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	dataplane "github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
+)
+
+// newTestBackendsClientManager returns a BackendsClientManager connected to
+// the provided Server as a single dataplane Pod.
+func newTestBackendsClientManager(t *testing.T, server *Server, maxMessageSize int) *dataplane.BackendsClientManager {
+	manager, err := dataplane.NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, dataplane.KeepaliveConfig{})
+	require.NoError(t, err)
+	manager.SetContextDialer(server.Dialer())
+	if maxMessageSize > 0 {
+		manager.SetMaxMessageSize(maxMessageSize)
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "dataplane", Namespace: vars.DefaultNamespace},
+		Status:     corev1.PodStatus{PodIP: "10.244.0.2"},
+	}
+	_, err = manager.SetClientsList(map[types.NamespacedName]corev1.Pod{{Namespace: pod.Namespace, Name: pod.Name}: pod})
+	require.NoError(t, err)
+	return manager
+}
+
+func TestServer_backendsClientManager(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	server.Start()
+	defer server.Stop()
+
+	manager := newTestBackendsClientManager(t, server, 0)
+	defer manager.Close()
+
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}
+	targets := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}}}
+
+	t.Log("updates go through gRPC and are recorded")
+	_, err := manager.Update(ctx, targets)
+	require.NoError(t, err)
+	require.Len(t, server.Updates(), 1)
+	assert.True(t, proto.Equal(targets, server.Updates()[0]))
+	programmed, ok := server.Backends(vip)
+	require.True(t, ok)
+	assert.Len(t, programmed.GetTargets(), 1)
+
+	t.Log("incremental updates are applied to the programmed backends")
+	added := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000b, Dport: 80}}}
+	_, err = manager.AddBackend(ctx, added)
+	require.NoError(t, err)
+	programmed, _ = server.Backends(vip)
+	assert.Len(t, programmed.GetTargets(), 2)
+
+	t.Log("deletes go through gRPC and are recorded")
+	_, err = manager.Delete(ctx, vip)
+	require.NoError(t, err)
+	require.Len(t, server.Deletes(), 1)
+	assert.True(t, proto.Equal(vip, server.Deletes()[0]))
+	_, ok = server.Backends(vip)
+	assert.False(t, ok)
+
+	t.Log("adding backends to a missing VIP fails like in the dataplane")
+	_, err = manager.AddBackend(ctx, added)
+	require.Error(t, err)
+}
+
+func TestServer_syncProgrammedBackends(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	server.Start()
+	defer server.Stop()
+
+	unchanged := &dataplane.Targets{Vip: &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}, Targets: []*dataplane.Target{
+		{Daddr: 0x0af4000a, Dport: 80},
+		{Daddr: 0x0af4000b, Dport: 80},
+	}}
+	partial := &dataplane.Targets{Vip: &dataplane.Vip{Ip: 0xac1200f0, Port: 9090}, Targets: []*dataplane.Target{
+		{Daddr: 0x0af4000a, Dport: 90},
+		{Daddr: 0x0af4000b, Dport: 90},
+	}}
+	missing := &dataplane.Targets{Vip: &dataplane.Vip{Ip: 0xac1200f0, Port: 7070}, Targets: []*dataplane.Target{
+		{Daddr: 0x0af4000a, Dport: 70},
+	}}
+
+	t.Log("the backends are programmed before the control plane restarts")
+	manager := newTestBackendsClientManager(t, server, 0)
+	for _, targets := range []*dataplane.Targets{unchanged, partial} {
+		_, err := manager.Update(ctx, targets)
+		require.NoError(t, err)
+	}
+	manager.Close()
+	require.Len(t, server.Updates(), 2)
+
+	t.Log("the restarted control plane syncs its desired backends with those the dataplane reports")
+	manager = newTestBackendsClientManager(t, server, 0)
+	defer manager.Close()
+	desiredPartial := &dataplane.Targets{Vip: partial.GetVip(), Targets: []*dataplane.Target{
+		{Daddr: 0x0af4000b, Dport: 90},
+		{Daddr: 0x0af4000c, Dport: 90},
+	}}
+	for _, targets := range []*dataplane.Targets{unchanged, desiredPartial, missing} {
+		_, err := manager.Sync(ctx, targets)
+		require.NoError(t, err)
+	}
+
+	t.Log("only the missing VIP has its whole backend set pushed")
+	require.Len(t, server.Updates(), 3)
+	assert.True(t, proto.Equal(missing, server.Updates()[2]))
+
+	t.Log("only the difference is pushed for the partially programmed VIP")
+	require.Len(t, server.Adds(), 1)
+	assert.True(t, proto.Equal(&dataplane.Targets{Vip: partial.GetVip(), Targets: desiredPartial.GetTargets()[1:]}, server.Adds()[0]))
+	require.Len(t, server.Removes(), 1)
+	assert.True(t, proto.Equal(&dataplane.Targets{Vip: partial.GetVip(), Targets: partial.GetTargets()[:1]}, server.Removes()[0]))
+
+	for _, targets := range []*dataplane.Targets{unchanged, desiredPartial, missing} {
+		programmed, ok := server.Backends(targets.GetVip())
+		require.True(t, ok)
+		added, removed := dataplane.TargetsDelta(programmed.GetTargets(), targets.GetTargets())
+		assert.Empty(t, added)
+		assert.Empty(t, removed)
+	}
+
+	t.Log("the backends reported by the dataplane are only used by the first push of each VIP")
+	_, err := manager.Sync(ctx, unchanged)
+	require.NoError(t, err)
+	require.Len(t, server.Updates(), 4)
+}
+
+func TestServer_healthGatedAdmission(t *testing.T) {
+	server := NewServer()
+	server.Start()
+	defer server.Stop()
+	server.SetServing(false)
+
+	manager, err := dataplane.NewBackendsClientManager(&rest.Config{}, vars.DefaultDataPlaneAPIPort, dataplane.KeepaliveConfig{})
+	require.NoError(t, err)
+	defer manager.Close()
+	manager.SetContextDialer(server.Dialer())
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "dataplane", Namespace: vars.DefaultNamespace},
+		Status:     corev1.PodStatus{PodIP: "10.244.0.2"},
+	}
+	pods := map[types.NamespacedName]corev1.Pod{{Namespace: pod.Namespace, Name: pod.Name}: pod}
+	targets := &dataplane.Targets{
+		Vip:     &dataplane.Vip{Ip: 0xac1200f0, Port: 8080},
+		Targets: []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}},
+	}
+
+	t.Log("a pod which is not serving yet isn't admitted")
+	updated, err := manager.SetClientsList(pods)
+	require.ErrorIs(t, err, dataplane.ErrPodNotServing)
+	require.False(t, updated)
+	_, err = manager.Update(context.Background(), targets)
+	require.ErrorIs(t, err, dataplane.ErrNoDataPlaneClients)
+	require.Empty(t, server.Updates())
+
+	t.Log("the pod is admitted once it's serving")
+	server.SetServing(true)
+	updated, err = manager.SetClientsList(pods)
+	require.NoError(t, err)
+	require.True(t, updated)
+	_, err = manager.Update(context.Background(), targets)
+	require.NoError(t, err)
+	require.Len(t, server.Updates(), 1)
+}
+
+func TestServer_maxMessageSize(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer()
+	server.Start()
+	defer server.Stop()
+
+	// a VIP with enough backends for the Targets to exceed the 4MiB gRPC
+	// default, but not DefaultMaxMessageSize.
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}
+	targets := &dataplane.Targets{Vip: vip}
+	for i := uint32(0); i < 500000; i++ {
+		targets.Targets = append(targets.Targets, &dataplane.Target{Daddr: 0x0a000000 + i, Dport: 8080})
+	}
+	require.Greater(t, proto.Size(targets), 4*1024*1024)
+	require.Less(t, proto.Size(targets), dataplane.DefaultMaxMessageSize)
+
+	t.Log("the oversized Targets are rejected under the gRPC default limit")
+	manager := newTestBackendsClientManager(t, server, 4*1024*1024)
+	defer manager.Close()
+	_, err := manager.Update(ctx, targets)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, codes.ResourceExhausted.String())
+	assert.Empty(t, server.Updates())
+
+	t.Log("the oversized Targets are accepted under the default limit")
+	manager = newTestBackendsClientManager(t, server, 0)
+	defer manager.Close()
+	_, err = manager.Update(ctx, targets)
+	require.NoError(t, err)
+	require.Len(t, server.Updates(), 1)
+	programmed, ok := server.Backends(vip)
+	require.True(t, ok)
+	assert.Len(t, programmed.GetTargets(), len(targets.Targets))
+}
+
+func TestServer_tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	defaultProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(defaultProvider)
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	server := NewServer()
+	server.Start()
+	defer server.Stop()
+
+	manager := newTestBackendsClientManager(t, server, 0)
+	defer manager.Close()
+
+	ctx, reconcile := otel.Tracer("test").Start(context.Background(), "reconcile")
+	_, err := manager.Update(ctx, &dataplane.Targets{
+		Vip:     &dataplane.Vip{Ip: 0xac1200f0, Port: 8080},
+		Targets: []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}},
+	})
+	require.NoError(t, err)
+	reconcile.End()
+
+	t.Log("the update and its call to the dataplane are traced as part of the reconcile")
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		// the health check admitting the dataplane Pod is traced separately.
+		if span.SpanContext().TraceID() == reconcile.SpanContext().TraceID() {
+			spans[span.Name()] = span
+		}
+	}
+	require.Len(t, spans, 3)
+	update, ok := spans["BackendsClientManager.Update"]
+	require.True(t, ok)
+	assert.Equal(t, reconcile.SpanContext().SpanID(), update.Parent().SpanID())
+	call, ok := spans["backends.backends/Update"]
+	require.True(t, ok)
+	assert.Equal(t, update.SpanContext().SpanID(), call.Parent().SpanID())
+	assert.Equal(t, reconcile.SpanContext().TraceID(), call.SpanContext().TraceID())
+}
+
+func TestServer_watchBackends(t *testing.T) {
+	server := NewServer()
+	server.Start()
+	defer server.Stop()
+
+	manager := newTestBackendsClientManager(t, server, 0)
+	defer manager.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vip := &dataplane.Vip{Ip: 0xac1200f0, Port: 8080}
+	targets := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000a, Dport: 80}}}
+	_, err := manager.Update(ctx, targets)
+	require.NoError(t, err)
+	next := func(events <-chan dataplane.WatchedBackendsEvent) *dataplane.BackendsEvent {
+		t.Helper()
+		select {
+		case event, ok := <-events:
+			require.True(t, ok)
+			require.NoError(t, event.Err)
+			assert.Equal(t, "dataplane", event.Pod)
+			return event.Event
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no event received")
+			return nil
+		}
+	}
+
+	t.Log("the stream starts with a snapshot of the programmed backends")
+	events := manager.WatchBackends(ctx)
+	event := next(events)
+	assert.Equal(t, dataplane.BackendsEvent_SNAPSHOT, event.GetType())
+	require.Len(t, event.GetBackends(), 1)
+	assert.True(t, proto.Equal(targets, event.GetBackends()[0]))
+
+	t.Log("the changes of the backends follow the snapshot")
+	added := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000b, Dport: 80}}}
+	_, err = manager.AddBackend(ctx, added)
+	require.NoError(t, err)
+	event = next(events)
+	assert.Equal(t, dataplane.BackendsEvent_UPDATED, event.GetType())
+	require.Len(t, event.GetBackends(), 1)
+	assert.Len(t, event.GetBackends()[0].GetTargets(), 2)
+
+	_, err = manager.Delete(ctx, vip)
+	require.NoError(t, err)
+	event = next(events)
+	assert.Equal(t, dataplane.BackendsEvent_DELETED, event.GetType())
+	require.Len(t, event.GetBackends(), 1)
+	assert.True(t, proto.Equal(vip, event.GetBackends()[0].GetVip()))
+
+	t.Log("a stream falling behind is sent a new snapshot")
+	large := &dataplane.Targets{Vip: vip}
+	for i := uint32(0); i < 5000; i++ {
+		large.Targets = append(large.Targets, &dataplane.Target{Daddr: 0x0a000000 + i, Dport: 8080})
+	}
+	for i := 0; i < 4*watchBufferSize; i++ {
+		_, err = manager.Update(ctx, large)
+		require.NoError(t, err)
+	}
+	last := &dataplane.Targets{Vip: vip, Targets: []*dataplane.Target{{Daddr: 0x0af4000c, Dport: 80}}}
+	_, err = manager.Update(ctx, last)
+	require.NoError(t, err)
+	resynced := false
+	for !resynced {
+		event = next(events)
+		if event.GetType() == dataplane.BackendsEvent_SNAPSHOT {
+			resynced = true
+			require.Len(t, event.GetBackends(), 1)
+			assert.True(t, proto.Equal(last, event.GetBackends()[0]))
+		}
+	}
+
+	t.Log("the stream is closed once the context is done")
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-events:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.watchers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
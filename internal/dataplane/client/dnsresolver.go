@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultDNSQueryTimeout is the default timeout of each query sent by the
+// DNSHostResolver.
+const defaultDNSQueryTimeout = 2 * time.Second
+
+// DNSHostResolver is a HostResolver querying the A records of the hosts from
+// the provided DNS servers, so that the TTL of the records is known. Hosts
+// which can't be resolved this way, such as the names relative to a search
+// domain, are resolved with the Fallback HostResolver instead.
+type DNSHostResolver struct {
+	// Servers are the addresses of the DNS servers, as host:port, which are
+	// queried in order until one answers.
+	Servers []string
+	// Timeout is the timeout of each query.
+	Timeout time.Duration
+	// Fallback resolves the hosts the DNS servers have no A records for.
+	Fallback HostResolver
+}
+
+// NewSystemDNSHostResolver returns a DNSHostResolver querying the name
+// servers configured in /etc/resolv.conf, and falling back to the system's
+// DNS resolver.
+func NewSystemDNSHostResolver() (*DNSHostResolver, error) {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	servers, err := parseResolvConfNameservers(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no nameserver found in /etc/resolv.conf")
+	}
+	return &DNSHostResolver{Servers: servers, Timeout: defaultDNSQueryTimeout, Fallback: NetHostResolver{}}, nil
+}
+
+// parseResolvConfNameservers returns the addresses of the name servers of the
+// provided resolv.conf file.
+func parseResolvConfNameservers(r io.Reader) ([]string, error) {
+	var servers []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		if ip := net.ParseIP(fields[1]); ip != nil {
+			servers = append(servers, net.JoinHostPort(ip.String(), "53"))
+		}
+	}
+	return servers, scanner.Err()
+}
+
+// LookupIPv4 returns the IPv4 addresses of the provided host, along with the
+// lowest TTL of the records resolving it.
+func (d *DNSHostResolver) LookupIPv4(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	var errs error
+	for _, server := range d.Servers {
+		ips, ttl, err := d.query(ctx, server, host)
+		if err == nil && len(ips) > 0 {
+			return ips, ttl, nil
+		}
+		errs = errors.Join(errs, err)
+	}
+	if d.Fallback != nil {
+		return d.Fallback.LookupIPv4(ctx, host)
+	}
+	if errs == nil {
+		errs = fmt.Errorf("no A record found for %s", host)
+	}
+	return nil, 0, errs
+}
+
+// query sends an A query for the provided host to the provided DNS server.
+func (d *DNSHostResolver) query(ctx context.Context, server, host string) ([]net.IP, time.Duration, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	id := uint16(rand.Uint32())
+	req, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = defaultDNSQueryTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, 0, err
+		}
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, 0, err
+	}
+
+	buf := make([]byte, 512)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		ips, ttl, err := parseAResponse(buf[:n], id)
+		if errors.Is(err, errDNSResponseIDMismatch) {
+			// a late answer to another query, keep waiting for ours.
+			continue
+		}
+		return ips, ttl, err
+	}
+}
+
+var errDNSResponseIDMismatch = errors.New("DNS response ID mismatch")
+
+// parseAResponse returns the addresses of the A records of the provided DNS
+// response to the query with the provided ID, and the lowest TTL of its
+// answers. A TTL of zero is reported as a second, as a zero TTL means that
+// it's unknown to the callers.
+func parseAResponse(msg []byte, id uint16) ([]net.IP, time.Duration, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(msg)
+	if err != nil {
+		return nil, 0, err
+	}
+	if header.ID != id || !header.Response {
+		return nil, 0, errDNSResponseIDMismatch
+	}
+	if header.Truncated {
+		return nil, 0, errors.New("truncated DNS response")
+	}
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return nil, 0, fmt.Errorf("DNS query failed: %s", header.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, 0, err
+	}
+
+	var ips []net.IP
+	var ttl uint32
+	for answers := 0; ; answers++ {
+		h, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if answers == 0 || h.TTL < ttl {
+			ttl = h.TTL
+		}
+		if h.Type != dnsmessage.TypeA || h.Class != dnsmessage.ClassINET {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		a, err := p.AResource()
+		if err != nil {
+			return nil, 0, err
+		}
+		ips = append(ips, net.IPv4(a.A[0], a.A[1], a.A[2], a.A[3]))
+	}
+	if len(ips) == 0 {
+		return nil, 0, nil
+	}
+	return ips, max(time.Duration(ttl)*time.Second, time.Second), nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers the A queries received on a local UDP socket with the
+// provided answers, or with NXDOMAIN if there are none, and returns its
+// address.
+func serveDNS(t *testing.T, answers ...dnsmessage.Resource) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			res := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true, RCode: dnsmessage.RCodeSuccess},
+				Questions: req.Questions,
+				Answers:   answers,
+			}
+			if len(answers) == 0 {
+				res.RCode = dnsmessage.RCodeNameError
+			}
+			msg, err := res.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(msg, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSHostResolver(t *testing.T) {
+	ctx := context.Background()
+	host := dnsmessage.MustNewName("backend.example.com.")
+	target := dnsmessage.MustNewName("lb.example.com.")
+	server := serveDNS(t,
+		dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: host, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 300},
+			Body:   &dnsmessage.CNAMEResource{CNAME: target},
+		},
+		dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: target, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: [4]byte{203, 0, 113, 10}},
+		},
+		dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: target, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 120},
+			Body:   &dnsmessage.AResource{A: [4]byte{203, 0, 113, 11}},
+		},
+	)
+
+	t.Log("the addresses are returned with the lowest TTL of the records resolving them")
+	resolver := &DNSHostResolver{Servers: []string{server}, Timeout: time.Second}
+	ips, ttl, err := resolver.LookupIPv4(ctx, "backend.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []net.IP{net.IPv4(203, 0, 113, 10), net.IPv4(203, 0, 113, 11)}, ips)
+	assert.Equal(t, time.Minute, ttl)
+
+	t.Log("the hosts the servers can't resolve are resolved by the fallback")
+	fallback := &stubHostResolver{ips: []net.IP{net.IPv4(10, 96, 0, 10)}}
+	resolver = &DNSHostResolver{Servers: []string{serveDNS(t)}, Timeout: time.Second, Fallback: fallback}
+	ips, ttl, err = resolver.LookupIPv4(ctx, "backend")
+	require.NoError(t, err)
+	assert.Equal(t, fallback.ips, ips)
+	assert.Zero(t, ttl)
+
+	t.Log("the failures are reported without a fallback")
+	resolver.Fallback = nil
+	_, _, err = resolver.LookupIPv4(ctx, "backend")
+	assert.ErrorContains(t, err, "DNS query failed")
+}
+
+func TestParseAResponse_zeroTTL(t *testing.T) {
+	name := dnsmessage.MustNewName("backend.example.com.")
+	msg, err := (&dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 42, Response: true},
+		Answers: []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+			Body:   &dnsmessage.AResource{A: [4]byte{203, 0, 113, 10}},
+		}},
+	}).Pack()
+	require.NoError(t, err)
+
+	_, ttl, err := parseAResponse(msg, 42)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, ttl, "a zero TTL is known, it's not reported as unknown")
+
+	_, _, err = parseAResponse(msg, 43)
+	assert.ErrorIs(t, err, errDNSResponseIDMismatch)
+}
+
+func TestParseResolvConfNameservers(t *testing.T) {
+	servers, err := parseResolvConfNameservers(strings.NewReader(`# generated
+search default.svc.cluster.local svc.cluster.local cluster.local
+nameserver 10.96.0.10
+nameserver fd00::10
+nameserver invalid
+options ndots:5
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.96.0.10:53", "[fd00::10]:53"}, servers)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	context "context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// EndpointsConfigMapKey is the key of the ConfigMap data holding the list of
+// endpoints for a BackendRef of kind ConfigMap.
+const EndpointsConfigMapKey = "endpoints"
+
+// EndpointSource resolves the endpoints that the traffic for a route's
+// BackendRef is forwarded to.
+type EndpointSource interface {
+	// Endpoints returns the endpoints the provided BackendRef refers to.
+	Endpoints(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef) (*corev1.Endpoints, error)
+
+	// BackendPort returns the port traffic is forwarded to on the addresses of
+	// an endpoint subset with the provided ports.
+	BackendPort(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef, epPorts []corev1.EndpointPort) (int32, error)
+}
+
+// EndpointSourceFor returns the EndpointSource for the kind of the provided
+// BackendRef. BackendRefs to a core ConfigMap use a static list of endpoints,
+// anything else is resolved as an in-cluster Service.
+func EndpointSourceFor(backendRef gatewayv1alpha2.BackendRef) EndpointSource {
+	if (backendRef.Group == nil || *backendRef.Group == corev1.GroupName) &&
+		backendRef.Kind != nil && *backendRef.Kind == "ConfigMap" {
+		return ConfigMapEndpointSource{}
+	}
+	return ServiceEndpointSource{}
+}
+
+// endpointSourceFor returns the EndpointSource for the kind of the provided
+// BackendRef, resolving the external names of ExternalName Services with the
+// provided ExternalNameResolver.
+func endpointSourceFor(backendRef gatewayv1alpha2.BackendRef, resolver *ExternalNameResolver) EndpointSource {
+	source := EndpointSourceFor(backendRef)
+	if _, ok := source.(ServiceEndpointSource); ok {
+		return ServiceEndpointSource{Resolver: resolver}
+	}
+	return source
+}
+
+// ServiceEndpointSource resolves BackendRefs to the Endpoints of an
+// in-cluster Service.
+type ServiceEndpointSource struct {
+	// Resolver resolves the external names of ExternalName Services, which
+	// fail to resolve if it's nil.
+	Resolver *ExternalNameResolver
+}
+
+// Endpoints returns the Endpoints of the Service the BackendRef refers to, or
+// the resolved addresses of its external name for an ExternalName Service.
+func (s ServiceEndpointSource) Endpoints(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef) (*corev1.Endpoints, error) {
+	svc, err := serviceFromBackendRef(ctx, c, namespace, backendRef)
+	if err != nil {
+		return nil, err
+	}
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return externalNameEndpoints(ctx, s.Resolver, svc)
+	}
+	return endpointsFromBackendRef(ctx, c, namespace, backendRef)
+}
+
+// BackendPort returns the target port of the Service port the BackendRef
+// refers to.
+func (ServiceEndpointSource) BackendPort(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef, epPorts []corev1.EndpointPort) (int32, error) {
+	return getBackendPort(ctx, c, namespace, backendRef, epPorts)
+}
+
+// ConfigMapEndpointSource resolves BackendRefs to a static list of endpoints
+// held by a ConfigMap, which allows forwarding traffic to backends outside of
+// the cluster (e.g. in another cluster). The EndpointsConfigMapKey of the
+// ConfigMap must hold a list of "IP:port" separated by commas or whitespace.
+type ConfigMapEndpointSource struct{}
+
+// Endpoints returns Endpoints built from the list in the ConfigMap the
+// BackendRef refers to, with one subset per distinct port.
+func (ConfigMapEndpointSource) Endpoints(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef) (*corev1.Endpoints, error) {
+	if backendRef.Namespace != nil {
+		namespace = string(*backendRef.Namespace)
+	}
+
+	configMap := new(corev1.ConfigMap)
+	key := client.ObjectKey{Namespace: namespace, Name: string(backendRef.Name)}
+	if err := c.Get(ctx, key, configMap); err != nil {
+		return nil, err
+	}
+
+	endpoints := &corev1.Endpoints{}
+	endpoints.Namespace, endpoints.Name = key.Namespace, key.Name
+	subsets := map[int32]int{}
+	for _, endpoint := range strings.FieldsFunc(configMap.Data[EndpointsConfigMapKey], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		host, portStr, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q in ConfigMap %s: %w", endpoint, key.String(), err)
+		}
+		if ip := net.ParseIP(host); ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("invalid endpoint %q in ConfigMap %s: not an IPv4 address", endpoint, key.String())
+		}
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid endpoint %q in ConfigMap %s: invalid port", endpoint, key.String())
+		}
+
+		i, ok := subsets[int32(port)]
+		if !ok {
+			i = len(endpoints.Subsets)
+			subsets[int32(port)] = i
+			endpoints.Subsets = append(endpoints.Subsets, corev1.EndpointSubset{
+				Ports: []corev1.EndpointPort{{Port: int32(port)}},
+			})
+		}
+		endpoints.Subsets[i].Addresses = append(endpoints.Subsets[i].Addresses, corev1.EndpointAddress{IP: host})
+	}
+
+	return endpoints, nil
+}
+
+// BackendPort returns the port of the provided endpoint subset, as every
+// subset built by the ConfigMapEndpointSource has exactly one port.
+func (ConfigMapEndpointSource) BackendPort(_ context.Context, _ client.Client, _ string, _ gatewayv1alpha2.BackendRef, epPorts []corev1.EndpointPort) (int32, error) {
+	if len(epPorts) != 1 {
+		return 0, fmt.Errorf("expected exactly one port for static endpoints, got %d", len(epPorts))
+	}
+	return epPorts[0].Port, nil
+}
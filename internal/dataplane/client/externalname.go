@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// DefaultExternalNameRefreshInterval is the default period after which the
+// addresses resolved for an ExternalName Service are resolved again.
+const DefaultExternalNameRefreshInterval = 30 * time.Second
+
+// MinExternalNameRefreshInterval is the minimum period after which the
+// addresses resolved for an ExternalName Service are resolved again, so that
+// DNS records with a very short TTL don't requeue their routes in a loop.
+const MinExternalNameRefreshInterval = 5 * time.Second
+
+// HostResolver resolves host names to IPv4 addresses.
+type HostResolver interface {
+	// LookupIPv4 returns the IPv4 addresses of the provided host, and how long
+	// they may be cached. A zero TTL means the TTL is unknown.
+	LookupIPv4(ctx context.Context, host string) ([]net.IP, time.Duration, error)
+}
+
+// NetHostResolver is a HostResolver using the system's DNS resolver, which
+// doesn't report TTLs.
+type NetHostResolver struct{}
+
+// LookupIPv4 returns the IPv4 addresses of the provided host.
+func (NetHostResolver) LookupIPv4(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+	return ips, 0, err
+}
+
+// ExternalNameResolver resolves the external names of ExternalName Services
+// and caches the addresses until they expire, either after the
+// RefreshInterval or the TTL of the DNS records if it's shorter, but not
+// before MinExternalNameRefreshInterval.
+type ExternalNameResolver struct {
+	Resolver        HostResolver
+	RefreshInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]resolvedHost
+}
+
+type resolvedHost struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// NewExternalNameResolver returns an ExternalNameResolver resolving the
+// external names with the provided HostResolver.
+func NewExternalNameResolver(resolver HostResolver, refreshInterval time.Duration) *ExternalNameResolver {
+	return &ExternalNameResolver{
+		Resolver:        resolver,
+		RefreshInterval: refreshInterval,
+		cache:           map[string]resolvedHost{},
+	}
+}
+
+// Resolve returns the IPv4 addresses of the provided host, resolving it again
+// if the cached addresses expired.
+func (r *ExternalNameResolver) Resolve(ctx context.Context, host string) ([]net.IP, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ips, nil
+	}
+
+	ips, ttl, err := r.Resolver.LookupIPv4(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no IPv4 address found for %s", host)
+	}
+
+	expiresIn := r.RefreshInterval
+	if ttl > 0 && ttl < expiresIn {
+		expiresIn = max(ttl, MinExternalNameRefreshInterval)
+	}
+	r.mu.Lock()
+	r.cache[host] = resolvedHost{ips: ips, expires: time.Now().Add(expiresIn)}
+	r.mu.Unlock()
+
+	return ips, nil
+}
+
+// RefreshAfter returns how long until the cached addresses of the provided
+// host expire.
+func (r *ExternalNameResolver) RefreshAfter(host string) time.Duration {
+	r.mu.Lock()
+	entry, ok := r.cache[host]
+	r.mu.Unlock()
+	if !ok {
+		return r.RefreshInterval
+	}
+	if refreshAfter := time.Until(entry.expires); refreshAfter > 0 {
+		return refreshAfter
+	}
+	// already expired, but don't requeue in a hot loop.
+	return time.Second
+}
+
+// BackendsRefreshAfter returns how long until the addresses of the
+// ExternalName Services referenced by the provided BackendRefs need to be
+// resolved again, so that the route referencing them can be compiled again.
+// It returns zero if none of the BackendRefs is an ExternalName Service.
+func (r *ExternalNameResolver) BackendsRefreshAfter(ctx context.Context, c client.Client, namespace string, refs []gatewayv1alpha2.BackendRef) (time.Duration, error) {
+	var refreshAfter time.Duration
+	for _, ref := range refs {
+		if _, ok := EndpointSourceFor(ref).(ServiceEndpointSource); !ok {
+			continue
+		}
+		svc, err := serviceFromBackendRef(ctx, c, namespace, ref)
+		if err != nil {
+			// missing Services are reported in the status of the route.
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return 0, err
+		}
+		if svc.Spec.Type != corev1.ServiceTypeExternalName {
+			continue
+		}
+		if after := r.RefreshAfter(svc.Spec.ExternalName); refreshAfter == 0 || after < refreshAfter {
+			refreshAfter = after
+		}
+	}
+	return refreshAfter, nil
+}
+
+// externalNameEndpoints returns Endpoints with the addresses of the provided
+// ExternalName Service resolved by the provided ExternalNameResolver. The
+// traffic is forwarded to the ports of the Service, as there are no target
+// ports for an ExternalName Service.
+func externalNameEndpoints(ctx context.Context, resolver *ExternalNameResolver, svc *corev1.Service) (*corev1.Endpoints, error) {
+	if resolver == nil {
+		return nil, fmt.Errorf("%w %s/%s (%s): no resolver configured", ErrExternalNameNotResolved, svc.Namespace, svc.Name, svc.Spec.ExternalName)
+	}
+	ips, err := resolver.Resolve(ctx, svc.Spec.ExternalName)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s/%s (%s): %v", ErrExternalNameNotResolved, svc.Namespace, svc.Name, svc.Spec.ExternalName, err)
+	}
+
+	subset := corev1.EndpointSubset{}
+	for _, ip := range ips {
+		subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: ip.String()})
+	}
+	for _, port := range svc.Spec.Ports {
+		subset.Ports = append(subset.Ports, corev1.EndpointPort{Name: port.Name, Port: port.Port, Protocol: port.Protocol})
+	}
+
+	endpoints := &corev1.Endpoints{Subsets: []corev1.EndpointSubset{subset}}
+	endpoints.Namespace, endpoints.Name = svc.Namespace, svc.Name
+	return endpoints, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// stubHostResolver resolves hosts to the provided IPs, counting the lookups.
+type stubHostResolver struct {
+	ips     []net.IP
+	ttl     time.Duration
+	err     error
+	lookups int
+}
+
+func (s *stubHostResolver) LookupIPv4(_ context.Context, _ string) ([]net.IP, time.Duration, error) {
+	s.lookups++
+	return s.ips, s.ttl, s.err
+}
+
+func TestCompileTCPRouteToDataPlaneBackend_externalName(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	tcproute := &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{
+					Name: "test-gateway",
+					Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+				}},
+			},
+			Rules: []gatewayv1alpha2.TCPRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("external-backend")},
+			}},
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "external-backend", Namespace: "test-namespace"},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: "backend.example.com",
+				Ports:        []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			},
+		}).
+		Build()
+
+	resolver := &stubHostResolver{ips: []net.IP{net.ParseIP("203.0.113.10"), net.ParseIP("203.0.113.11")}}
+	externalNames := NewExternalNameResolver(resolver, time.Hour)
+	backendRefs := tcproute.Spec.Rules[0].BackendRefs
+
+	t.Log("the external name is resolved into Targets")
+	targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, externalNames, tcproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, []*Target{
+		{Daddr: 0xcb00710a, Dport: 80},
+		{Daddr: 0xcb00710b, Dport: 80},
+	}, targets.Targets)
+
+	t.Log("the resolved addresses are cached until the refresh interval")
+	_, err = CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, externalNames, tcproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resolver.lookups)
+	refreshAfter, err := externalNames.BackendsRefreshAfter(context.Background(), fakeClient, tcproute.Namespace, backendRefs)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, refreshAfter, float64(time.Minute))
+
+	t.Log("the external name is resolved again once the TTL expires")
+	externalNames = NewExternalNameResolver(resolver, time.Hour)
+	resolver.ttl = 10 * time.Minute
+	_, err = CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, externalNames, tcproute, gateway)
+	require.NoError(t, err)
+	refreshAfter, err = externalNames.BackendsRefreshAfter(context.Background(), fakeClient, tcproute.Namespace, backendRefs)
+	require.NoError(t, err)
+	assert.InDelta(t, 10*time.Minute, refreshAfter, float64(time.Minute))
+	externalNames.cache["backend.example.com"] = resolvedHost{expires: time.Now()}
+	_, err = CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, externalNames, tcproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, 3, resolver.lookups)
+
+	t.Log("very short TTLs are raised to the minimum refresh interval")
+	externalNames = NewExternalNameResolver(resolver, time.Hour)
+	resolver.ttl = time.Nanosecond
+	for i := 0; i < 2; i++ {
+		_, err = CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, externalNames, tcproute, gateway)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, resolver.lookups)
+	refreshAfter, err = externalNames.BackendsRefreshAfter(context.Background(), fakeClient, tcproute.Namespace, backendRefs)
+	require.NoError(t, err)
+	assert.InDelta(t, MinExternalNameRefreshInterval, refreshAfter, float64(time.Second))
+
+	t.Log("resolution failures are reported")
+	externalNames = NewExternalNameResolver(resolver, time.Hour)
+	resolver.err = errors.New("no such host")
+	_, err = CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, externalNames, tcproute, gateway)
+	assert.ErrorIs(t, err, ErrExternalNameNotResolved)
+
+	t.Log("external names aren't resolved without a resolver")
+	_, err = CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+	assert.ErrorIs(t, err, ErrExternalNameNotResolved)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PlacementStrategy selects the dataplane Pods each VIP is programmed on, so
+// that only some dataplane instances handle the traffic of a Gateway, for
+// instance those on the Nodes with some labels.
+type PlacementStrategy interface {
+	// Places indicates whether the provided VIP is programmed on the provided
+	// dataplane Pod. It's called with the BackendsClientManager locked, so it
+	// must not call the manager.
+	Places(vip *Vip, pod *corev1.Pod) bool
+}
+
+// PlacementFunc is a function implementing PlacementStrategy.
+type PlacementFunc func(vip *Vip, pod *corev1.Pod) bool
+
+// Places calls the function.
+func (f PlacementFunc) Places(vip *Vip, pod *corev1.Pod) bool {
+	return f(vip, pod)
+}
+
+// AllPodsPlacement programs every VIP on every dataplane Pod, it's the
+// default PlacementStrategy.
+type AllPodsPlacement struct{}
+
+// Places always returns true.
+func (AllPodsPlacement) Places(_ *Vip, _ *corev1.Pod) bool {
+	return true
+}
+
+// NodeSelectorPlacement programs every VIP on the dataplane Pods running on
+// the Nodes matching the Selector only.
+type NodeSelectorPlacement struct {
+	// Reader reads the Nodes of the dataplane Pods. It's called with the
+	// BackendsClientManager locked, so it should be a cached client.
+	Reader client.Reader
+	// Selector selects the Nodes by their labels.
+	Selector labels.Selector
+}
+
+// Places indicates whether the Node of the provided dataplane Pod matches the
+// Selector. The Pods whose Node can't be read aren't placed on.
+func (p NodeSelectorPlacement) Places(_ *Vip, pod *corev1.Pod) bool {
+	if pod == nil || pod.Spec.NodeName == "" {
+		return false
+	}
+	node := new(corev1.Node)
+	if err := p.Reader.Get(context.Background(), client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+		return false
+	}
+	return p.Selector.Matches(labels.Set(node.Labels))
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// programmedBackends is a snapshot of the backends programmed into a
+// dataplane instance, as reported by its ListBackends. It's listed on first
+// use, and the backends of each VIP are dropped once they're used or the VIP
+// is pushed, as they're not up to date anymore. A nil snapshot has no
+// backends.
+type programmedBackends struct {
+	mu      sync.Mutex
+	listed  bool
+	targets map[vipKey]*Targets
+}
+
+// take returns the backends programmed for the provided VIP, listing them
+// with the provided client first if needed, and drops them from the snapshot.
+// It returns nil if the VIP isn't programmed, or isn't in the snapshot
+// anymore.
+func (p *programmedBackends) take(ctx context.Context, client BackendsClient, vip *Vip, opts ...grpc.CallOption) (*Targets, error) {
+	if p == nil {
+		return nil, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.listed {
+		list, err := client.ListBackends(ctx, &ListBackendsRequest{}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		p.targets = make(map[vipKey]*Targets, len(list.GetBackends()))
+		for _, targets := range list.GetBackends() {
+			p.targets[newVipKey(targets.GetVip())] = targets
+		}
+		p.listed = true
+	}
+
+	key := newVipKey(vip)
+	targets := p.targets[key]
+	delete(p.targets, key)
+	return targets, nil
+}
+
+// drop drops the backends programmed for the provided VIP from the snapshot.
+func (p *programmedBackends) drop(vip *Vip) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.targets, newVipKey(vip))
+}
+
+// invalidate discards the snapshot, so that the backends are listed again on
+// next use.
+func (p *programmedBackends) invalidate() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listed = false
+	p.targets = nil
+}
+
+// syncDelta returns the backends to add to and to remove from the programmed
+// backends of a VIP to get the desired ones. It returns false if they can't be
+// patched with AddBackend and RemoveBackend, because a backend which is in
+// both has different settings, or because either has duplicate backends, as
+// weighted backends do.
+func syncDelta(programmed, desired []*Target) (added, removed []*Target, ok bool) {
+	if HasDuplicateTargets(programmed) || HasDuplicateTargets(desired) {
+		return nil, nil, false
+	}
+	programmedSet := make(map[string]*Target, len(programmed))
+	for _, target := range programmed {
+		programmedSet[target.Addr()] = target
+	}
+	for _, target := range desired {
+		previous, ok := programmedSet[target.Addr()]
+		if !ok {
+			continue
+		}
+		// the interface index is resolved by the dataplane unless it's set.
+		if previous.GetMaxConnections() != target.GetMaxConnections() ||
+			(target.Ifindex != nil && previous.GetIfindex() != target.GetIfindex()) {
+			return nil, nil, false
+		}
+	}
+
+	added, removed = TargetsDelta(programmed, desired)
+	return added, removed, true
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// The dataplane messages hold IPv4 addresses as uint32 in network byte order,
+// which is unreadable when they're dumped. The JSON encoding below renders
+// them as dotted-quad strings instead, for snapshots and debugging.
+
+type vipJSON struct {
+	IP       string `json:"ip"`
+	Port     uint32 `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+type targetJSON struct {
+	Daddr          string  `json:"daddr"`
+	Dport          uint32  `json:"dport"`
+	Ifindex        *uint32 `json:"ifindex,omitempty"`
+	MaxConnections *uint32 `json:"maxConnections,omitempty"`
+}
+
+type targetsJSON struct {
+	Vip                   *Vip      `json:"vip"`
+	Targets               []*Target `json:"targets"`
+	TCPIdleTimeoutSeconds *uint32   `json:"tcpIdleTimeoutSeconds,omitempty"`
+	Action                string    `json:"action,omitempty"`
+}
+
+// Addr returns the VIP as "IP:port".
+func (x *Vip) Addr() string {
+	return net.JoinHostPort(ipString(x.GetIp()), fmt.Sprint(x.GetPort()))
+}
+
+// Key returns the VIP as "IP:port/protocol", which identifies it in the
+// dataplane: a TCP and a UDP VIP can share the same address and port.
+func (x *Vip) Key() string {
+	return x.Addr() + "/" + x.GetProtocol().String()
+}
+
+// Addr returns the address of the Target as "IP:port".
+func (x *Target) Addr() string {
+	return net.JoinHostPort(ipString(x.GetDaddr()), fmt.Sprint(x.GetDport()))
+}
+
+// MarshalJSON encodes the Vip with its IP as a dotted-quad string.
+func (x *Vip) MarshalJSON() ([]byte, error) {
+	return json.Marshal(vipJSON{IP: ipString(x.GetIp()), Port: x.GetPort(), Protocol: x.GetProtocol().String()})
+}
+
+// UnmarshalJSON decodes a Vip encoded by MarshalJSON.
+func (x *Vip) UnmarshalJSON(data []byte) error {
+	var v vipJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	ip, err := parseIPv4(v.IP)
+	if err != nil {
+		return err
+	}
+	// VIPs encoded without protocol are TCP VIPs, like in the dataplane.
+	protocol := Vip_TCP
+	if v.Protocol != "" {
+		value, ok := Vip_Protocol_value[v.Protocol]
+		if !ok {
+			return fmt.Errorf("%q is not a Vip protocol", v.Protocol)
+		}
+		protocol = Vip_Protocol(value)
+	}
+	x.Ip, x.Port, x.Protocol = ip, v.Port, protocol
+	return nil
+}
+
+// MarshalJSON encodes the Target with its address as a dotted-quad string.
+func (x *Target) MarshalJSON() ([]byte, error) {
+	return json.Marshal(targetJSON{Daddr: ipString(x.GetDaddr()), Dport: x.GetDport(), Ifindex: x.Ifindex, MaxConnections: x.MaxConnections})
+}
+
+// UnmarshalJSON decodes a Target encoded by MarshalJSON.
+func (x *Target) UnmarshalJSON(data []byte) error {
+	var t targetJSON
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	daddr, err := parseIPv4(t.Daddr)
+	if err != nil {
+		return err
+	}
+	x.Daddr, x.Dport, x.Ifindex, x.MaxConnections = daddr, t.Dport, t.Ifindex, t.MaxConnections
+	return nil
+}
+
+// MarshalJSON encodes the Targets with human-readable addresses.
+func (x *Targets) MarshalJSON() ([]byte, error) {
+	t := targetsJSON{Vip: x.GetVip(), Targets: x.GetTargets(), TCPIdleTimeoutSeconds: x.TcpIdleTimeoutSeconds}
+	if x.GetAction() != Targets_FORWARD {
+		t.Action = x.GetAction().String()
+	}
+	return json.Marshal(t)
+}
+
+// UnmarshalJSON decodes Targets encoded by MarshalJSON.
+func (x *Targets) UnmarshalJSON(data []byte) error {
+	var t targetsJSON
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	action := Targets_FORWARD
+	if t.Action != "" {
+		value, ok := Targets_Action_value[t.Action]
+		if !ok {
+			return fmt.Errorf("%q is not a Targets action", t.Action)
+		}
+		action = Targets_Action(value)
+	}
+	x.Vip, x.Targets, x.TcpIdleTimeoutSeconds, x.Action = t.Vip, t.Targets, t.TCPIdleTimeoutSeconds, action
+	return nil
+}
+
+// ParseTargets decodes Targets from their JSON encoding, as produced by
+// json.Marshal.
+func ParseTargets(data []byte) (*Targets, error) {
+	targets := new(Targets)
+	if err := json.Unmarshal(data, targets); err != nil {
+		return nil, fmt.Errorf("invalid Targets: %w", err)
+	}
+	return targets, nil
+}
+
+func ipString(ip uint32) string {
+	addr := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(addr, ip)
+	return addr.String()
+}
+
+func parseIPv4(s string) (uint32, error) {
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("%q is not an IPv4 address", s)
+	}
+	return binary.BigEndian.Uint32(ip), nil
+}
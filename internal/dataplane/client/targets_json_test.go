@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/utils/ptr"
+)
+
+func TestTargets_JSON(t *testing.T) {
+	targets := &Targets{
+		Vip: &Vip{Ip: 0xac1200f0, Port: 9875},
+		Targets: []*Target{
+			{Daddr: 0x0af4000a, Dport: 8080},
+			{Daddr: 0x0af4000b, Dport: 8080, Ifindex: ptr.To(uint32(4))},
+		},
+	}
+
+	data, err := json.Marshal(targets)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"vip": {"ip": "172.18.0.240", "port": 9875, "protocol": "TCP"},
+		"targets": [
+			{"daddr": "10.244.0.10", "dport": 8080},
+			{"daddr": "10.244.0.11", "dport": 8080, "ifindex": 4}
+		]
+	}`, string(data))
+
+	parsed, err := ParseTargets(data)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(targets, parsed), "expected %v, got %v", targets, parsed)
+
+	assert.Equal(t, "172.18.0.240:9875", targets.GetVip().Addr())
+	assert.Equal(t, "172.18.0.240:9875/TCP", targets.GetVip().Key())
+	assert.Equal(t, "10.244.0.10:8080", targets.GetTargets()[0].Addr())
+
+	_, err = ParseTargets([]byte(`{"vip": {"ip": "not-an-ip", "port": 9875}}`))
+	assert.Error(t, err)
+
+	t.Log("actions other than forwarding are encoded by name")
+	rejected := &Targets{Vip: &Vip{Ip: 0xac1200f0, Port: 9875}, Action: Targets_REJECT}
+	data, err = json.Marshal(rejected)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"vip": {"ip": "172.18.0.240", "port": 9875, "protocol": "TCP"}, "targets": null, "action": "REJECT"}`, string(data))
+	parsed, err = ParseTargets(data)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(rejected, parsed), "expected %v, got %v", rejected, parsed)
+
+	_, err = ParseTargets([]byte(`{"vip": {"ip": "172.18.0.240", "port": 9875}, "action": "BOUNCE"}`))
+	assert.Error(t, err)
+
+	t.Log("the VIP protocol defaults to TCP")
+	parsed, err = ParseTargets([]byte(`{"vip": {"ip": "172.18.0.240", "port": 9875}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Vip_TCP, parsed.GetVip().GetProtocol())
+
+	t.Log("UDP VIPs are told apart from the TCP VIPs on the same port")
+	parsed, err = ParseTargets([]byte(`{"vip": {"ip": "172.18.0.240", "port": 9875, "protocol": "UDP"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Vip_UDP, parsed.GetVip().GetProtocol())
+	assert.Equal(t, "172.18.0.240:9875/UDP", parsed.GetVip().Key())
+
+	_, err = ParseTargets([]byte(`{"vip": {"ip": "172.18.0.240", "port": 9875, "protocol": "SCTP"}}`))
+	assert.Error(t, err)
+}
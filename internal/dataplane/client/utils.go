@@ -19,18 +19,108 @@ package client
 import (
 	context "context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"sort"
+	"strconv"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
+var (
+	// ErrNoHealthyBackends is returned when a route compiles to no backend Targets.
+	ErrNoHealthyBackends = errors.New("no healthy backends")
+
+	// ErrEndpointsNotReady is returned when the Endpoints of a backend
+	// referenced by a route have no ready addresses yet.
+	ErrEndpointsNotReady = errors.New("addresses not ready for endpoints")
+
+	// ErrGatewayIPNotReady is returned when the Gateway has not been assigned
+	// an IP address yet.
+	ErrGatewayIPNotReady = errors.New("IP address not ready for Gateway")
+
+	// ErrExternalNameNotResolved is returned when the external name of an
+	// ExternalName Service referenced by a route could not be resolved.
+	ErrExternalNameNotResolved = errors.New("could not resolve external name of Service")
+
+	// ErrInvalidMaxConnections is returned when the MaxConnectionsAnnotation of
+	// a route isn't a non-negative integer.
+	ErrInvalidMaxConnections = errors.New("invalid max connections")
+
+	// ErrInvalidBackendPortOverride is returned when the
+	// BackendPortOverrideAnnotation of a route isn't a valid port number.
+	ErrInvalidBackendPortOverride = errors.New("invalid backend port override")
+
+	// ErrInvalidTCPIdleTimeout is returned when the TCPIdleTimeoutAnnotation of
+	// a route isn't a positive duration in whole seconds.
+	ErrInvalidTCPIdleTimeout = errors.New("invalid TCP idle timeout")
+
+	// ErrInvalidAction is returned when the ActionAnnotation of a route isn't
+	// one of the supported actions.
+	ErrInvalidAction = errors.New("invalid action")
+
+	// ErrUnsupportedIPFamily is returned when an endpoint or Gateway address
+	// isn't an IPv4 address, as the dataplane only supports IPv4.
+	ErrUnsupportedIPFamily = errors.New("not an IPv4 address")
+
+	// ErrBackendPortNotFound is returned when the Service a BackendRef refers
+	// to has no port matching the BackendRef, or its target port can't be
+	// resolved.
+	ErrBackendPortNotFound = errors.New("backend port not found")
+
+	// ErrConflictingBackendRefs is returned when the rules of a route, which
+	// are all programmed on the same VIP, reference the same backend with
+	// different weights.
+	ErrConflictingBackendRefs = errors.New("conflicting backend references")
+)
+
+// MaxConnectionsAnnotation can be set on a TCPRoute to limit the number of
+// concurrent connections each of its backends receives. Backends which reach
+// the limit aren't selected for new connections. Zero means no limit.
+const MaxConnectionsAnnotation = "blixt/max-connections"
+
+// BackendPortOverrideAnnotation can be set on a TCPRoute or UDPRoute to
+// forward the traffic to the provided port of the addresses of its backend
+// Services, instead of the target port of the Service port each BackendRef
+// refers to. This allows routing to another container port without editing
+// the Services. The backends with static endpoints keep their own ports.
+const BackendPortOverrideAnnotation = "blixt/backend-port-override"
+
+// TCPIdleTimeoutAnnotation can be set on a TCPRoute to evict its connections
+// from the dataplane connection tracking once they had no traffic for the
+// provided duration, e.g. "90s" or "5m", so that connections which were never
+// closed don't hold their backend forever.
+const TCPIdleTimeoutAnnotation = "blixt/tcp-idle-timeout"
+
+// ActionAnnotation can be set on a TCPRoute or UDPRoute to reject or drop the
+// traffic to its VIP instead of forwarding it to its backends, e.g. during a
+// maintenance. With "Reject" the clients are sent ICMP port unreachable
+// messages, and with "Drop" the traffic is silently dropped. The backends
+// aren't resolved then, and the established TCP connections are kept until
+// they're closed.
+const ActionAnnotation = "blixt/action"
+
 // CompileUDPRouteToDataPlaneBackend takes a UDPRoute and the Gateway it is
 // attached to and produces Backend Targets for the DataPlane to configure.
-func CompileUDPRouteToDataPlaneBackend(ctx context.Context, c client.Client, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) (*Targets, error) {
+// The external names of ExternalName Service backends are resolved with the
+// provided ExternalNameResolver.
+func CompileUDPRouteToDataPlaneBackend(ctx context.Context, c client.Client, resolver *ExternalNameResolver, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) (*Targets, error) {
+	ctx, span := startSpan(ctx, "CompileUDPRouteToDataPlaneBackend", routeAttributes(udproute)...)
+	targets, err := compileUDPRouteToDataPlaneBackend(ctx, c, resolver, udproute, gateway)
+	endSpan(span, err)
+	return targets, err
+}
+
+func compileUDPRouteToDataPlaneBackend(ctx context.Context, c client.Client, resolver *ExternalNameResolver, udproute *gatewayv1alpha2.UDPRoute, gateway *gatewayv1beta1.Gateway) (*Targets, error) {
 	gatewayIP, err := GetGatewayIP(gateway)
 	if gatewayIP == nil {
 		return nil, err
@@ -40,17 +130,47 @@ func CompileUDPRouteToDataPlaneBackend(ctx context.Context, c client.Client, udp
 	if err != nil {
 		return nil, err
 	}
-	var backendTargets []*Target
+	vip, err := GatewayVip(gateway, gatewayIP, gatewayPort, Vip_UDP)
+	if err != nil {
+		return nil, err
+	}
+	action, err := routeAction(udproute)
+	if err != nil {
+		return nil, err
+	}
+	if action != Targets_FORWARD {
+		return &Targets{Vip: vip, Action: action}, nil
+	}
+	portOverride, err := routeBackendPortOverride(udproute)
+	if err != nil {
+		return nil, err
+	}
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range udproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	if err := validateBackendRefs(udproute.Namespace, backendRefs); err != nil {
+		return nil, err
+	}
+	var groups []weightedTargets
+	var portErrs []error
 	for _, rule := range udproute.Spec.Rules {
 		for _, backendRef := range rule.BackendRefs {
-			endpoints, err := endpointsFromBackendRef(ctx, c, udproute.Namespace, backendRef)
+			// a BackendRef with a weight of zero receives no traffic.
+			if backendRefWeight(backendRef) == 0 {
+				continue
+			}
+
+			group := weightedTargets{weight: backendRefWeight(backendRef)}
+			source := endpointSourceFor(backendRef, resolver)
+			endpoints, err := source.Endpoints(ctx, c, udproute.Namespace, backendRef)
 			if err != nil {
 				return nil, err
 			}
 
 			for _, subset := range endpoints.Subsets {
 				if len(subset.Addresses) < 1 {
-					return nil, fmt.Errorf("addresses not ready for endpoints")
+					return nil, ErrEndpointsNotReady
 				}
 				if len(subset.Ports) < 1 {
 					return nil, fmt.Errorf("ports not ready for endpoints")
@@ -61,34 +181,44 @@ func CompileUDPRouteToDataPlaneBackend(ctx context.Context, c client.Client, udp
 						return nil, fmt.Errorf("empty IP for endpoint subset")
 					}
 
-					ip := net.ParseIP(addr.IP)
-					podip := binary.BigEndian.Uint32(ip.To4())
-					podPort, err := getBackendPort(ctx, c, udproute.Namespace, backendRef, subset.Ports)
+					podip, err := ipv4ToUint32(addr.IP)
+					if err != nil {
+						return nil, fmt.Errorf("endpoint address of backend %s: %w", backendRef.Name, err)
+					}
+					podPort, err := backendPort(ctx, c, source, udproute.Namespace, backendRef, subset.Ports, portOverride)
 					if err != nil {
-						return nil, err
+						if !isUnresolvedBackendPort(err) {
+							return nil, err
+						}
+						// the other backends can still receive traffic.
+						log.FromContext(ctx).Info("skipping backend whose port could not be resolved",
+							"route", client.ObjectKeyFromObject(udproute).String(), "backend", string(backendRef.Name), "error", err.Error())
+						portErrs = append(portErrs, err)
+						break
 					}
 
 					target := &Target{
 						Daddr: podip,
 						Dport: uint32(podPort),
 					}
-					backendTargets = append(backendTargets, target)
+					group.targets = append(group.targets, target)
 				}
 			}
+			groups = append(groups, group)
 		}
 	}
 
+	backendTargets := expandWeightedTargets(groups)
+	sortTargets(backendTargets)
 	if len(backendTargets) == 0 {
-		return nil, fmt.Errorf("no healthy backends")
+		if len(portErrs) > 0 {
+			return nil, errors.Join(portErrs...)
+		}
+		return nil, ErrNoHealthyBackends
 	}
 
-	ipint := binary.BigEndian.Uint32(gatewayIP.To4())
-
 	targets := &Targets{
-		Vip: &Vip{
-			Ip:   ipint,
-			Port: gatewayPort,
-		},
+		Vip:     vip,
 		Targets: backendTargets,
 	}
 
@@ -97,7 +227,19 @@ func CompileUDPRouteToDataPlaneBackend(ctx context.Context, c client.Client, udp
 
 // CompileTCPRouteToDataPlaneBackend takes a TCPRoute and the Gateway it is
 // attached to and produces Backend Targets for the DataPlane to configure.
-func CompileTCPRouteToDataPlaneBackend(ctx context.Context, c client.Client,
+// As there's no L7 discrimination for TCP, the backends of all rules are
+// merged into the backend pool of the Gateway VIP. The external names of
+// ExternalName Service backends are resolved with the provided
+// ExternalNameResolver.
+func CompileTCPRouteToDataPlaneBackend(ctx context.Context, c client.Client, resolver *ExternalNameResolver,
+	tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) (*Targets, error) {
+	ctx, span := startSpan(ctx, "CompileTCPRouteToDataPlaneBackend", routeAttributes(tcproute)...)
+	targets, err := compileTCPRouteToDataPlaneBackend(ctx, c, resolver, tcproute, gateway)
+	endSpan(span, err)
+	return targets, err
+}
+
+func compileTCPRouteToDataPlaneBackend(ctx context.Context, c client.Client, resolver *ExternalNameResolver,
 	tcproute *gatewayv1alpha2.TCPRoute, gateway *gatewayv1beta1.Gateway) (*Targets, error) {
 	gatewayIP, err := GetGatewayIP(gateway)
 	if gatewayIP == nil {
@@ -108,10 +250,48 @@ func CompileTCPRouteToDataPlaneBackend(ctx context.Context, c client.Client,
 	if err != nil {
 		return nil, err
 	}
-	var backendTargets []*Target
+	vip, err := GatewayVip(gateway, gatewayIP, gatewayPort, Vip_TCP)
+	if err != nil {
+		return nil, err
+	}
+	maxConnections, err := routeMaxConnections(tcproute)
+	if err != nil {
+		return nil, err
+	}
+	portOverride, err := routeBackendPortOverride(tcproute)
+	if err != nil {
+		return nil, err
+	}
+	idleTimeout, err := routeTCPIdleTimeout(tcproute)
+	if err != nil {
+		return nil, err
+	}
+	action, err := routeAction(tcproute)
+	if err != nil {
+		return nil, err
+	}
+	if action != Targets_FORWARD {
+		return &Targets{Vip: vip, Action: action, TcpIdleTimeoutSeconds: idleTimeout}, nil
+	}
+	var backendRefs []gatewayv1alpha2.BackendRef
+	for _, rule := range tcproute.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+	if err := validateBackendRefs(tcproute.Namespace, backendRefs); err != nil {
+		return nil, err
+	}
+	var groups []weightedTargets
+	var portErrs []error
 	for _, rule := range tcproute.Spec.Rules {
 		for _, backendRef := range rule.BackendRefs {
-			endpoints, err := endpointsFromBackendRef(ctx, c, tcproute.Namespace, backendRef)
+			// a BackendRef with a weight of zero receives no traffic.
+			if backendRefWeight(backendRef) == 0 {
+				continue
+			}
+
+			group := weightedTargets{weight: backendRefWeight(backendRef)}
+			source := endpointSourceFor(backendRef, resolver)
+			endpoints, err := source.Endpoints(ctx, c, tcproute.Namespace, backendRef)
 			if err != nil {
 				return nil, err
 			}
@@ -121,7 +301,7 @@ func CompileTCPRouteToDataPlaneBackend(ctx context.Context, c client.Client,
 			}
 			for _, subset := range endpoints.Subsets {
 				if len(subset.Addresses) < 1 {
-					return nil, fmt.Errorf("addresses not ready for endpoints")
+					return nil, ErrEndpointsNotReady
 				}
 				if len(subset.Ports) < 1 {
 					return nil, fmt.Errorf("ports not ready for endpoints")
@@ -132,40 +312,182 @@ func CompileTCPRouteToDataPlaneBackend(ctx context.Context, c client.Client,
 						return nil, fmt.Errorf("empty IP for endpoint subset")
 					}
 
-					ip := net.ParseIP(addr.IP)
-					podip := binary.BigEndian.Uint32(ip.To4())
-					podPort, err := getBackendPort(ctx, c, tcproute.Namespace, backendRef, subset.Ports)
+					podip, err := ipv4ToUint32(addr.IP)
+					if err != nil {
+						return nil, fmt.Errorf("endpoint address of backend %s: %w", backendRef.Name, err)
+					}
+					podPort, err := backendPort(ctx, c, source, tcproute.Namespace, backendRef, subset.Ports, portOverride)
 					if err != nil {
-						return nil, err
+						if !isUnresolvedBackendPort(err) {
+							return nil, err
+						}
+						// the other backends can still receive traffic.
+						log.FromContext(ctx).Info("skipping backend whose port could not be resolved",
+							"route", client.ObjectKeyFromObject(tcproute).String(), "backend", string(backendRef.Name), "error", err.Error())
+						portErrs = append(portErrs, err)
+						break
 					}
 
 					target := &Target{
-						Daddr: podip,
-						Dport: uint32(podPort),
+						Daddr:          podip,
+						Dport:          uint32(podPort),
+						MaxConnections: maxConnections,
 					}
-					backendTargets = append(backendTargets, target)
+					group.targets = append(group.targets, target)
 				}
 			}
+			groups = append(groups, group)
 		}
 	}
 
+	backendTargets := expandWeightedTargets(groups)
+	sortTargets(backendTargets)
 	if len(backendTargets) == 0 {
-		return nil, fmt.Errorf("no healthy backends")
+		if len(portErrs) > 0 {
+			return nil, errors.Join(portErrs...)
+		}
+		return nil, ErrNoHealthyBackends
 	}
 
-	ipint := binary.BigEndian.Uint32(gatewayIP.To4())
-
 	targets := &Targets{
-		Vip: &Vip{
-			Ip:   ipint,
-			Port: gatewayPort,
-		},
-		Targets: backendTargets,
+		Vip:                   vip,
+		Targets:               backendTargets,
+		TcpIdleTimeoutSeconds: idleTimeout,
 	}
 
 	return targets, nil
 }
 
+// validateBackendRefs verifies that the BackendRefs of all the rules of a route
+// in the provided namespace, which are programmed on the same VIP, don't
+// reference the same backend with different weights, as the share of the
+// traffic that backend should receive would be ambiguous.
+func validateBackendRefs(namespace string, refs []gatewayv1alpha2.BackendRef) error {
+	weights := make(map[string]int32, len(refs))
+	for _, ref := range refs {
+		refNamespace := namespace
+		if ref.Namespace != nil {
+			refNamespace = string(*ref.Namespace)
+		}
+		kind := "Service"
+		if ref.Kind != nil {
+			kind = string(*ref.Kind)
+		}
+		backend := fmt.Sprintf("%s %s/%s", kind, refNamespace, ref.Name)
+		if ref.Port != nil {
+			backend = fmt.Sprintf("%s:%d", backend, *ref.Port)
+		}
+
+		weight, ok := weights[backend]
+		if ok && weight != backendRefWeight(ref) {
+			return fmt.Errorf("%w: %s is referenced with weights %d and %d", ErrConflictingBackendRefs, backend, weight, backendRefWeight(ref))
+		}
+		weights[backend] = backendRefWeight(ref)
+	}
+	return nil
+}
+
+// routeMaxConnections returns the concurrent connections limit of the backends
+// of the provided route, from its MaxConnectionsAnnotation. It returns nil if
+// the backends have no limit.
+func routeMaxConnections(obj client.Object) (*uint32, error) {
+	value, ok := obj.GetAnnotations()[MaxConnectionsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	maxConnections, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || maxConnections < 0 || maxConnections > math.MaxUint32 {
+		return nil, fmt.Errorf("%w %q in %s annotation: must be a non-negative integer", ErrInvalidMaxConnections, value, MaxConnectionsAnnotation)
+	}
+	if maxConnections == 0 {
+		return nil, nil
+	}
+	limit := uint32(maxConnections)
+	return &limit, nil
+}
+
+// routeBackendPortOverride returns the port of the backend Services of the
+// provided route, from its BackendPortOverrideAnnotation. It returns zero if
+// the ports aren't overridden.
+func routeBackendPortOverride(obj client.Object) (int32, error) {
+	value, ok := obj.GetAnnotations()[BackendPortOverrideAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	port, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || port < 1 || port > math.MaxUint16 {
+		return 0, fmt.Errorf("%w %q in %s annotation: must be a port number between 1 and 65535", ErrInvalidBackendPortOverride, value, BackendPortOverrideAnnotation)
+	}
+	return int32(port), nil
+}
+
+// GatewayVip returns the VIP of the provided Gateway address, port and
+// protocol. It returns ErrUnsupportedIPFamily if the address isn't an IPv4
+// address.
+func GatewayVip(gateway *gatewayv1beta1.Gateway, gatewayIP net.IP, gatewayPort uint32, protocol Vip_Protocol) (*Vip, error) {
+	gatewayIPv4 := gatewayIP.To4()
+	if gatewayIPv4 == nil {
+		return nil, fmt.Errorf("%w: address %s of Gateway %s/%s", ErrUnsupportedIPFamily, gatewayIP, gateway.Namespace, gateway.Name)
+	}
+	return &Vip{Ip: binary.BigEndian.Uint32(gatewayIPv4), Port: gatewayPort, Protocol: protocol}, nil
+}
+
+// routeAction returns the action applied to the traffic to the VIP of the
+// provided route, from its ActionAnnotation. It returns Targets_FORWARD if
+// the traffic is forwarded to the backends.
+func routeAction(obj client.Object) (Targets_Action, error) {
+	value, ok := obj.GetAnnotations()[ActionAnnotation]
+	if !ok {
+		return Targets_FORWARD, nil
+	}
+	switch value {
+	case "Reject":
+		return Targets_REJECT, nil
+	case "Drop":
+		return Targets_DROP, nil
+	default:
+		return Targets_FORWARD, fmt.Errorf("%w %q in %s annotation: must be Reject or Drop", ErrInvalidAction, value, ActionAnnotation)
+	}
+}
+
+// routeTCPIdleTimeout returns the idle timeout in seconds of the connections
+// of the provided route, from its TCPIdleTimeoutAnnotation. It returns nil if
+// the connections have no idle timeout.
+func routeTCPIdleTimeout(obj client.Object) (*uint32, error) {
+	value, ok := obj.GetAnnotations()[TCPIdleTimeoutAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < time.Second || timeout%time.Second != 0 || timeout/time.Second > math.MaxUint32 {
+		return nil, fmt.Errorf("%w %q in %s annotation: must be a positive duration in whole seconds, e.g. 90s or 5m", ErrInvalidTCPIdleTimeout, value, TCPIdleTimeoutAnnotation)
+	}
+	seconds := uint32(timeout / time.Second)
+	return &seconds, nil
+}
+
+// ipv4ToUint32 returns the provided IPv4 address as an integer, in the byte
+// order of the dataplane. IPv4-mapped IPv6 addresses such as
+// "::ffff:10.0.0.1" are accepted as their IPv4 address.
+func ipv4ToUint32(addr string) (uint32, error) {
+	ip := net.ParseIP(addr).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedIPFamily, addr)
+	}
+	return binary.BigEndian.Uint32(ip), nil
+}
+
+// backendPort returns the port the addresses of an endpoint subset with the
+// provided ports receive the traffic on, which is the provided override for
+// Service backends if it isn't zero.
+func backendPort(ctx context.Context, c client.Client, source EndpointSource, namespace string, backendRef gatewayv1alpha2.BackendRef,
+	epPorts []corev1.EndpointPort, override int32) (int32, error) {
+	if _, ok := source.(ServiceEndpointSource); ok && override != 0 {
+		return override, nil
+	}
+	return source.BackendPort(ctx, c, namespace, backendRef, epPorts)
+}
+
 func endpointsFromBackendRef(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef) (*corev1.Endpoints, error) {
 	if backendRef.Namespace != nil {
 		namespace = string(*backendRef.Namespace)
@@ -182,45 +504,127 @@ func endpointsFromBackendRef(ctx context.Context, c client.Client, namespace str
 	return endpoints, nil
 }
 
-func getBackendPort(ctx context.Context, c client.Client, ns string, backendRef gatewayv1alpha2.BackendRef,
-	epPorts []corev1.EndpointPort) (int32, error) {
-	svc := new(corev1.Service)
+// serviceFromBackendRef returns the Service the provided BackendRef refers to.
+func serviceFromBackendRef(ctx context.Context, c client.Client, namespace string, backendRef gatewayv1alpha2.BackendRef) (*corev1.Service, error) {
 	if backendRef.Namespace != nil {
-		ns = string(*backendRef.Namespace)
+		namespace = string(*backendRef.Namespace)
 	}
-	key := client.ObjectKey{
-		Namespace: ns,
+
+	svc := new(corev1.Service)
+	if err := c.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
 		Name:      string(backendRef.Name),
+	}, svc); err != nil {
+		return nil, err
 	}
-	if err := c.Get(ctx, key, svc); err != nil {
+
+	return svc, nil
+}
+
+// getBackendPort returns the port the addresses of an endpoint subset with the
+// provided ports listen on for the Service port the BackendRef refers to.
+// Endpoint ports are named after the Service port they belong to, so the port
+// is selected by name: this resolves named target ports, which can differ
+// between subsets, and tells apart the ports of Services exposing several.
+func getBackendPort(ctx context.Context, c client.Client, ns string, backendRef gatewayv1alpha2.BackendRef,
+	epPorts []corev1.EndpointPort) (int32, error) {
+	svc, err := serviceFromBackendRef(ctx, c, ns, backendRef)
+	if err != nil {
 		return 0, err
 	}
+	key := client.ObjectKeyFromObject(svc)
 
 	for _, port := range svc.Spec.Ports {
 		// backendRef must have a port if the backend is a Service.
-		if port.Port == int32(*backendRef.Port) {
-			if port.TargetPort.IntValue() == 0 {
-				return port.Port, nil
+		if port.Port != int32(*backendRef.Port) {
+			continue
+		}
+		for _, epPort := range epPorts {
+			if epPort.Name == port.Name {
+				return epPort.Port, nil
 			}
-			return int32(port.TargetPort.IntValue()), nil
 		}
+		if port.TargetPort.Type == intstr.String {
+			return 0, fmt.Errorf("%w: could not resolve named target port %s for backend ref: %s", ErrBackendPortNotFound, port.TargetPort.StrVal, key.String())
+		}
+		if port.TargetPort.IntValue() == 0 {
+			return port.Port, nil
+		}
+		return int32(port.TargetPort.IntValue()), nil
 	}
-	return 0, fmt.Errorf("could not find target port for backend ref: %s", key.String())
+	return 0, fmt.Errorf("%w: could not find target port for backend ref: %s", ErrBackendPortNotFound, key.String())
 }
 
-func GetGatewayIP(gw *gatewayv1beta1.Gateway) (ip net.IP, err error) {
-	if len(gw.Status.Addresses) > 1 {
-		return nil, fmt.Errorf("Gateway %s/%s had %d addresses but we only currently support 1", gw.Namespace, gw.Name, len(gw.Status.Addresses))
+// isUnresolvedBackendPort indicates whether the provided error, returned when
+// resolving the port of a backend, was caused by its Service or Service port
+// not existing. Such backends are skipped, while the other backends of the
+// route still receive traffic.
+func isUnresolvedBackendPort(err error) bool {
+	return errors.Is(err, ErrBackendPortNotFound) || apierrors.IsNotFound(err)
+}
+
+// UnresolvedBackendPorts returns an error for each of the provided
+// BackendRefs of the provided route whose port can't be resolved. Those
+// backends are skipped when the route is compiled, while its other backends
+// are programmed.
+func UnresolvedBackendPorts(ctx context.Context, c client.Client, resolver *ExternalNameResolver, route client.Object, backendRefs []gatewayv1alpha2.BackendRef) ([]error, error) {
+	portOverride, err := routeBackendPortOverride(route)
+	if err != nil {
+		// the compilation of the route reports the invalid annotation.
+		return nil, nil
+	}
+
+	var unresolved []error
+	for _, backendRef := range backendRefs {
+		if backendRefWeight(backendRef) == 0 {
+			continue
+		}
+		source := endpointSourceFor(backendRef, resolver)
+		endpoints, err := source.Endpoints(ctx, c, route.GetNamespace(), backendRef)
+		if err != nil {
+			// the backends without endpoints fail the compilation of the
+			// route instead.
+			continue
+		}
+		for _, subset := range endpoints.Subsets {
+			if len(subset.Addresses) == 0 || len(subset.Ports) == 0 {
+				continue
+			}
+			if _, err := backendPort(ctx, c, source, route.GetNamespace(), backendRef, subset.Ports, portOverride); err != nil {
+				if !isUnresolvedBackendPort(err) {
+					return nil, err
+				}
+				unresolved = append(unresolved, fmt.Errorf("backend %s: %w", backendRef.Name, err))
+				break
+			}
+		}
 	}
+	return unresolved, nil
+}
 
-	for _, address := range gw.Status.Addresses {
+// GetGatewayIP returns the first IPAddress type address in the status of the
+// provided Gateway. Only one address is supported for now, the other addresses
+// are logged and ignored, so that a Gateway which also has a hostname for
+// instance still gets its traffic routed.
+func GetGatewayIP(gw *gatewayv1beta1.Gateway) (ip net.IP, err error) {
+	for i, address := range gw.Status.Addresses {
 		if address.Type != nil && *address.Type == gatewayv1beta1.IPAddressType {
 			ip = net.ParseIP(address.Value)
+			if len(gw.Status.Addresses) > 1 {
+				var ignored []string
+				for j, other := range gw.Status.Addresses {
+					if j != i {
+						ignored = append(ignored, other.Value)
+					}
+				}
+				log.Log.Info("Gateway has several addresses, only the first IP address is used",
+					"gateway", client.ObjectKeyFromObject(gw).String(), "address", address.Value, "ignored", ignored)
+			}
 			return
 		}
 	}
 
-	err = fmt.Errorf("IP address not ready for Gateway %s/%s", gw.Namespace, gw.Name)
+	err = fmt.Errorf("%w %s/%s", ErrGatewayIPNotReady, gw.Namespace, gw.Name)
 	return
 }
 
@@ -236,3 +640,107 @@ func GetGatewayPort(gw *gatewayv1beta1.Gateway, refs []gatewayv1alpha2.ParentRef
 
 	return uint32(*refs[0].Port), nil
 }
+
+// DiffTargets compares a previously pushed set of backend Targets with a new
+// one and returns the backends (as "IP:port") which were added and removed.
+func DiffTargets(previous, current []*Target) (added, removed []string) {
+	previousSet := make(map[string]struct{}, len(previous))
+	for _, target := range previous {
+		previousSet[target.Addr()] = struct{}{}
+	}
+	currentSet := make(map[string]struct{}, len(current))
+	for _, target := range current {
+		currentSet[target.Addr()] = struct{}{}
+	}
+
+	for target := range currentSet {
+		if _, ok := previousSet[target]; !ok {
+			added = append(added, target)
+		}
+	}
+	for target := range previousSet {
+		if _, ok := currentSet[target]; !ok {
+			removed = append(removed, target)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
+
+// sortTargets sorts the provided Targets by address and port, so that compiling
+// a route again from the same state produces the same Targets regardless of
+// the order the endpoints were listed in.
+func sortTargets(targets []*Target) {
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].GetDaddr() != targets[j].GetDaddr() {
+			return targets[i].GetDaddr() < targets[j].GetDaddr()
+		}
+		return targets[i].GetDport() < targets[j].GetDport()
+	})
+}
+
+// TargetsDelta compares a previously pushed set of backend Targets with a new
+// one and returns the Targets which were added and removed. The Targets are
+// compared as sets, so the delta of weighted Targets, which repeat the same
+// backend, misses the weight changes: see HasDuplicateTargets.
+func TargetsDelta(previous, current []*Target) (added, removed []*Target) {
+	previousSet := make(map[string]struct{}, len(previous))
+	for _, target := range previous {
+		previousSet[target.Addr()] = struct{}{}
+	}
+	currentSet := make(map[string]struct{}, len(current))
+	for _, target := range current {
+		currentSet[target.Addr()] = struct{}{}
+		if _, ok := previousSet[target.Addr()]; !ok {
+			added = append(added, target)
+		}
+	}
+	for _, target := range previous {
+		if _, ok := currentSet[target.Addr()]; !ok {
+			removed = append(removed, target)
+		}
+	}
+
+	return added, removed
+}
+
+// HasDuplicateTargets indicates whether the provided Targets repeat a backend,
+// as weighted Targets do. Such Targets can't be patched with AddBackend and
+// RemoveBackend, which treat the backends of a VIP as a set, and must be
+// replaced with Update.
+func HasDuplicateTargets(targets []*Target) bool {
+	seen := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		if _, ok := seen[target.Addr()]; ok {
+			return true
+		}
+		seen[target.Addr()] = struct{}{}
+	}
+	return false
+}
+
+// ApplyTargetsDelta returns the provided backend Targets with the added ones
+// appended and the removed ones left out.
+func ApplyTargetsDelta(targets, added, removed []*Target) []*Target {
+	removedSet := make(map[string]struct{}, len(removed))
+	for _, target := range removed {
+		removedSet[target.Addr()] = struct{}{}
+	}
+
+	result := make([]*Target, 0, len(targets)+len(added))
+	present := make(map[string]struct{}, len(targets)+len(added))
+	for _, target := range append(append([]*Target{}, targets...), added...) {
+		if _, ok := removedSet[target.Addr()]; ok {
+			continue
+		}
+		if _, ok := present[target.Addr()]; ok {
+			continue
+		}
+		present[target.Addr()] = struct{}{}
+		result = append(result, target)
+	}
+
+	return result
+}
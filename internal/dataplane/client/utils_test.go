@@ -0,0 +1,1202 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func TestDiffTargets(t *testing.T) {
+	// 10.244.0.10, 10.244.0.11 and 10.244.0.12
+	const ip1, ip2, ip3 = 0x0af4000a, 0x0af4000b, 0x0af4000c
+
+	for _, tt := range []struct {
+		name            string
+		previous        []*Target
+		current         []*Target
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:          "everything is added on the first push",
+			current:       []*Target{{Daddr: ip1, Dport: 8080}, {Daddr: ip2, Dport: 8080}},
+			expectedAdded: []string{"10.244.0.10:8080", "10.244.0.11:8080"},
+		},
+		{
+			name:     "no changes",
+			previous: []*Target{{Daddr: ip1, Dport: 8080}, {Daddr: ip2, Dport: 8080}},
+			current:  []*Target{{Daddr: ip2, Dport: 8080}, {Daddr: ip1, Dport: 8080}},
+		},
+		{
+			name:            "backends added and removed",
+			previous:        []*Target{{Daddr: ip1, Dport: 8080}, {Daddr: ip2, Dport: 8080}},
+			current:         []*Target{{Daddr: ip2, Dport: 8080}, {Daddr: ip3, Dport: 8080}},
+			expectedAdded:   []string{"10.244.0.12:8080"},
+			expectedRemoved: []string{"10.244.0.10:8080"},
+		},
+		{
+			name:            "a port change is a removal and an addition",
+			previous:        []*Target{{Daddr: ip1, Dport: 8080}},
+			current:         []*Target{{Daddr: ip1, Dport: 9090}},
+			expectedAdded:   []string{"10.244.0.10:9090"},
+			expectedRemoved: []string{"10.244.0.10:8080"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := DiffTargets(tt.previous, tt.current)
+			assert.Equal(t, tt.expectedAdded, added)
+			assert.Equal(t, tt.expectedRemoved, removed)
+		})
+	}
+}
+
+func TestHasDuplicateTargets(t *testing.T) {
+	a := &Target{Daddr: 0x0af4000a, Dport: 80}
+	b := &Target{Daddr: 0x0af4000b, Dport: 80}
+
+	assert.False(t, HasDuplicateTargets(nil))
+	assert.False(t, HasDuplicateTargets([]*Target{a, b}))
+	assert.False(t, HasDuplicateTargets([]*Target{a, {Daddr: 0x0af4000a, Dport: 81}}))
+	assert.True(t, HasDuplicateTargets([]*Target{a, a, a, b}))
+	assert.True(t, HasDuplicateTargets([]*Target{a, b, {Daddr: 0x0af4000b, Dport: 80}}))
+}
+
+func TestGetGatewayIP(t *testing.T) {
+	ipAddrType, hostnameType := gatewayv1beta1.IPAddressType, gatewayv1beta1.HostnameAddressType
+
+	for _, tt := range []struct {
+		name        string
+		addresses   []gatewayv1beta1.GatewayStatusAddress
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:      "a single IP address",
+			addresses: []gatewayv1beta1.GatewayStatusAddress{{Type: &ipAddrType, Value: "172.18.0.240"}},
+			expected:  "172.18.0.240",
+		},
+		{
+			name: "the IP address is selected after a hostname",
+			addresses: []gatewayv1beta1.GatewayStatusAddress{
+				{Type: &hostnameType, Value: "gateway.example.com"},
+				{Type: &ipAddrType, Value: "172.18.0.240"},
+			},
+			expected: "172.18.0.240",
+		},
+		{
+			name: "the first IP address is selected among several",
+			addresses: []gatewayv1beta1.GatewayStatusAddress{
+				{Type: &ipAddrType, Value: "172.18.0.240"},
+				{Type: &hostnameType, Value: "gateway.example.com"},
+				{Type: &ipAddrType, Value: "172.18.0.241"},
+			},
+			expected: "172.18.0.240",
+		},
+		{
+			name:        "no IP address",
+			addresses:   []gatewayv1beta1.GatewayStatusAddress{{Type: &hostnameType, Value: "gateway.example.com"}},
+			expectedErr: ErrGatewayIPNotReady,
+		},
+		{
+			name:        "no address",
+			expectedErr: ErrGatewayIPNotReady,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Status:     gatewayv1beta1.GatewayStatus{Addresses: tt.addresses},
+			}
+			ip, err := GetGatewayIP(gateway)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ip.String())
+		})
+	}
+}
+
+func TestCompileTCPRouteToDataPlaneBackend_multipleRules(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	tcproute := &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{
+					Name: "test-gateway",
+					Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+				}},
+			},
+			Rules: []gatewayv1alpha2.TCPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-b")}},
+			},
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(append(
+			newTestBackend("backend-a", "10.244.0.10", "10.244.0.11"),
+			newTestBackend("backend-b", "10.244.0.20")...,
+		)...).
+		Build()
+
+	targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, &Vip{Ip: 0xac1200f0, Port: 8080}, targets.Vip)
+	// both backends have the same weight, so the single endpoint of backend-b
+	// receives as much traffic as the two endpoints of backend-a together.
+	assert.Equal(t, []*Target{
+		{Daddr: 0x0af4000a, Dport: 80},
+		{Daddr: 0x0af4000b, Dport: 80},
+		{Daddr: 0x0af40014, Dport: 80},
+		{Daddr: 0x0af40014, Dport: 80},
+	}, targets.Targets)
+}
+
+func TestCompileRouteToDataPlaneBackend_sortedTargets(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	parentRefs := []gatewayv1alpha2.ParentReference{{
+		Name: "test-gateway",
+		Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+	}}
+	tcproute := &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+			Rules: []gatewayv1alpha2.TCPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a"), newTestBackendRef("backend-b")}},
+			},
+		},
+	}
+	udproute := &gatewayv1alpha2.UDPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.UDPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+			Rules: []gatewayv1alpha2.UDPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a"), newTestBackendRef("backend-b")}},
+			},
+		},
+	}
+	expected := []*Target{
+		{Daddr: 0x0af4000a, Dport: 80},
+		{Daddr: 0x0af4000b, Dport: 80},
+		{Daddr: 0x0af40014, Dport: 80},
+		{Daddr: 0x0af4001e, Dport: 80},
+	}
+
+	for _, tt := range []struct {
+		name string
+		objs []client.Object
+	}{
+		{
+			name: "endpoints listed in order",
+			objs: append(
+				newTestBackend("backend-a", "10.244.0.10", "10.244.0.30"),
+				newTestBackend("backend-b", "10.244.0.11", "10.244.0.20")...,
+			),
+		},
+		{
+			name: "endpoints listed in reverse order",
+			objs: append(
+				newTestBackend("backend-a", "10.244.0.30", "10.244.0.10"),
+				newTestBackend("backend-b", "10.244.0.20", "10.244.0.11")...,
+			),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fakectrlruntimeclient.
+				NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(tt.objs...).
+				Build()
+
+			targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			require.NoError(t, err)
+			assert.Equal(t, expected, targets.Targets)
+
+			targets, err = CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			require.NoError(t, err)
+			assert.Equal(t, expected, targets.Targets)
+		})
+	}
+}
+
+func TestCompileRouteToDataPlaneBackend_ipv6Addresses(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	parentRefs := []gatewayv1alpha2.ParentReference{{
+		Name: "test-gateway",
+		Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+	}}
+	tcproute := &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+			Rules: []gatewayv1alpha2.TCPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+			},
+		},
+	}
+	udproute := &gatewayv1alpha2.UDPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.UDPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+			Rules: []gatewayv1alpha2.UDPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		gatewayIP string
+		addresses []string
+		expected  []*Target
+	}{
+		{
+			name:      "IPv4-mapped IPv6 endpoints are programmed as IPv4",
+			gatewayIP: "172.18.0.240",
+			addresses: []string{"::ffff:10.244.0.10", "10.244.0.11"},
+			expected: []*Target{
+				{Daddr: 0x0af4000a, Dport: 80},
+				{Daddr: 0x0af4000b, Dport: 80},
+			},
+		},
+		{
+			name:      "IPv6 endpoints are rejected",
+			gatewayIP: "172.18.0.240",
+			addresses: []string{"10.244.0.10", "fd00:10:244::a"},
+		},
+		{
+			name:      "IPv6 Gateway addresses are rejected",
+			gatewayIP: "fd00:172:18::f0",
+			addresses: []string{"10.244.0.10"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+				Status: gatewayv1beta1.GatewayStatus{
+					Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+						Type:  &ipAddrType,
+						Value: tt.gatewayIP,
+					}},
+				},
+			}
+			fakeClient := fakectrlruntimeclient.
+				NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(newTestBackend("backend-a", tt.addresses...)...).
+				Build()
+
+			tcpTargets, tcpErr := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			udpTargets, udpErr := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			if tt.expected == nil {
+				require.ErrorIs(t, tcpErr, ErrUnsupportedIPFamily)
+				require.ErrorIs(t, udpErr, ErrUnsupportedIPFamily)
+				return
+			}
+			require.NoError(t, tcpErr)
+			require.NoError(t, udpErr)
+			assert.Equal(t, tt.expected, tcpTargets.Targets)
+			assert.Equal(t, tt.expected, udpTargets.Targets)
+		})
+	}
+}
+
+func TestCompileRouteToDataPlaneBackend_conflictingBackendRefs(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	parentRefs := []gatewayv1alpha2.ParentReference{{
+		Name: "test-gateway",
+		Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+	}}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(append(
+			newTestBackend("backend-a", "10.244.0.10"),
+			newTestBackend("backend-b", "10.244.0.11")...,
+		)...).
+		Build()
+
+	weighted := func(name string, weight int32) gatewayv1alpha2.BackendRef {
+		ref := newTestBackendRef(name)
+		ref.Weight = ptr.To(weight)
+		return ref
+	}
+
+	for _, tt := range []struct {
+		name  string
+		rules [][]gatewayv1alpha2.BackendRef
+		err   string
+	}{
+		{
+			name: "same backend with the same weight across rules",
+			rules: [][]gatewayv1alpha2.BackendRef{
+				{newTestBackendRef("backend-a"), newTestBackendRef("backend-b")},
+				{weighted("backend-a", 1)},
+			},
+		},
+		{
+			name: "same backend with different weights across rules",
+			rules: [][]gatewayv1alpha2.BackendRef{
+				{weighted("backend-a", 1), newTestBackendRef("backend-b")},
+				{weighted("backend-a", 3)},
+			},
+			err: "Service test-namespace/backend-a:80 is referenced with weights 1 and 3",
+		},
+		{
+			name: "same backend with different weights in one rule",
+			rules: [][]gatewayv1alpha2.BackendRef{
+				{newTestBackendRef("backend-a"), weighted("backend-a", 0)},
+			},
+			err: "Service test-namespace/backend-a:80 is referenced with weights 1 and 0",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tcproute := &gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+				Spec:       gatewayv1alpha2.TCPRouteSpec{CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs}},
+			}
+			udproute := &gatewayv1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+				Spec:       gatewayv1alpha2.UDPRouteSpec{CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs}},
+			}
+			for _, refs := range tt.rules {
+				tcproute.Spec.Rules = append(tcproute.Spec.Rules, gatewayv1alpha2.TCPRouteRule{BackendRefs: refs})
+				udproute.Spec.Rules = append(udproute.Spec.Rules, gatewayv1alpha2.UDPRouteRule{BackendRefs: refs})
+			}
+
+			_, tcpErr := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			_, udpErr := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			if tt.err == "" {
+				require.NoError(t, tcpErr)
+				require.NoError(t, udpErr)
+				return
+			}
+			require.ErrorIs(t, tcpErr, ErrConflictingBackendRefs)
+			assert.ErrorContains(t, tcpErr, tt.err)
+			require.ErrorIs(t, udpErr, ErrConflictingBackendRefs)
+			assert.ErrorContains(t, udpErr, tt.err)
+		})
+	}
+}
+
+func TestCompileTCPRouteToDataPlaneBackend_maxConnections(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(newTestBackend("backend-a", "10.244.0.10", "10.244.0.11")...).
+		Build()
+
+	for _, tt := range []struct {
+		name        string
+		annotations map[string]string
+		expected    *uint32
+		expectedErr error
+	}{
+		{
+			name: "backends have no limit without the annotation",
+		},
+		{
+			name:        "the annotation limits the connections of every backend",
+			annotations: map[string]string{MaxConnectionsAnnotation: "100"},
+			expected:    ptr.To(uint32(100)),
+		},
+		{
+			name:        "zero means no limit",
+			annotations: map[string]string{MaxConnectionsAnnotation: "0"},
+		},
+		{
+			name:        "negative values are rejected",
+			annotations: map[string]string{MaxConnectionsAnnotation: "-1"},
+			expectedErr: ErrInvalidMaxConnections,
+		},
+		{
+			name:        "values which aren't integers are rejected",
+			annotations: map[string]string{MaxConnectionsAnnotation: "many"},
+			expectedErr: ErrInvalidMaxConnections,
+		},
+		{
+			name:        "values which overflow are rejected",
+			annotations: map[string]string{MaxConnectionsAnnotation: "4294967296"},
+			expectedErr: ErrInvalidMaxConnections,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tcproute := &gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+						ParentRefs: []gatewayv1alpha2.ParentReference{{
+							Name: "test-gateway",
+							Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+						}},
+					},
+					Rules: []gatewayv1alpha2.TCPRouteRule{
+						{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+					},
+				},
+			}
+
+			targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []*Target{
+				{Daddr: 0x0af4000a, Dport: 80, MaxConnections: tt.expected},
+				{Daddr: 0x0af4000b, Dport: 80, MaxConnections: tt.expected},
+			}, targets.Targets)
+		})
+	}
+}
+
+func TestCompileTCPRouteToDataPlaneBackend_tcpIdleTimeout(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(newTestBackend("backend-a", "10.244.0.10")...).
+		Build()
+
+	for _, tt := range []struct {
+		name        string
+		annotations map[string]string
+		expected    *uint32
+		expectedErr error
+	}{
+		{
+			name: "connections have no idle timeout without the annotation",
+		},
+		{
+			name:        "the annotation is mapped to seconds",
+			annotations: map[string]string{TCPIdleTimeoutAnnotation: "90s"},
+			expected:    ptr.To(uint32(90)),
+		},
+		{
+			name:        "durations in other units are converted to seconds",
+			annotations: map[string]string{TCPIdleTimeoutAnnotation: "1h30m"},
+			expected:    ptr.To(uint32(5400)),
+		},
+		{
+			name:        "values which aren't durations are rejected",
+			annotations: map[string]string{TCPIdleTimeoutAnnotation: "90"},
+			expectedErr: ErrInvalidTCPIdleTimeout,
+		},
+		{
+			name:        "zero is rejected",
+			annotations: map[string]string{TCPIdleTimeoutAnnotation: "0s"},
+			expectedErr: ErrInvalidTCPIdleTimeout,
+		},
+		{
+			name:        "negative durations are rejected",
+			annotations: map[string]string{TCPIdleTimeoutAnnotation: "-5m"},
+			expectedErr: ErrInvalidTCPIdleTimeout,
+		},
+		{
+			name:        "fractions of seconds are rejected",
+			annotations: map[string]string{TCPIdleTimeoutAnnotation: "1500ms"},
+			expectedErr: ErrInvalidTCPIdleTimeout,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tcproute := &gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+						ParentRefs: []gatewayv1alpha2.ParentReference{{
+							Name: "test-gateway",
+							Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+						}},
+					},
+					Rules: []gatewayv1alpha2.TCPRouteRule{
+						{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+					},
+				},
+			}
+
+			targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, targets.TcpIdleTimeoutSeconds)
+			assert.Equal(t, []*Target{{Daddr: 0x0af4000a, Dport: 80}}, targets.Targets)
+		})
+	}
+}
+
+func TestCompileRouteToDataPlaneBackend_action(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	parentRefs := []gatewayv1alpha2.ParentReference{{
+		Name: "test-gateway",
+		Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+	}}
+	backendRefs := []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(newTestBackend("backend-a", "10.244.0.10")...).
+		Build()
+
+	for _, tt := range []struct {
+		name           string
+		annotations    map[string]string
+		expectedAction Targets_Action
+		expectedErr    error
+	}{
+		{
+			name:           "the traffic is forwarded to the backends without the annotation",
+			expectedAction: Targets_FORWARD,
+		},
+		{
+			name:           "Reject programs the VIP without backends",
+			annotations:    map[string]string{ActionAnnotation: "Reject"},
+			expectedAction: Targets_REJECT,
+		},
+		{
+			name:           "Drop programs the VIP without backends",
+			annotations:    map[string]string{ActionAnnotation: "Drop"},
+			expectedAction: Targets_DROP,
+		},
+		{
+			name:        "the action is case sensitive",
+			annotations: map[string]string{ActionAnnotation: "reject"},
+			expectedErr: ErrInvalidAction,
+		},
+		{
+			name:        "unknown actions are rejected",
+			annotations: map[string]string{ActionAnnotation: "Deny"},
+			expectedErr: ErrInvalidAction,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tcproute := &gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+					Rules:           []gatewayv1alpha2.TCPRouteRule{{BackendRefs: backendRefs}},
+				},
+			}
+			udproute := &gatewayv1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec: gatewayv1alpha2.UDPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+					Rules:           []gatewayv1alpha2.UDPRouteRule{{BackendRefs: backendRefs}},
+				},
+			}
+
+			tcpTargets, tcpErr := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			udpTargets, udpErr := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, tcpErr, tt.expectedErr)
+				require.ErrorIs(t, udpErr, tt.expectedErr)
+				return
+			}
+			require.NoError(t, tcpErr)
+			require.NoError(t, udpErr)
+
+			assert.Equal(t, &Vip{Ip: 0xac1200f0, Port: 8080, Protocol: Vip_TCP}, tcpTargets.GetVip())
+			assert.Equal(t, &Vip{Ip: 0xac1200f0, Port: 8080, Protocol: Vip_UDP}, udpTargets.GetVip())
+			for _, targets := range []*Targets{tcpTargets, udpTargets} {
+				assert.Equal(t, tt.expectedAction, targets.GetAction())
+				if tt.expectedAction == Targets_FORWARD {
+					assert.Equal(t, []*Target{{Daddr: 0x0af4000a, Dport: 80}}, targets.GetTargets())
+				} else {
+					assert.Empty(t, targets.GetTargets())
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRouteToDataPlaneBackend_backendPortOverride(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(newTestBackend("backend-a", "10.244.0.10", "10.244.0.11")...).
+		Build()
+	parentRefs := []gatewayv1alpha2.ParentReference{{
+		Name: "test-gateway",
+		Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+	}}
+
+	for _, tt := range []struct {
+		name        string
+		annotations map[string]string
+		expected    uint32
+		expectedErr error
+	}{
+		{
+			name:     "the target port of the Service is used without the annotation",
+			expected: 80,
+		},
+		{
+			name:        "the annotation takes precedence over the target port of the Service",
+			annotations: map[string]string{BackendPortOverrideAnnotation: "8443"},
+			expected:    8443,
+		},
+		{
+			name:        "zero is rejected",
+			annotations: map[string]string{BackendPortOverrideAnnotation: "0"},
+			expectedErr: ErrInvalidBackendPortOverride,
+		},
+		{
+			name:        "ports out of range are rejected",
+			annotations: map[string]string{BackendPortOverrideAnnotation: "65536"},
+			expectedErr: ErrInvalidBackendPortOverride,
+		},
+		{
+			name:        "values which aren't integers are rejected",
+			annotations: map[string]string{BackendPortOverrideAnnotation: "http"},
+			expectedErr: ErrInvalidBackendPortOverride,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tcproute := &gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+					Rules: []gatewayv1alpha2.TCPRouteRule{
+						{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+					},
+				},
+			}
+			udproute := &gatewayv1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace", Annotations: tt.annotations},
+				Spec: gatewayv1alpha2.UDPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+					Rules: []gatewayv1alpha2.UDPRouteRule{
+						{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+					},
+				},
+			}
+
+			tcpTargets, tcpErr := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+			udpTargets, udpErr := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, tcpErr, tt.expectedErr)
+				require.ErrorIs(t, udpErr, tt.expectedErr)
+				return
+			}
+			require.NoError(t, tcpErr)
+			require.NoError(t, udpErr)
+			expected := []*Target{
+				{Daddr: 0x0af4000a, Dport: tt.expected},
+				{Daddr: 0x0af4000b, Dport: tt.expected},
+			}
+			assert.Equal(t, expected, tcpTargets.Targets)
+			assert.Equal(t, expected, udpTargets.Targets)
+		})
+	}
+}
+
+func TestCompileRouteToDataPlaneBackend_multipleSubsets(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	parentRefs := []gatewayv1alpha2.ParentReference{{
+		Name: "test-gateway",
+		Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+	}}
+	tcproute := &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+			Rules: []gatewayv1alpha2.TCPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+			},
+		},
+	}
+	udproute := &gatewayv1alpha2.UDPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.UDPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{ParentRefs: parentRefs},
+			Rules: []gatewayv1alpha2.UDPRouteRule{
+				{BackendRefs: []gatewayv1alpha2.BackendRef{newTestBackendRef("backend-a")}},
+			},
+		},
+	}
+	// the Pods of the Service are split in subsets by the container port its
+	// named target port resolves to, e.g. during a rollout changing it.
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "backend-a", Namespace: "test-namespace"},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Name: "app", Port: 80, TargetPort: intstr.FromString("app")}},
+				},
+			},
+			&corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: "backend-a", Namespace: "test-namespace"},
+				Subsets: []corev1.EndpointSubset{
+					{
+						Addresses: []corev1.EndpointAddress{{IP: "10.244.0.12"}, {IP: "10.244.0.10"}},
+						Ports:     []corev1.EndpointPort{{Name: "app", Port: 8080}},
+					},
+					{
+						Addresses: []corev1.EndpointAddress{{IP: "10.244.0.11"}},
+						Ports:     []corev1.EndpointPort{{Name: "app", Port: 9090}},
+					},
+				},
+			},
+		).
+		Build()
+	expected := []*Target{
+		{Daddr: 0x0af4000a, Dport: 8080},
+		{Daddr: 0x0af4000b, Dport: 9090},
+		{Daddr: 0x0af4000c, Dport: 8080},
+	}
+
+	t.Log("the addresses of all the subsets are collected, with the port of their subset")
+	targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, expected, targets.Targets)
+
+	targets, err = CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, expected, targets.Targets)
+}
+
+func TestCompileUDPRouteToDataPlaneBackend_staticEndpoints(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	udproute := &gatewayv1alpha2.UDPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.UDPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{
+					Name: "test-gateway",
+					Port: ptr.To(gatewayv1alpha2.PortNumber(9875)),
+				}},
+			},
+			Rules: []gatewayv1alpha2.UDPRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{{
+					BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+						Kind: ptr.To(gatewayv1alpha2.Kind("ConfigMap")),
+						Name: "remote-backends",
+					},
+				}},
+			}},
+		},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		endpoints string
+		expected  []*Target
+		wantErr   bool
+	}{
+		{
+			name:      "endpoints separated by commas and newlines",
+			endpoints: "10.0.0.1:9000, 10.0.0.2:9000\n10.0.0.3:9001\n",
+			expected: []*Target{
+				{Daddr: 0x0a000001, Dport: 9000},
+				{Daddr: 0x0a000002, Dport: 9000},
+				{Daddr: 0x0a000003, Dport: 9001},
+			},
+		},
+		{
+			name:      "an endpoint without a port is invalid",
+			endpoints: "10.0.0.1:9000,10.0.0.2",
+			wantErr:   true,
+		},
+		{
+			name:      "an endpoint with a hostname is invalid",
+			endpoints: "backend.example.com:9000",
+			wantErr:   true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fakectrlruntimeclient.
+				NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "remote-backends", Namespace: "test-namespace"},
+					Data:       map[string]string{EndpointsConfigMapKey: tt.endpoints},
+				}).
+				Build()
+
+			targets, err := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &Vip{Ip: 0xac1200f0, Port: 9875, Protocol: Vip_UDP}, targets.Vip)
+			assert.Equal(t, tt.expected, targets.Targets)
+		})
+	}
+}
+
+func TestCompileUDPRouteToDataPlaneBackend_multiplePorts(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	// a Service exposing several UDP ports, one of them with a named target
+	// port which resolves to a different port on each subset.
+	objs := []client.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "udp-backend", Namespace: "test-namespace"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{
+					{Name: "dns", Port: 53, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt32(1053)},
+					{Name: "syslog", Port: 514, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromString("syslog")},
+				},
+			},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "udp-backend", Namespace: "test-namespace"},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+					Ports: []corev1.EndpointPort{
+						{Name: "dns", Port: 1053, Protocol: corev1.ProtocolUDP},
+						{Name: "syslog", Port: 5140, Protocol: corev1.ProtocolUDP},
+					},
+				},
+				{
+					Addresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+					Ports: []corev1.EndpointPort{
+						{Name: "dns", Port: 1053, Protocol: corev1.ProtocolUDP},
+						{Name: "syslog", Port: 5141, Protocol: corev1.ProtocolUDP},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		port     gatewayv1alpha2.PortNumber
+		expected []*Target
+	}{
+		{
+			name: "numeric target port",
+			port: 53,
+			expected: []*Target{
+				{Daddr: 0x0a000001, Dport: 1053},
+				{Daddr: 0x0a000002, Dport: 1053},
+			},
+		},
+		{
+			name: "named target port resolved per subset",
+			port: 514,
+			expected: []*Target{
+				{Daddr: 0x0a000001, Dport: 5140},
+				{Daddr: 0x0a000002, Dport: 5141},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			udproute := &gatewayv1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+				Spec: gatewayv1alpha2.UDPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+						ParentRefs: []gatewayv1alpha2.ParentReference{{
+							Name: "test-gateway",
+							Port: ptr.To(gatewayv1alpha2.PortNumber(9875)),
+						}},
+					},
+					Rules: []gatewayv1alpha2.UDPRouteRule{{
+						BackendRefs: []gatewayv1alpha2.BackendRef{{
+							BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+								Name: "udp-backend",
+								Port: ptr.To(tt.port),
+							},
+						}},
+					}},
+				},
+			}
+			fakeClient := fakectrlruntimeclient.
+				NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(objs...).
+				Build()
+
+			targets, err := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, targets.Targets)
+		})
+	}
+}
+
+func TestCompileUDPRouteToDataPlaneBackend_unresolvablePort(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	// a backend whose named target port isn't exposed by its endpoints.
+	objs := append(newTestBackend("resolvable", "10.0.0.1"),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "unresolvable", Namespace: "test-namespace"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "dns", Port: 80, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromString("dns")}},
+			},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "unresolvable", Namespace: "test-namespace"},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+				Ports:     []corev1.EndpointPort{{Name: "other", Port: 5353, Protocol: corev1.ProtocolUDP}},
+			}},
+		},
+	)
+
+	for _, tt := range []struct {
+		name        string
+		backends    []string
+		getErr      error
+		expected    []*Target
+		expectError bool
+	}{
+		{
+			name:     "the backend with an unresolvable port is skipped",
+			backends: []string{"resolvable", "unresolvable"},
+			expected: []*Target{{Daddr: 0x0a000001, Dport: 80}},
+		},
+		{
+			name:        "the route fails when no backend remains",
+			backends:    []string{"unresolvable"},
+			expectError: true,
+		},
+		{
+			name:        "the route fails when the port can't be read",
+			backends:    []string{"resolvable", "unresolvable"},
+			getErr:      errors.New("the cache is not synced"),
+			expectError: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			udproute := &gatewayv1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-udproute", Namespace: "test-namespace"},
+				Spec: gatewayv1alpha2.UDPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+						ParentRefs: []gatewayv1alpha2.ParentReference{{
+							Name: "test-gateway",
+							Port: ptr.To(gatewayv1alpha2.PortNumber(9875)),
+						}},
+					},
+					Rules: []gatewayv1alpha2.UDPRouteRule{{}},
+				},
+			}
+			for _, backend := range tt.backends {
+				udproute.Spec.Rules[0].BackendRefs = append(udproute.Spec.Rules[0].BackendRefs, newTestBackendRef(backend))
+			}
+			// the Service of a backend is read for its endpoints, then for
+			// its port.
+			serviceGets := 0
+			fakeClient := fakectrlruntimeclient.
+				NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(objs...).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+						if _, ok := obj.(*corev1.Service); ok && key.Name == "unresolvable" && tt.getErr != nil {
+							if serviceGets++; serviceGets > 1 {
+								return tt.getErr
+							}
+						}
+						return c.Get(ctx, key, obj, opts...)
+					},
+				}).
+				Build()
+
+			targets, err := CompileUDPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, udproute, gateway)
+			if tt.expectError {
+				require.Error(t, err)
+				if tt.getErr != nil {
+					assert.ErrorIs(t, err, tt.getErr)
+				} else {
+					assert.ErrorIs(t, err, ErrBackendPortNotFound)
+					assert.Contains(t, err.Error(), "could not resolve named target port dns")
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, targets.Targets)
+
+			t.Log("the skipped backends are reported")
+			unresolved, err := UnresolvedBackendPorts(context.Background(), fakeClient, nil, udproute, udproute.Spec.Rules[0].BackendRefs)
+			require.NoError(t, err)
+			require.Len(t, unresolved, 1)
+			assert.ErrorIs(t, unresolved[0], ErrBackendPortNotFound)
+			assert.Contains(t, unresolved[0].Error(), "backend unresolvable")
+		})
+	}
+}
+
+func newTestBackendRef(name string) gatewayv1alpha2.BackendRef {
+	return gatewayv1alpha2.BackendRef{
+		BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+			Name: gatewayv1alpha2.ObjectName(name),
+			Port: ptr.To(gatewayv1alpha2.PortNumber(80)),
+		},
+	}
+}
+
+// newTestBackend returns a Service on port 80 and its Endpoints with the
+// provided addresses.
+func newTestBackend(name string, addresses ...string) []client.Object {
+	endpointAddresses := make([]corev1.EndpointAddress, 0, len(addresses))
+	for _, addr := range addresses {
+		endpointAddresses = append(endpointAddresses, corev1.EndpointAddress{IP: addr})
+	}
+	return []client.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses: endpointAddresses,
+				Ports:     []corev1.EndpointPort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			}},
+		},
+	}
+}
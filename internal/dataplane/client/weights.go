@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// BackendWeight is the share of a route's traffic that one of its
+// BackendRefs receives.
+type BackendWeight struct {
+	// Backend is the "namespace/name" of the BackendRef.
+	Backend string
+	// Percent is the percentage of the route's traffic sent to the backend.
+	Percent int
+}
+
+// backendRefWeight returns the weight of the provided BackendRef, which
+// defaults to 1 when unset.
+func backendRefWeight(ref gatewayv1alpha2.BackendRef) int32 {
+	if ref.Weight == nil {
+		return 1
+	}
+	return *ref.Weight
+}
+
+// NormalizeBackendWeights converts the weights of the provided BackendRefs of
+// a route in the provided namespace to percentages of the route's traffic.
+// The percentages are rounded so that they sum up to exactly 100, unless all
+// the weights are zero in which case no backend receives any traffic.
+func NormalizeBackendWeights(namespace string, refs []gatewayv1alpha2.BackendRef) []BackendWeight {
+	var total int64
+	for _, ref := range refs {
+		total += int64(backendRefWeight(ref))
+	}
+
+	weights := make([]BackendWeight, len(refs))
+	remainders := make([]int64, len(refs))
+	distributed := 0
+	for i, ref := range refs {
+		refNamespace := namespace
+		if ref.Namespace != nil {
+			refNamespace = string(*ref.Namespace)
+		}
+		weights[i].Backend = refNamespace + "/" + string(ref.Name)
+		if total == 0 {
+			continue
+		}
+		scaled := int64(backendRefWeight(ref)) * 100
+		weights[i].Percent = int(scaled / total)
+		remainders[i] = scaled % total
+		distributed += weights[i].Percent
+	}
+
+	// hand out the percentage points lost to rounding down, to the backends
+	// with the largest remainders first.
+	if total > 0 {
+		order := make([]int, len(refs))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return remainders[order[a]] > remainders[order[b]]
+		})
+		for i := 0; distributed < 100; i++ {
+			weights[order[i%len(order)]].Percent++
+			distributed++
+		}
+	}
+
+	return weights
+}
+
+// FormatBackendWeights renders the provided BackendWeights in a human readable
+// form, suitable for status messages.
+func FormatBackendWeights(weights []BackendWeight) string {
+	if len(weights) == 0 {
+		return "no backends"
+	}
+	parts := make([]string, 0, len(weights))
+	for _, weight := range weights {
+		parts = append(parts, fmt.Sprintf("%s=%d%%", weight.Backend, weight.Percent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MaxTargets is the maximum number of backend Targets the dataplane supports
+// for a single VIP.
+const MaxTargets = 128
+
+// weightedTargets are the Targets a BackendRef with the provided weight
+// compiled to.
+type weightedTargets struct {
+	weight  int32
+	targets []*Target
+}
+
+// expandWeightedTargets merges the Targets of the provided BackendRefs into a
+// single list. As the dataplane balances connections over the Targets in a
+// round-robin fashion, the Targets of each BackendRef are repeated so that
+// each BackendRef receives a share of the traffic proportional to its weight,
+// whatever its number of endpoints, split evenly between its endpoints. The
+// number of repetitions is scaled down, at the cost of precision, to keep
+// within MaxTargets, unless the endpoints alone exceed it.
+func expandWeightedTargets(groups []weightedTargets) []*Target {
+	var divisor int64
+	for _, group := range groups {
+		if group.weight > 0 && len(group.targets) > 0 {
+			divisor = gcd(divisor, int64(group.weight))
+		}
+	}
+	if divisor == 0 {
+		return nil
+	}
+
+	// each endpoint of a BackendRef is repeated weight*scale/endpoints times,
+	// which is a whole number for every BackendRef with the least common
+	// multiple of the endpoints/gcd(weight, endpoints).
+	var scale int64 = 1
+	for _, group := range groups {
+		if group.weight <= 0 || len(group.targets) == 0 {
+			continue
+		}
+		weight, endpoints := int64(group.weight)/divisor, int64(len(group.targets))
+		scale = lcm(scale, endpoints/gcd(weight, endpoints))
+		// each BackendRef has at least scale Targets.
+		if scale > MaxTargets {
+			break
+		}
+	}
+
+	repeats := make([]int, len(groups))
+	total := 0
+	if scale <= MaxTargets {
+		var repeatsDivisor int64
+		exact := make([]int64, len(groups))
+		for i, group := range groups {
+			if group.weight <= 0 || len(group.targets) == 0 {
+				continue
+			}
+			exact[i] = int64(group.weight) / divisor * scale / int64(len(group.targets))
+			repeatsDivisor = gcd(repeatsDivisor, exact[i])
+		}
+		for i := range groups {
+			if exact[i] == 0 {
+				continue
+			}
+			repeats[i] = int(exact[i] / repeatsDivisor)
+			total += repeats[i] * len(groups[i].targets)
+			if total > MaxTargets {
+				break
+			}
+		}
+	}
+	if scale > MaxTargets || total > MaxTargets {
+		repeats = scaledRepeats(groups)
+	}
+
+	var targets []*Target
+	for i, group := range groups {
+		for r := 0; r < repeats[i]; r++ {
+			targets = append(targets, group.targets...)
+		}
+	}
+	return targets
+}
+
+// scaledRepeats returns how many times the Targets of each of the provided
+// BackendRefs are repeated, when repeating them exactly proportionally to
+// their weights exceeds MaxTargets: each BackendRef gets its share of
+// MaxTargets, with at least one Target per endpoint. The repetitions are then
+// decreased, largest first, until they fit within MaxTargets again.
+func scaledRepeats(groups []weightedTargets) []int {
+	var totalWeight int64
+	for _, group := range groups {
+		if group.weight > 0 && len(group.targets) > 0 {
+			totalWeight += int64(group.weight)
+		}
+	}
+
+	repeats := make([]int, len(groups))
+	total := 0
+	for i, group := range groups {
+		if group.weight <= 0 || len(group.targets) == 0 {
+			continue
+		}
+		share := int64(group.weight) * MaxTargets / totalWeight
+		repeats[i] = max(1, int(share)/len(group.targets))
+		total += repeats[i] * len(group.targets)
+	}
+
+	for total > MaxTargets {
+		largest := -1
+		for i := range repeats {
+			if repeats[i] > 1 && (largest < 0 || repeats[i] > repeats[largest]) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			// the endpoints alone exceed MaxTargets.
+			break
+		}
+		repeats[largest]--
+		total -= len(groups[largest].targets)
+	}
+	return repeats
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int64) int64 {
+	return a / gcd(a, b) * b
+}
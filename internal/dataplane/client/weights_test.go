@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func TestNormalizeBackendWeights(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		weights  []*int32
+		expected []int
+	}{
+		{
+			name:     "a single backend receives all the traffic",
+			weights:  []*int32{nil},
+			expected: []int{100},
+		},
+		{
+			name:     "unset weights default to 1",
+			weights:  []*int32{nil, ptr.To(int32(1))},
+			expected: []int{50, 50},
+		},
+		{
+			name:     "weights are proportional",
+			weights:  []*int32{ptr.To(int32(3)), ptr.To(int32(1))},
+			expected: []int{75, 25},
+		},
+		{
+			name:     "rounding still sums up to 100",
+			weights:  []*int32{ptr.To(int32(1)), ptr.To(int32(1)), ptr.To(int32(1))},
+			expected: []int{34, 33, 33},
+		},
+		{
+			name:     "a backend with a zero weight receives nothing",
+			weights:  []*int32{ptr.To(int32(0)), ptr.To(int32(2))},
+			expected: []int{0, 100},
+		},
+		{
+			name:     "no backend receives traffic when all weights are zero",
+			weights:  []*int32{ptr.To(int32(0)), ptr.To(int32(0))},
+			expected: []int{0, 0},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			refs := make([]gatewayv1alpha2.BackendRef, 0, len(tt.weights))
+			for i, weight := range tt.weights {
+				ref := newTestBackendRef(string(rune('a' + i)))
+				ref.Weight = weight
+				refs = append(refs, ref)
+			}
+
+			weights := NormalizeBackendWeights("test-namespace", refs)
+			require.Len(t, weights, len(tt.expected))
+			sum := 0
+			for i, weight := range weights {
+				assert.Equal(t, "test-namespace/"+string(rune('a'+i)), weight.Backend)
+				assert.Equal(t, tt.expected[i], weight.Percent)
+				sum += weight.Percent
+			}
+			if sum != 0 {
+				assert.Equal(t, 100, sum)
+			}
+		})
+	}
+}
+
+func TestCompileTCPRouteToDataPlaneBackend_zeroWeight(t *testing.T) {
+	ipAddrType := gatewayv1beta1.IPAddressType
+	gateway := &gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gateway", Namespace: "test-namespace"},
+		Status: gatewayv1beta1.GatewayStatus{
+			Addresses: []gatewayv1beta1.GatewayStatusAddress{{
+				Type:  &ipAddrType,
+				Value: "172.18.0.240",
+			}},
+		},
+	}
+	drained := newTestBackendRef("backend-a")
+	drained.Weight = ptr.To(int32(0))
+	tcproute := &gatewayv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-tcproute", Namespace: "test-namespace"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{
+					Name: "test-gateway",
+					Port: ptr.To(gatewayv1alpha2.PortNumber(8080)),
+				}},
+			},
+			Rules: []gatewayv1alpha2.TCPRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{drained, newTestBackendRef("backend-b")},
+			}},
+		},
+	}
+	fakeClient := fakectrlruntimeclient.
+		NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(append(
+			newTestBackend("backend-a", "10.244.0.10"),
+			newTestBackend("backend-b", "10.244.0.20")...,
+		)...).
+		Build()
+
+	targets, err := CompileTCPRouteToDataPlaneBackend(context.Background(), fakeClient, nil, tcproute, gateway)
+	require.NoError(t, err)
+	assert.Equal(t, []*Target{{Daddr: 0x0af40014, Dport: 80}}, targets.Targets)
+
+	weights := NormalizeBackendWeights(tcproute.Namespace, tcproute.Spec.Rules[0].BackendRefs)
+	assert.Equal(t, "test-namespace/backend-a=0%, test-namespace/backend-b=100%", FormatBackendWeights(weights))
+}
+
+func TestExpandWeightedTargets(t *testing.T) {
+	a := &Target{Daddr: 0x0af4000a, Dport: 80}
+	b := &Target{Daddr: 0x0af40014, Dport: 80}
+	c := &Target{Daddr: 0x0af40015, Dport: 80}
+
+	tests := []struct {
+		name     string
+		groups   []weightedTargets
+		expected []*Target
+	}{
+		{
+			name:     "equal weights keep one Target per endpoint",
+			groups:   []weightedTargets{{weight: 1, targets: []*Target{a}}, {weight: 1, targets: []*Target{b}}},
+			expected: []*Target{a, b},
+		},
+		{
+			name:     "BackendRefs with equal weights receive the same share whatever their endpoints",
+			groups:   []weightedTargets{{weight: 1, targets: []*Target{a}}, {weight: 1, targets: []*Target{b, c}}},
+			expected: []*Target{a, a, b, c},
+		},
+		{
+			name:     "the share of a BackendRef is split evenly between its endpoints",
+			groups:   []weightedTargets{{weight: 1, targets: []*Target{a, b}}, {weight: 2, targets: []*Target{c}}},
+			expected: []*Target{a, b, c, c, c, c},
+		},
+		{
+			name:     "Targets are repeated proportionally to the weights",
+			groups:   []weightedTargets{{weight: 75, targets: []*Target{a}}, {weight: 25, targets: []*Target{b}}},
+			expected: []*Target{a, a, a, b},
+		},
+		{
+			name:     "BackendRefs without weight or endpoints are ignored",
+			groups:   []weightedTargets{{weight: 0, targets: []*Target{a}}, {weight: 3}, {weight: 2, targets: []*Target{b}}},
+			expected: []*Target{b},
+		},
+		{
+			name:   "no Targets",
+			groups: []weightedTargets{{weight: 0, targets: []*Target{a}}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, expandWeightedTargets(tt.groups))
+		})
+	}
+
+	t.Run("repetitions are scaled down to fit in MaxTargets", func(t *testing.T) {
+		targets := expandWeightedTargets([]weightedTargets{{weight: 1000, targets: []*Target{a}}, {weight: 1, targets: []*Target{b}}})
+		assert.LessOrEqual(t, len(targets), MaxTargets)
+		assert.Equal(t, b, targets[len(targets)-1])
+	})
+
+	t.Run("shares are proportional to the weights with uneven endpoint counts", func(t *testing.T) {
+		endpoints := func(first uint32, n int) []*Target {
+			targets := make([]*Target, 0, n)
+			for i := 0; i < n; i++ {
+				targets = append(targets, &Target{Daddr: first + uint32(i), Dport: 80})
+			}
+			return targets
+		}
+		groups := []weightedTargets{
+			{weight: 3, targets: endpoints(0x0af40100, 2)},
+			{weight: 1, targets: endpoints(0x0af40200, 5)},
+		}
+		counts := countTargets(expandWeightedTargets(groups))
+		shares := make([]int, len(groups))
+		for i, group := range groups {
+			for _, target := range group.targets {
+				assert.Equal(t, counts[group.targets[0].Daddr], counts[target.Daddr], "the endpoints of a BackendRef receive the same traffic")
+				shares[i] += counts[target.Daddr]
+			}
+		}
+		assert.Equal(t, 3*shares[1], shares[0], "the first BackendRef receives 3 times the traffic")
+	})
+
+	t.Run("many small weights keep every endpoint within MaxTargets", func(t *testing.T) {
+		groups := []weightedTargets{{weight: 1000, targets: []*Target{a}}}
+		for i := 0; i < 40; i++ {
+			first := 0x0af41000 + uint32(i)*0x10
+			groups = append(groups, weightedTargets{weight: 1, targets: []*Target{
+				{Daddr: first, Dport: 80}, {Daddr: first + 1, Dport: 80}, {Daddr: first + 2, Dport: 80},
+			}})
+		}
+		targets := expandWeightedTargets(groups)
+		assert.Len(t, targets, MaxTargets)
+		counts := countTargets(targets)
+		assert.Len(t, counts, 121, "every endpoint keeps at least one Target")
+		assert.Equal(t, MaxTargets-120, counts[a.Daddr])
+	})
+
+	t.Run("endpoints exceeding MaxTargets are all kept", func(t *testing.T) {
+		many := make([]*Target, 0, MaxTargets+2)
+		for i := 0; i < MaxTargets+2; i++ {
+			many = append(many, &Target{Daddr: 0x0af40100 + uint32(i), Dport: 80})
+		}
+		targets := expandWeightedTargets([]weightedTargets{{weight: 2, targets: many}, {weight: 1, targets: []*Target{a}}})
+		assert.Len(t, targets, MaxTargets+3)
+	})
+}
+
+// countTargets returns the number of times each address appears in the
+// provided Targets.
+func countTargets(targets []*Target) map[uint32]int {
+	counts := map[uint32]int{}
+	for _, target := range targets {
+		counts[target.Daddr]++
+	}
+	return counts
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// -----------------------------------------------------------------------------
+// Route Metrics
+// -----------------------------------------------------------------------------
+
+const (
+	// CompileFailureReasonBackendNotFound indicates that a Service or Endpoints
+	// referenced by a route could not be found.
+	CompileFailureReasonBackendNotFound = "BackendNotFound"
+
+	// CompileFailureReasonNoHealthyBackends indicates that none of the backends
+	// referenced by a route had ready endpoints.
+	CompileFailureReasonNoHealthyBackends = "NoHealthyBackends"
+
+	// CompileFailureReasonGatewayNotReady indicates that the Gateway the route
+	// is attached to has no IP address yet.
+	CompileFailureReasonGatewayNotReady = "GatewayNotReady"
+
+	// CompileFailureReasonOther is used for any other compilation failure.
+	CompileFailureReasonOther = "Other"
+)
+
+var (
+	// RouteBackends is the number of healthy backend Targets the route was
+	// last compiled to.
+	RouteBackends = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blixt_route_backends",
+		Help: "Number of healthy backends the route was compiled to for the dataplane.",
+	}, []string{"namespace", "name", "kind"})
+
+	// RouteCompileFailures counts the failures to compile a route to dataplane
+	// Targets, by route kind and reason.
+	RouteCompileFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blixt_route_compile_failures_total",
+		Help: "Total number of failures to compile a route for the dataplane.",
+	}, []string{"kind", "reason"})
+)
+
+// -----------------------------------------------------------------------------
+// Controller Metrics
+// -----------------------------------------------------------------------------
+
+// LastSuccessfulReconcile is the time at which each controller last completed
+// a Reconcile without error, which allows alerting on stuck controllers.
+var LastSuccessfulReconcile = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "blixt_controller_last_successful_reconcile_timestamp_seconds",
+	Help: "Unix timestamp of the last successful reconcile of the controller.",
+}, []string{"controller"})
+
+// -----------------------------------------------------------------------------
+// Dataplane Metrics
+// -----------------------------------------------------------------------------
+
+// DataPlaneClientEjected indicates whether the connection to each dataplane
+// Pod is ejected from the updates fan-out because of repeated failures.
+var DataPlaneClientEjected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "blixt_dataplane_client_ejected",
+	Help: "Whether the dataplane client is ejected from updates after repeated failures (1) or not (0).",
+}, []string{"pod"})
+
+// DataPlaneConnectFailures counts the failures to reach each dataplane Pod,
+// when it's connected to or when a call to it fails because it's unreachable.
+var DataPlaneConnectFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "blixt_dataplane_connect_failures_total",
+	Help: "Total number of failures to connect to the dataplane Pod.",
+}, []string{"pod"})
+
+// DataPlaneUnreachable indicates whether each ready dataplane Pod currently
+// can't be reached.
+var DataPlaneUnreachable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "blixt_dataplane_unreachable",
+	Help: "Whether the ready dataplane Pod could not be connected to (1) or not (0).",
+}, []string{"pod"})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		RouteBackends,
+		RouteCompileFailures,
+		LastSuccessfulReconcile,
+		DataPlaneClientEjected,
+		DataPlaneConnectFailures,
+		DataPlaneUnreachable,
+	)
+}
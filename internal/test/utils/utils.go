@@ -19,6 +19,7 @@ package utils
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"testing"
@@ -39,8 +40,17 @@ import (
 	"github.com/kubernetes-sigs/blixt/pkg/vars"
 )
 
+// BlixtReadinessTimeout is the default timeout of WaitForBlixtReadiness.
 const BlixtReadinessTimeout = time.Minute * 7
 
+// blixtReadinessPollInterval is the period at which WaitForBlixtReadiness
+// checks whether the Blixt components are ready.
+const blixtReadinessPollInterval = time.Second
+
+// ErrBlixtReadinessTimeout is returned by WaitForBlixtReadiness when the Blixt
+// components aren't ready before the timeout.
+var ErrBlixtReadinessTimeout = errors.New("timeout waiting for blixt components exceeded")
+
 // NewBytesBufferLogger creates a standard logger with a *bytes.Buffer as the
 // output wrapped in a logr.Logger implementation to provide to reconcilers.
 func NewBytesBufferLogger() (logr.Logger, *bytes.Buffer) {
@@ -90,51 +100,53 @@ func NewFakeClientWithGatewayClasses(initObjects ...client.Object) (gatewayv1bet
 
 // WaitForBlixtReadiness waits for Blixt to be ready in the provided testing
 // environment (but deploying Blixt is expected to have already been handled
-// elsewhere).
-func WaitForBlixtReadiness(ctx context.Context, env environments.Environment) error {
-	ticker := time.NewTicker(BlixtReadinessTimeout)
+// elsewhere). It waits up to the provided timeout, or BlixtReadinessTimeout if
+// it's zero, after which the diagnostics of the cluster are dumped and an
+// ErrBlixtReadinessTimeout error is returned.
+func WaitForBlixtReadiness(ctx context.Context, env environments.Environment, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = BlixtReadinessTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(blixtReadinessPollInterval)
+	defer ticker.Stop()
 	for {
+		var controlplaneReady, dataplaneReady bool
+
+		controlplane, err := env.Cluster().Client().AppsV1().Deployments(vars.DefaultNamespace).Get(ctx, vars.DefaultControlPlaneDeploymentName, metav1.GetOptions{})
+		if err != nil {
+			fmt.Printf("Error while checking controlplane components: %s\n", err)
+			return err
+		}
+		if controlplane.Status.AvailableReplicas > 0 {
+			controlplaneReady = true
+		}
+
+		dataplane, err := env.Cluster().Client().AppsV1().DaemonSets(vars.DefaultNamespace).Get(ctx, vars.DefaultDataPlaneDaemonSetName, metav1.GetOptions{})
+		if err != nil {
+			fmt.Printf("Error while checking dataplane components: %s\n", err)
+			return err
+		}
+		if dataplane.Status.NumberAvailable > 0 {
+			dataplaneReady = true
+		}
+
+		if controlplaneReady && dataplaneReady {
+			return nil
+		}
+
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			fmt.Printf("ERROR: timed out waiting for blixt readiness for cluster %s. dumping diagnostics\n", env.Cluster().Name())
 			dir, err := env.Cluster().DumpDiagnostics(ctx, "wait-for-blixt-readiness-timeout")
 			if err != nil {
-				return fmt.Errorf("error after timeout waiting for blixt components when trying to dump diagnostics: %w", err)
+				return fmt.Errorf("%w after %s, and dumping diagnostics failed: %w", ErrBlixtReadinessTimeout, timeout, err)
 			}
-			return fmt.Errorf("timeout waiting for blixt components exceeded, diagnostics dumped to %s", dir)
+			return fmt.Errorf("%w after %s, diagnostics dumped to %s", ErrBlixtReadinessTimeout, timeout, dir)
 		case <-ctx.Done():
-			if err := ctx.Err(); err != nil {
-				return fmt.Errorf("context completed while waiting for components: %w", err)
-			}
-			dir, diagErr := env.Cluster().DumpDiagnostics(ctx, "wait-for-blixt-readiness-context-completed")
-			if diagErr != nil {
-				return fmt.Errorf("error after timeout waiting for blixt components when trying to dump diagnostics: %w", diagErr)
-			}
-			return fmt.Errorf("context completed while waiting for components, diagnostics dumped to %s", dir)
-		default:
-			var controlplaneReady, dataplaneReady bool
-
-			controlplane, err := env.Cluster().Client().AppsV1().Deployments(vars.DefaultNamespace).Get(ctx, vars.DefaultControlPlaneDeploymentName, metav1.GetOptions{})
-			if err != nil {
-				fmt.Printf("Error while checking controlplane components: %s\n", err)
-				return err
-			}
-			if controlplane.Status.AvailableReplicas > 0 {
-				controlplaneReady = true
-			}
-
-			dataplane, err := env.Cluster().Client().AppsV1().DaemonSets(vars.DefaultNamespace).Get(ctx, vars.DefaultDataPlaneDaemonSetName, metav1.GetOptions{})
-			if err != nil {
-				fmt.Printf("Error while checking dataplane components: %s\n", err)
-				return err
-			}
-			if dataplane.Status.NumberAvailable > 0 {
-				dataplaneReady = true
-			}
-
-			if controlplaneReady && dataplaneReady {
-				return nil
-			}
+			return fmt.Errorf("context completed while waiting for components: %w", ctx.Err())
+		case <-ticker.C:
 		}
 	}
 }
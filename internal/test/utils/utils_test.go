@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kong/kubernetes-testing-framework/pkg/clusters"
+	"github.com/kong/kubernetes-testing-framework/pkg/environments"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// fakeEnvironment is a testing environment whose cluster is served by an
+// httptest API server, the methods WaitForBlixtReadiness doesn't use are not
+// implemented.
+type fakeEnvironment struct {
+	environments.Environment
+	cluster *fakeCluster
+}
+
+func (e *fakeEnvironment) Cluster() clusters.Cluster {
+	return e.cluster
+}
+
+// fakeCluster records the diagnostics it's asked to dump.
+type fakeCluster struct {
+	clusters.Cluster
+	client      *kubernetes.Clientset
+	diagnostics []string
+}
+
+func (c *fakeCluster) Name() string {
+	return "fake-cluster"
+}
+
+func (c *fakeCluster) Client() *kubernetes.Clientset {
+	return c.client
+}
+
+func (c *fakeCluster) DumpDiagnostics(_ context.Context, meta string) (string, error) {
+	c.diagnostics = append(c.diagnostics, meta)
+	return "/tmp/" + meta, nil
+}
+
+// newFakeEnvironment returns a testing environment whose API server serves
+// the blixt controlplane Deployment and dataplane DaemonSet, which are
+// available if ready is true.
+func newFakeEnvironment(t *testing.T, ready bool) *fakeEnvironment {
+	var available int32
+	if ready {
+		available = 1
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var obj interface{}
+		switch {
+		case strings.Contains(r.URL.Path, "/deployments/"):
+			obj = &appsv1.Deployment{Status: appsv1.DeploymentStatus{AvailableReplicas: available}}
+		case strings.Contains(r.URL.Path, "/daemonsets/"):
+			obj = &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{NumberAvailable: available}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(obj)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return &fakeEnvironment{cluster: &fakeCluster{client: client}}
+}
+
+func TestWaitForBlixtReadiness(t *testing.T) {
+	ctx := context.Background()
+
+	t.Log("ready components are waited for without dumping diagnostics")
+	env := newFakeEnvironment(t, true)
+	require.NoError(t, WaitForBlixtReadiness(ctx, env, time.Second))
+	require.Empty(t, env.cluster.diagnostics)
+
+	t.Log("components which never get ready time out with diagnostics")
+	env = newFakeEnvironment(t, false)
+	start := time.Now()
+	err := WaitForBlixtReadiness(ctx, env, 100*time.Millisecond)
+	require.ErrorIs(t, err, ErrBlixtReadinessTimeout)
+	require.Contains(t, err.Error(), "/tmp/wait-for-blixt-readiness-timeout")
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, []string{"wait-for-blixt-readiness-timeout"}, env.cluster.diagnostics)
+
+	t.Log("the wait ends when the context is done")
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = WaitForBlixtReadiness(ctx, newFakeEnvironment(t, false), time.Minute)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Setup registers a TracerProvider which logs the spans it records with the
+// provided logger, and the W3C trace context propagator, so that the spans
+// covering the compilation of the routes and the dataplane updates are
+// recorded and their context is propagated to the dataplane. It returns a
+// function flushing the remaining spans, to be called before exiting.
+func Setup(logger logr.Logger) func(context.Context) error {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(&logExporter{log: logger}))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown
+}
+
+// logExporter is a SpanExporter logging the spans along with their duration.
+type logExporter struct {
+	log logr.Logger
+}
+
+// ExportSpans logs the provided spans.
+func (e *logExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		kv := []interface{}{
+			"name", span.Name(),
+			"traceID", span.SpanContext().TraceID().String(),
+			"spanID", span.SpanContext().SpanID().String(),
+			"duration", span.EndTime().Sub(span.StartTime()).String(),
+			"status", span.Status().Code.String(),
+		}
+		if span.Parent().IsValid() {
+			kv = append(kv, "parentSpanID", span.Parent().SpanID().String())
+		}
+		for _, attr := range span.Attributes() {
+			kv = append(kv, string(attr.Key), attr.Value.Emit())
+		}
+		e.log.Info("span", kv...)
+	}
+	return nil
+}
+
+// Shutdown does nothing, as the spans are logged as they're exported.
+func (e *logExporter) Shutdown(_ context.Context) error {
+	return nil
+}
@@ -19,16 +19,27 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"go.uber.org/zap/zapcore"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -37,7 +48,10 @@ import (
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/kubernetes-sigs/blixt/controllers"
+	"github.com/kubernetes-sigs/blixt/internal/config"
 	"github.com/kubernetes-sigs/blixt/internal/dataplane/client"
+	"github.com/kubernetes-sigs/blixt/internal/tracing"
+	"github.com/kubernetes-sigs/blixt/pkg/vars"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -58,22 +72,174 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var namedAddressAnnotation string
+	var disableMetalLBEndpointsHack bool
+	var keepaliveConfig client.KeepaliveConfig
+	var dataplaneMaxMessageSize int
+	var dataplaneMaxConcurrentCalls int
+	var gatewayClassResyncPeriod time.Duration
+	var lbProvider string
+	var watchNamespace string
+	var configFile string
+	var externalNameRefreshInterval time.Duration
+	var orphanedVIPsPruneInterval time.Duration
+	var serviceReadyRequeueInterval, serviceReadyMaxRequeueInterval time.Duration
+	var partialUpdateRequeueInterval time.Duration
+	var rateLimiter controllers.RateLimiterConfig
+	var enableTracing bool
+	var adminAddr, adminTokenFile string
+	var enablePprof bool
+	var pprofAddr string
+	var enableHTTPRoute bool
+	flag.StringVar(&configFile, "config", "",
+		"Path to the control plane configuration file, usually a mounted ConfigMap. "+
+			"Flags which are explicitly set take precedence over the configuration file.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&namedAddressAnnotation, "named-address-annotation", vars.DefaultNamedAddressAnnotation,
+		"The Service annotation which the name of a Gateway address of type NamedAddress is mapped to. "+
+			"An empty value disables support for NamedAddress.")
+	flag.StringVar(&lbProvider, "lb-provider", string(controllers.LoadBalancerProviderMetalLB),
+		"The provider of LoadBalancer Services for Gateways, one of: metallb, cloud, none. "+
+			"Only the metallb provider uses MetalLB events to detect address allocation failures and manufactures Endpoints for Gateway Services.")
+	flag.StringVar(&watchNamespace, "watch-namespaces", "",
+		"Comma separated list of namespaces to watch Gateways and routes in. All namespaces are watched if empty.")
+	flag.StringVar(&watchNamespace, "watch-namespace", "",
+		"Deprecated alias of --watch-namespaces.")
+	flag.BoolVar(&disableMetalLBEndpointsHack, "disable-metallb-endpoints-hack", false,
+		"Disable the creation of Endpoints for Gateway Services, which works around a MetalLB L2 mode issue. "+
+			"Clusters which don't use MetalLB can safely disable it.")
+	flag.DurationVar(&keepaliveConfig.Time, "dataplane-keepalive-time", 0,
+		"The period of inactivity after which connections to the dataplane are pinged to check they're alive. "+
+			"Zero disables keepalive pings.")
+	flag.DurationVar(&keepaliveConfig.Timeout, "dataplane-keepalive-timeout", 20*time.Second,
+		"How long to wait for a keepalive ping to the dataplane to be acknowledged before closing the connection.")
+	flag.BoolVar(&keepaliveConfig.PermitWithoutStream, "dataplane-keepalive-permit-without-stream", false,
+		"Send keepalive pings to the dataplane even when there are no active requests.")
+	flag.IntVar(&dataplaneMaxMessageSize, "dataplane-max-message-size", client.DefaultMaxMessageSize,
+		"The maximum size in bytes of the messages sent to and received from the dataplane. "+
+			"It must not be larger than the maximum message size of the dataplane.")
+	flag.IntVar(&dataplaneMaxConcurrentCalls, "dataplane-max-concurrent-calls", client.DefaultMaxConcurrentCalls,
+		"The maximum number of dataplane instances called concurrently when pushing an update. "+
+			"Zero removes the limit.")
+	flag.DurationVar(&gatewayClassResyncPeriod, "gatewayclass-resync-period", time.Minute,
+		"The period after which managed GatewayClasses are reconciled again to ensure they're accepted. "+
+			"Zero disables the periodic resync.")
+	flag.DurationVar(&externalNameRefreshInterval, "externalname-refresh-interval", client.DefaultExternalNameRefreshInterval,
+		"The period after which the external names of ExternalName Service backends are resolved again, "+
+			"unless the TTL of their DNS records is shorter.")
+	flag.DurationVar(&orphanedVIPsPruneInterval, "orphaned-vips-prune-interval", controllers.DefaultOrphanedVIPsPruneInterval,
+		"The period after which the VIPs programmed into the dataplane which don't correspond to any route are deleted. "+
+			"Zero disables the pruning.")
+	flag.DurationVar(&serviceReadyRequeueInterval, "service-ready-requeue-interval", controllers.DefaultServiceReadyRequeueInterval,
+		"The initial period after which a Gateway whose Service is not ready yet is reconciled again. "+
+			"It's doubled on each reconcile while the Service stays not ready.")
+	flag.DurationVar(&serviceReadyMaxRequeueInterval, "service-ready-max-requeue-interval", controllers.DefaultServiceReadyMaxRequeueInterval,
+		"The maximum period after which a Gateway whose Service is not ready yet is reconciled again.")
+	flag.DurationVar(&partialUpdateRequeueInterval, "partial-update-requeue-interval", controllers.DefaultPartialUpdateRequeueInterval,
+		"The period after which a route whose update failed on some of the dataplane instances is reconciled again, "+
+			"to retry the update on those instances only.")
+	flag.DurationVar(&rateLimiter.BaseDelay, "rate-limiter-base-delay", controllers.DefaultRateLimiterBaseDelay,
+		"The delay after which a request which failed to be reconciled is reconciled again, by each controller. "+
+			"It's doubled on each consecutive failure of the request.")
+	flag.DurationVar(&rateLimiter.MaxDelay, "rate-limiter-max-delay", controllers.DefaultRateLimiterMaxDelay,
+		"The maximum delay after which a request which failed to be reconciled is reconciled again, by each controller.")
+	flag.IntVar(&rateLimiter.BucketSize, "rate-limiter-bucket-size", controllers.DefaultRateLimiterBucketSize,
+		"The number of requests each controller can queue in a burst, before being limited to 10 requests per second.")
+	flag.StringVar(&adminAddr, "admin-bind-address", "127.0.0.1:8082",
+		"The address the admin endpoints bind to, which include a POST "+controllers.ResyncPath+" endpoint re-pushing "+
+			"the whole dataplane configuration. Set it to \"0\" to disable the admin endpoints.")
+	flag.StringVar(&adminTokenFile, "admin-token-file", "",
+		"A file containing a token which the requests to the admin endpoints must carry as a bearer token. "+
+			"It's required to bind the admin endpoints to a non-loopback address.")
+	flag.BoolVar(&enablePprof, "enable-pprof", false,
+		"Serve the net/http/pprof profiles of the control plane under "+controllers.PprofPath+" on the --pprof-bind-address.")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", controllers.DefaultPprofBindAddress,
+		"The address the pprof endpoints bind to when enabled. They're not protected, so it should be a loopback address.")
+	flag.BoolVar(&enableHTTPRoute, "enable-httproute", false,
+		"Advertise HTTPRoute as a supported route kind of the HTTP and HTTPS listeners, which are programmed as TCP listeners. "+
+			"HTTPRoutes aren't implemented, it's only meant for the Gateway API conformance tests.")
+	flag.BoolVar(&enableTracing, "enable-tracing", false,
+		"Record OpenTelemetry spans around the compilation of the routes and the dataplane updates, which are logged, "+
+			"and propagate their context to the dataplane.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	controlPlaneConfig, configErr := loadConfig(configFile, lbProvider, watchNamespace)
+	if configErr == nil && controlPlaneConfig.LogVerbosity > 0 && !isFlagSet("zap-log-level") {
+		opts.Level = zapcore.Level(-controlPlaneConfig.LogVerbosity)
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if enableTracing {
+		shutdownTracing := tracing.Setup(ctrl.Log.WithName("tracing"))
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				setupLog.Error(err, "unable to flush the recorded spans")
+			}
+		}()
+	}
+
+	if configErr != nil {
+		setupLog.Error(configErr, "invalid configuration")
+		os.Exit(1)
+	}
+
+	loadBalancerProvider, err := controllers.ParseLoadBalancerProvider(controlPlaneConfig.LBProvider)
+	if err != nil {
+		setupLog.Error(err, "invalid lbProvider")
+		os.Exit(1)
+	}
+
+	if externalNameRefreshInterval <= 0 {
+		setupLog.Error(fmt.Errorf("must be positive, got %s", externalNameRefreshInterval), "invalid --externalname-refresh-interval")
+		os.Exit(1)
+	}
+	var hostResolver client.HostResolver = client.NetHostResolver{}
+	if dnsResolver, err := client.NewSystemDNSHostResolver(); err != nil {
+		setupLog.Info("the TTL of the ExternalName Service records can't be known, they're resolved every --externalname-refresh-interval", "error", err.Error())
+	} else {
+		hostResolver = dnsResolver
+	}
+	// the resolver is shared by the route controllers, so that an external
+	// name referenced by both kinds of routes is resolved once.
+	externalNameResolver := client.NewExternalNameResolver(hostResolver, externalNameRefreshInterval)
+
+	if serviceReadyRequeueInterval <= 0 || serviceReadyMaxRequeueInterval < serviceReadyRequeueInterval {
+		setupLog.Error(fmt.Errorf("the initial interval %s must be positive and not exceed the maximum %s", serviceReadyRequeueInterval, serviceReadyMaxRequeueInterval),
+			"invalid --service-ready-requeue-interval or --service-ready-max-requeue-interval")
+		os.Exit(1)
+	}
+
+	if rateLimiter.BaseDelay <= 0 || rateLimiter.MaxDelay < rateLimiter.BaseDelay || rateLimiter.BucketSize < 1 {
+		setupLog.Error(fmt.Errorf("the base delay %s must be positive and not exceed the maximum delay %s, and the bucket size %d must be at least 1",
+			rateLimiter.BaseDelay, rateLimiter.MaxDelay, rateLimiter.BucketSize),
+			"invalid --rate-limiter-base-delay, --rate-limiter-max-delay or --rate-limiter-bucket-size")
+		os.Exit(1)
+	}
+
+	if enablePprof && (pprofAddr == metricsAddr || pprofAddr == probeAddr || pprofAddr == adminAddr) {
+		setupLog.Error(fmt.Errorf("%s is already bound by the metrics, health probe or admin endpoints", pprofAddr), "invalid --pprof-bind-address")
+		os.Exit(1)
+	}
+
+	controllerName := gatewayv1beta1.GatewayController(controlPlaneConfig.ControllerName)
+	watchNamespaces := controlPlaneConfig.WatchNamespaces()
+
 	cfg := ctrl.GetConfigOrDie()
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme,
+		Cache:  cacheOptions(watchNamespaces),
+		Controller: ctrlconfig.Controller{
+			MaxConcurrentReconciles: controlPlaneConfig.MaxConcurrentReconciles,
+		},
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
@@ -97,14 +263,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	clientsManager, err := client.NewBackendsClientManager(cfg)
+	clientsManager, err := client.NewBackendsClientManager(cfg, controlPlaneConfig.DataplaneAPIPort, keepaliveConfig)
 	if err != nil {
 		setupLog.Error(err, "unable to create backends client manager")
 		os.Exit(1)
 	}
+	clientsManager.SetMaxMessageSize(dataplaneMaxMessageSize)
+	clientsManager.SetMaxConcurrentCalls(dataplaneMaxConcurrentCalls)
+	if len(controlPlaneConfig.DataplaneNodeSelector) > 0 {
+		// the placement reads the Nodes with the clients manager locked, the
+		// Node informer is registered now so that it's synced with the cache
+		// instead of on the first read.
+		if _, err := mgr.GetCache().GetInformer(context.Background(), &corev1.Node{}); err != nil {
+			setupLog.Error(err, "unable to watch the Nodes of the dataplane Pods")
+			os.Exit(1)
+		}
+		clientsManager.SetPlacementStrategy(client.NodeSelectorPlacement{
+			Reader:   mgr.GetClient(),
+			Selector: labels.SelectorFromSet(controlPlaneConfig.DataplaneNodeSelector),
+		})
+	}
 	defer clientsManager.Close()
 
 	dataplaneReconciler := controllers.NewDataplaneReconciler(mgr.GetClient(), mgr.GetScheme(), clientsManager)
+	dataplaneReconciler.RateLimiter = rateLimiter
 	if err = dataplaneReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Dataplane")
 		os.Exit(1)
@@ -114,37 +296,103 @@ func main() {
 	udpReconcileRequestChan, tcpReconcileRequestChan := tee(ctx, dataplaneReconciler.GetUpdates())
 
 	if err = (&controllers.GatewayReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                         mgr.GetClient(),
+		Scheme:                         mgr.GetScheme(),
+		NamedAddressAnnotation:         namedAddressAnnotation,
+		DisableMetalLBEndpointsHack:    disableMetalLBEndpointsHack,
+		LBProvider:                     loadBalancerProvider,
+		BackendsClientManager:          clientsManager,
+		DataPlaneDaemonSet:             &types.NamespacedName{Namespace: vars.DefaultNamespace, Name: vars.DefaultDataPlaneDaemonSetName},
+		DataPlaneReadiness:             dataplaneReconciler,
+		DataPlaneUpdates:               dataplaneReconciler.GetGatewayUpdates(),
+		WatchNamespaces:                watchNamespaces,
+		ControllerName:                 controllerName,
+		ServiceReadyRequeueInterval:    serviceReadyRequeueInterval,
+		ServiceReadyMaxRequeueInterval: serviceReadyMaxRequeueInterval,
+		RateLimiter:                    rateLimiter,
+		EnableHTTPRoute:                enableHTTPRoute,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Gateway")
 		os.Exit(1)
 	}
 	if err = (&controllers.GatewayClassReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		ResyncPeriod:   gatewayClassResyncPeriod,
+		ControllerName: controllerName,
+		RateLimiter:    rateLimiter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GatewayClass")
 		os.Exit(1)
 	}
 	if err = (&controllers.UDPRouteReconciler{
-		Client:                     mgr.GetClient(),
-		Scheme:                     mgr.GetScheme(),
-		ClientReconcileRequestChan: udpReconcileRequestChan,
-		BackendsClientManager:      clientsManager,
+		Client:                       mgr.GetClient(),
+		Scheme:                       mgr.GetScheme(),
+		ClientReconcileRequestChan:   udpReconcileRequestChan,
+		BackendsClientManager:        clientsManager,
+		ExternalNameResolver:         externalNameResolver,
+		APIReader:                    mgr.GetAPIReader(),
+		WatchNamespaces:              watchNamespaces,
+		ControllerName:               controllerName,
+		DeletionGracePeriod:          controlPlaneConfig.DeletionGracePeriod.Duration,
+		PartialUpdateRequeueInterval: partialUpdateRequeueInterval,
+		RateLimiter:                  rateLimiter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "UDPRoute")
 		os.Exit(1)
 	}
 	if err = (&controllers.TCPRouteReconciler{
-		Client:                     mgr.GetClient(),
-		Scheme:                     mgr.GetScheme(),
-		ClientReconcileRequestChan: tcpReconcileRequestChan,
-		BackendsClientManager:      clientsManager,
+		Client:                       mgr.GetClient(),
+		Scheme:                       mgr.GetScheme(),
+		ClientReconcileRequestChan:   tcpReconcileRequestChan,
+		BackendsClientManager:        clientsManager,
+		ExternalNameResolver:         externalNameResolver,
+		APIReader:                    mgr.GetAPIReader(),
+		WatchNamespaces:              watchNamespaces,
+		ControllerName:               controllerName,
+		DeletionGracePeriod:          controlPlaneConfig.DeletionGracePeriod.Duration,
+		PartialUpdateRequeueInterval: partialUpdateRequeueInterval,
+		RateLimiter:                  rateLimiter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TCPRoute")
 		os.Exit(1)
 	}
+	if orphanedVIPsPruneInterval > 0 {
+		if err = (&controllers.OrphanedVIPsReconciler{
+			Client:          mgr.GetClient(),
+			Pruner:          clientsManager,
+			Interval:        orphanedVIPsPruneInterval,
+			WatchNamespaces: watchNamespaces,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OrphanedVIPs")
+			os.Exit(1)
+		}
+	}
+	if adminAddr != "0" {
+		var adminToken string
+		if adminTokenFile != "" {
+			if adminToken, err = controllers.ReadAdminToken(adminTokenFile); err != nil {
+				setupLog.Error(err, "unable to read the admin token")
+				os.Exit(1)
+			}
+		}
+		if err = controllers.ValidateAdminAddress(adminAddr, adminToken); err != nil {
+			setupLog.Error(err, "invalid --admin-bind-address")
+			os.Exit(1)
+		}
+		mux := http.NewServeMux()
+		mux.Handle(controllers.ResyncPath, controllers.NewResyncHandler(dataplaneReconciler, adminToken, mgr.Elected()))
+		if err = mgr.Add(&controllers.AdminServer{Addr: adminAddr, Handler: mux}); err != nil {
+			setupLog.Error(err, "unable to set up the admin endpoints")
+			os.Exit(1)
+		}
+	}
+	if pprofServer := controllers.NewPprofServer(enablePprof, pprofAddr); pprofServer != nil {
+		if err = mgr.Add(pprofServer); err != nil {
+			setupLog.Error(err, "unable to set up the pprof endpoints")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -163,6 +411,61 @@ func main() {
 	}
 }
 
+// loadConfig loads the control plane configuration from the provided file, if
+// any, and overrides it with the flags which were explicitly set.
+func loadConfig(path, lbProvider, watchNamespace string) (*config.Config, error) {
+	controlPlaneConfig, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "lb-provider":
+			controlPlaneConfig.LBProvider = lbProvider
+		case "watch-namespace", "watch-namespaces":
+			controlPlaneConfig.WatchNamespace = watchNamespace
+		}
+	})
+
+	return controlPlaneConfig, controlPlaneConfig.Validate()
+}
+
+// isFlagSet indicates whether the flag with the provided name was explicitly
+// set on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// cacheOptions returns the manager cache options restricting the cache to the
+// provided namespaces, if any. The dataplane DaemonSet and its Pods are always
+// watched in the dataplane namespace.
+func cacheOptions(watchNamespaces []string) cache.Options {
+	if len(watchNamespaces) == 0 {
+		return cache.Options{}
+	}
+
+	defaultNamespaces := make(map[string]cache.Config, len(watchNamespaces))
+	for _, namespace := range watchNamespaces {
+		defaultNamespaces[namespace] = cache.Config{}
+	}
+	dataplaneNamespaces := map[string]cache.Config{vars.DefaultNamespace: {}}
+
+	return cache.Options{
+		DefaultNamespaces: defaultNamespaces,
+		ByObject: map[ctrlclient.Object]cache.ByObject{
+			&appsv1.DaemonSet{}: {Namespaces: dataplaneNamespaces},
+			&corev1.Pod{}:       {Namespaces: dataplaneNamespaces},
+		},
+	}
+}
+
 // Tee consumes the received channel and mirrors the messages into 2 new channels.
 func tee(ctx context.Context, in <-chan event.GenericEvent) (_, _ <-chan event.GenericEvent) {
 	out1, out2 := make(chan event.GenericEvent), make(chan event.GenericEvent)
@@ -22,7 +22,9 @@ package vars
 
 const (
 	// GatewayClassControllerName is the unique identifier indicating controller
-	// responsible for relevant resources.
+	// responsible for relevant resources. It's mirrored by the
+	// GATEWAY_CLASS_CONTROLLER_NAME constant of the Rust controlplane and by
+	// the manifests, which must be kept in sync.
 	GatewayClassControllerName = "gateway.networking.k8s.io/blixt"
 )
 
@@ -54,4 +56,9 @@ const (
 	// DefaultDataPlaneComponentLabel indicates the label value that can be used
 	// to identify dataplane Pods (by default).
 	DefaultDataPlaneComponentLabel = "dataplane"
+
+	// DefaultNamedAddressAnnotation is the Service annotation that the name of
+	// a Gateway address of type NamedAddress is mapped to (by default), which
+	// requests an IP from the MetalLB address pool of that name.
+	DefaultNamedAddressAnnotation = "metallb.universe.tf/address-pool"
 )
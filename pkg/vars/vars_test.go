@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vars
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatewayClassControllerName_sharedValue(t *testing.T) {
+	for _, tt := range []struct {
+		file    string
+		pattern *regexp.Regexp
+	}{
+		{
+			file:    "../../controlplane/src/lib.rs",
+			pattern: regexp.MustCompile(`pub const GATEWAY_CLASS_CONTROLLER_NAME: &str = "([^"]*)";`),
+		},
+		{
+			file:    "../../config/manager/controller_manager_config.yaml",
+			pattern: regexp.MustCompile(`(?m)^controllerName: (\S+)$`),
+		},
+		{
+			file:    "../../config/samples/gateway_v1.yaml",
+			pattern: regexp.MustCompile(`(?m)^\s+controllerName: (\S+)$`),
+		},
+		{
+			file:    "../../config/samples/tcproute/gateway.yaml",
+			pattern: regexp.MustCompile(`(?m)^\s+controllerName: (\S+)$`),
+		},
+		{
+			file:    "../../config/samples/udproute/gateway.yaml",
+			pattern: regexp.MustCompile(`(?m)^\s+controllerName: (\S+)$`),
+		},
+	} {
+		tt := tt
+		t.Run(tt.file, func(t *testing.T) {
+			content, err := os.ReadFile(tt.file)
+			require.NoError(t, err)
+			match := tt.pattern.FindSubmatch(content)
+			require.NotNil(t, match, "the controller name should be defined in %s", tt.file)
+			require.Equal(t, GatewayClassControllerName, string(match[1]))
+		})
+	}
+}
@@ -180,7 +180,7 @@ func TestMain(m *testing.M) {
 	}
 
 	fmt.Println("INFO: waiting for Blixt component readiness")
-	exitOnErr(testutils.WaitForBlixtReadiness(ctx, env))
+	exitOnErr(testutils.WaitForBlixtReadiness(ctx, env, testutils.BlixtReadinessTimeout))
 
 	fmt.Println("INFO: waiting for Dataplane readiness")
 	exitOnErr(waitForDataplaneReadiness(ctx, env))
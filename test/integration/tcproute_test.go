@@ -38,9 +38,10 @@ import (
 )
 
 const (
-	tcprouteSampleKustomize = "../../config/tests/tcproute"
-	tcprouteRRKustomize     = "../../config/tests/tcproute-rr"
-	tcprouteSampleName      = "blixt-tcproute-sample"
+	tcprouteSampleKustomize   = "../../config/tests/tcproute"
+	tcprouteRRKustomize       = "../../config/tests/tcproute-rr"
+	tcprouteWeightedKustomize = "../../config/tests/tcproute-weighted"
+	tcprouteSampleName        = "blixt-tcproute-sample"
 )
 
 var tcpServerNames = []string{"blixt-tcproute-sample", "tcproute-rr-v1", "tcproute-rr-v2"}
@@ -230,6 +231,88 @@ func TestTCPRouteRoundRobin(t *testing.T) {
 	}, time.Minute, time.Second)
 }
 
+func TestTCPRouteWeightedBackends(t *testing.T) {
+	tcpRouteWeightedCleanupKey := "tcprouteweighted"
+	defer func() {
+		testutils.DumpDiagnosticsIfFailed(ctx, t, env.Cluster())
+		if err := runCleanup(tcpRouteWeightedCleanupKey); err != nil {
+			t.Errorf("cleanup failed: %s", err)
+		}
+	}()
+
+	t.Log("deploying config/tests/tcproute-weighted kustomize")
+	require.NoError(t, clusters.KustomizeDeployForCluster(ctx, env.Cluster(), tcprouteWeightedKustomize))
+	addCleanup(tcpRouteWeightedCleanupKey, func(ctx context.Context) error {
+		cleanupLog("cleaning up config/tests/tcproute-weighted kustomize")
+		return clusters.KustomizeDeleteForCluster(ctx, env.Cluster(), tcprouteWeightedKustomize, "--ignore-not-found=true")
+	})
+
+	t.Log("waiting for Gateway to have an address")
+	var gw *gatewayv1beta1.Gateway
+	require.Eventually(t, func() bool {
+		var err error
+		gw, err = gwclient.GatewayV1beta1().Gateways(corev1.NamespaceDefault).Get(ctx, tcprouteSampleName, metav1.GetOptions{})
+		require.NoError(t, err)
+		return len(gw.Status.Addresses) > 0
+	}, time.Minute, time.Second)
+	require.NotNil(t, gw.Status.Addresses[0].Type)
+	require.Equal(t, gatewayv1beta1.IPAddressType, *gw.Status.Addresses[0].Type)
+	gwaddr := fmt.Sprintf("%s:8080", gw.Status.Addresses[0].Value)
+
+	t.Log("waiting for TCP servers to be available")
+	weightedServerNames := []string{"tcproute-rr-v1", "tcproute-rr-v2"}
+	labelSelector := metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      "app",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   weightedServerNames,
+			},
+		},
+	}
+	require.Eventually(t, func() bool {
+		servers, err := env.Cluster().Client().AppsV1().Deployments(corev1.NamespaceDefault).List(ctx, metav1.ListOptions{
+			LabelSelector: metav1.FormatLabelSelector(&labelSelector),
+		})
+		require.NoError(t, err)
+		for _, server := range servers.Items {
+			if server.Status.AvailableReplicas <= 0 {
+				return false
+			}
+		}
+		return len(servers.Items) == len(weightedServerNames)
+	}, time.Minute, time.Second)
+
+	t.Log("waiting for the weighted TCPRoute to be programmed")
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", gwaddr)
+		if err != nil {
+			t.Logf("received error connecting to TCP server: [%s], retrying...", err)
+			return false
+		}
+		defer conn.Close()
+		return strings.HasPrefix(writeAndReadTCP(t, conn), "tcproute-rr-v")
+	}, time.Minute*5, time.Second)
+
+	t.Log("verifying that connections are distributed according to the backend weights")
+	const connections = 100
+	counts := map[string]int{}
+	for i := 0; i < connections; i++ {
+		conn, err := net.Dial("tcp", gwaddr)
+		require.NoError(t, err)
+		response := writeAndReadTCP(t, conn)
+		require.NoError(t, conn.Close())
+
+		split := strings.Split(response, ":")
+		require.Len(t, split, 2)
+		counts[split[0]]++
+	}
+	t.Logf("connections per backend: %v", counts)
+	require.Equal(t, connections, counts["tcproute-rr-v1"]+counts["tcproute-rr-v2"])
+	require.InDelta(t, 75, counts["tcproute-rr-v1"]*100/connections, 10)
+	require.InDelta(t, 25, counts["tcproute-rr-v2"]*100/connections, 10)
+}
+
 func removeName(names []string, name string) ([]string, bool) {
 	for i, v := range names {
 		if v == name {
@@ -26,7 +26,10 @@ import (
 	"io"
 	"net"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -187,6 +190,119 @@ func TestUDPRouteRoundRobin(t *testing.T) {
 	}
 }
 
+func TestUDPRouteRoundRobinConcurrency(t *testing.T) {
+	udpRouteRRConcurrencyCleanupKey := "udprouterrconcurrency"
+	defer func() {
+		testutils.DumpDiagnosticsIfFailed(ctx, t, env.Cluster())
+		if err := runCleanup(udpRouteRRConcurrencyCleanupKey); err != nil {
+			t.Errorf("cleanup failed: %s", err)
+		}
+	}()
+
+	t.Log("deploying config/samples/udproute-rr kustomize")
+	require.NoError(t, clusters.KustomizeDeployForCluster(ctx, env.Cluster(), udprouteRRSampleKustomize))
+	addCleanup(udpRouteRRConcurrencyCleanupKey, func(ctx context.Context) error {
+		cleanupLog("cleaning up config/samples/udproute-rr kustomize")
+		return clusters.KustomizeDeleteForCluster(ctx, env.Cluster(), udprouteRRSampleKustomize)
+	})
+
+	t.Log("waiting for Gateway to have an address")
+	var gw *gatewayv1beta1.Gateway
+	require.Eventually(t, func() bool {
+		var err error
+		gw, err = gwclient.GatewayV1beta1().Gateways(corev1.NamespaceDefault).Get(ctx, udprouteSampleName, metav1.GetOptions{})
+		require.NoError(t, err)
+		return len(gw.Status.Addresses) > 0
+	}, time.Minute, time.Second)
+	require.NotNil(t, gw.Status.Addresses[0].Type)
+	require.Equal(t, gatewayv1beta1.IPAddressType, *gw.Status.Addresses[0].Type)
+	gwaddr := fmt.Sprintf("%s:9875", gw.Status.Addresses[0].Value)
+
+	t.Log("waiting for udp server to be available")
+	require.Eventually(t, func() bool {
+		server, err := env.Cluster().Client().AppsV1().Deployments(corev1.NamespaceDefault).Get(ctx, udprouteSampleName, metav1.GetOptions{})
+		require.NoError(t, err)
+		return server.Status.AvailableReplicas > 0
+	}, time.Minute, time.Second)
+
+	pods, err := env.Cluster().Client().CoreV1().Pods(corev1.NamespaceDefault).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", udprouteSampleName),
+	})
+	require.NoError(t, err)
+
+	var udpServerPod corev1.Pod
+	// there might be pods that have been deleted but not garbage collected yet,
+	// so chose the one that doesn't have a deletion timestamp set on it.
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			udpServerPod = pod
+		}
+	}
+	var udpPorts []int32
+	for _, port := range udpServerPod.Spec.Containers[0].Ports {
+		if port.Protocol == corev1.ProtocolUDP {
+			udpPorts = append(udpPorts, port.ContainerPort)
+		}
+	}
+	require.Len(t, udpPorts, 3)
+
+	// the datagrams of this test are tagged with a unique prefix, so they can be
+	// told apart from the ones of other tests in the logs of the server.
+	const senders, datagramsPerSender = 20, 30
+	prefix := uuid.NewString()
+	t.Logf("sending %d datagrams from %d concurrent senders to the UDP server at %s", senders*datagramsPerSender, senders, gwaddr)
+	var wg sync.WaitGroup
+	errs := make(chan error, senders)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(sender int) {
+			defer wg.Done()
+			conn, err := net.Dial("udp", gwaddr)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer conn.Close()
+			for j := 0; j < datagramsPerSender; j++ {
+				if _, err := fmt.Fprintf(conn, "%s-%d-%d", prefix, sender, j); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// a few datagrams may be dropped on their way, the share of each backend
+	// is computed over the ones received.
+	t.Log("waiting for the UDP server to receive the datagrams")
+	received := regexp.MustCompile(fmt.Sprintf(`port (\d+): buffer contents: %s-\d+-\d+`, regexp.QuoteMeta(prefix)))
+	var counts map[int32]int
+	var total int
+	require.Eventually(t, func() bool {
+		logs, err := env.Cluster().Client().CoreV1().Pods(corev1.NamespaceDefault).GetLogs(udpServerPod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+		require.NoError(t, err)
+		counts, total = make(map[int32]int), 0
+		for _, match := range received.FindAllSubmatch(logs, -1) {
+			port, err := strconv.ParseInt(string(match[1]), 10, 32)
+			require.NoError(t, err)
+			counts[int32(port)]++
+			total++
+		}
+		return total >= senders*datagramsPerSender*95/100
+	}, time.Minute, time.Second)
+
+	t.Logf("verifying each backend received an equal share of the %d datagrams within tolerance: %v", total, counts)
+	share := float64(total) / float64(len(udpPorts))
+	for _, port := range udpPorts {
+		require.InDelta(t, share, float64(counts[port]), share*0.2, "backend on port %d", port)
+	}
+}
+
 // listenForPacketMsg reads the provided log stream and sends relevant log messages
 // into the provided msgs channel.
 func listenForPacketMsg(logStream io.Reader, msgs chan string, errs chan error) {
@@ -235,8 +351,8 @@ func sendUDPPacket(t *testing.T, msg, gwaddr string) int {
 }
 
 func TestUDPRouteNoReach(t *testing.T) {
-	if os.Getenv("RUN_ICMP_TEST") == "" {
-		t.Skip("skipping ICMP integration test")
+	if os.Geteuid() != 0 {
+		t.Skip("listening for ICMP packets requires root")
 	}
 
 	udpRouteNoReachCleanupKey := "udproutenoreach"
@@ -266,13 +382,6 @@ func TestUDPRouteNoReach(t *testing.T) {
 	require.Equal(t, gatewayv1beta1.IPAddressType, *gw.Status.Addresses[0].Type)
 	gwaddr := fmt.Sprintf("%s:9875", gw.Status.Addresses[0].Value)
 
-	t.Log("waiting for udp server to be available")
-	require.Eventually(t, func() bool {
-		server, err := env.Cluster().Client().AppsV1().Deployments(corev1.NamespaceDefault).Get(ctx, udprouteSampleName, metav1.GetOptions{})
-		require.NoError(t, err)
-		return server.Status.AvailableReplicas > 0
-	}, time.Minute, time.Second)
-
 	// start listening for ICMP packets originating from the cluster
 	gwHost := strings.Split(gwaddr, ":")[0]
 	ip := net.ParseIP(gwHost)
@@ -280,28 +389,61 @@ func TestUDPRouteNoReach(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, routes, 1)
 
-	msgs := make(chan icmp.Message, 2)
+	msgs := make(chan icmp.Message, 16)
 	errs := make(chan error, 1)
 	go listenForICMPPacket(routes[0].Src.String(), gwHost, msgs, errs)
 	// Block unitl we get a ping that indicates that we have an active connection
 	// listening for ICMP packets, otherwise we might be too late to capture the packet.
 	<-msgs
 
-	t.Logf("sending a datagram to the UDP server at %s", gwaddr)
-	message := uuid.NewString()
 	conn, err := net.Dial("udp", gwaddr)
 	require.NoError(t, err)
 	defer conn.Close()
+
+	// the UDPRoute has no backends, but it takes a moment until its VIP is
+	// programmed in the dataplane, up to then datagrams are simply dropped.
+	t.Logf("waiting for the dataplane to reply with icmp destination unreachable for %s", gwaddr)
+	require.Eventually(t, func() bool {
+		_, err := conn.Write([]byte(uuid.NewString()))
+		require.NoError(t, err)
+		select {
+		case msg := <-msgs:
+			return strings.Contains(fmt.Sprintf("%s", msg.Type), "destination unreachable")
+		case err := <-errs:
+			t.Fatalf("received error while listening for ICMP packets: %s", err)
+		case <-time.After(time.Second):
+		}
+		return false
+	}, time.Minute, time.Millisecond)
+
+	// drain the replies to any other datagrams sent while waiting.
+	for drained := false; !drained; {
+		select {
+		case <-msgs:
+		case <-time.After(time.Second):
+			drained = true
+		}
+	}
+
+	t.Logf("sending a datagram to %s", gwaddr)
+	message := uuid.NewString()
 	bytesWritten, err := conn.Write([]byte(message))
 	require.NoError(t, err)
 	require.Equal(t, len(message), bytesWritten)
 
-	t.Logf("ensuring UDP server sent back icmp destination unreachable")
+	t.Logf("ensuring exactly one icmp destination unreachable is sent back")
 	select {
 	case msg := <-msgs:
 		require.Contains(t, fmt.Sprintf("%s", msg.Type), "destination unreachable")
 	case err := <-errs:
 		t.Fatalf("received error while listening for ICMP packets: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for icmp destination unreachable")
+	}
+	select {
+	case msg := <-msgs:
+		t.Fatalf("received an unexpected additional ICMP message: %s", msg.Type)
+	case <-time.After(2 * time.Second):
 	}
 }
 
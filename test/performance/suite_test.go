@@ -81,7 +81,7 @@ func TestMain(m *testing.M) {
 
 	fmt.Println("INFO: deploying blixt via config/test kustomize")
 	exitOnErr(clusters.KustomizeDeployForCluster(ctx, env.Cluster(), testKustomize))
-	exitOnErr(testutils.WaitForBlixtReadiness(ctx, env))
+	exitOnErr(testutils.WaitForBlixtReadiness(ctx, env, testutils.BlixtReadinessTimeout))
 
 	fmt.Println("INFO: running performance tests")
 	exit := m.Run()
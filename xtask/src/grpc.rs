@@ -11,6 +11,7 @@ use anyhow::Error;
 use clap::Parser;
 
 use api_server::backends::backends_client::BackendsClient;
+use api_server::backends::vip::Protocol;
 use api_server::backends::{Target, Targets, Vip};
 
 #[derive(Debug, Parser)]
@@ -29,6 +30,8 @@ pub struct Options {
     pub dport: u32,
     #[clap(default_value = "0", long)]
     pub ifindex: u32,
+    #[clap(long, action)]
+    pub udp: bool,
     #[clap(long, short, action)]
     pub delete: bool,
 }
@@ -41,9 +44,15 @@ pub async fn update(opts: Options) -> Result<(), Error> {
     let addr = net::Ipv4Addr::from_str(&opts.vip_ip)?;
     let daddr = net::Ipv4Addr::from_str(&opts.daddr)?;
 
+    let protocol = if opts.udp {
+        Protocol::Udp
+    } else {
+        Protocol::Tcp
+    };
     let vip = Vip {
         ip: addr.into(),
         port: opts.vip_port,
+        protocol: protocol as i32,
     };
 
     if opts.delete {
@@ -60,7 +69,9 @@ pub async fn update(opts: Options) -> Result<(), Error> {
                     daddr: daddr.into(),
                     dport: opts.dport,
                     ifindex: Some(opts.ifindex),
+                    max_connections: None,
                 }],
+                ..Default::default()
             })
             .await?;
         println!(